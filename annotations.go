@@ -0,0 +1,330 @@
+package dockertesting
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// containerWorkDir is the directory of the module inside the test container.
+const containerWorkDir = "/app"
+
+// Annotation is a failure located in a source file, e.g. a failed test
+// assertion or a compiler error, for annotating it in CI.
+type Annotation struct {
+	// File is the path of the file, relative to the module root.
+	File string
+
+	// Line is the line in File.
+	Line int
+
+	// Column is the column in Line, or 0 if unknown.
+	Column int
+
+	// Title summarizes the failure, e.g. "TestAdd failed".
+	Title string
+
+	// Message is the message reported at the location.
+	Message string
+}
+
+var (
+	// buildKitLinePrefix matches the step and time BuildKit prefixes build
+	// log lines with, e.g. "#8 0.512 ".
+	buildKitLinePrefix = regexp.MustCompile(`^#\d+ \d+(\.\d+)? `)
+
+	// compilerErrorPattern matches compiler and vet errors, e.g.
+	// "./add.go:3:2: undefined: x", and go.mod errors, e.g.
+	// "/app/go.mod:5: unknown directive: foo".
+	compilerErrorPattern = regexp.MustCompile(`^(\S+\.(?:go|mod|s)):(\d+)(?::(\d+))?: (.+)$`)
+
+	// testLogPattern matches the locations of t.Error and friends, e.g.
+	// "    add_test.go:12: expected 3, got 4".
+	testLogPattern = regexp.MustCompile(`^(\s+)(\S+\.go):(\d+): (.*)$`)
+
+	// testStatePattern matches the lines naming the test the following
+	// output belongs to, e.g. "=== RUN   TestAdd" or "--- FAIL: TestAdd (0.00s)".
+	testStatePattern = regexp.MustCompile(`^\s*(=== RUN|=== CONT|=== NAME|=== PAUSE|--- FAIL:|--- PASS:|--- SKIP:)\s+(\S+)`)
+
+	// packageResultPattern matches the result line of a package, e.g.
+	// "FAIL\texample.com/pkg\t0.1s" or "ok  \texample.com/pkg\t0.1s".
+	packageResultPattern = regexp.MustCompile(`^(?:ok|FAIL)\s+(\S+)`)
+)
+
+// Annotations returns the failures in output with their source location:
+// the failed assertions of failed tests and the compiler and vet errors of
+// go test, and the go.mod and compiler errors of a docker build. output is
+// either the plain or the -json output of go test, or the log of a
+// BuildError. modulePath is the path of the tested module from its go.mod,
+// which locates the files of failed tests by the import path of their
+// package.
+//
+// Example:
+//
+//	for _, a := range dockertesting.Annotations(result.Stdout, "example.com/mymodule") {
+//	    fmt.Printf("%s:%d: %s\n", a.File, a.Line, a.Message)
+//	}
+func Annotations(output []byte, modulePath string) []Annotation {
+	p := &annotationParser{modulePath: modulePath}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		var event TestEvent
+		if strings.HasPrefix(line, "{") && json.Unmarshal([]byte(line), &event) == nil && event.Action != "" {
+			p.event(event)
+			continue
+		}
+		p.line(line, "", "")
+	}
+	p.flush("")
+	return p.annotations
+}
+
+// annotationParser collects the annotations of go test output line by line.
+// The locations logged by tests are kept until the package result line, as
+// only then it is known which tests failed and which package they are in.
+type annotationParser struct {
+	modulePath  string
+	annotations []Annotation
+
+	// test is the test the current output belongs to.
+	test string
+
+	// pending are the logged locations of the current package by test.
+	pending map[string][]*Annotation
+	order   []string
+	failed  map[string]bool
+
+	// last is the last pending annotation, which indented lines continue.
+	last *Annotation
+	// indent is the indentation of last.
+	indent int
+}
+
+// event processes a go test -json event.
+func (p *annotationParser) event(event TestEvent) {
+	switch event.Action {
+	case "output":
+		p.line(strings.TrimSuffix(event.Output, "\n"), event.Package, event.Test)
+	case "fail":
+		if event.Test != "" {
+			p.markFailed(event.Test)
+		} else {
+			p.flush(event.Package)
+		}
+	case "pass", "skip":
+		if event.Test == "" {
+			p.flush(event.Package)
+		}
+	}
+}
+
+// line processes a line of output. pkg and test are set for -json output.
+func (p *annotationParser) line(line, pkg, test string) {
+	line = buildKitLinePrefix.ReplaceAllString(line, "")
+
+	if match := testLogPattern.FindStringSubmatch(line); match != nil {
+		if test == "" {
+			test = p.test
+		}
+		lineNumber, _ := strconv.Atoi(match[3])
+		p.add(test, Annotation{File: match[2], Line: lineNumber, Message: match[4]})
+		p.indent = len(match[1])
+		return
+	}
+	if match := testStatePattern.FindStringSubmatch(line); match != nil {
+		p.test, p.last = match[2], nil
+		if match[1] == "--- FAIL:" {
+			p.markFailed(p.test)
+		}
+		return
+	}
+	if p.last != nil {
+		// Deeper indented lines continue a multi-line message
+		if trimmed := strings.TrimLeft(line, " \t"); trimmed != "" && len(line)-len(trimmed) > p.indent {
+			p.last.Message += "\n" + trimmed
+			return
+		}
+		p.last = nil
+	}
+
+	if match := compilerErrorPattern.FindStringSubmatch(line); match != nil {
+		file, ok := moduleRelativePath(match[1])
+		if !ok {
+			return
+		}
+		lineNumber, _ := strconv.Atoi(match[2])
+		column, _ := strconv.Atoi(match[3])
+		p.annotations = append(p.annotations, Annotation{
+			File:    file,
+			Line:    lineNumber,
+			Column:  column,
+			Title:   "build failed",
+			Message: match[4],
+		})
+		return
+	}
+	if match := packageResultPattern.FindStringSubmatch(line); match != nil && pkg == "" {
+		p.flush(match[1])
+	}
+}
+
+// add keeps the location logged by test until its package finished.
+func (p *annotationParser) add(test string, annotation Annotation) {
+	if p.pending == nil {
+		p.pending = make(map[string][]*Annotation)
+	}
+	if _, ok := p.pending[test]; !ok {
+		p.order = append(p.order, test)
+	}
+	annotation.Title = test + " failed"
+	p.last = &annotation
+	p.pending[test] = append(p.pending[test], p.last)
+}
+
+// markFailed records that test failed.
+func (p *annotationParser) markFailed(test string) {
+	if p.failed == nil {
+		p.failed = make(map[string]bool)
+	}
+	p.failed[test] = true
+}
+
+// flush adds the locations logged by the failed tests of the package pkg to
+// the annotations and starts a new package.
+func (p *annotationParser) flush(pkg string) {
+	dir := packageDir(pkg, p.modulePath)
+	for _, test := range p.order {
+		if !p.failed[test] {
+			continue
+		}
+		for _, annotation := range p.pending[test] {
+			if dir != "" {
+				annotation.File = path.Join(dir, annotation.File)
+			}
+			p.annotations = append(p.annotations, *annotation)
+		}
+	}
+	p.pending, p.order, p.failed, p.last = nil, nil, nil, nil
+}
+
+// packageDir returns the directory of the package pkg relative to the root of
+// the module modulePath, "." for the root, or "" if pkg is not in the module.
+func packageDir(pkg, modulePath string) string {
+	if modulePath == "" {
+		return ""
+	}
+	if pkg == modulePath {
+		return "."
+	}
+	if rest, ok := strings.CutPrefix(pkg, modulePath+"/"); ok {
+		return rest
+	}
+	return ""
+}
+
+// moduleRelativePath converts a path printed inside the test container to a
+// path relative to the module root. It reports false for files outside of
+// the module, e.g. in the Go installation.
+func moduleRelativePath(file string) (string, bool) {
+	if rest, ok := strings.CutPrefix(file, containerWorkDir+"/"); ok {
+		file = rest
+	}
+	file = path.Clean(file)
+	if path.IsAbs(file) || strings.HasPrefix(file, "../") {
+		return "", false
+	}
+	return file, true
+}
+
+// GitHubCommand returns the GitHub Actions workflow command creating an error
+// annotation for a. dir is the directory of the module relative to the root
+// of the repository, or "" if the module is at the root.
+func (a Annotation) GitHubCommand(dir string) string {
+	props := []string{"file=" + escapeGitHubProperty(path.Join(filepath.ToSlash(dir), a.File))}
+	if a.Line > 0 {
+		props = append(props, "line="+strconv.Itoa(a.Line))
+	}
+	if a.Column > 0 {
+		props = append(props, "col="+strconv.Itoa(a.Column))
+	}
+	if a.Title != "" {
+		props = append(props, "title="+escapeGitHubProperty(a.Title))
+	}
+	return "::error " + strings.Join(props, ",") + "::" + escapeGitHubData(a.Message)
+}
+
+// escapeGitHubData escapes the message of a workflow command.
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeGitHubProperty escapes a property value of a workflow command.
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// WriteGitHubAnnotations writes GitHub Actions workflow commands to w that
+// annotate the failures in output inline on pull request diffs, see
+// Annotations. output is the output of go test, e.g. Result.Stdout, or the
+// log of a BuildError. packagePath is the path passed to Run: the module path
+// is read from its go.mod, and the file paths are made relative to
+// GITHUB_WORKSPACE, or the working directory outside of GitHub Actions.
+//
+// Example:
+//
+//	result, err := dockertesting.Run(ctx, "./mypackage")
+//	var buildErr *dockertesting.BuildError
+//	switch {
+//	case errors.As(err, &buildErr):
+//	    dockertesting.WriteGitHubAnnotations(os.Stdout, "./mypackage", buildErr.Log)
+//	case err == nil && result.ExitCode != 0:
+//	    dockertesting.WriteGitHubAnnotations(os.Stdout, "./mypackage", result.Stdout)
+//	}
+func WriteGitHubAnnotations(w io.Writer, packagePath string, output []byte) error {
+	// Non-fatal: without a module path, only build errors are located
+	modulePath, _ := readModulePath(packagePath)
+	dir, err := annotationDir(packagePath)
+	if err != nil {
+		return err
+	}
+	for _, annotation := range Annotations(output, modulePath) {
+		if _, err := fmt.Fprintln(w, annotation.GitHubCommand(dir)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// annotationDir returns the directory of the module at packagePath relative
+// to the root of the repository.
+func annotationDir(packagePath string) (string, error) {
+	absPath, err := filepath.Abs(packagePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path for package: %w", err)
+	}
+	root := os.Getenv("GITHUB_WORKSPACE")
+	if root == "" {
+		if root, err = os.Getwd(); err != nil {
+			return "", fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+	dir, err := filepath.Rel(root, absPath)
+	if err != nil || strings.HasPrefix(dir, "..") {
+		// Outside of the repository, GitHub cannot place the annotations
+		return "", nil
+	}
+	if dir == "." {
+		return "", nil
+	}
+	return dir, nil
+}
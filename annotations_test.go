@@ -0,0 +1,113 @@
+package dockertesting
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAnnotations_PlainOutput(t *testing.T) {
+	t.Parallel()
+	output := `--- FAIL: TestDiv (0.00s)
+    --- FAIL: TestDiv/by_zero (0.00s)
+        div_test.go:12: expected error
+            got: <nil>
+--- PASS: TestAdd (0.00s)
+    add_test.go:5: logged by a passing test
+FAIL
+FAIL	example.com/mod/calc	0.002s
+=== RUN   TestRoot
+    root_test.go:7: root failure
+--- FAIL: TestRoot (0.00s)
+FAIL
+FAIL	example.com/mod	0.001s
+FAIL
+`
+
+	annotations := Annotations([]byte(output), "example.com/mod")
+	if len(annotations) != 2 {
+		t.Fatalf("expected 2 annotations, got %d: %+v", len(annotations), annotations)
+	}
+	want := Annotation{File: "calc/div_test.go", Line: 12, Title: "TestDiv/by_zero failed", Message: "expected error\ngot: <nil>"}
+	if annotations[0] != want {
+		t.Errorf("expected %+v, got %+v", want, annotations[0])
+	}
+	want = Annotation{File: "root_test.go", Line: 7, Title: "TestRoot failed", Message: "root failure"}
+	if annotations[1] != want {
+		t.Errorf("expected %+v, got %+v", want, annotations[1])
+	}
+}
+
+func TestAnnotations_JSONOutput(t *testing.T) {
+	t.Parallel()
+	output := `{"Action":"run","Package":"example.com/mod/calc","Test":"TestDiv"}
+{"Action":"output","Package":"example.com/mod/calc","Test":"TestDiv","Output":"    div_test.go:12: expected error\n"}
+{"Action":"fail","Package":"example.com/mod/calc","Test":"TestDiv"}
+{"Action":"output","Package":"example.com/mod/calc","Test":"TestAdd","Output":"    add_test.go:5: logged\n"}
+{"Action":"pass","Package":"example.com/mod/calc","Test":"TestAdd"}
+{"Action":"fail","Package":"example.com/mod/calc"}
+`
+
+	annotations := Annotations([]byte(output), "example.com/mod")
+	if len(annotations) != 1 {
+		t.Fatalf("expected 1 annotation, got %d: %+v", len(annotations), annotations)
+	}
+	if a := annotations[0]; a.File != "calc/div_test.go" || a.Line != 12 || a.Title != "TestDiv failed" {
+		t.Errorf("unexpected annotation %+v", a)
+	}
+}
+
+func TestAnnotations_BuildErrors(t *testing.T) {
+	t.Parallel()
+	output := `# example.com/mod/calc
+calc/add.go:3:2: undefined: x
+/usr/local/go/src/fmt/print.go:10:1: outside the module
+FAIL	example.com/mod/calc [build failed]
+#8 0.512 go: errors parsing go.mod:
+#8 0.512 /app/go.mod:5: unknown directive: foo
+`
+
+	annotations := Annotations([]byte(output), "example.com/mod")
+	if len(annotations) != 2 {
+		t.Fatalf("expected 2 annotations, got %d: %+v", len(annotations), annotations)
+	}
+	want := Annotation{File: "calc/add.go", Line: 3, Column: 2, Title: "build failed", Message: "undefined: x"}
+	if annotations[0] != want {
+		t.Errorf("expected %+v, got %+v", want, annotations[0])
+	}
+	if a := annotations[1]; a.File != "go.mod" || a.Line != 5 || a.Column != 0 {
+		t.Errorf("unexpected go.mod annotation %+v", a)
+	}
+}
+
+func TestAnnotation_GitHubCommand(t *testing.T) {
+	t.Parallel()
+	a := Annotation{File: "calc/div_test.go", Line: 12, Column: 3, Title: "TestDiv, by: zero", Message: "100% wrong\nreally"}
+	want := "::error file=services/api/calc/div_test.go,line=12,col=3,title=TestDiv%2C by%3A zero::100%25 wrong%0Areally"
+	if got := a.GitHubCommand("services/api"); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestWriteGitHubAnnotations(t *testing.T) {
+	workspace := t.TempDir()
+	module := filepath.Join(workspace, "services", "api")
+	if err := os.MkdirAll(module, 0o755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(module, "go.mod"), []byte("module example.com/api\n"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Setenv("GITHUB_WORKSPACE", workspace)
+
+	output := "--- FAIL: TestAdd (0.00s)\n    add_test.go:5: wrong sum\nFAIL\nFAIL\texample.com/api/calc\t0.001s\n"
+	var buf bytes.Buffer
+	if err := WriteGitHubAnnotations(&buf, module, []byte(output)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "::error file=services/api/calc/add_test.go,line=5,title=TestAdd failed::wrong sum\n"
+	if buf.String() != want {
+		t.Errorf("expected %q, got %q", want, buf.String())
+	}
+}
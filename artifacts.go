@@ -0,0 +1,145 @@
+package dockertesting
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// collectArtifacts copies all files matching the patterns out of the container.
+// The returned map is keyed by the absolute file path inside the container.
+// Patterns that match nothing are ignored.
+func collectArtifacts(ctx context.Context, container *TestContainer, patterns []string) (map[string][]byte, error) {
+	artifacts := make(map[string][]byte)
+	for _, pattern := range patterns {
+		matcher, err := compileArtifactPattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		// List candidate files below the static prefix of the pattern
+		result, err := container.ExecCommand(ctx, []string{
+			"sh", "-c", `find "$1" -type f 2>/dev/null`, "sh", artifactBaseDir(pattern),
+		}, ExecOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list artifacts for %q: %w", pattern, err)
+		}
+
+		for _, file := range strings.Split(string(result.Stdout), "\n") {
+			file = strings.TrimSpace(file)
+			if file == "" || !matcher.matches(file) {
+				continue
+			}
+			if _, ok := artifacts[file]; ok {
+				continue
+			}
+			content, err := container.CopyFileFromContainer(ctx, file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to copy artifact %s: %w", file, err)
+			}
+			if content == nil {
+				// The file disappeared between listing and copying
+				continue
+			}
+			artifacts[file] = container.redact(content)
+		}
+	}
+	return artifacts, nil
+}
+
+// DefaultTestBinaryDir is the directory inside the container where test binaries
+// are written in compile-only mode.
+const DefaultTestBinaryDir = "/tmp/testbin"
+
+// collectTestBinaries copies the compiled test binaries out of the container,
+// keyed by file name.
+func collectTestBinaries(ctx context.Context, container *TestContainer) (map[string][]byte, error) {
+	files, err := collectArtifacts(ctx, container, []string{DefaultTestBinaryDir + "/*"})
+	if err != nil {
+		return nil, err
+	}
+	binaries := make(map[string][]byte, len(files))
+	for file, content := range files {
+		binaries[path.Base(file)] = content
+	}
+	return binaries, nil
+}
+
+// writeArtifacts writes the artifacts under dir, mirroring their paths inside the container.
+func writeArtifacts(dir string, artifacts map[string][]byte) error {
+	for file, content := range artifacts {
+		target := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(file, "/")))
+		if err := writeFileAtomic(target, content, 0644); err != nil {
+			return fmt.Errorf("failed to write artifact %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// artifactMatcher matches container file paths against an artifact pattern.
+type artifactMatcher struct {
+	re *regexp.Regexp
+}
+
+// matches reports whether file matches the pattern, either directly or because
+// the pattern matches one of its parent directories.
+func (m *artifactMatcher) matches(file string) bool {
+	for p := file; p != "/" && p != "."; p = path.Dir(p) {
+		if m.re.MatchString(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileArtifactPattern converts a glob pattern to an artifactMatcher.
+// It supports "*" and "?" within a path segment and "**" across segments.
+func compileArtifactPattern(pattern string) (*artifactMatcher, error) {
+	if !path.IsAbs(pattern) {
+		return nil, fmt.Errorf("artifact pattern %q must be an absolute path", pattern)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "/**/"):
+			// Matches zero or more directories
+			sb.WriteString("/(?:.*/)?")
+			i += 3
+		case strings.HasPrefix(pattern[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid artifact pattern %q: %w", pattern, err)
+	}
+	return &artifactMatcher{re: re}, nil
+}
+
+// artifactBaseDir returns the longest directory prefix of pattern that contains
+// no glob characters. For patterns without globs the pattern itself is returned.
+func artifactBaseDir(pattern string) string {
+	i := strings.IndexAny(pattern, "*?")
+	if i < 0 {
+		return pattern
+	}
+	dir := path.Dir(pattern[:i+1])
+	if dir == "" {
+		return "/"
+	}
+	return dir
+}
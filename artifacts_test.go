@@ -0,0 +1,86 @@
+package dockertesting
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompileArtifactPattern(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		pattern string
+		file    string
+		matches bool
+	}{
+		{"/tmp/reports/**", "/tmp/reports/junit.xml", true},
+		{"/tmp/reports/**", "/tmp/reports/nested/junit.xml", true},
+		{"/tmp/reports/**", "/tmp/other/junit.xml", false},
+		{"/app/logs/*.log", "/app/logs/app.log", true},
+		{"/app/logs/*.log", "/app/logs/nested/app.log", false},
+		{"/app/logs/*.log", "/app/logs/app.txt", false},
+		{"/app/**/*.log", "/app/app.log", true},
+		{"/app/**/*.log", "/app/a/b/app.log", true},
+		{"/app/log?.txt", "/app/log1.txt", true},
+		{"/app/logs", "/app/logs/app.log", true},
+		{"/app/logs", "/app/logs2/app.log", false},
+	}
+	for _, tt := range tests {
+		matcher, err := compileArtifactPattern(tt.pattern)
+		if err != nil {
+			t.Fatalf("compileArtifactPattern(%q) failed: %v", tt.pattern, err)
+		}
+		if got := matcher.matches(tt.file); got != tt.matches {
+			t.Errorf("pattern %q matching %q = %v, want %v", tt.pattern, tt.file, got, tt.matches)
+		}
+	}
+}
+
+func TestCompileArtifactPattern_RelativePath(t *testing.T) {
+	t.Parallel()
+	if _, err := compileArtifactPattern("reports/*.xml"); err == nil {
+		t.Error("expected error for relative pattern")
+	}
+}
+
+func TestArtifactBaseDir(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		"/tmp/reports/**":  "/tmp/reports",
+		"/app/logs/*.log":  "/app/logs",
+		"/app/**/*.log":    "/app",
+		"/app/logs":        "/app/logs",
+		"/*.log":           "/",
+		"/app/log?.txt":    "/app",
+		"/tmp/reports/a/*": "/tmp/reports/a",
+	}
+	for pattern, expected := range tests {
+		if got := artifactBaseDir(pattern); got != expected {
+			t.Errorf("artifactBaseDir(%q) = %q, want %q", pattern, got, expected)
+		}
+	}
+}
+
+func TestWriteArtifacts(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	artifacts := map[string][]byte{
+		"/tmp/reports/junit.xml": []byte("<testsuites/>"),
+		"/app/logs/app.log":      []byte("log line\n"),
+	}
+
+	if err := writeArtifacts(dir, artifacts); err != nil {
+		t.Fatalf("writeArtifacts failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "tmp", "reports", "junit.xml"))
+	if err != nil {
+		t.Fatalf("failed to read artifact: %v", err)
+	}
+	if string(content) != "<testsuites/>" {
+		t.Errorf("unexpected artifact content %q", content)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app", "logs", "app.log")); err != nil {
+		t.Errorf("expected app.log to be written: %v", err)
+	}
+}
@@ -0,0 +1,179 @@
+package dockertesting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// AttachContainer returns a TestContainer for an already-running container
+// that is managed outside of this library, identified by its ID or name. The
+// container is looked up through the provider or client of WithDockerProvider
+// or WithDockerClient if given, otherwise through a provider configured from
+// the environment; other options are ignored.
+//
+// The container is not owned by the returned TestContainer: calling Terminate
+// on it removes the container, so callers normally must not do that. Terminate
+// also closes the provider configured from the environment.
+func AttachContainer(ctx context.Context, containerID string, opts ...Option) (*TestContainer, error) {
+	options := &Options{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	provider, err := runProvider(options, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Only the provider configured from the environment is owned
+	c := &TestContainer{}
+	if provider == nil {
+		provider, err = newDockerProvider(nil)
+		if err != nil {
+			return nil, err
+		}
+		c.closeProvider = func() {
+			// Non-fatal: the container is done with the provider
+			_ = provider.Close()
+		}
+	}
+
+	c.ctr, err = attachContainer(ctx, provider, containerID)
+	if err != nil {
+		c.detach()
+		return nil, err
+	}
+	return c, nil
+}
+
+// attachContainer looks up the running container containerID, an ID or a
+// name, through provider.
+func attachContainer(ctx context.Context, provider *testcontainers.DockerProvider, containerID string) (testcontainers.Container, error) {
+	summaries, err := provider.Client().ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("id", containerID)),
+	})
+	if err == nil && len(summaries) == 0 {
+		// Not an ID, try it as a container name
+		summaries, err = provider.Client().ContainerList(ctx, container.ListOptions{
+			All:     true,
+			Filters: filters.NewArgs(filters.Arg("name", "^/"+containerID+"$")),
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up container %s: %w", containerID, err)
+	}
+	if len(summaries) == 0 {
+		return nil, fmt.Errorf("container %s not found", containerID)
+	}
+	if summaries[0].State != "running" {
+		return nil, fmt.Errorf("container %s is not running (state: %s)", containerID, summaries[0].State)
+	}
+
+	ctr, err := provider.ContainerFromType(ctx, summaries[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach to container %s: %w", containerID, err)
+	}
+	return ctr, nil
+}
+
+// RunInContainer executes go test in an already-running container that the
+// caller manages, skipping the network, image build and container creation of
+// Run. The test output is streamed to cfg.Output (default: os.Stdout) and the
+// coverage profile is copied out of the container into the Result. The
+// container is looked up as by AttachContainer with opts.
+//
+// The container must contain the Go toolchain and the module to test; use
+// cfg.WorkingDir if its working directory is not the module root.
+// The container is left running.
+//
+// Example:
+//
+//	result, err := dockertesting.RunInContainer(ctx, "my-dev-container", dockertesting.ExecConfig{
+//	    Pattern:    "./...",
+//	    WorkingDir: "/src",
+//	})
+func RunInContainer(ctx context.Context, containerID string, cfg ExecConfig, opts ...Option) (*Result, error) {
+	container, err := AttachContainer(ctx, containerID, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer container.detach()
+
+	if cfg.Output == nil {
+		cfg.Output = os.Stdout
+	}
+	return runTests(ctx, container, cfg, nil, "", nil)
+}
+
+// runTests executes go test in container as configured by cfg and copies the
+// coverage profile out of the container into the Result. cmd is the go test
+// command, or nil for the one ExecTest builds from cfg. modulePath locates
+// the files of the failed tests, see ParseFailures. reporter, if not nil,
+// receives StageCoverageCopied, see copyCoverage.
+func runTests(ctx context.Context, container *TestContainer, cfg ExecConfig, cmd []string, modulePath string, reporter ProgressReporter) (*Result, error) {
+	if cfg.CoverageFile == "" {
+		cfg.CoverageFile = DefaultCoverageFile
+	}
+
+	// Remove coverage left over from a previous run, so it is not mistaken
+	// for the coverage of this one if the tests fail early
+	if _, err := container.ExecCommand(ctx, []string{"rm", "-f", cfg.CoverageFile}, ExecOptions{}); err != nil {
+		return nil, wrapTimeoutError(ctx, err, "remove stale coverage")
+	}
+
+	start := time.Now()
+	var result *ExecResult
+	var err error
+	if cmd == nil {
+		result, err = container.ExecTest(ctx, cfg)
+	} else {
+		result, err = container.execTest(ctx, cfg, cmd)
+	}
+	if err != nil {
+		var timeoutErr *TimeoutError
+		if errors.As(err, &timeoutErr) {
+			return nil, timeoutErr
+		}
+		return nil, wrapTimeoutError(ctx, err, "execute tests")
+	}
+
+	coverage := copyCoverage(ctx, container, cfg.CoverageFile, reporter)
+
+	// Non-fatal: a malformed profile leaves the percentage at 0
+	coveragePercent, _ := CoveragePercent(coverage)
+
+	outcomes := parseOutcomes(result.Stdout, modulePath)
+
+	return &Result{
+		Stdout:          result.Stdout,
+		Coverage:        coverage,
+		CoveragePercent: coveragePercent,
+		ContainerID:     container.ID(),
+		ExitCode:        result.ExitCode,
+		Duration:        time.Since(start),
+		Failures:        outcomes.failures,
+		Skipped:         outcomes.skipped,
+		Benchmarks:      ExtractBenchmarks(result.Stdout),
+		Races:           ParseRaces(result.Stdout),
+		Crashes:         ParseCrashes(result.Stdout),
+	}, nil
+}
+
+// copyCoverage copies the coverage profile at path out of container and
+// reports StageCoverageCopied to reporter once it was copied. It returns nil
+// if the profile cannot be copied.
+func copyCoverage(ctx context.Context, container *TestContainer, path string, reporter ProgressReporter) []byte {
+	coverage, err := container.CopyCoverageFromPath(ctx, path)
+	if err != nil {
+		// Non-fatal: coverage may not exist if tests failed early
+		return nil
+	}
+	reportProgress(reporter, ProgressEvent{Stage: StageCoverageCopied, Message: "coverage copied"})
+	return coverage
+}
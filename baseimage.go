@@ -0,0 +1,199 @@
+package dockertesting
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// basePulls deduplicates concurrent pulls of base images within the process,
+// so parallel runs of several packages pull a shared base image once instead
+// of every build pulling it at the same time.
+var basePulls pullGroup
+
+// pullGroup runs at most one pull per image at a time.
+type pullGroup struct {
+	mu      sync.Mutex
+	pending map[string]chan struct{}
+}
+
+// do runs pull for image, unless a pull of the same image is already in
+// progress; then it waits for that pull to finish instead and returns nil.
+// Waiters do not see the error of the pull they waited for, as the build
+// pulls a missing base image itself.
+func (g *pullGroup) do(ctx context.Context, image string, pull func() error) error {
+	g.mu.Lock()
+	if done, ok := g.pending[image]; ok {
+		g.mu.Unlock()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if g.pending == nil {
+		g.pending = make(map[string]chan struct{})
+	}
+	done := make(chan struct{})
+	g.pending[image] = done
+	g.mu.Unlock()
+
+	err := pull()
+
+	g.mu.Lock()
+	delete(g.pending, image)
+	g.mu.Unlock()
+	close(done)
+	return err
+}
+
+// pullBaseImages pulls the base images of dockerfile that are not present on
+// the daemon yet, coordinated with concurrent builds of the process.
+func pullBaseImages(ctx context.Context, provider *testcontainers.DockerProvider, dockerfile []byte, buildArgs map[string]*string) error {
+	images := dockerfileBaseImages(dockerfile, buildArgs)
+	if len(images) == 0 {
+		return nil
+	}
+
+	cli, closeClient, err := dockerClient(ctx, provider)
+	if err != nil {
+		return err
+	}
+	defer closeClient()
+
+	for _, image := range images {
+		err := basePulls.do(ctx, image, func() error {
+			exists, err := imageExists(ctx, cli, image)
+			if err != nil || exists {
+				return err
+			}
+			return pullImage(ctx, cli, image)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dockerfileBaseImages returns the images referenced by the FROM instructions
+// of dockerfile, with the ARGs declared before the first FROM and buildArgs
+// expanded. Stages built from earlier stages, scratch and references that
+// cannot be fully expanded are skipped.
+func dockerfileBaseImages(dockerfile []byte, buildArgs map[string]*string) []string {
+	args := make(map[string]string)
+	stages := make(map[string]bool)
+	var images []string
+	seenFrom := false
+
+	for _, line := range dockerfileInstructions(dockerfile) {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "ARG":
+			if seenFrom {
+				continue
+			}
+			name, value, _ := strings.Cut(fields[1], "=")
+			if override, ok := buildArgs[name]; ok && override != nil {
+				value = *override
+			}
+			args[name] = strings.Trim(value, `"'`)
+		case "FROM":
+			seenFrom = true
+			// Skip flags such as --platform
+			rest := fields[1:]
+			for len(rest) > 0 && strings.HasPrefix(rest[0], "--") {
+				rest = rest[1:]
+			}
+			if len(rest) == 0 {
+				continue
+			}
+			resolved := true
+			image := os.Expand(rest[0], func(name string) string {
+				name, fallback, _ := strings.Cut(name, ":-")
+				if value := args[name]; value != "" {
+					return value
+				}
+				if fallback == "" {
+					resolved = false
+				}
+				return fallback
+			})
+			isStage := stages[strings.ToLower(image)]
+			if len(rest) >= 3 && strings.EqualFold(rest[1], "AS") {
+				stages[strings.ToLower(rest[2])] = true
+			}
+			if isStage || !resolved || image == "" || image == "scratch" {
+				continue
+			}
+			images = append(images, image)
+		}
+	}
+	return images
+}
+
+// dockerfileStages returns the lowercased names of the named stages of
+// dockerfile, in order.
+func dockerfileStages(dockerfile []byte) []string {
+	var stages []string
+	for _, line := range dockerfileInstructions(dockerfile) {
+		fields := strings.Fields(line)
+		if len(fields) >= 4 && strings.EqualFold(fields[0], "FROM") && strings.EqualFold(fields[len(fields)-2], "AS") {
+			stages = append(stages, strings.ToLower(fields[len(fields)-1]))
+		}
+	}
+	return stages
+}
+
+// checkBuildTarget returns an error if target is set but not a stage of
+// dockerfile, which the build would only report after the preceding stages.
+func checkBuildTarget(dockerfile []byte, target string) error {
+	if target == "" {
+		return nil
+	}
+	stages := dockerfileStages(dockerfile)
+	if slices.Contains(stages, strings.ToLower(target)) {
+		return nil
+	}
+	if len(stages) == 0 {
+		return fmt.Errorf("build target %q is not a stage of the Dockerfile, which has no named stages; name one with FROM <image> AS %s", target, target)
+	}
+	return fmt.Errorf("build target %q is not a stage of the Dockerfile; stages: %s", target, strings.Join(stages, ", "))
+}
+
+// dockerfileInstructions returns the instructions of dockerfile with comments
+// and blank lines removed and continuation lines joined.
+func dockerfileInstructions(dockerfile []byte) []string {
+	var instructions []string
+	var current strings.Builder
+	scanner := bufio.NewScanner(bytes.NewReader(dockerfile))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasSuffix(line, `\`) {
+			current.WriteString(strings.TrimSuffix(line, `\`))
+			current.WriteString(" ")
+			continue
+		}
+		current.WriteString(line)
+		instructions = append(instructions, current.String())
+		current.Reset()
+	}
+	if current.Len() > 0 {
+		instructions = append(instructions, current.String())
+	}
+	return instructions
+}
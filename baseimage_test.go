@@ -0,0 +1,183 @@
+package dockertesting
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDockerfileBaseImages(t *testing.T) {
+	t.Parallel()
+
+	version := "1.24.3"
+	render := func(flavor ImageFlavor) string {
+		dockerfile, err := renderDockerfile(dockerfileTemplate, templateParams{flavor: flavor})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return dockerfile
+	}
+	tests := []struct {
+		name       string
+		dockerfile string
+		buildArgs  map[string]*string
+		expected   []string
+	}{
+		{
+			name:       "embedded template",
+			dockerfile: render(ImageFlavorDefault),
+			expected:   []string{"golang:1.25.6"},
+		},
+		{
+			name:       "build arg override",
+			dockerfile: render(ImageFlavorDefault),
+			buildArgs:  map[string]*string{"GO_VERSION": &version},
+			expected:   []string{"golang:1.24.3"},
+		},
+		{
+			name:       "image flavor",
+			dockerfile: render(ImageFlavorAlpine),
+			buildArgs:  map[string]*string{"GO_VERSION": &version},
+			expected:   []string{"golang:1.24.3-alpine"},
+		},
+		{
+			name: "multi-stage",
+			dockerfile: `# syntax=docker/dockerfile:1
+FROM --platform=$BUILDPLATFORM golang:1.25 AS build
+RUN go build ./...
+FROM build AS test
+FROM alpine:3.20
+COPY --from=build /app /app
+`,
+			expected: []string{"golang:1.25", "alpine:3.20"},
+		},
+		{
+			name:       "default expansion",
+			dockerfile: "ARG BASE\nFROM ${BASE:-debian:bookworm}\n",
+			expected:   []string{"debian:bookworm"},
+		},
+		{
+			name:       "unresolved arg",
+			dockerfile: "FROM golang:${GO_VERSION}\n",
+		},
+		{
+			name:       "scratch",
+			dockerfile: "FROM scratch\n",
+		},
+		{
+			name:       "continuation",
+			dockerfile: "ARG GO_VERSION=1.25\n\nFROM \\\n  # comment\n  golang:${GO_VERSION}\n",
+			expected:   []string{"golang:1.25"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			images := dockerfileBaseImages([]byte(tt.dockerfile), tt.buildArgs)
+			if !slices.Equal(images, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, images)
+			}
+		})
+	}
+}
+
+func TestPullGroup_DeduplicatesConcurrentPulls(t *testing.T) {
+	t.Parallel()
+
+	var g pullGroup
+	var pulls atomic.Int32
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		errs[0] = g.do(context.Background(), "golang:1.25", func() error {
+			pulls.Add(1)
+			close(started)
+			<-release
+			return errors.New("pull failed")
+		})
+	}()
+	<-started
+	for i := 1; i < len(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = g.do(context.Background(), "golang:1.25", func() error {
+				pulls.Add(1)
+				return nil
+			})
+		}()
+	}
+
+	// Give the waiters time to block on the pending pull
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := pulls.Load(); n != 1 {
+		t.Errorf("expected 1 pull, got %d", n)
+	}
+	if errs[0] == nil {
+		t.Error("expected the pulling caller to get the pull error")
+	}
+	for i, err := range errs[1:] {
+		if err != nil {
+			t.Errorf("waiter %d: unexpected error: %v", i+1, err)
+		}
+	}
+
+	// A later call pulls again
+	if err := g.do(context.Background(), "golang:1.25", func() error {
+		pulls.Add(1)
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := pulls.Load(); n != 2 {
+		t.Errorf("expected 2 pulls, got %d", n)
+	}
+}
+
+func TestDockerfileStages(t *testing.T) {
+	t.Parallel()
+	dockerfile := []byte(`FROM golang:1.24 AS Test
+WORKDIR /app
+FROM test as build
+RUN go build ./...
+FROM --platform=linux/amd64 gcr.io/distroless/base AS final
+FROM scratch
+`)
+	got := dockerfileStages(dockerfile)
+	expected := []string{"test", "build", "final"}
+	if !slices.Equal(got, expected) {
+		t.Errorf("expected stages %v, got %v", expected, got)
+	}
+}
+
+func TestCheckBuildTarget(t *testing.T) {
+	t.Parallel()
+	dockerfile := []byte("FROM golang:1.24 AS test\nFROM test AS build\n")
+
+	if err := checkBuildTarget(dockerfile, ""); err != nil {
+		t.Errorf("unexpected error without target: %v", err)
+	}
+	if err := checkBuildTarget(dockerfile, "TEST"); err != nil {
+		t.Errorf("unexpected error for a stage: %v", err)
+	}
+	if err := checkBuildTarget(dockerfile, "lint"); err == nil || !strings.Contains(err.Error(), "stages: test, build") {
+		t.Errorf("expected error listing the stages, got %v", err)
+	}
+	if err := checkBuildTarget([]byte("FROM golang:1.24\n"), "test"); err == nil || !strings.Contains(err.Error(), "no named stages") {
+		t.Errorf("expected error for a Dockerfile without stages, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package dockertesting
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+var (
+	// benchmarkResultPattern matches the result lines of benchmarks, e.g.
+	// "BenchmarkAdd-8   1000000   1050 ns/op   16 B/op".
+	benchmarkResultPattern = regexp.MustCompile(`^Benchmark\S*\s+\d+\s+\S+ \S+`)
+
+	// benchmarkConfigPattern matches the configuration lines go test prints
+	// before the results of a package, e.g. "pkg: example.com/mod".
+	benchmarkConfigPattern = regexp.MustCompile(`^(goos|goarch|pkg|cpu): `)
+)
+
+// ExtractBenchmarks returns the benchmark results in output, the plain or
+// -json output of go test, in the format of the benchmark data files read by
+// benchstat (golang.org/x/perf/cmd/benchstat): the result lines with the
+// configuration lines naming their package and platform. It returns nil if
+// output contains no benchmark results.
+//
+// Example:
+//
+//	_ = os.WriteFile("new.txt", dockertesting.ExtractBenchmarks(result.Stdout), 0o644)
+//	// benchstat old.txt new.txt
+func ExtractBenchmarks(output []byte) []byte {
+	var b bytes.Buffer
+	found := false
+	var text bytes.Buffer
+	for _, out := range packageOutputs(output) {
+		text.WriteString(out.text)
+	}
+	scanner := bufio.NewScanner(&text)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		switch {
+		case benchmarkResultPattern.MatchString(line):
+			found = true
+		case benchmarkConfigPattern.MatchString(line):
+		default:
+			continue
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	if !found {
+		return nil
+	}
+	return b.Bytes()
+}
+
+// packageOutput is the text go test printed for a package.
+type packageOutput struct {
+	// pkg is the import path of the package, empty for plain output.
+	pkg string
+
+	// text is the printed text, ending with a newline.
+	text string
+}
+
+// packageOutputs returns the text go test printed in output. The output
+// events of -json are joined per package, as test2json splits lines into
+// several events and the packages may interleave. Plain output is returned
+// as one packageOutput without a package.
+func packageOutputs(output []byte) []packageOutput {
+	events := parseTestEvents(output)
+	if len(events) == 0 {
+		return []packageOutput{{text: string(output)}}
+	}
+	var packages []string
+	text := make(map[string]*strings.Builder)
+	for _, event := range events {
+		if event.Action != "output" {
+			continue
+		}
+		if text[event.Package] == nil {
+			text[event.Package] = &strings.Builder{}
+			packages = append(packages, event.Package)
+		}
+		text[event.Package].WriteString(event.Output)
+	}
+	outputs := make([]packageOutput, len(packages))
+	for i, pkg := range packages {
+		s := text[pkg].String()
+		if !strings.HasSuffix(s, "\n") {
+			s += "\n"
+		}
+		outputs[i] = packageOutput{pkg: pkg, text: s}
+	}
+	return outputs
+}
@@ -0,0 +1,53 @@
+package dockertesting
+
+import "testing"
+
+func TestExtractBenchmarks_PlainOutput(t *testing.T) {
+	t.Parallel()
+	output := "goos: linux\n" +
+		"goarch: amd64\n" +
+		"pkg: example.com/mod\n" +
+		"cpu: AMD EPYC 7B13\n" +
+		"BenchmarkAdd-8   \t1000000000\t         0.2500 ns/op\t       0 B/op\n" +
+		"    add_test.go:30: logged by the benchmark\n" +
+		"BenchmarkDiv\n" +
+		"--- FAIL: BenchmarkDiv\n" +
+		"PASS\n" +
+		"ok  \texample.com/mod\t1.234s\n"
+
+	expected := "goos: linux\n" +
+		"goarch: amd64\n" +
+		"pkg: example.com/mod\n" +
+		"cpu: AMD EPYC 7B13\n" +
+		"BenchmarkAdd-8   \t1000000000\t         0.2500 ns/op\t       0 B/op\n"
+	if got := string(ExtractBenchmarks([]byte(output))); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestExtractBenchmarks_JSONOutput(t *testing.T) {
+	t.Parallel()
+	// test2json splits result lines, and packages may interleave
+	output := `{"Action":"output","Package":"example.com/mod","Output":"pkg: example.com/mod\n"}
+{"Action":"output","Package":"example.com/mod","Test":"BenchmarkAdd","Output":"BenchmarkAdd-8   \t"}
+{"Action":"output","Package":"example.com/other","Output":"pkg: example.com/other\n"}
+{"Action":"output","Package":"example.com/mod","Test":"BenchmarkAdd","Output":"1000000000\t         0.2500 ns/op\n"}
+{"Action":"output","Package":"example.com/other","Test":"BenchmarkSub","Output":"BenchmarkSub-8   \t500\t    2000 ns/op\n"}
+{"Action":"pass","Package":"example.com/mod"}
+`
+
+	expected := "pkg: example.com/mod\n" +
+		"BenchmarkAdd-8   \t1000000000\t         0.2500 ns/op\n" +
+		"pkg: example.com/other\n" +
+		"BenchmarkSub-8   \t500\t    2000 ns/op\n"
+	if got := string(ExtractBenchmarks([]byte(output))); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestExtractBenchmarks_NoBenchmarks(t *testing.T) {
+	t.Parallel()
+	if got := ExtractBenchmarks([]byte(sampleJSONOutput)); got != nil {
+		t.Errorf("expected nil without benchmarks, got %q", got)
+	}
+}
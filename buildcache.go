@@ -0,0 +1,198 @@
+package dockertesting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// BuildCacheRepository is the repository under which the build cache of a
+// package is tagged when it is exported to or imported from a directory with
+// WithBuildCacheDir.
+const BuildCacheRepository = "dockertesting-buildcache"
+
+// buildCache is where the build cache of a package is imported from before
+// the build and exported to after it, see WithBuildCacheRegistry and
+// WithBuildCacheDir.
+type buildCache struct {
+	// ref is the image reference in a registry, or empty.
+	ref string
+
+	// dir is the local directory, or empty.
+	dir string
+
+	// key identifies the package within dir.
+	key string
+}
+
+// newBuildCache returns the build cache of the package at the absolute path
+// packagePath.
+func newBuildCache(ref, dir, packagePath string) buildCache {
+	return buildCache{ref: ref, dir: dir, key: packageHash(packagePath)}
+}
+
+// enabled reports whether a registry or a directory is configured.
+func (c buildCache) enabled() bool {
+	return c.ref != "" || c.dir != ""
+}
+
+// localRef returns the tag of the cache image saved to and loaded from dir.
+func (c buildCache) localRef() string {
+	return BuildCacheRepository + ":" + c.key
+}
+
+// file returns the path of the cache image archive in dir.
+func (c buildCache) file() string {
+	return filepath.Join(c.dir, c.key+".tar")
+}
+
+// load makes the cache images available to the daemon and returns their
+// references for use as cache sources of the build. A cache that cannot be
+// imported only makes the build slower, so failures are written to w instead
+// of being returned; a missing archive in dir is expected on the first run and
+// skipped silently.
+func (c buildCache) load(ctx context.Context, provider *testcontainers.DockerProvider, w io.Writer) []string {
+	cli, closeClient, err := dockerClient(ctx, provider)
+	if err != nil {
+		fmt.Fprintf(w, "Build cache not imported: %v\n", err)
+		return nil
+	}
+	defer closeClient()
+
+	var refs []string
+	if c.ref != "" {
+		if err := pullImage(ctx, cli, c.ref); err != nil {
+			fmt.Fprintf(w, "Build cache %s not imported: %v\n", c.ref, err)
+		} else {
+			refs = append(refs, c.ref)
+		}
+	}
+	if c.dir != "" {
+		if err := loadImage(ctx, cli, c.file()); err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				fmt.Fprintf(w, "Build cache %s not imported: %v\n", c.file(), err)
+			}
+		} else {
+			refs = append(refs, c.localRef())
+		}
+	}
+	return refs
+}
+
+// save exports the image built with the cache as the cache of the next build:
+// it is pushed to ref and saved to dir.
+func (c buildCache) save(ctx context.Context, provider *testcontainers.DockerProvider, imageRef string) error {
+	cli, closeClient, err := dockerClient(ctx, provider)
+	if err != nil {
+		return err
+	}
+	defer closeClient()
+
+	var errs []error
+	if c.ref != "" {
+		if err := cli.ImageTag(ctx, imageRef, c.ref); err != nil {
+			errs = append(errs, fmt.Errorf("failed to tag build cache %s: %w", c.ref, err))
+		} else if err := pushImage(ctx, cli, c.ref); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if c.dir != "" {
+		if err := cli.ImageTag(ctx, imageRef, c.localRef()); err != nil {
+			errs = append(errs, fmt.Errorf("failed to tag build cache %s: %w", c.localRef(), err))
+		} else if err := saveImage(ctx, cli, c.localRef(), c.file()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// exportBuildCache exports the image of the container ctr as build cache.
+func exportBuildCache(ctx context.Context, provider *testcontainers.DockerProvider, ctr testcontainers.Container, cache buildCache) error {
+	info, err := ctr.Inspect(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to inspect container: %w", err)
+	}
+	return cache.save(ctx, provider, info.Image)
+}
+
+// registryAuth returns the encoded credentials for the registry of ref, or an
+// empty string if none are configured.
+func registryAuth(ctx context.Context, ref string) string {
+	_, authConfig, err := testcontainers.DockerImageAuth(ctx, ref)
+	if err != nil {
+		return ""
+	}
+	auth, err := registry.EncodeAuthConfig(authConfig)
+	if err != nil {
+		return ""
+	}
+	return auth
+}
+
+// pullImage pulls ref with the credentials of its registry.
+func pullImage(ctx context.Context, cli client.APIClient, ref string) error {
+	rc, err := cli.ImagePull(ctx, ref, image.PullOptions{RegistryAuth: registryAuth(ctx, ref)})
+	if err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", ref, err)
+	}
+	defer rc.Close()
+	if err := jsonmessage.DisplayJSONMessagesStream(rc, io.Discard, 0, false, nil); err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", ref, err)
+	}
+	return nil
+}
+
+// pushImage pushes ref with the credentials of its registry.
+func pushImage(ctx context.Context, cli client.APIClient, ref string) error {
+	rc, err := cli.ImagePush(ctx, ref, image.PushOptions{RegistryAuth: registryAuth(ctx, ref)})
+	if err != nil {
+		return fmt.Errorf("failed to push image %s: %w", ref, err)
+	}
+	defer rc.Close()
+	if err := jsonmessage.DisplayJSONMessagesStream(rc, io.Discard, 0, false, nil); err != nil {
+		return fmt.Errorf("failed to push image %s: %w", ref, err)
+	}
+	return nil
+}
+
+// loadImage loads the image archive at file into the daemon.
+func loadImage(ctx context.Context, cli client.APIClient, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	resp, err := cli.ImageLoad(ctx, f, client.ImageLoadWithQuiet(true))
+	if err != nil {
+		return fmt.Errorf("failed to load image archive %s: %w", file, err)
+	}
+	defer resp.Body.Close()
+	if err := jsonmessage.DisplayJSONMessagesStream(resp.Body, io.Discard, 0, false, nil); err != nil {
+		return fmt.Errorf("failed to load image archive %s: %w", file, err)
+	}
+	return nil
+}
+
+// saveImage saves ref as an image archive to file.
+func saveImage(ctx context.Context, cli client.APIClient, ref, file string) error {
+	rc, err := cli.ImageSave(ctx, []string{ref})
+	if err != nil {
+		return fmt.Errorf("failed to save image %s: %w", ref, err)
+	}
+	defer rc.Close()
+	if err := writeFileAtomicFrom(file, rc, 0644); err != nil {
+		return fmt.Errorf("failed to save image %s: %w", ref, err)
+	}
+	return nil
+}
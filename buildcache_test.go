@@ -0,0 +1,47 @@
+package dockertesting
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewBuildCache(t *testing.T) {
+	t.Parallel()
+
+	cache := newBuildCache("", "/tmp/cache", "/src/app/pkg")
+	if !cache.enabled() {
+		t.Error("expected cache with a directory to be enabled")
+	}
+	if again := newBuildCache("", "/tmp/cache", "/src/app/pkg"); again.key != cache.key {
+		t.Errorf("expected a stable key, got %q and %q", cache.key, again.key)
+	}
+	if other := newBuildCache("", "/tmp/cache", "/src/app/other"); other.key == cache.key {
+		t.Errorf("expected different packages to have different keys, both got %q", cache.key)
+	}
+	if !strings.HasPrefix(cache.localRef(), BuildCacheRepository+":") {
+		t.Errorf("expected local ref in %s, got %q", BuildCacheRepository, cache.localRef())
+	}
+	if expected := filepath.Join("/tmp/cache", cache.key+".tar"); cache.file() != expected {
+		t.Errorf("expected file %q, got %q", expected, cache.file())
+	}
+
+	if newBuildCache("", "", "/src/app/pkg").enabled() {
+		t.Error("expected cache without registry and directory to be disabled")
+	}
+	if !newBuildCache("registry.example.com/cache:main", "", "/src/app/pkg").enabled() {
+		t.Error("expected cache with a registry to be enabled")
+	}
+}
+
+func TestLoadImage_MissingArchive(t *testing.T) {
+	t.Parallel()
+
+	err := loadImage(context.Background(), nil, filepath.Join(t.TempDir(), "missing.tar"))
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package dockertesting
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// buildCancelTimeout bounds how long asking the daemon to cancel a build may
+// take once the context of the build is done.
+const buildCancelTimeout = 10 * time.Second
+
+// newBuildID returns a unique ID for an image build, by which the build can
+// be canceled on the daemon.
+func newBuildID() string {
+	return uuid.NewString()
+}
+
+// cancelBuildOnDone asks the daemon to cancel the build with the given ID as
+// soon as ctx is done. Abandoning the build request alone stops reading its
+// output but does not reliably stop the build itself, BuildKit builds in
+// particular. The returned function stops watching ctx; it must be called
+// once the build has finished.
+func cancelBuildOnDone(ctx context.Context, provider *testcontainers.DockerProvider, buildID string) (stop func() bool) {
+	return context.AfterFunc(ctx, func() {
+		cancelCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), buildCancelTimeout)
+		defer cancel()
+
+		cli, closeClient, err := dockerClient(cancelCtx, provider)
+		if err != nil {
+			return
+		}
+		defer closeClient()
+		// Non-fatal: the build also fails once its request is abandoned
+		_ = cli.BuildCancel(cancelCtx, buildID)
+	})
+}
@@ -0,0 +1,25 @@
+package dockertesting
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNewBuildID_Unique(t *testing.T) {
+	t.Parallel()
+	first, second := newBuildID(), newBuildID()
+	if first == "" || first == second {
+		t.Errorf("expected unique non-empty build IDs, got %q and %q", first, second)
+	}
+}
+
+func TestCancelBuildOnDone_StopBeforeDone(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	stop := cancelBuildOnDone(ctx, nil, newBuildID())
+	if !stop() {
+		t.Error("expected stop to prevent the build cancellation")
+	}
+}
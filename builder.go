@@ -0,0 +1,467 @@
+package dockertesting
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// Builder configures a run step by step, as an alternative to passing options
+// to Run for those who prefer discovering the settings through methods. Each
+// method adds the option of the same name with the With prefix, so the
+// settings and their defaults are the same as with the options; With adds
+// options directly.
+//
+// A Builder is not safe for concurrent use. Its methods modify and return it,
+// so derive independent runs from separate Builders.
+//
+// Example:
+//
+//	result, err := dockertesting.New("./mypackage").
+//	    GoVersion("1.23").
+//	    Aliases("api.test").
+//	    VarSock().
+//	    Timeout(5 * time.Minute).
+//	    Run(ctx)
+type Builder struct {
+	packagePath string
+	opts        []Option
+}
+
+// New returns a Builder for running the tests of the package at packagePath.
+func New(packagePath string) *Builder {
+	return &Builder{packagePath: packagePath}
+}
+
+// With adds opts to the run.
+func (b *Builder) With(opts ...Option) *Builder {
+	b.opts = append(b.opts, opts...)
+	return b
+}
+
+// Options returns the Options of the run, or an error if they are invalid.
+func (b *Builder) Options() (*Options, error) {
+	return NewOptions(b.packagePath, b.opts...)
+}
+
+// Run executes the tests, see Run.
+func (b *Builder) Run(ctx context.Context) (*Result, error) {
+	return Run(ctx, b.packagePath, b.opts...)
+}
+
+// RunT executes the tests as part of the test t, see RunT.
+func (b *Builder) RunT(t *testing.T) *Result {
+	t.Helper()
+	return RunT(t, b.packagePath, b.opts...)
+}
+
+// Runner creates a Runner for running the tests repeatedly, see NewRunner.
+func (b *Builder) Runner(ctx context.Context) (*Runner, error) {
+	return NewRunner(ctx, b.packagePath, b.opts...)
+}
+
+// Warmup builds the test image and pulls the sidecar images, see Warmup.
+func (b *Builder) Warmup(ctx context.Context) error {
+	return Warmup(ctx, b.packagePath, b.opts...)
+}
+
+// Pattern sets the package pattern passed to go test, see WithPattern.
+func (b *Builder) Pattern(pattern string) *Builder {
+	return b.With(WithPattern(pattern))
+}
+
+// PackageSubdir runs go test in a directory of the module, see
+// WithPackageSubdir.
+func (b *Builder) PackageSubdir(dir string) *Builder {
+	return b.With(WithPackageSubdir(dir))
+}
+
+// ParallelModules sets how many modules RunAllModules runs at a time, see
+// WithParallelModules.
+func (b *Builder) ParallelModules(n int) *Builder {
+	return b.With(WithParallelModules(n))
+}
+
+// Args appends arguments to go test, see WithArgs.
+func (b *Builder) Args(args ...string) *Builder {
+	return b.With(WithArgs(args...))
+}
+
+// Name names the run, see WithName.
+func (b *Builder) Name(name string) *Builder {
+	return b.With(WithName(name))
+}
+
+// Aliases sets the DNS aliases of the test container, see WithAliases.
+func (b *Builder) Aliases(aliases ...string) *Builder {
+	return b.With(WithAliases(aliases...))
+}
+
+// Network attaches the run to a caller-created network, see WithNetwork.
+func (b *Builder) Network(network *DockerNetwork) *Builder {
+	return b.With(WithNetwork(network))
+}
+
+// NetworkCIDR sets the subnet of the network created for the run, see
+// WithNetworkCIDR.
+func (b *Builder) NetworkCIDR(cidr string) *Builder {
+	return b.With(WithNetworkCIDR(cidr))
+}
+
+// NetworkDriver sets the driver of the network created for the run and its
+// options, see WithNetworkDriver.
+func (b *Builder) NetworkDriver(driver string, opts map[string]string) *Builder {
+	return b.With(WithNetworkDriver(driver, opts))
+}
+
+// HostPorts makes ports of the host reachable from the test container, see
+// WithHostPorts.
+func (b *Builder) HostPorts(ports ...int) *Builder {
+	return b.With(WithHostPorts(ports...))
+}
+
+// StaticIP assigns a fixed address to the test container, see WithStaticIP.
+func (b *Builder) StaticIP(ip string) *Builder {
+	return b.With(WithStaticIP(ip))
+}
+
+// VarSock gives the test container access to the Docker daemon, see WithVarSock.
+func (b *Builder) VarSock() *Builder {
+	return b.With(WithVarSock())
+}
+
+// SockPath sets the path of the Docker socket on the host, see WithSockPath.
+func (b *Builder) SockPath(path string) *Builder {
+	return b.With(WithSockPath(path))
+}
+
+// ContainerdCompat adapts the container to containerd-compatible APIs, see WithContainerdCompat.
+func (b *Builder) ContainerdCompat() *Builder {
+	return b.With(WithContainerdCompat())
+}
+
+// Timeout sets the maximum duration of the run, see WithTimeout.
+func (b *Builder) Timeout(timeout time.Duration) *Builder {
+	return b.With(WithTimeout(timeout))
+}
+
+// CleanupTimeout sets the maximum duration for removing the resources, see WithCleanupTimeout.
+func (b *Builder) CleanupTimeout(timeout time.Duration) *Builder {
+	return b.With(WithCleanupTimeout(timeout))
+}
+
+// Init enables or disables the init process of the test container, see
+// WithInit.
+func (b *Builder) Init(enabled bool) *Builder {
+	return b.With(WithInit(enabled))
+}
+
+// StopTimeout lets the processes in the test container exit after SIGTERM, see WithStopTimeout.
+func (b *Builder) StopTimeout(timeout time.Duration) *Builder {
+	return b.With(WithStopTimeout(timeout))
+}
+
+// DockerfilePath sets a custom Dockerfile, see WithDockerfilePath.
+func (b *Builder) DockerfilePath(dockerfilePath string) *Builder {
+	return b.With(WithDockerfilePath(dockerfilePath))
+}
+
+// ImageFlavor selects the variant of the golang base image, see
+// WithImageFlavor.
+func (b *Builder) ImageFlavor(flavor ImageFlavor) *Builder {
+	return b.With(WithImageFlavor(flavor))
+}
+
+// GoTools adds tools to go install into the image, see WithGoTools.
+func (b *Builder) GoTools(tools ...string) *Builder {
+	return b.With(WithGoTools(tools...))
+}
+
+// DockerfileGenerator generates the Dockerfile, see WithDockerfileGenerator.
+func (b *Builder) DockerfileGenerator(generator DockerfileGenerator) *Builder {
+	return b.With(WithDockerfileGenerator(generator))
+}
+
+// BuildTarget selects the stage of a multi-stage Dockerfile, see
+// WithBuildTarget.
+func (b *Builder) BuildTarget(target string) *Builder {
+	return b.With(WithBuildTarget(target))
+}
+
+// Template replaces the embedded Dockerfile template, see WithTemplate.
+func (b *Builder) Template(source string) *Builder {
+	return b.With(WithTemplate(source))
+}
+
+// TemplateData sets data the Dockerfile template is rendered with, see
+// WithTemplateData.
+func (b *Builder) TemplateData(data map[string]any) *Builder {
+	return b.With(WithTemplateData(data))
+}
+
+// GoVersion sets the version of the golang base image, see WithGoVersion.
+func (b *Builder) GoVersion(version string) *Builder {
+	return b.With(WithGoVersion(version))
+}
+
+// Toolchain selects the Go toolchain through GOTOOLCHAIN, see WithToolchain.
+func (b *Builder) Toolchain(toolchain string) *Builder {
+	return b.With(WithToolchain(toolchain))
+}
+
+// SecretEnv passes a secret environment variable to the commands run in the
+// test container, see WithSecretEnv.
+func (b *Builder) SecretEnv(name, value string) *Builder {
+	return b.With(WithSecretEnv(name, value))
+}
+
+// SocketProxy gives the test container access to the Docker daemon through a
+// restricted proxy, see WithSocketProxy.
+func (b *Builder) SocketProxy(write ...SocketProxySection) *Builder {
+	return b.With(WithSocketProxy(write...))
+}
+
+// SockGuard sets guard rails on what the tests do with the Docker socket, see
+// WithSockGuard.
+func (b *Builder) SockGuard(guard SockGuard) *Builder {
+	return b.With(WithSockGuard(guard))
+}
+
+// ReadOnlySource makes the package source read-only inside the test
+// container, see WithReadOnlySource.
+func (b *Builder) ReadOnlySource() *Builder {
+	return b.With(WithReadOnlySource())
+}
+
+// Root runs the tests as root instead of TestUser, see WithRoot.
+func (b *Builder) Root() *Builder {
+	return b.With(WithRoot())
+}
+
+// HostProxyEnv copies the proxy variables of the host into the build and the
+// test container, see WithHostProxyEnv.
+func (b *Builder) HostProxyEnv() *Builder {
+	return b.With(WithHostProxyEnv())
+}
+
+// TLSBundle trusts the CA certificates in certsDir in the test image, see
+// WithTLSBundle.
+func (b *Builder) TLSBundle(certsDir string) *Builder {
+	return b.With(WithTLSBundle(certsDir))
+}
+
+// GitCredentials configures git for fetching private modules, see
+// WithGitCredentials.
+func (b *Builder) GitCredentials(creds ...GitCredentials) *Builder {
+	return b.With(WithGitCredentials(creds...))
+}
+
+// SetupCommands adds commands to run before go test, see WithSetupCommands.
+func (b *Builder) SetupCommands(commands ...[]string) *Builder {
+	return b.With(WithSetupCommands(commands...))
+}
+
+// TeardownCommands adds commands to run after go test, see WithTeardownCommands.
+func (b *Builder) TeardownCommands(commands ...[]string) *Builder {
+	return b.With(WithTeardownCommands(commands...))
+}
+
+// OutputCallback sets a callback for every line of output, see WithOutputCallback.
+func (b *Builder) OutputCallback(callback func(OutputLine)) *Builder {
+	return b.With(WithOutputCallback(callback))
+}
+
+// ProgressReporter sets a reporter for the milestones of the run, see WithProgressReporter.
+func (b *Builder) ProgressReporter(reporter ProgressReporter) *Builder {
+	return b.With(WithProgressReporter(reporter))
+}
+
+// Logger sets a logger for the operations of the run, see WithLogger.
+func (b *Builder) Logger(logger *slog.Logger) *Builder {
+	return b.With(WithLogger(logger))
+}
+
+// Metrics records the statistics of the run, see WithMetrics.
+func (b *Builder) Metrics(metrics Metrics) *Builder {
+	return b.With(WithMetrics(metrics))
+}
+
+// Hooks invokes hooks at the milestones of the run, see WithHooks.
+func (b *Builder) Hooks(hooks Hooks) *Builder {
+	return b.With(WithHooks(hooks))
+}
+
+// FailFast passes -failfast to go test, see WithFailFast.
+func (b *Builder) FailFast() *Builder {
+	return b.With(WithFailFast())
+}
+
+// Short passes -short to go test, see WithShort.
+func (b *Builder) Short() *Builder {
+	return b.With(WithShort())
+}
+
+// Verbosity sets the output verbosity, see WithVerbosity.
+func (b *Builder) Verbosity(level Verbosity) *Builder {
+	return b.With(WithVerbosity(level))
+}
+
+// CoverMode sets the coverage mode, see WithCoverMode.
+func (b *Builder) CoverMode(mode CoverMode) *Builder {
+	return b.With(WithCoverMode(mode))
+}
+
+// CoverageOutput writes the coverage profile to a file on the host, see WithCoverageOutput.
+func (b *Builder) CoverageOutput(path string) *Builder {
+	return b.With(WithCoverageOutput(path))
+}
+
+// CoberturaReport converts the coverage to a Cobertura XML report, see WithCoberturaReport.
+func (b *Builder) CoberturaReport() *Builder {
+	return b.With(WithCoberturaReport())
+}
+
+// CPUProfile collects a CPU profile, see WithCPUProfile.
+func (b *Builder) CPUProfile() *Builder {
+	return b.With(WithCPUProfile())
+}
+
+// MemProfile collects a heap profile, see WithMemProfile.
+func (b *Builder) MemProfile() *Builder {
+	return b.With(WithMemProfile())
+}
+
+// MemProfileRate sets the memory profiling rate, see WithMemProfileRate.
+func (b *Builder) MemProfileRate(rate int) *Builder {
+	return b.With(WithMemProfileRate(rate))
+}
+
+// BlockProfile collects a goroutine blocking profile, see WithBlockProfile.
+func (b *Builder) BlockProfile() *Builder {
+	return b.With(WithBlockProfile())
+}
+
+// MutexProfile collects a mutex contention profile, see WithMutexProfile.
+func (b *Builder) MutexProfile() *Builder {
+	return b.With(WithMutexProfile())
+}
+
+// Artifacts adds files to copy out of the test container, see WithArtifacts.
+func (b *Builder) Artifacts(patterns ...string) *Builder {
+	return b.With(WithArtifacts(patterns...))
+}
+
+// ArtifactsDir writes the artifacts to a directory on the host, see WithArtifactsDir.
+func (b *Builder) ArtifactsDir(dir string) *Builder {
+	return b.With(WithArtifactsDir(dir))
+}
+
+// CompileOnly compiles the test binaries without running them, see WithCompileOnly.
+func (b *Builder) CompileOnly() *Builder {
+	return b.With(WithCompileOnly())
+}
+
+// BuildKit builds the image with BuildKit if available, see WithBuildKit.
+func (b *Builder) BuildKit() *Builder {
+	return b.With(WithBuildKit())
+}
+
+// LazyModDownload downloads modules at test time into a shared volume, see WithLazyModDownload.
+func (b *Builder) LazyModDownload() *Builder {
+	return b.With(WithLazyModDownload())
+}
+
+// ImageCache reuses images built from an identical build context, see WithImageCache.
+func (b *Builder) ImageCache(enabled bool) *Builder {
+	return b.With(WithImageCache(enabled))
+}
+
+// BuildCacheRegistry imports and exports the build cache through a registry, see WithBuildCacheRegistry.
+func (b *Builder) BuildCacheRegistry(ref string) *Builder {
+	return b.With(WithBuildCacheRegistry(ref))
+}
+
+// BuildCacheDir imports and exports the build cache through a directory, see WithBuildCacheDir.
+func (b *Builder) BuildCacheDir(dir string) *Builder {
+	return b.With(WithBuildCacheDir(dir))
+}
+
+// ContextExcludes sets the patterns left out of the build context, see WithContextExcludes.
+func (b *Builder) ContextExcludes(patterns ...string) *Builder {
+	return b.With(WithContextExcludes(patterns...))
+}
+
+// MaxContextSize limits the size of the build context, see WithMaxContextSize.
+func (b *Builder) MaxContextSize(maxBytes int64) *Builder {
+	return b.With(WithMaxContextSize(maxBytes))
+}
+
+// KeepFailedBuild tags the last built layer of a failed build, see WithKeepFailedBuild.
+func (b *Builder) KeepFailedBuild() *Builder {
+	return b.With(WithKeepFailedBuild())
+}
+
+// Sidecar adds a sidecar container, see WithSidecar.
+func (b *Builder) Sidecar(spec SidecarSpec) *Builder {
+	return b.With(WithSidecar(spec))
+}
+
+// WaitStrategy sets the readiness check of the test container, see WithWaitStrategy.
+func (b *Builder) WaitStrategy(strategy wait.Strategy) *Builder {
+	return b.With(WithWaitStrategy(strategy))
+}
+
+// SignalCleanup removes the resources on SIGINT and SIGTERM, see WithSignalCleanup.
+func (b *Builder) SignalCleanup() *Builder {
+	return b.With(WithSignalCleanup())
+}
+
+// Vendor forces or disables the use of the vendor directory, see WithVendor.
+func (b *Builder) Vendor(enabled bool) *Builder {
+	return b.With(WithVendor(enabled))
+}
+
+// Reaper enables or disables the testcontainers reaper, see WithReaper.
+func (b *Builder) Reaper(enabled bool) *Builder {
+	return b.With(WithReaper(enabled))
+}
+
+// ReaperSessionID sets the reaper session of the resources, see WithReaperSessionID.
+func (b *Builder) ReaperSessionID(id string) *Builder {
+	return b.With(WithReaperSessionID(id))
+}
+
+// KeepResources leaves the resources running after the run, see WithKeepResources.
+func (b *Builder) KeepResources() *Builder {
+	return b.With(WithKeepResources())
+}
+
+// KeepOnFailure leaves the resources running after a failed run, see WithKeepOnFailure.
+func (b *Builder) KeepOnFailure() *Builder {
+	return b.With(WithKeepOnFailure())
+}
+
+// Probe adds services to probe before the build, see WithProbe.
+func (b *Builder) Probe(targets ...ProbeTarget) *Builder {
+	return b.With(WithProbe(targets...))
+}
+
+// Seed adds scripts to seed a sidecar with, see WithSeed.
+func (b *Builder) Seed(sidecarName string, scripts ...string) *Builder {
+	return b.With(WithSeed(sidecarName, scripts...))
+}
+
+// DockerProvider sets the testcontainers provider for the Docker API calls, see WithDockerProvider.
+func (b *Builder) DockerProvider(provider *testcontainers.DockerProvider) *Builder {
+	return b.With(WithDockerProvider(provider))
+}
+
+// DockerClient sets the client for the Docker API calls, see WithDockerClient.
+func (b *Builder) DockerClient(cli client.APIClient) *Builder {
+	return b.With(WithDockerClient(cli))
+}
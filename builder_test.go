@@ -0,0 +1,78 @@
+package dockertesting
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuilder_Options(t *testing.T) {
+	t.Parallel()
+	opts, err := New("/path/to/package").
+		GoVersion("1.23").
+		Aliases("api.test").
+		VarSock().
+		Timeout(5*time.Minute).
+		Args("-run", "TestA").
+		With(WithShort()).
+		Options()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.PackagePath != "/path/to/package" {
+		t.Errorf("expected PackagePath %q, got %q", "/path/to/package", opts.PackagePath)
+	}
+	if opts.GoVersion != "1.23" {
+		t.Errorf("expected GoVersion %q, got %q", "1.23", opts.GoVersion)
+	}
+	if !slices.Equal(opts.Aliases, []string{"api.test"}) {
+		t.Errorf("expected Aliases %q, got %q", []string{"api.test"}, opts.Aliases)
+	}
+	if !opts.EnableVarSock {
+		t.Error("expected EnableVarSock to be true")
+	}
+	if opts.Timeout != 5*time.Minute {
+		t.Errorf("expected Timeout %v, got %v", 5*time.Minute, opts.Timeout)
+	}
+	if !slices.Equal(opts.Args, []string{"-run", "TestA"}) {
+		t.Errorf("expected Args %q, got %q", []string{"-run", "TestA"}, opts.Args)
+	}
+	if !opts.Short {
+		t.Error("expected Short to be true")
+	}
+}
+
+func TestBuilder_InvalidOptions(t *testing.T) {
+	t.Parallel()
+	if _, err := New("").Options(); err == nil {
+		t.Error("expected error for empty package path")
+	}
+}
+
+// TestBuilder_CoversAllOptions verifies that every WithX option has a Builder
+// method X.
+func TestBuilder_CoversAllOptions(t *testing.T) {
+	t.Parallel()
+	file, err := parser.ParseFile(token.NewFileSet(), "options.go", nil, 0)
+	if err != nil {
+		t.Fatalf("failed to parse options.go: %v", err)
+	}
+
+	builder := reflect.TypeFor[*Builder]()
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || !strings.HasPrefix(fn.Name.Name, "With") {
+			continue
+		}
+		method := strings.TrimPrefix(fn.Name.Name, "With")
+		if _, ok := builder.MethodByName(method); !ok {
+			t.Errorf("expected Builder method %s for option %s", method, fn.Name.Name)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package dockertesting
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types/build"
+	"github.com/docker/docker/api/types/versions"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// minBuildKitAPIVersion is the first daemon API version that can build images
+// with BuildKit.
+const minBuildKitAPIVersion = "1.39"
+
+// buildKitAvailable reports whether the daemon can build images with BuildKit.
+func buildKitAvailable(ctx context.Context, provider *testcontainers.DockerProvider) (bool, error) {
+	cli, closeClient, err := dockerClient(ctx, provider)
+	if err != nil {
+		return false, err
+	}
+	defer closeClient()
+
+	ping, err := cli.Ping(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to ping docker daemon: %w", err)
+	}
+	return supportsBuildKit(ping.BuilderVersion, ping.APIVersion, ping.OSType), nil
+}
+
+// supportsBuildKit reports whether a daemon with the given default builder,
+// API version and OS type can build with BuildKit. BuildKit is not available
+// for Windows containers.
+func supportsBuildKit(builderVersion build.BuilderVersion, apiVersion, osType string) bool {
+	if builderVersion == build.BuilderBuildKit {
+		return true
+	}
+	return osType != "windows" && apiVersion != "" && versions.GreaterThanOrEqualTo(apiVersion, minBuildKitAPIVersion)
+}
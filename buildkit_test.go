@@ -0,0 +1,52 @@
+package dockertesting
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/build"
+)
+
+func TestSupportsBuildKit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		builderVersion build.BuilderVersion
+		apiVersion     string
+		osType         string
+		expected       bool
+	}{
+		{name: "buildkit default builder", builderVersion: build.BuilderBuildKit, apiVersion: "1.51", osType: "linux", expected: true},
+		{name: "classic default on recent daemon", builderVersion: build.BuilderV1, apiVersion: "1.51", osType: "linux", expected: true},
+		{name: "minimum api version", apiVersion: "1.39", osType: "linux", expected: true},
+		{name: "old daemon", apiVersion: "1.38", osType: "linux", expected: false},
+		{name: "windows containers", builderVersion: build.BuilderV1, apiVersion: "1.51", osType: "windows", expected: false},
+		{name: "unknown api version", osType: "linux", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := supportsBuildKit(tt.builderVersion, tt.apiVersion, tt.osType); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestBuildKitDockerfileTemplate(t *testing.T) {
+	t.Parallel()
+
+	for _, mount := range []string{
+		"--mount=type=cache,id=dockertesting-gomodcache,target=/tmp/gomodcache",
+		"--mount=type=cache,id=dockertesting-gocache,target=/root/.cache/go-build",
+	} {
+		if !strings.Contains(buildKitDockerfileTemplate, mount) {
+			t.Errorf("expected BuildKit template to contain %q", mount)
+		}
+	}
+	if strings.Contains(dockerfileTemplate, "--mount") {
+		t.Error("expected the classic template to work without BuildKit")
+	}
+}
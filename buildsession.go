@@ -0,0 +1,44 @@
+package dockertesting
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/session/secrets/secretsprovider"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// startBuildSession starts a BuildKit session serving secrets to the builds
+// that refer to its ID, so that they are mounted with RUN --mount=type=secret
+// rather than passed as build args, which are recorded in the image history.
+// The returned function closes the session; it must be called once the build
+// has finished.
+func startBuildSession(ctx context.Context, provider *testcontainers.DockerProvider, secrets map[string][]byte) (id string, stop func(), err error) {
+	cli, closeClient, err := dockerClient(ctx, provider)
+	if err != nil {
+		return "", nil, err
+	}
+	s, err := session.NewSession(ctx, "dockertesting")
+	if err != nil {
+		closeClient()
+		return "", nil, fmt.Errorf("failed to create build session: %w", err)
+	}
+	s.Allow(secretsprovider.FromMap(secrets))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// Non-fatal: a build mounting the secrets fails without the session
+		_ = s.Run(context.WithoutCancel(ctx), func(ctx context.Context, proto string, meta map[string][]string) (net.Conn, error) {
+			return cli.DialHijack(ctx, "/session", proto, meta)
+		})
+	}()
+	return s.ID(), func() {
+		// Non-fatal: the build is done with the session
+		_ = s.Close()
+		<-done
+		closeClient()
+	}, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stringList is a flag that can be repeated, collecting its values.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// intList is a flag that can be repeated, collecting its integer values.
+type intList []int
+
+func (l *intList) String() string {
+	values := make([]string, len(*l))
+	for i, v := range *l {
+		values[i] = strconv.Itoa(v)
+	}
+	return strings.Join(values, ",")
+}
+
+func (l *intList) Set(value string) error {
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, v)
+	return nil
+}
+
+// keyValueFlag is a flag that can be repeated, collecting key=value pairs.
+type keyValueFlag map[string]string
+
+func (f *keyValueFlag) String() string {
+	pairs := make([]string, 0, len(*f))
+	for _, key := range slices.Sorted(maps.Keys(*f)) {
+		pairs = append(pairs, key+"="+(*f)[key])
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (f *keyValueFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	if *f == nil {
+		*f = make(keyValueFlag)
+	}
+	(*f)[key] = val
+	return nil
+}
+
+// durationFlag is a duration flag that records whether it was set, so that
+// unset flags keep the library defaults.
+type durationFlag struct {
+	value time.Duration
+	set   bool
+}
+
+func (d *durationFlag) String() string {
+	if !d.set {
+		return ""
+	}
+	return d.value.String()
+}
+
+func (d *durationFlag) Set(value string) error {
+	v, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	d.value, d.set = v, true
+	return nil
+}
@@ -0,0 +1,573 @@
+// Command dockertesting runs the tests of a Go package inside a Docker
+// container from the shell, for CI pipelines that do not want to write a Go
+// wrapper around the library.
+//
+// Usage:
+//
+//	dockertesting run [flags] <package path> [-- go test flags]
+//
+// Example:
+//
+//	dockertesting run ./mypkg --alias myapp.test --var-sock --junit out.xml --coverage cover.out
+//
+// Flags may appear before or after the package path. Arguments after "--"
+// are passed to go test. The exit code is that of go test, 1 if the run
+// failed before the tests finished, and 2 for invalid arguments.
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/djosh34/dockertesting"
+)
+
+// Exit codes besides the exit code of go test.
+const (
+	exitError = 1
+	exitUsage = 2
+)
+
+const usage = `Usage: dockertesting run [flags] <package path> [-- go test flags]
+
+Runs the tests of the Go package in a Docker container.
+
+Flags:
+`
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command line args and returns the exit code.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "run" {
+		if len(args) > 0 && (args[0] == "help" || args[0] == "-h" || args[0] == "--help") {
+			fmt.Fprint(stdout, usage)
+			newFlagSet(&config{}, stdout).PrintDefaults()
+			return 0
+		}
+		fmt.Fprint(stderr, usage)
+		newFlagSet(&config{}, stderr).PrintDefaults()
+		return exitUsage
+	}
+
+	cfg, err := parseArgs(args[1:], stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "dockertesting: %v\n", err)
+		return exitUsage
+	}
+
+	result, err := dockertesting.Run(ctx, cfg.packagePath, cfg.options(stdout, stderr)...)
+	if err != nil {
+		fmt.Fprintf(stderr, "dockertesting: %v\n", err)
+		var buildErr *dockertesting.BuildError
+		if errors.As(err, &buildErr) {
+			if cfg.verbosity() < dockertesting.VerbosityVerbose {
+				stderr.Write(buildErr.Log)
+			}
+			cfg.annotate(stdout, stderr, buildErr.Log)
+		}
+		return exitError
+	}
+	if result.ExitCode != 0 {
+		cfg.annotate(stdout, stderr, result.Stdout)
+	}
+	reportRaces(stderr, result.Races)
+	reportCrashes(stderr, result.Crashes)
+
+	if err := cfg.writeReports(result); err != nil {
+		fmt.Fprintf(stderr, "dockertesting: %v\n", err)
+		return exitError
+	}
+	return result.ExitCode
+}
+
+// config holds the parsed command line of dockertesting run.
+type config struct {
+	packagePath string
+	testArgs    []string
+
+	name           string
+	aliases        stringList
+	networkCIDR    string
+	staticIP       string
+	hostPorts      intList
+	networkDriver  string
+	networkOpts    keyValueFlag
+	varSock        bool
+	root           bool
+	readOnly       bool
+	socketProxy    bool
+	proxyWrite     stringList
+	registries     stringList
+	requireLabel   bool
+	cleanupLeaks   bool
+	guardFail      bool
+	sockPath       string
+	containerd     bool
+	init           bool
+	timeout        durationFlag
+	cleanupTimeout durationFlag
+	stopTimeout    durationFlag
+	dockerfile     string
+	goVersion      string
+	buildTarget    string
+	flavor         string
+	goTools        stringList
+	toolchain      string
+	pattern        string
+	subdir         string
+	setup          stringList
+	teardown       stringList
+	failFast       bool
+	short          bool
+	verbose        bool
+	quiet          bool
+	coverMode      string
+	coverage       string
+	cobertura      string
+	junit          string
+	summary        string
+	benchstat      string
+	annotations    bool
+	artifacts      stringList
+	artifactsDir   string
+	buildKit       bool
+	lazyModules    bool
+	proxyEnv       bool
+	tlsBundle      string
+	secretEnv      stringList
+	secrets        map[string]string
+	imageCache     bool
+	keepOnFailure  bool
+	keepResources  bool
+	logLevel       string
+	level          slog.Level
+}
+
+// newFlagSet returns the flags of dockertesting run, bound to cfg.
+func newFlagSet(cfg *config, output io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("dockertesting run", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.Usage = func() {
+		fmt.Fprint(output, usage)
+		fs.PrintDefaults()
+	}
+
+	fs.StringVar(&cfg.name, "name", "", "name of the run, prefixed to the output lines")
+	fs.Var(&cfg.aliases, "alias", "DNS alias of the test container (repeatable)")
+	fs.StringVar(&cfg.networkCIDR, "network-cidr", "", "IPv4 subnet of the network, e.g. 172.28.0.0/16")
+	fs.StringVar(&cfg.networkDriver, "network-driver", "", "driver of the network, default bridge")
+	fs.Var(&cfg.networkOpts, "network-opt", "driver option of the network, e.g. com.docker.network.driver.mtu=1400 (repeatable)")
+	fs.StringVar(&cfg.staticIP, "static-ip", "", "IPv4 address of the test container in the -network-cidr subnet")
+	fs.Var(&cfg.hostPorts, "host-port", "port of the host reachable from the test container at "+dockertesting.HostAlias+" (repeatable)")
+	fs.BoolVar(&cfg.varSock, "var-sock", false, "mount the Docker socket into the test container")
+	fs.BoolVar(&cfg.socketProxy, "socket-proxy", false, "give the test container read-only access to the Docker daemon through a restricted proxy instead of the socket")
+	fs.Var(&cfg.proxyWrite, "socket-proxy-write", "section of the Docker API the tests may write to through -socket-proxy, e.g. CONTAINERS, or testcontainers for all it needs (repeatable)")
+	fs.Var(&cfg.registries, "trusted-registry", "registry or repository prefix the tests may pull images from through the Docker daemon (repeatable)")
+	fs.BoolVar(&cfg.requireLabel, "require-run-label", false, "require the resources the tests create through the Docker daemon to carry "+dockertesting.LabelRunID)
+	fs.BoolVar(&cfg.cleanupLeaks, "cleanup-leaks", false, "remove the containers the tests leaked through the Docker daemon")
+	fs.BoolVar(&cfg.guardFail, "sock-guard-fail", false, "fail the run on violations of -trusted-registry and -require-run-label instead of warning")
+	fs.BoolVar(&cfg.root, "root", false, "run the tests as root instead of the unprivileged "+dockertesting.TestUser+" user")
+	fs.BoolVar(&cfg.readOnly, "read-only-source", false, "make the package source read-only inside the test container")
+	fs.StringVar(&cfg.sockPath, "sock-path", "", "path of the Docker socket on the host")
+	fs.BoolVar(&cfg.containerd, "containerd", false, "adapt the container to containerd-compatible APIs")
+	fs.Var(&cfg.timeout, "timeout", "maximum duration of the run, e.g. 10m")
+	fs.Var(&cfg.cleanupTimeout, "cleanup-timeout", "maximum duration for removing the resources")
+	fs.BoolVar(&cfg.init, "init", true, "run an init process as PID 1 of the test container")
+	fs.Var(&cfg.stopTimeout, "stop-timeout", "grace period for the processes in the container after SIGTERM")
+	fs.StringVar(&cfg.dockerfile, "dockerfile", "", "custom Dockerfile for the test image")
+	fs.StringVar(&cfg.goVersion, "go-version", "", "version of the golang base image")
+	fs.StringVar(&cfg.buildTarget, "target", "", "stage of a multi-stage Dockerfile to build the test container from")
+	fs.StringVar(&cfg.flavor, "flavor", "", "variant of the golang base image, e.g. alpine or bookworm")
+	fs.Var(&cfg.goTools, "tool", "Go tool to install into the image, e.g. golang.org/x/tools/cmd/stringer@latest (repeatable)")
+	fs.StringVar(&cfg.toolchain, "toolchain", "", "GOTOOLCHAIN of go test, e.g. go1.23.4, without rebuilding the image")
+	fs.StringVar(&cfg.pattern, "pattern", dockertesting.DefaultPattern, "package pattern passed to go test")
+	fs.StringVar(&cfg.subdir, "subdir", "", "directory of the module to run go test in, e.g. internal/service")
+	fs.Var(&cfg.setup, "setup", "shell command to run before go test (repeatable)")
+	fs.Var(&cfg.teardown, "teardown", "shell command to run after go test (repeatable)")
+	fs.BoolVar(&cfg.failFast, "failfast", false, "pass -failfast to go test")
+	fs.BoolVar(&cfg.short, "short", false, "pass -short to go test")
+	fs.BoolVar(&cfg.verbose, "v", false, "pass -v to go test and show the build output")
+	fs.BoolVar(&cfg.quiet, "q", false, "only report errors")
+	fs.StringVar(&cfg.coverMode, "covermode", "", "coverage mode: set, count or atomic")
+	fs.StringVar(&cfg.coverage, "coverage", "", "write the coverage profile to `file`")
+	fs.StringVar(&cfg.cobertura, "cobertura", "", "write the coverage as a Cobertura XML report to `file`")
+	fs.StringVar(&cfg.junit, "junit", "", "write a JUnit XML report to `file`")
+	fs.StringVar(&cfg.summary, "summary", "", "write a JSON summary of the run to `file`")
+	fs.StringVar(&cfg.benchstat, "benchstat", "", "write the benchmark results in benchstat format to `file`")
+	fs.BoolVar(&cfg.annotations, "github-annotations", false, "annotate failed tests and build errors for GitHub Actions")
+	fs.Var(&cfg.artifacts, "artifact", "glob of files to copy out of the container (repeatable)")
+	fs.StringVar(&cfg.artifactsDir, "artifacts-dir", "", "directory to write the artifacts to")
+	fs.BoolVar(&cfg.buildKit, "buildkit", false, "build the image with BuildKit if available")
+	fs.BoolVar(&cfg.lazyModules, "lazy-mod-download", false, "download modules at test time into a shared volume")
+	fs.BoolVar(&cfg.proxyEnv, "proxy-env", false, "copy HTTP_PROXY, HTTPS_PROXY and NO_PROXY of the host into the build and the test container")
+	fs.StringVar(&cfg.tlsBundle, "tls-bundle", "", "directory of CA certificates (.crt, .pem) to trust in the test image")
+	fs.Var(&cfg.secretEnv, "secret-env", "environment variable passed to the tests at exec time only and redacted from the output (repeatable)")
+	fs.BoolVar(&cfg.imageCache, "image-cache", false, "reuse images built from an identical build context")
+	fs.BoolVar(&cfg.keepOnFailure, "keep-on-failure", false, "leave the resources running after a failed run")
+	fs.BoolVar(&cfg.keepResources, "keep-resources", false, "leave the resources running after the run")
+	fs.StringVar(&cfg.logLevel, "log-level", "", "log the operations of the run to stderr at `level`: debug, info, warn or error")
+	return fs
+}
+
+// parseArgs parses the arguments of dockertesting run. Unlike the flag
+// package, it accepts flags after the package path.
+func parseArgs(args []string, output io.Writer) (*config, error) {
+	cfg := &config{}
+	fs := newFlagSet(cfg, output)
+
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		// Parse consumes a "--" terminator, which starts the go test flags
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			cfg.testArgs = rest
+			break
+		}
+		if len(rest) == 0 {
+			break
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+
+	switch len(positional) {
+	case 0:
+		return nil, errors.New("missing package path")
+	case 1:
+		cfg.packagePath = positional[0]
+	default:
+		return nil, fmt.Errorf("expected one package path, got %s", strings.Join(positional, " "))
+	}
+	if cfg.verbose && cfg.quiet {
+		return nil, errors.New("-v and -q are mutually exclusive")
+	}
+	if cfg.logLevel != "" {
+		if err := cfg.level.UnmarshalText([]byte(cfg.logLevel)); err != nil {
+			return nil, fmt.Errorf("invalid -log-level: %w", err)
+		}
+	}
+	// Secrets are read from the environment, so they do not show up in the
+	// command line of the process
+	for _, name := range cfg.secretEnv {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return nil, fmt.Errorf("-secret-env %s is not set in the environment", name)
+		}
+		if cfg.secrets == nil {
+			cfg.secrets = make(map[string]string)
+		}
+		cfg.secrets[name] = value
+	}
+	return cfg, nil
+}
+
+// verbosity returns the verbosity selected by -v and -q.
+func (c *config) verbosity() dockertesting.Verbosity {
+	switch {
+	case c.quiet:
+		return dockertesting.VerbosityQuiet
+	case c.verbose:
+		return dockertesting.VerbosityVerbose
+	default:
+		return dockertesting.DefaultVerbosity
+	}
+}
+
+// options returns the options of the run. With -junit, the tests run with
+// -json and their output is decoded to stdout. Logs are written to stderr.
+func (c *config) options(stdout, stderr io.Writer) []dockertesting.Option {
+	opts := []dockertesting.Option{
+		dockertesting.WithPattern(c.pattern),
+		dockertesting.WithSignalCleanup(),
+	}
+	if c.name != "" {
+		opts = append(opts, dockertesting.WithName(c.name))
+	}
+	if len(c.aliases) > 0 {
+		opts = append(opts, dockertesting.WithAliases(c.aliases...))
+	}
+	if c.networkCIDR != "" {
+		opts = append(opts, dockertesting.WithNetworkCIDR(c.networkCIDR))
+	}
+	if c.networkDriver != "" || len(c.networkOpts) > 0 {
+		opts = append(opts, dockertesting.WithNetworkDriver(c.networkDriver, c.networkOpts))
+	}
+	if len(c.hostPorts) > 0 {
+		opts = append(opts, dockertesting.WithHostPorts(c.hostPorts...))
+	}
+	if c.staticIP != "" {
+		opts = append(opts, dockertesting.WithStaticIP(c.staticIP))
+	}
+	if c.varSock {
+		opts = append(opts, dockertesting.WithVarSock())
+	}
+	if c.socketProxy || len(c.proxyWrite) > 0 {
+		var write []dockertesting.SocketProxySection
+		for _, section := range c.proxyWrite {
+			if strings.EqualFold(section, "testcontainers") {
+				write = append(write, dockertesting.SocketProxyTestcontainers...)
+			} else {
+				write = append(write, dockertesting.SocketProxySection(strings.ToUpper(section)))
+			}
+		}
+		opts = append(opts, dockertesting.WithSocketProxy(write...))
+	}
+	if len(c.registries) > 0 || c.requireLabel || c.cleanupLeaks || c.guardFail {
+		guard := dockertesting.SockGuard{
+			TrustedRegistries: c.registries,
+			RequireLabel:      c.requireLabel,
+			Cleanup:           c.cleanupLeaks,
+		}
+		if c.guardFail {
+			guard.Mode = dockertesting.SockGuardFail
+		}
+		opts = append(opts, dockertesting.WithSockGuard(guard))
+	}
+	if c.root {
+		opts = append(opts, dockertesting.WithRoot())
+	}
+	if c.readOnly {
+		opts = append(opts, dockertesting.WithReadOnlySource())
+	}
+	if c.sockPath != "" {
+		opts = append(opts, dockertesting.WithSockPath(c.sockPath))
+	}
+	if c.containerd {
+		opts = append(opts, dockertesting.WithContainerdCompat())
+	}
+	if c.timeout.set {
+		opts = append(opts, dockertesting.WithTimeout(c.timeout.value))
+	}
+	if c.cleanupTimeout.set {
+		opts = append(opts, dockertesting.WithCleanupTimeout(c.cleanupTimeout.value))
+	}
+	if !c.init {
+		opts = append(opts, dockertesting.WithInit(false))
+	}
+	if c.stopTimeout.set {
+		opts = append(opts, dockertesting.WithStopTimeout(c.stopTimeout.value))
+	}
+	if c.dockerfile != "" {
+		opts = append(opts, dockertesting.WithDockerfilePath(c.dockerfile))
+	}
+	if c.goVersion != "" {
+		opts = append(opts, dockertesting.WithGoVersion(c.goVersion))
+	}
+	if c.buildTarget != "" {
+		opts = append(opts, dockertesting.WithBuildTarget(c.buildTarget))
+	}
+	if c.flavor != "" {
+		opts = append(opts, dockertesting.WithImageFlavor(dockertesting.ImageFlavor(c.flavor)))
+	}
+	if len(c.goTools) > 0 {
+		opts = append(opts, dockertesting.WithGoTools(c.goTools...))
+	}
+	if c.toolchain != "" {
+		opts = append(opts, dockertesting.WithToolchain(c.toolchain))
+	}
+	if c.subdir != "" {
+		opts = append(opts, dockertesting.WithPackageSubdir(c.subdir))
+	}
+	for _, command := range c.setup {
+		opts = append(opts, dockertesting.WithSetupCommands([]string{"sh", "-c", command}))
+	}
+	for _, command := range c.teardown {
+		opts = append(opts, dockertesting.WithTeardownCommands([]string{"sh", "-c", command}))
+	}
+	if c.failFast {
+		opts = append(opts, dockertesting.WithFailFast())
+	}
+	if c.short {
+		opts = append(opts, dockertesting.WithShort())
+	}
+	if c.coverMode != "" {
+		opts = append(opts, dockertesting.WithCoverMode(dockertesting.CoverMode(c.coverMode)))
+	}
+	if c.coverage != "" {
+		opts = append(opts, dockertesting.WithCoverageOutput(c.coverage))
+	}
+	if c.cobertura != "" {
+		opts = append(opts, dockertesting.WithCoberturaReport())
+	}
+	if len(c.artifacts) > 0 {
+		opts = append(opts, dockertesting.WithArtifacts(c.artifacts...))
+	}
+	if c.artifactsDir != "" {
+		opts = append(opts, dockertesting.WithArtifactsDir(c.artifactsDir))
+	}
+	if c.buildKit {
+		opts = append(opts, dockertesting.WithBuildKit())
+	}
+	if c.lazyModules {
+		opts = append(opts, dockertesting.WithLazyModDownload())
+	}
+	if c.proxyEnv {
+		opts = append(opts, dockertesting.WithHostProxyEnv())
+	}
+	if c.tlsBundle != "" {
+		opts = append(opts, dockertesting.WithTLSBundle(c.tlsBundle))
+	}
+	for _, name := range c.secretEnv {
+		opts = append(opts, dockertesting.WithSecretEnv(name, c.secrets[name]))
+	}
+	if c.imageCache {
+		opts = append(opts, dockertesting.WithImageCache(true))
+	}
+	if c.keepOnFailure {
+		opts = append(opts, dockertesting.WithKeepOnFailure())
+	}
+	if c.keepResources {
+		opts = append(opts, dockertesting.WithKeepResources())
+	}
+	if c.logLevel != "" {
+		logger := slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: c.level}))
+		opts = append(opts, dockertesting.WithLogger(logger))
+	}
+
+	testArgs := c.testArgs
+	verbosity := c.verbosity()
+	if c.junit != "" {
+		// The JSON events are decoded by the callback instead of forwarded as is
+		testArgs = append([]string{"-json"}, testArgs...)
+		if verbosity >= dockertesting.VerbosityVerbose {
+			testArgs = append([]string{"-v"}, testArgs...)
+		}
+		if verbosity >= dockertesting.VerbosityNormal {
+			opts = append(opts, dockertesting.WithOutputCallback(decodeOutput(stdout, verbosity)))
+		}
+		verbosity = dockertesting.VerbosityQuiet
+	}
+	if len(testArgs) > 0 {
+		opts = append(opts, dockertesting.WithArgs(testArgs...))
+	}
+	return append(opts, dockertesting.WithVerbosity(verbosity))
+}
+
+// decodeOutput returns an output callback writing the output of go test -json
+// to w as go test would print it without -json. Other lines are written as
+// they are, the build output only with VerbosityVerbose.
+func decodeOutput(w io.Writer, verbosity dockertesting.Verbosity) func(dockertesting.OutputLine) {
+	return func(line dockertesting.OutputLine) {
+		if line.Source == dockertesting.OutputSourceBuild {
+			if verbosity >= dockertesting.VerbosityVerbose {
+				fmt.Fprintln(w, line.Text)
+			}
+			return
+		}
+		var event dockertesting.TestEvent
+		if strings.HasPrefix(line.Text, "{") && json.Unmarshal([]byte(line.Text), &event) == nil && event.Action != "" {
+			if event.Action == "output" {
+				fmt.Fprint(w, event.Output)
+			}
+			return
+		}
+		fmt.Fprintln(w, line.Text)
+	}
+}
+
+// reportRaces points out the data races of the run, which are easily missed
+// among other failures.
+func reportRaces(w io.Writer, races []dockertesting.RaceReport) {
+	if len(races) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "dockertesting: %d data race(s) detected\n", len(races))
+	for _, race := range races {
+		location := ""
+		if len(race.Accesses) > 0 && len(race.Accesses[0].Stack) > 0 {
+			frame := race.Accesses[0].Stack[0]
+			location = fmt.Sprintf(" at %s:%d", frame.File, frame.Line)
+		}
+		fmt.Fprintf(w, "  %s%s\n", cmp.Or(race.Test, race.Package, "unknown test"), location)
+	}
+}
+
+// reportCrashes points out the crashed test binaries of the run, whose cause
+// is otherwise buried in goroutine dumps.
+func reportCrashes(w io.Writer, crashes []dockertesting.Crash) {
+	for _, crash := range crashes {
+		location := ""
+		if frame, ok := crash.Location(); ok {
+			location = fmt.Sprintf(" at %s:%d", frame.File, frame.Line)
+		}
+		message, _, _ := strings.Cut(crash.Message, "\n")
+		fmt.Fprintf(w, "dockertesting: %s crashed%s: %s\n", cmp.Or(crash.Test, crash.Package, "go test"), location, message)
+	}
+}
+
+// writeReports writes the reports requested by -junit, -cobertura, -summary
+// and -benchstat.
+// The coverage profile is written by the library.
+func (c *config) writeReports(result *dockertesting.Result) error {
+	if c.junit != "" {
+		report, err := dockertesting.ConvertToJUnit(result.Stdout)
+		if err != nil {
+			return fmt.Errorf("failed to create JUnit report: %w", err)
+		}
+		if err := writeFile(c.junit, report); err != nil {
+			return err
+		}
+	}
+	if c.cobertura != "" && result.Cobertura != nil {
+		if err := writeFile(c.cobertura, result.Cobertura); err != nil {
+			return err
+		}
+	}
+	if c.benchstat != "" && result.Benchmarks != nil {
+		if err := writeFile(c.benchstat, result.Benchmarks); err != nil {
+			return err
+		}
+	}
+	if c.summary != "" {
+		var summary bytes.Buffer
+		if err := result.WriteJSON(&summary); err != nil {
+			return fmt.Errorf("failed to create summary: %w", err)
+		}
+		if err := writeFile(c.summary, summary.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// annotate writes the GitHub Actions annotations of the failures in output
+// if -github-annotations is set.
+func (c *config) annotate(stdout, stderr io.Writer, output []byte) {
+	if !c.annotations {
+		return
+	}
+	if err := dockertesting.WriteGitHubAnnotations(stdout, c.packagePath, output); err != nil {
+		// Non-fatal: the annotations only complement the output
+		fmt.Fprintf(stderr, "dockertesting: failed to write annotations: %v\n", err)
+	}
+}
+
+// writeFile writes data to path, creating its parent directories.
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
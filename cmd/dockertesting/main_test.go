@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/djosh34/dockertesting"
+)
+
+func TestParseArgs(t *testing.T) {
+	t.Parallel()
+	cfg, err := parseArgs([]string{
+		"--alias", "myapp.test", "./mypkg", "--var-sock", "--alias=db.test",
+		"--timeout", "5m", "--coverage", "cover.out", "--host-port", "8080", "--", "-run", "TestFoo",
+	}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	options, err := dockertesting.NewOptions(cfg.packagePath, cfg.options(&bytes.Buffer{}, &bytes.Buffer{})...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.packagePath != "./mypkg" {
+		t.Errorf("expected package path %q, got %q", "./mypkg", cfg.packagePath)
+	}
+	if !slices.Equal(options.Aliases, []string{"myapp.test", "db.test"}) {
+		t.Errorf("expected aliases [myapp.test db.test], got %v", options.Aliases)
+	}
+	if !options.EnableVarSock {
+		t.Error("expected VarSock to be enabled")
+	}
+	if !slices.Equal(options.HostPorts, []int{8080}) {
+		t.Errorf("expected host ports [8080], got %v", options.HostPorts)
+	}
+	if options.Timeout != 5*time.Minute {
+		t.Errorf("expected timeout 5m, got %v", options.Timeout)
+	}
+	if options.CoverageOutput != "cover.out" {
+		t.Errorf("expected coverage output %q, got %q", "cover.out", options.CoverageOutput)
+	}
+	if !slices.Equal(options.Args, []string{"-run", "TestFoo"}) {
+		t.Errorf("expected args [-run TestFoo], got %v", options.Args)
+	}
+	if !options.SignalCleanup {
+		t.Error("expected SignalCleanup to be enabled")
+	}
+}
+
+func TestParseArgs_NetworkDriver(t *testing.T) {
+	t.Parallel()
+	cfg, err := parseArgs([]string{
+		"./mypkg", "--network-opt", "com.docker.network.driver.mtu=1400",
+		"--network-opt=com.docker.network.bridge.enable_icc=false",
+	}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	options, err := dockertesting.NewOptions(cfg.packagePath, cfg.options(&bytes.Buffer{}, &bytes.Buffer{})...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]string{dockertesting.NetworkOptionMTU: "1400", dockertesting.NetworkOptionICC: "false"}
+	if options.NetworkDriver != "" || !maps.Equal(options.NetworkDriverOptions, expected) {
+		t.Errorf("expected the default driver with options %v, got %q with %v", expected, options.NetworkDriver, options.NetworkDriverOptions)
+	}
+}
+
+func TestParseArgs_SocketProxyWrite(t *testing.T) {
+	t.Parallel()
+	cfg, err := parseArgs([]string{"./mypkg", "--socket-proxy-write", "volumes", "--socket-proxy-write=testcontainers"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	options, err := dockertesting.NewOptions(cfg.packagePath, cfg.options(&bytes.Buffer{}, &bytes.Buffer{})...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := append([]dockertesting.SocketProxySection{dockertesting.SocketProxyVolumes}, dockertesting.SocketProxyTestcontainers...)
+	if !options.SocketProxy || !slices.Equal(options.SocketProxyWrite, expected) {
+		t.Errorf("expected the proxy with write access to %v, got %v with %v", expected, options.SocketProxy, options.SocketProxyWrite)
+	}
+}
+
+func TestParseArgs_SockGuard(t *testing.T) {
+	t.Parallel()
+	cfg, err := parseArgs([]string{
+		"./mypkg", "--var-sock", "--trusted-registry", "docker.io/library",
+		"--trusted-registry=registry.example.com", "--require-run-label", "--sock-guard-fail",
+	}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	options, err := dockertesting.NewOptions(cfg.packagePath, cfg.options(&bytes.Buffer{}, &bytes.Buffer{})...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := dockertesting.SockGuard{
+		TrustedRegistries: []string{"docker.io/library", "registry.example.com"},
+		RequireLabel:      true,
+		Mode:              dockertesting.SockGuardFail,
+	}
+	if options.SockGuard == nil || !reflect.DeepEqual(*options.SockGuard, expected) {
+		t.Errorf("expected sock guard %+v, got %+v", expected, options.SockGuard)
+	}
+}
+
+func TestParseArgs_JUnit(t *testing.T) {
+	t.Parallel()
+	cfg, err := parseArgs([]string{"./mypkg", "--junit", "out.xml", "--", "-race"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	options, err := dockertesting.NewOptions(cfg.packagePath, cfg.options(&bytes.Buffer{}, &bytes.Buffer{})...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(options.Args, []string{"-json", "-race"}) {
+		t.Errorf("expected args [-json -race], got %v", options.Args)
+	}
+	if options.Verbosity != dockertesting.VerbosityQuiet {
+		t.Errorf("expected the raw output not to be forwarded, got verbosity %v", options.Verbosity)
+	}
+	if options.OutputCallback == nil {
+		t.Error("expected an output callback decoding the events")
+	}
+}
+
+func TestParseArgs_Errors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"missing package", []string{"--var-sock"}},
+		{"two packages", []string{"./a", "./b"}},
+		{"unknown flag", []string{"./a", "--nope"}},
+		{"invalid duration", []string{"./a", "--timeout", "soon"}},
+		{"verbose and quiet", []string{"./a", "-v", "-q"}},
+		{"invalid log level", []string{"./a", "--log-level", "loud"}},
+		{"invalid host port", []string{"./a", "--host-port", "http"}},
+		{"network option without value", []string{"./a", "--network-opt", "mtu"}},
+		{"unset secret env", []string{"./a", "--secret-env", "DOCKERTESTING_UNSET_SECRET"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := parseArgs(tt.args, &bytes.Buffer{}); err == nil {
+				t.Errorf("expected error for %v", tt.args)
+			}
+		})
+	}
+}
+
+func TestRun_Usage(t *testing.T) {
+	t.Parallel()
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), nil, &stdout, &stderr); code != exitUsage {
+		t.Errorf("expected exit code %d, got %d", exitUsage, code)
+	}
+	if !bytes.Contains(stderr.Bytes(), []byte("-var-sock")) {
+		t.Errorf("expected usage with flags, got %q", stderr.String())
+	}
+	if code := run(context.Background(), []string{"run", "--nope"}, &stdout, &stderr); code != exitUsage {
+		t.Errorf("expected exit code %d for an unknown flag, got %d", exitUsage, code)
+	}
+}
+
+func TestDecodeOutput(t *testing.T) {
+	t.Parallel()
+	var out bytes.Buffer
+	callback := decodeOutput(&out, dockertesting.VerbosityNormal)
+
+	event, _ := json.Marshal(dockertesting.TestEvent{Action: "output", Test: "TestFoo", Output: "--- PASS: TestFoo\n"})
+	pass, _ := json.Marshal(dockertesting.TestEvent{Action: "pass", Test: "TestFoo"})
+	callback(dockertesting.OutputLine{Source: dockertesting.OutputSourceBuild, Text: "Step 1/9"})
+	callback(dockertesting.OutputLine{Source: dockertesting.OutputSourceExec, Text: string(event)})
+	callback(dockertesting.OutputLine{Source: dockertesting.OutputSourceExec, Text: string(pass)})
+	callback(dockertesting.OutputLine{Source: dockertesting.OutputSourceExec, Text: "seeded database"})
+
+	if got, want := out.String(), "--- PASS: TestFoo\nseeded database\n"; got != want {
+		t.Errorf("expected output %q, got %q", want, got)
+	}
+}
+
+func TestWriteReports_Summary(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "reports", "summary.json")
+	cfg, err := parseArgs([]string{"./mypkg", "--summary", path}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := cfg.writeReports(&dockertesting.Result{ExitCode: 1, ContainerID: "abc"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var summary dockertesting.Summary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		t.Fatalf("failed to parse summary: %v\n%s", err, data)
+	}
+	if summary.ExitCode != 1 || summary.Resources.ContainerID != "abc" {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+}
+
+func TestWriteReports_Benchstat(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "bench.txt")
+	cfg, err := parseArgs([]string{"./mypkg", "--benchstat", path}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	benchmarks := []byte("pkg: example.com/mod\nBenchmarkAdd-8\t1000\t1050 ns/op\n")
+	if err := cfg.writeReports(&dockertesting.Result{Benchmarks: benchmarks}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(data, benchmarks) {
+		t.Errorf("expected %q, got %q", benchmarks, data)
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	t.Parallel()
+	cfg, err := parseArgs([]string{t.TempDir(), "--github-annotations"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cfg.annotate(&stdout, &stderr, []byte("add.go:3:2: undefined: x\n"))
+	if !strings.Contains(stdout.String(), "add.go,line=3,col=2,title=build failed::undefined: x") {
+		t.Errorf("expected an annotation, got %q (stderr %q)", stdout.String(), stderr.String())
+	}
+
+	cfg.annotations = false
+	stdout.Reset()
+	cfg.annotate(&stdout, &stderr, []byte("add.go:3:2: undefined: x\n"))
+	if stdout.Len() != 0 {
+		t.Errorf("expected no annotations without the flag, got %q", stdout.String())
+	}
+}
+
+func TestReportRaces(t *testing.T) {
+	t.Parallel()
+	var stderr bytes.Buffer
+	reportRaces(&stderr, nil)
+	if stderr.Len() != 0 {
+		t.Errorf("expected no output without races, got %q", stderr.String())
+	}
+
+	reportRaces(&stderr, []dockertesting.RaceReport{{
+		Test:     "TestRace",
+		Accesses: []dockertesting.RaceAccess{{Stack: []dockertesting.StackFrame{{File: "race_test.go", Line: 12}}}},
+	}})
+	expected := "dockertesting: 1 data race(s) detected\n  TestRace at race_test.go:12\n"
+	if stderr.String() != expected {
+		t.Errorf("expected %q, got %q", expected, stderr.String())
+	}
+}
+
+func TestReportCrashes(t *testing.T) {
+	t.Parallel()
+	var stderr bytes.Buffer
+	reportCrashes(&stderr, []dockertesting.Crash{
+		{
+			Test:    "TestPanic",
+			Message: "panic: boom [recovered]\n\tpanic: boom",
+			Stack:   []dockertesting.StackFrame{{Function: "panic", File: "/usr/local/go/src/runtime/panic.go", Line: 792}, {Function: "example.com/mod.TestPanic", File: "panic_test.go", Line: 8}},
+		},
+		{Package: "example.com/mod", Kind: dockertesting.CrashOOMKilled, Message: "signal: killed"},
+	})
+	expected := "dockertesting: TestPanic crashed at panic_test.go:8: panic: boom [recovered]\n" +
+		"dockertesting: example.com/mod crashed: signal: killed\n"
+	if stderr.String() != expected {
+		t.Errorf("expected %q, got %q", expected, stderr.String())
+	}
+}
@@ -0,0 +1,205 @@
+package dockertesting
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/modfile"
+)
+
+// coberturaCoverage is the root element of a Cobertura XML report.
+type coberturaCoverage struct {
+	XMLName         xml.Name           `xml:"coverage"`
+	LineRate        float64            `xml:"line-rate,attr"`
+	BranchRate      float64            `xml:"branch-rate,attr"`
+	LinesCovered    int                `xml:"lines-covered,attr"`
+	LinesValid      int                `xml:"lines-valid,attr"`
+	BranchesCovered int                `xml:"branches-covered,attr"`
+	BranchesValid   int                `xml:"branches-valid,attr"`
+	Complexity      float64            `xml:"complexity,attr"`
+	Version         string             `xml:"version,attr"`
+	Timestamp       int64              `xml:"timestamp,attr"`
+	Sources         []string           `xml:"sources>source"`
+	Packages        []coberturaPackage `xml:"packages>package"`
+}
+
+type coberturaPackage struct {
+	Name       string           `xml:"name,attr"`
+	LineRate   float64          `xml:"line-rate,attr"`
+	BranchRate float64          `xml:"branch-rate,attr"`
+	Complexity float64          `xml:"complexity,attr"`
+	Classes    []coberturaClass `xml:"classes>class"`
+}
+
+type coberturaClass struct {
+	Name       string          `xml:"name,attr"`
+	Filename   string          `xml:"filename,attr"`
+	LineRate   float64         `xml:"line-rate,attr"`
+	BranchRate float64         `xml:"branch-rate,attr"`
+	Complexity float64         `xml:"complexity,attr"`
+	Methods    struct{}        `xml:"methods"`
+	Lines      []coberturaLine `xml:"lines>line"`
+}
+
+type coberturaLine struct {
+	Number int `xml:"number,attr"`
+	Hits   int `xml:"hits,attr"`
+}
+
+// ConvertToCobertura converts a Go coverage profile to a Cobertura XML report,
+// the format accepted by Codecov, GitLab and most CI coverage dashboards.
+//
+// If modulePath is non-empty, it is stripped from the file names in the
+// profile so that the report refers to paths relative to the module root,
+// which is what upload tools expect when run from the repository.
+//
+// Example:
+//
+//	report, err := dockertesting.ConvertToCobertura(result.Coverage, "example.com/mymodule")
+func ConvertToCobertura(profile []byte, modulePath string) ([]byte, error) {
+	merged, err := mergeCoverProfiles([][]byte{profile})
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect per-line hits for each file. A line touched by several blocks
+	// reports the highest execution count.
+	files := make(map[string]map[int]int)
+	for _, block := range merged.blocks {
+		file, startLine, endLine, err := parseBlockPosition(block.position)
+		if err != nil {
+			return nil, err
+		}
+		if modulePath != "" {
+			file = strings.TrimPrefix(file, modulePath+"/")
+		}
+		lines, ok := files[file]
+		if !ok {
+			lines = make(map[int]int)
+			files[file] = lines
+		}
+		for line := startLine; line <= endLine; line++ {
+			if hits, ok := lines[line]; !ok || block.count > hits {
+				lines[line] = block.count
+			}
+		}
+	}
+
+	// Group files into packages by directory
+	packages := make(map[string][]coberturaClass)
+	var totalValid, totalCovered int
+	for file, lines := range files {
+		class := coberturaClass{
+			Name:     path.Base(file),
+			Filename: file,
+		}
+		var covered int
+		for number, hits := range lines {
+			class.Lines = append(class.Lines, coberturaLine{Number: number, Hits: hits})
+			if hits > 0 {
+				covered++
+			}
+		}
+		sort.Slice(class.Lines, func(i, j int) bool {
+			return class.Lines[i].Number < class.Lines[j].Number
+		})
+		class.LineRate = rate(covered, len(lines))
+		totalValid += len(lines)
+		totalCovered += covered
+
+		pkg := path.Dir(file)
+		packages[pkg] = append(packages[pkg], class)
+	}
+
+	report := coberturaCoverage{
+		LineRate:     rate(totalCovered, totalValid),
+		LinesCovered: totalCovered,
+		LinesValid:   totalValid,
+		Version:      "dockertesting",
+		Timestamp:    time.Now().UnixMilli(),
+		Sources:      []string{"."},
+	}
+	for name, classes := range packages {
+		sort.Slice(classes, func(i, j int) bool {
+			return classes[i].Filename < classes[j].Filename
+		})
+		var valid, covered int
+		for _, class := range classes {
+			for _, line := range class.Lines {
+				valid++
+				if line.Hits > 0 {
+					covered++
+				}
+			}
+		}
+		report.Packages = append(report.Packages, coberturaPackage{
+			Name:     name,
+			LineRate: rate(covered, valid),
+			Classes:  classes,
+		})
+	}
+	sort.Slice(report.Packages, func(i, j int) bool {
+		return report.Packages[i].Name < report.Packages[j].Name
+	})
+
+	out, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal cobertura report: %w", err)
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// parseBlockPosition splits a "file:startLine.startCol,endLine.endCol" block
+// position into the file name and the start and end lines.
+func parseBlockPosition(position string) (string, int, int, error) {
+	i := strings.LastIndex(position, ":")
+	if i < 0 {
+		return "", 0, 0, fmt.Errorf("invalid block position %q", position)
+	}
+	file, span := position[:i], position[i+1:]
+
+	start, end, ok := strings.Cut(span, ",")
+	if !ok {
+		return "", 0, 0, fmt.Errorf("invalid block position %q", position)
+	}
+	startLine, _, _ := strings.Cut(start, ".")
+	endLine, _, _ := strings.Cut(end, ".")
+
+	startNum, err := strconv.Atoi(startLine)
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("invalid start line in %q: %w", position, err)
+	}
+	endNum, err := strconv.Atoi(endLine)
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("invalid end line in %q: %w", position, err)
+	}
+	return file, startNum, endNum, nil
+}
+
+// rate returns covered/valid, or 0 if valid is 0.
+func rate(covered, valid int) float64 {
+	if valid == 0 {
+		return 0
+	}
+	return float64(covered) / float64(valid)
+}
+
+// readModulePath returns the module path declared in the go.mod file in dir.
+func readModulePath(dir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read go.mod: %w", err)
+	}
+	modulePath := modfile.ModulePath(data)
+	if modulePath == "" {
+		return "", fmt.Errorf("no module directive in %s", filepath.Join(dir, "go.mod"))
+	}
+	return modulePath, nil
+}
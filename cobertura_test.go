@@ -0,0 +1,72 @@
+package dockertesting
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConvertToCobertura(t *testing.T) {
+	t.Parallel()
+	profile := "mode: count\n" +
+		"example.com/mod/math.go:3.24,5.2 1 2\n" +
+		"example.com/mod/math.go:7.24,8.2 1 0\n" +
+		"example.com/mod/sub/util.go:3.20,3.40 1 1\n"
+
+	out, err := ConvertToCobertura([]byte(profile), "example.com/mod")
+	if err != nil {
+		t.Fatalf("ConvertToCobertura failed: %v", err)
+	}
+
+	var report coberturaCoverage
+	if err := xml.Unmarshal(out, &report); err != nil {
+		t.Fatalf("failed to parse generated XML: %v\n%s", err, out)
+	}
+
+	// math.go lines 3-5 covered, 7-8 uncovered; util.go line 3 covered
+	if report.LinesValid != 6 || report.LinesCovered != 4 {
+		t.Errorf("expected 4/6 lines covered, got %d/%d", report.LinesCovered, report.LinesValid)
+	}
+	if len(report.Packages) != 2 {
+		t.Fatalf("expected 2 packages, got %d", len(report.Packages))
+	}
+	if report.Packages[0].Name != "." || report.Packages[1].Name != "sub" {
+		t.Errorf("unexpected package names %q, %q", report.Packages[0].Name, report.Packages[1].Name)
+	}
+
+	class := report.Packages[0].Classes[0]
+	if class.Filename != "math.go" {
+		t.Errorf("expected module path to be stripped, got filename %q", class.Filename)
+	}
+	if class.Lines[0].Number != 3 || class.Lines[0].Hits != 2 {
+		t.Errorf("unexpected first line %+v", class.Lines[0])
+	}
+}
+
+func TestConvertToCobertura_InvalidProfile(t *testing.T) {
+	t.Parallel()
+	if _, err := ConvertToCobertura([]byte("mode: set\nmath.go 1 1\n"), ""); err == nil {
+		t.Error("expected error for invalid block position")
+	}
+}
+
+func TestReadModulePath(t *testing.T) {
+	t.Parallel()
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module example.com/mod\n\ngo 1.25.6\n"), 0644); err != nil {
+		t.Fatalf("failed to write go.mod: %v", err)
+	}
+
+	modulePath, err := readModulePath(tmpDir)
+	if err != nil {
+		t.Fatalf("readModulePath failed: %v", err)
+	}
+	if modulePath != "example.com/mod" {
+		t.Errorf("expected 'example.com/mod', got %q", modulePath)
+	}
+
+	if _, err := readModulePath(t.TempDir()); err == nil {
+		t.Error("expected error for missing go.mod")
+	}
+}
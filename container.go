@@ -8,25 +8,98 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/docker/docker/api/types/build"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-connections/nat"
+	"github.com/google/uuid"
 	"github.com/testcontainers/testcontainers-go"
+	tclog "github.com/testcontainers/testcontainers-go/log"
 	"github.com/testcontainers/testcontainers-go/network"
 	"github.com/testcontainers/testcontainers-go/wait"
 )
 
-// dockerfileTemplate is the embedded Dockerfile template for building test containers.
+// dockerfileTemplate is the embedded Dockerfile template for building test
+// containers. It is a text/template, see renderDockerfile.
 //
 //go:embed template.Dockerfile
 var dockerfileTemplate string
 
+// buildKitDockerfileTemplate is the Dockerfile template used instead of
+// dockerfileTemplate when building with BuildKit, see WithBuildKit.
+//
+//go:embed template.buildkit.Dockerfile
+var buildKitDockerfileTemplate string
+
+// ModCacheVolume is the named volume mounted as Go module cache with
+// WithLazyModDownload. It is shared by all runs and not removed automatically.
+const ModCacheVolume = "dockertesting-gomodcache"
+
+// containerModCache is the module cache directory of the golang image.
+const containerModCache = "/go/pkg/mod"
+
+// BuildError represents a failure to build the test container image, for example
+// when `go mod download` fails. It carries the docker build log so callers can
+// distinguish build failures from test failures and report them accordingly.
+type BuildError struct {
+	// Log contains the output of the docker build.
+	Log []byte
+
+	// Err is the underlying build error.
+	Err error
+
+	// DebugImage is the tag of the last successfully built layer, set when
+	// WithKeepFailedBuild is enabled and an intermediate image was available.
+	// Run it with `docker run -it <tag> sh` to investigate the failing step.
+	DebugImage string
+}
+
+func (e *BuildError) Error() string {
+	if e.DebugImage != "" {
+		return fmt.Sprintf("failed to build image (debug image: %s): %v", e.DebugImage, e.Err)
+	}
+	return fmt.Sprintf("failed to build image: %v", e.Err)
+}
+
+func (e *BuildError) Unwrap() error {
+	return e.Err
+}
+
 // TestContainer wraps a testcontainers container for running Go tests.
 type TestContainer struct {
 	// container is the underlying testcontainers container.
 	ctr testcontainers.Container
+
+	// stopTimeout is how long Terminate waits for the processes in the
+	// container to exit after SIGTERM, see WithStopTimeout.
+	stopTimeout time.Duration
+
+	// networkName is the network the container was attached to, whose IP
+	// address ContainerIP returns.
+	networkName string
+
+	// image is the image the container was created from.
+	image string
+
+	// secretEnv are the variables of WithSecretEnv, passed to the commands
+	// run in the container only.
+	secretEnv map[string]string
+
+	// redactor redacts the values of secretEnv, or is nil without secrets.
+	redactor *strings.Replacer
+
+	// closeProvider closes the provider AttachContainer created for the
+	// container, or is nil.
+	closeProvider func()
 }
 
 // CreateContainerConfig holds the configuration needed to create a test container.
@@ -40,17 +113,171 @@ type CreateContainerConfig struct {
 	// Aliases are DNS aliases for the container within the network.
 	Aliases []string
 
+	// HostPorts are the ports of the host forwarded into the network
+	// (optional), see WithHostPorts.
+	HostPorts []int
+
+	// StaticIP is the IPv4 address of the container on Network (optional),
+	// see WithStaticIP.
+	StaticIP string
+
 	// EnableVarSock enables mounting the Docker socket into the container.
 	EnableVarSock bool
 
 	// SockPath is the path to the Docker socket on the host.
+	// If empty or DefaultSockPath, the socket is detected, see WithSockPath.
 	SockPath string
 
+	// DockerHost describes the Docker daemon in use. If it is remote,
+	// EnableVarSock passes its address and TLS certificates into the container
+	// instead of mounting the socket.
+	DockerHost DockerHostConfig
+
 	// NetworkName is the name of the Docker network (for env var).
 	NetworkName string
 
+	// Env sets additional environment variables in the container (optional).
+	Env map[string]string
+
 	// DockerfilePath is the path to a custom Dockerfile (optional).
 	DockerfilePath string
+
+	// BuildOutput receives the docker build log as it is produced (optional).
+	BuildOutput io.Writer
+
+	// Progress receives milestone events during creation (optional).
+	Progress ProgressReporter
+
+	// Logger receives container lifecycle logs (optional).
+	// If nil, the testcontainers default logger is used.
+	Logger tclog.Logger
+
+	// Log receives structured logs of the build and the container start
+	// (optional), see WithLogger.
+	Log *slog.Logger
+
+	// Metrics receives the build statistics (optional), see WithMetrics.
+	Metrics Metrics
+
+	// Hooks are invoked before the image build and once the container
+	// started (optional), see WithHooks.
+	Hooks Hooks
+
+	// KeepFailedBuild tags the last successful intermediate image when the
+	// build fails, see BuildError.DebugImage.
+	KeepFailedBuild bool
+
+	// WaitFor decides when the container is ready for executing commands
+	// (optional). If nil, it waits until a command can be executed.
+	WaitFor wait.Strategy
+
+	// BuildKit builds the image with BuildKit and cache mounts for the module
+	// and build caches if the daemon supports it, see WithBuildKit.
+	BuildKit bool
+
+	// LazyModDownload skips go mod download at build time and mounts the
+	// ModCacheVolume volume as module cache instead, see WithLazyModDownload.
+	LazyModDownload bool
+
+	// Vendored skips go mod download at build time as the modules are
+	// vendored, see WithVendor.
+	Vendored bool
+
+	// ImageFlavor is the variant of the golang base image of the Dockerfile
+	// template, see WithImageFlavor.
+	ImageFlavor ImageFlavor
+
+	// GoTools are the tools installed into the image, see WithGoTools.
+	GoTools []string
+
+	// DockerfileGenerator generates the Dockerfile unless DockerfilePath is
+	// set. If nil, TemplateGenerator renders the Dockerfile template.
+	DockerfileGenerator DockerfileGenerator
+
+	// BuildTarget is the stage of a multi-stage Dockerfile the test
+	// container is built from (optional), see WithBuildTarget.
+	BuildTarget string
+
+	// Template replaces the embedded Dockerfile template if set, see
+	// WithTemplate.
+	Template string
+
+	// TemplateData is the data the Dockerfile template is rendered with, see
+	// WithTemplateData.
+	TemplateData map[string]any
+
+	// GoVersion is passed to the Dockerfile as the GO_VERSION build arg if
+	// set, see WithGoVersion. The embedded templates check it against go.mod
+	// and, if unset, follow the toolchain of go.mod.
+	GoVersion string
+
+	// GitCredentials are passed to the Dockerfile as the GOPRIVATE build arg
+	// and, with BuildKit, the gitconfig secret if set, see WithGitCredentials.
+	GitCredentials []GitCredentials
+
+	// TLSBundle is the directory of CA certificates added to the trust store
+	// of the image (optional), see WithTLSBundle.
+	TLSBundle string
+
+	// Root runs the tests of the embedded templates as root instead of
+	// TestUser, see WithRoot.
+	Root bool
+
+	// SecretEnv are environment variables passed to the commands run in the
+	// container only, never to the image build or the container itself, and
+	// redacted from their output, see WithSecretEnv.
+	SecretEnv map[string]string
+
+	// ReadOnlySource makes the file system of the container read-only, except
+	// ReadOnlyTmpDir, see WithReadOnlySource.
+	ReadOnlySource bool
+
+	// ProxyEnv are the proxy variables passed to the build as build args
+	// (optional), see WithHostProxyEnv.
+	ProxyEnv map[string]string
+
+	// ContextExcludes are the patterns of paths left out of the build context.
+	// If nil, DefaultContextExcludes is used, see WithContextExcludes.
+	ContextExcludes []string
+
+	// MaxContextSize is the maximum size of the build context in bytes, or 0
+	// for no limit, see WithMaxContextSize.
+	MaxContextSize int64
+
+	// Labels are added to the labels of the container, the built image and
+	// the module cache volume if it is created.
+	Labels map[string]string
+
+	// BuildCacheRef is the registry image the build cache is imported from
+	// and exported to, see WithBuildCacheRegistry.
+	BuildCacheRef string
+
+	// BuildCacheDir is the directory the build cache is imported from and
+	// exported to, see WithBuildCacheDir.
+	BuildCacheDir string
+
+	// ImageCache reuses the image of an earlier build with an identical build
+	// context instead of building it again, see WithImageCache.
+	ImageCache bool
+
+	// ContainerdCompat adapts the container configuration to Docker-compatible
+	// APIs in front of containerd, such as nerdctl's. It is enabled
+	// automatically when DockerHost is a known containerd-compatible socket.
+	ContainerdCompat bool
+
+	// Init runs the init process of the daemon (tini) as PID 1 of the
+	// container, see WithInit.
+	Init bool
+
+	// StopTimeout is how long Terminate waits for the processes in the
+	// container to exit after SIGTERM before killing them. If 0, they are
+	// killed with the container, see WithStopTimeout.
+	StopTimeout time.Duration
+
+	// Provider builds and creates the container (optional). If nil, the
+	// provider of Network is used, and otherwise one configured from the
+	// environment.
+	Provider *testcontainers.DockerProvider
 }
 
 // CreateContainer builds and creates a Docker container for running Go tests.
@@ -59,35 +286,79 @@ type CreateContainerConfig struct {
 //
 // The container starts with "sleep infinity" to keep it alive for executing tests via Exec.
 //
+// If the image build fails, a *BuildError containing the build log is returned.
+//
 // The caller is responsible for terminating the container by calling Terminate().
 func CreateContainer(ctx context.Context, cfg CreateContainerConfig) (*TestContainer, error) {
-	// Validate package path exists
-	absPath, err := filepath.Abs(cfg.PackagePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get absolute path for package: %w", err)
+	provider := cfg.Provider
+	if provider == nil && cfg.Network != nil {
+		provider = cfg.Network.provider
 	}
+	log := orDiscard(cfg.Log)
+	start := time.Now()
 
-	if _, err = os.Stat(absPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("package path does not exist: %s", absPath)
+	// Capture the build log and track whether the build phase completed,
+	// so build failures can be reported separately from other errors
+	var buildLog bytes.Buffer
+	var building, built bool
+	var buildStart time.Time
+	buildLogWriters := []io.Writer{&buildLog}
+	if cfg.BuildOutput != nil {
+		buildLogWriters = append(buildLogWriters, cfg.BuildOutput)
+	}
+	if cfg.Progress != nil {
+		buildLogWriters = append(buildLogWriters, newBuildProgressWriter(cfg.Progress))
 	}
+	if cfg.Log != nil {
+		buildLogWriters = append(buildLogWriters, newLayerCacheWriter(log))
+	}
+	buildLogWriter := io.MultiWriter(buildLogWriters...)
 
-	contextArchive, err := CreateTarContext(absPath, cfg.DockerfilePath)
+	imgBuild, err := prepareImageBuild(ctx, cfg, provider, buildLogWriter)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create tar context: %w", err)
+		return nil, err
+	}
+	// Non-fatal: the archive and the session are only needed for the build
+	defer func() {
+		_ = imgBuild.archive.Close()
+		imgBuild.stopSession()
+	}()
+
+	waitFor := cfg.WaitFor
+	if waitFor == nil {
+		waitFor = defaultWaitStrategy()
 	}
 
 	// Build container request
 	req := testcontainers.ContainerRequest{
-		FromDockerfile: testcontainers.FromDockerfile{
-			ContextArchive: contextArchive,
-			Dockerfile:     "Dockerfile",
-		},
-		// Keep container alive for exec commands
-		WaitingFor: wait.ForExec([]string{"echo", "ready"}),
+		FromDockerfile: imgBuild.fromDockerfile,
+		Image:          imgBuild.image,
+		WaitingFor:     waitFor,
+		Labels:         resourceLabels(),
+		LifecycleHooks: []testcontainers.ContainerLifecycleHooks{{
+			PreBuilds: []testcontainers.ContainerRequestHook{
+				func(context.Context, testcontainers.ContainerRequest) error {
+					building = true
+					buildStart = time.Now()
+					log.Info("image build started", "build_id", imgBuild.buildID)
+					invokeHook(ctx, cfg.Hooks.OnBuildStart, HookEvent{PackagePath: cfg.PackagePath, ImageTag: imgBuild.ref()})
+					return nil
+				},
+			},
+			PostBuilds: []testcontainers.ContainerRequestHook{
+				func(context.Context, testcontainers.ContainerRequest) error {
+					built = true
+					log.Info("image build finished", "duration", time.Since(buildStart))
+					observeMetric(cfg.Metrics, MetricBuildDuration, time.Since(buildStart).Seconds())
+					return nil
+				},
+			},
+		}},
 	}
 
 	// Set environment variables
 	req.Env = make(map[string]string)
+	maps.Copy(req.Env, cfg.Env)
 	if cfg.NetworkName != "" {
 		req.Env["TESTCONTAINERS_DOCKER_NETWORK"] = cfg.NetworkName
 	}
@@ -106,7 +377,9 @@ func CreateContainer(ctx context.Context, cfg CreateContainerConfig) (*TestConta
 	genReq := testcontainers.GenericContainerRequest{
 		ContainerRequest: req,
 		Started:          true,
+		Logger:           cfg.Logger,
 	}
+	withLabels(&genReq, cfg.Labels)
 
 	// Apply network option if network is provided
 	if cfg.Network != nil && cfg.Network.Network() != nil {
@@ -115,68 +388,594 @@ func CreateContainer(ctx context.Context, cfg CreateContainerConfig) (*TestConta
 			return nil, fmt.Errorf("failed to apply network option: %w", err)
 		}
 	}
+	if cfg.Network != nil && cfg.StaticIP != "" {
+		genReq.EndpointSettingsModifier = staticIPModifier(cfg.Network.Name, cfg.StaticIP)
+	}
 
-	// Mount Docker socket if enabled using HostConfigModifier
-	if cfg.EnableVarSock {
-		sockPath := cfg.SockPath
-		if sockPath == "" {
-			sockPath = DefaultSockPath
+	// Give the container access to the daemon if enabled: a remote daemon is
+	// reached over TCP, a local one through the mounted socket
+	var (
+		mounts []mount.Mount
+		groups []string
+	)
+	switch {
+	case cfg.EnableVarSock && cfg.DockerHost.IsRemote():
+		env, files, err := remoteDockerAccess(cfg.DockerHost)
+		if err != nil {
+			return nil, err
+		}
+		// Copied files are owned by root, so TestUser needs read access
+		if !cfg.Root {
+			for i := range files {
+				files[i].FileMode = 0644
+			}
+		}
+		maps.Copy(genReq.Env, env)
+		genReq.Files = append(genReq.Files, files...)
+	case cfg.EnableVarSock:
+		sockPath := resolveSockPath(cfg.SockPath, cfg.DockerHost)
+		mounts = append(mounts, sockMount(sockPath))
+		// The non-root TestUser reaches the socket through its group
+		if !cfg.Root {
+			home, _ := os.UserHomeDir()
+			if gid, ok := sockGroup(runtime.GOOS, sockPath, cfg.DockerHost, home); ok {
+				groups = append(groups, strconv.Itoa(gid))
+			} else if cfg.DockerfilePath == "" {
+				log.Warn("cannot determine the group of the Docker socket, tests may not reach the daemon as the non-root test user, see WithRoot", "socket", sockPath)
+			}
 		}
+	}
+
+	// Tests that write to their source tree fail instead of changing it
+	// for the next Test call
+	if cfg.ReadOnlySource {
+		readOnlySourceEnv(genReq.Env)
+		modCache := readOnlyModCache(cfg.LazyModDownload, imgBuild.modDownloadAtExec, genReq.Env)
+		mounts = append(mounts, readOnlySourceMounts(len(genReq.Files) > 0, modCache, cfg.Labels)...)
+	}
+
+	// Modules skipped at build time are downloaded into a shared volume
+	if cfg.LazyModDownload {
+		volumeLabels := resourceLabels()
+		maps.Copy(volumeLabels, ownLabels(cfg.Labels))
+		mounts = append(mounts, mount.Mount{
+			Type:   mount.TypeVolume,
+			Source: ModCacheVolume,
+			Target: containerModCache,
+			// Only applied when the volume is created by this run
+			VolumeOptions: &mount.VolumeOptions{Labels: volumeLabels},
+		})
+	}
+
+	// Host ports are forwarded through the SSH container of testcontainers
+	if len(cfg.HostPorts) > 0 {
+		if err := testcontainers.WithHostPortAccess(cfg.HostPorts...).Customize(&genReq); err != nil {
+			return nil, fmt.Errorf("failed to apply host port option: %w", err)
+		}
+	}
+
+	// An init process as PID 1 reaps the zombies of tests that fork
+	if len(mounts) > 0 || cfg.Init || len(cfg.HostPorts) > 0 {
 		hostConfigOpt := testcontainers.WithHostConfigModifier(func(hc *container.HostConfig) {
-			hc.Mounts = append(hc.Mounts, mount.Mount{
-				Type:   mount.TypeBind,
-				Source: sockPath,
-				Target: "/var/run/docker.sock",
-			})
+			hc.Mounts = append(hc.Mounts, mounts...)
+			hc.GroupAdd = append(hc.GroupAdd, groups...)
+			hc.ReadonlyRootfs = cfg.ReadOnlySource
+			if cfg.Init {
+				hc.Init = &cfg.Init
+			}
+			if len(cfg.HostPorts) > 0 {
+				hc.ExtraHosts = withHostAlias(hc.ExtraHosts)
+			}
 		})
 		if err := hostConfigOpt.Customize(&genReq); err != nil {
 			return nil, fmt.Errorf("failed to apply host config option: %w", err)
 		}
 	}
 
-	// Create container using GenericContainer
-	ctr, err := testcontainers.GenericContainer(ctx, genReq)
+	// Containerd-compatible APIs only implement binds, so rewrite the host
+	// config after all other modifications
+	if cfg.ContainerdCompat || isContainerdCompatHost(cfg.DockerHost.Host) {
+		modifier := genReq.HostConfigModifier
+		genReq.HostConfigModifier = func(hc *container.HostConfig) {
+			if modifier != nil {
+				modifier(hc)
+			}
+			adaptHostConfigForContainerd(hc)
+		}
+	}
+
+	// Stop the build on the daemon, not just waiting for it, on cancellation
+	if imgBuild.image == "" {
+		stop := cancelBuildOnDone(ctx, provider, imgBuild.buildID)
+		defer stop()
+	}
+
+	ctr, err := startContainer(ctx, provider, genReq)
 	if err != nil {
+		if building && !built {
+			log.Info("image build failed", "duration", time.Since(buildStart), "error", err)
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("image build aborted: %w", ctx.Err())
+			}
+			buildErr := &BuildError{Log: buildLog.Bytes(), Err: err}
+			if cfg.KeepFailedBuild {
+				// Non-fatal: the build error is more relevant than a tagging failure
+				buildErr.DebugImage, _ = tagDebugImage(ctx, provider, buildErr.Log)
+			}
+			return nil, buildErr
+		}
 		return nil, fmt.Errorf("failed to create container: %w", err)
 	}
+	reportProgress(cfg.Progress, ProgressEvent{Stage: StageContainerStarted, Message: "container started"})
+	log.Info("container started", "container", ctr.GetContainerID(), "duration", time.Since(start))
+	invokeHook(ctx, cfg.Hooks.OnContainerStarted, HookEvent{
+		PackagePath: cfg.PackagePath,
+		ImageTag:    imgBuild.ref(),
+		ContainerID: ctr.GetContainerID(),
+	})
+
+	if imgBuild.cache.enabled() {
+		if err := exportBuildCache(ctx, provider, ctr, imgBuild.cache); err != nil {
+			// Non-fatal: the run does not depend on the exported cache
+			fmt.Fprintf(buildLogWriter, "Build cache not exported: %v\n", err)
+			log.Debug("build cache not exported", "error", err)
+		}
+	}
 
 	return &TestContainer{
-		ctr: ctr,
+		ctr:         ctr,
+		stopTimeout: cfg.StopTimeout,
+		networkName: cfg.NetworkName,
+		image:       imgBuild.ref(),
+		secretEnv:   cfg.SecretEnv,
+		redactor:    newRedactor(cfg.SecretEnv, gitTokens(cfg.GitCredentials)...),
 	}, nil
 }
 
+// imageBuild describes how to obtain the image of the test container.
+type imageBuild struct {
+	// fromDockerfile builds the image. It is empty if image is set.
+	fromDockerfile testcontainers.FromDockerfile
+
+	// image is a cached image to use instead of building one.
+	image string
+
+	// archive is the build context, which must be closed after the build.
+	archive io.ReadSeekCloser
+
+	// stopSession closes the BuildKit session serving the secrets of the
+	// build, which must be closed after the build.
+	stopSession func()
+
+	// modDownloadAtExec reports whether go test downloads the modules at
+	// exec time, see modDownloadAtExec.
+	modDownloadAtExec bool
+
+	// cache receives the built image as build cache of the next run. It is
+	// disabled if image is set.
+	cache buildCache
+
+	// buildID identifies the build on the daemon, so it can be canceled.
+	buildID string
+}
+
+// ref returns the reference of the image, the cached one or the one built.
+func (b *imageBuild) ref() string {
+	if b.image != "" {
+		return b.image
+	}
+	return b.fromDockerfile.Repo + ":" + b.fromDockerfile.Tag
+}
+
+// prepareImageBuild creates the build context for the package at
+// cfg.PackagePath and the build configuration according to cfg. If
+// cfg.ImageCache is set and an identical image was built before, the build is
+// replaced by that image. The build log is written to buildLogWriter.
+func prepareImageBuild(ctx context.Context, cfg CreateContainerConfig, provider *testcontainers.DockerProvider, buildLogWriter io.Writer) (*imageBuild, error) {
+	// Validate package path exists
+	absPath, err := filepath.Abs(cfg.PackagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path for package: %w", err)
+	}
+
+	if _, err = os.Stat(absPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("package path does not exist: %s", absPath)
+	}
+	log := orDiscard(cfg.Log)
+
+	// Build with BuildKit cache mounts if requested and supported
+	buildKit := false
+	if cfg.BuildKit {
+		buildKit, err = buildKitAvailable(ctx, provider)
+		if err != nil {
+			return nil, err
+		}
+		log.Debug("BuildKit requested", "available", buildKit)
+	}
+	var template string
+	if cfg.DockerfilePath == "" {
+		template, err = generateDockerfile(cfg, buildKit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Match the base image of the embedded templates to go.mod
+	goVersion := cfg.GoVersion
+	if cfg.DockerfilePath == "" && cfg.DockerfileGenerator == nil {
+		goVersion, err = templateGoVersion(absPath, []byte(template), cfg.GoVersion)
+		if err != nil {
+			return nil, err
+		}
+		if goVersion != cfg.GoVersion {
+			log.Info("base image bumped for go.mod", "go_version", goVersion)
+		}
+	}
+
+	excludes := cfg.ContextExcludes
+	if excludes == nil {
+		excludes = DefaultContextExcludes
+	}
+	contextArchive, err := createTarContext(absPath, cfg.DockerfilePath, tarContextOptions{
+		template:       template,
+		excludes:       excludes,
+		spillThreshold: tarSpillThreshold,
+		maxSize:        cfg.MaxContextSize,
+		tlsBundle:      cfg.TLSBundle,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tar context: %w", err)
+	}
+	reportProgress(cfg.Progress, ProgressEvent{Stage: StageTarCreated, Message: "build context created"})
+	// Non-fatal: the size is only reported
+	if size, err := archiveSize(contextArchive); err == nil {
+		log.Debug("build context created", "path", absPath, "bytes", size)
+		observeMetric(cfg.Metrics, MetricContextSize, float64(size))
+	}
+
+	buildArgs := templateBuildArgs(cfg, goVersion, buildKit)
+
+	// Look up an image built from an identical context by an earlier run
+	var cacheDigest string
+	var cached bool
+	if cfg.ImageCache {
+		cacheDigest, cached, err = lookupCachedImage(ctx, provider, contextArchive, buildArgs, cfg.BuildTarget)
+		if err != nil {
+			_ = contextArchive.Close()
+			return nil, err
+		}
+	}
+
+	// Added after the cache lookup, as the proxy does not change the image
+	for name, value := range cfg.ProxyEnv {
+		buildArgs[name] = &value
+	}
+
+	// Pull the base images once for all concurrent builds of the process
+	if !cached {
+		dockerfile, err := readDockerfile(absPath, cfg.DockerfilePath, template)
+		if err != nil {
+			_ = contextArchive.Close()
+			return nil, err
+		}
+		if err := checkBuildTarget(dockerfile, cfg.BuildTarget); err != nil {
+			_ = contextArchive.Close()
+			return nil, err
+		}
+		// Non-fatal: the build pulls missing base images itself
+		if err := pullBaseImages(ctx, provider, dockerfile, buildArgs); err != nil {
+			log.Debug("base images not pulled", "error", err)
+		}
+	}
+
+	// Import the build cache of an earlier run, e.g. on an ephemeral CI runner
+	var cacheFrom []string
+	cache := newBuildCache(cfg.BuildCacheRef, cfg.BuildCacheDir, absPath)
+	if cache.enabled() && !cached {
+		cacheFrom = cache.load(ctx, provider, buildLogWriter)
+		if buildKit {
+			// Embed the cache metadata in the image so it can serve as cache source
+			inline := "1"
+			buildArgs["BUILDKIT_INLINE_CACHE"] = &inline
+		}
+	}
+
+	// Serve the git configuration as BuildKit secret rather than build arg
+	var sessionID string
+	stopSession := func() {}
+	if buildKit && !cached && len(cfg.GitCredentials) > 0 {
+		secrets := map[string][]byte{gitConfigSecretID: []byte(gitConfig(cfg.GitCredentials))}
+		sessionID, stopSession, err = startBuildSession(ctx, provider, secrets)
+		if err != nil {
+			_ = contextArchive.Close()
+			return nil, err
+		}
+	}
+
+	b := &imageBuild{
+		fromDockerfile: testcontainers.FromDockerfile{
+			ContextArchive: contextArchive,
+			Dockerfile:     "Dockerfile",
+			BuildArgs:      buildArgs,
+			BuildLogWriter: buildLogWriter,
+		},
+		archive:           contextArchive,
+		stopSession:       stopSession,
+		modDownloadAtExec: modDownloadAtExec(cfg, buildKit),
+		buildID:           newBuildID(),
+	}
+
+	imageLabels := resourceLabels()
+	maps.Copy(imageLabels, ownLabels(cfg.Labels))
+	b.fromDockerfile.BuildOptionsModifier = func(opts *build.ImageBuildOptions) {
+		opts.BuildID = b.buildID
+		opts.SessionID = sessionID
+		opts.Labels = imageLabels
+		opts.Target = cfg.BuildTarget
+		if buildKit {
+			opts.Version = build.BuilderBuildKit
+		}
+		opts.CacheFrom = append(opts.CacheFrom, cacheFrom...)
+	}
+
+	// Use the cached image, or keep the built image for later runs
+	switch {
+	case cached:
+		b.image = imageCacheRef(cacheDigest)
+		b.fromDockerfile = testcontainers.FromDockerfile{}
+		fmt.Fprintf(buildLogWriter, "Using cached image %s\n", b.image)
+		log.Info("image cache hit", "image", b.image)
+		countMetric(cfg.Metrics, MetricImageCache, map[string]string{"result": "hit"})
+	case cfg.ImageCache:
+		log.Debug("image cache miss", "digest", cacheDigest)
+		countMetric(cfg.Metrics, MetricImageCache, map[string]string{"result": "miss"})
+		b.fromDockerfile.Repo = ImageCacheRepository
+		b.fromDockerfile.Tag = cacheDigest
+		b.fromDockerfile.KeepImage = true
+	default:
+		// Name the image like testcontainers would, but up front, so the
+		// hooks can refer to it before the build
+		b.fromDockerfile.Repo = uuid.NewString()
+		b.fromDockerfile.Tag = uuid.NewString()
+	}
+	if !cached {
+		b.cache = cache
+	}
+
+	return b, nil
+}
+
+// templateBuildArgs returns the build args of the embedded templates for cfg
+// and the base image version goVersion. Vendored modules need no download,
+// and private modules of WithGitCredentials are only downloaded at build time
+// with BuildKit, which mounts their git configuration as secret; otherwise go
+// test downloads the modules at exec time.
+func templateBuildArgs(cfg CreateContainerConfig, goVersion string, buildKit bool) map[string]*string {
+	buildArgs := make(map[string]*string)
+	if cfg.LazyModDownload || cfg.Vendored || modDownloadAtExec(cfg, buildKit) {
+		lazy := "1"
+		buildArgs["LAZY_MOD_DOWNLOAD"] = &lazy
+	}
+	if goVersion != "" {
+		buildArgs["GO_VERSION"] = &goVersion
+	}
+	if len(cfg.GitCredentials) > 0 {
+		private := goPrivate(cfg.GitCredentials)
+		buildArgs[goPrivateEnv] = &private
+	}
+	return buildArgs
+}
+
+// modDownloadAtExec reports whether go test downloads the modules at exec
+// time rather than the build: the private modules of WithGitCredentials need
+// the git configuration, which only BuildKit builds receive.
+func modDownloadAtExec(cfg CreateContainerConfig, buildKit bool) bool {
+	return len(cfg.GitCredentials) > 0 && !buildKit && !cfg.Vendored
+}
+
+// startContainer creates and starts the container described by genReq using
+// provider. A nil provider falls back to testcontainers.GenericContainer, which
+// configures one from the environment.
+func startContainer(ctx context.Context, provider *testcontainers.DockerProvider, genReq testcontainers.GenericContainerRequest) (testcontainers.Container, error) {
+	if provider == nil {
+		return testcontainers.GenericContainer(ctx, genReq)
+	}
+
+	// Mirrors testcontainers.GenericContainer, minus creating the provider
+	ctr, err := provider.CreateContainer(ctx, genReq.ContainerRequest)
+	if err != nil {
+		return ctr, fmt.Errorf("create container: %w", err)
+	}
+	if genReq.Started && !ctr.IsRunning() {
+		if err := ctr.Start(ctx); err != nil {
+			return ctr, fmt.Errorf("start container: %w", err)
+		}
+	}
+	return ctr, nil
+}
+
+// generateDockerfile returns the Dockerfile of cfg.DockerfileGenerator, or
+// of TemplateGenerator if nil. buildKit reports whether the image is built
+// with BuildKit.
+func generateDockerfile(cfg CreateContainerConfig, buildKit bool) (string, error) {
+	generator := cfg.DockerfileGenerator
+	if generator == nil {
+		generator = TemplateGenerator{}
+	}
+	vendor := VendorDisabled
+	if cfg.Vendored {
+		vendor = VendorEnabled
+	}
+	dockerfile, err := generator.Generate(Options{
+		PackagePath:     cfg.PackagePath,
+		BuildKit:        buildKit,
+		LazyModDownload: cfg.LazyModDownload,
+		Vendor:          vendor,
+		ImageFlavor:     cfg.ImageFlavor,
+		GoTools:         cfg.GoTools,
+		BuildTarget:     cfg.BuildTarget,
+		Template:        cfg.Template,
+		TemplateData:    cfg.TemplateData,
+		GoVersion:       cfg.GoVersion,
+		GitCredentials:  cfg.GitCredentials,
+		TLSBundle:       cfg.TLSBundle,
+		Root:            cfg.Root,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate Dockerfile: %w", err)
+	}
+	if len(dockerfile) == 0 {
+		return "", fmt.Errorf("failed to generate Dockerfile: the generator returned an empty Dockerfile")
+	}
+	return string(dockerfile), nil
+}
+
 // CreateTarContext creates a tar archive of the contextPath directory,
 // adding the Dockerfile from dockerfilePath.
 // If dockerfilePath is empty, it adds the embedded Dockerfile template instead.
+// Paths matching DefaultContextExcludes are left out.
+//
+// Directories outside of contextPath that the replace directives of its
+// go.mod or the use and replace directives of its go.work point to, such as
+// sibling modules of a multi-module repository, are added under ReplaceDir,
+// and the directives are rewritten in the archive to point to them. Absolute
+// paths become relative, and the go.mod files of the added modules are
+// rewritten alike. The files on the host are not modified.
+//
+// Archives larger than a few tens of megabytes are written to a temporary file
+// instead of memory. The returned reader also implements io.Closer; closing it
+// removes the temporary file.
 func CreateTarContext(contextPath string, dockerfilePath string) (io.ReadSeeker, error) {
-	var buf bytes.Buffer
-	tw := tar.NewWriter(&buf)
+	template, err := renderDockerfile(dockerfileTemplate, templateParams{})
+	if err != nil {
+		return nil, err
+	}
+	return createTarContext(contextPath, dockerfilePath, tarContextOptions{
+		template:       template,
+		excludes:       DefaultContextExcludes,
+		spillThreshold: tarSpillThreshold,
+	})
+}
 
-	// Get the Dockerfile content
-	var dockerfileContent []byte
+// readDockerfile returns the content of the Dockerfile at dockerfilePath, which
+// is relative to contextPath unless absolute, or template if dockerfilePath is
+// empty.
+func readDockerfile(contextPath, dockerfilePath, template string) ([]byte, error) {
 	if dockerfilePath == "" {
-		// Use the default embedded Dockerfile template
-		dockerfileContent = []byte(dockerfileTemplate)
+		// Use the embedded Dockerfile template
+		return []byte(template), nil
+	}
+
+	// Support both relative (relative to contextPath) and absolute paths
+	var fullPath string
+	if filepath.IsAbs(dockerfilePath) {
+		fullPath = dockerfilePath
 	} else {
-		// Read the custom Dockerfile
-		// Support both relative (relative to contextPath) and absolute paths
-		var fullPath string
-		if filepath.IsAbs(dockerfilePath) {
-			fullPath = dockerfilePath
-		} else {
-			fullPath = filepath.Join(contextPath, dockerfilePath)
-		}
+		fullPath = filepath.Join(contextPath, dockerfilePath)
+	}
+
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read custom Dockerfile at %s: %w", fullPath, err)
+	}
+	return content, nil
+}
+
+// tarContextOptions configures createTarContext.
+type tarContextOptions struct {
+	// template is the rendered Dockerfile template added if no Dockerfile
+	// path is given.
+	template string
 
-		content, err := os.ReadFile(fullPath)
+	// excludes are the patterns of paths left out of the archive,
+	// see WithContextExcludes.
+	excludes []string
+
+	// spillThreshold is the size above which the archive is written to a
+	// temporary file instead of memory.
+	spillThreshold int
+
+	// maxSize is the maximum total size of the files in the archive, or 0
+	// for no limit, see WithMaxContextSize.
+	maxSize int64
+
+	// tlsBundle is the directory of the CA certificates added under
+	// TLSBundleDir, see WithTLSBundle. Empty adds none.
+	tlsBundle string
+}
+
+// createTarContext is CreateTarContext configured by opts.
+func createTarContext(contextPath, dockerfilePath string, opts tarContextOptions) (_ io.ReadSeekCloser, err error) {
+	if err := validateContextExcludes(opts.excludes); err != nil {
+		return nil, err
+	}
+
+	archive := &spillWriter{threshold: opts.spillThreshold}
+	defer func() {
 		if err != nil {
-			return nil, fmt.Errorf("failed to read custom Dockerfile at %s: %w", fullPath, err)
+			archive.discard()
 		}
-		dockerfileContent = content
+	}()
+	tw := tar.NewWriter(archive)
+
+	// Get the Dockerfile content
+	dockerfileContent, err := readDockerfile(contextPath, dockerfilePath, opts.template)
+	if err != nil {
+		return nil, err
+	}
+
+	// Modules that go.mod and go.work refer to outside of the context are
+	// added under ReplaceDir, with the directives rewritten to point to them
+	layout, err := newModuleLayout(contextPath)
+	if err != nil {
+		return nil, err
 	}
 
 	// Walk the context directory and add all files to the tar
-	contextFS := os.DirFS(contextPath)
-	err := fs.WalkDir(contextFS, ".", func(path string, d fs.DirEntry, err error) error {
+	sizer := &contextSizer{limit: opts.maxSize}
+	if err := addTarTree(tw, contextPath, "", layout.files, opts.excludes, sizer); err != nil {
+		return nil, fmt.Errorf("failed to walk context directory: %w", err)
+	}
+	for _, replace := range layout.replaces {
+		if err := addTarTree(tw, replace.dir, replace.archivePath, layout.files, opts.excludes, sizer); err != nil {
+			return nil, fmt.Errorf("failed to walk replaced module directory %s: %w", replace.dir, err)
+		}
+	}
+	if err := sizer.err(); err != nil {
+		return nil, err
+	}
+	if opts.tlsBundle != "" {
+		if err := addTLSBundle(tw, opts.tlsBundle); err != nil {
+			return nil, err
+		}
+	}
+
+	// Add the Dockerfile to the tar archive
+	dockerfileHeader := &tar.Header{
+		Name: "Dockerfile",
+		Mode: 0644,
+		Size: int64(len(dockerfileContent)),
+	}
+	if err := tw.WriteHeader(dockerfileHeader); err != nil {
+		return nil, fmt.Errorf("failed to write Dockerfile header: %w", err)
+	}
+	if _, err := tw.Write(dockerfileContent); err != nil {
+		return nil, fmt.Errorf("failed to write Dockerfile content: %w", err)
+	}
+
+	// Close the tar writer
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close tar writer: %w", err)
+	}
+
+	return archive.reader()
+}
+
+// addTarTree adds the files of dir to tw under prefix, leaving out the paths
+// matching excludes and any Dockerfile. files replaces the content of the
+// files by their name in the archive.
+func addTarTree(tw *tar.Writer, dir, prefix string, files map[string][]byte, excludes []string, sizer *contextSizer) error {
+	return fs.WalkDir(os.DirFS(dir), ".", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -191,25 +990,54 @@ func CreateTarContext(contextPath string, dockerfilePath string) (io.ReadSeeker,
 			return nil
 		}
 
+		// Skip excluded files and directories
+		if isContextExcluded(path, excludes) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
 		info, err := d.Info()
 		if err != nil {
 			return fmt.Errorf("failed to get file info for %s: %w", path, err)
 		}
+		name := path
+		if prefix != "" {
+			name = prefix + "/" + path
+		}
+		size := info.Size()
+		content, replaceContent := files[name]
+		if replaceContent {
+			size = int64(len(content))
+		}
+
+		// Once the context is too large, only measure the rest of it so the
+		// error can list the largest files
+		if info.Mode().IsRegular() {
+			sizer.add(name, size)
+		}
+		if sizer.exceeded() {
+			return nil
+		}
 
 		// Create tar header
 		header, err := tar.FileInfoHeader(info, "")
 		if err != nil {
 			return fmt.Errorf("failed to create tar header for %s: %w", path, err)
 		}
-		header.Name = path
+		// fs paths always use forward slashes, as required by tar, also on Windows
+		header.Name = name
+		header.Size = size
 
 		// Handle symlinks
 		if info.Mode()&fs.ModeSymlink != 0 {
-			linkTarget, err := os.Readlink(filepath.Join(contextPath, path))
+			linkTarget, err := os.Readlink(filepath.Join(dir, filepath.FromSlash(path)))
 			if err != nil {
 				return fmt.Errorf("failed to read symlink %s: %w", path, err)
 			}
-			header.Linkname = linkTarget
+			// Links inside the Linux container must use forward slashes
+			header.Linkname = filepath.ToSlash(linkTarget)
 		}
 
 		// Write header
@@ -218,8 +1046,12 @@ func CreateTarContext(contextPath string, dockerfilePath string) (io.ReadSeeker,
 		}
 
 		// For regular files, write the content
-		if info.Mode().IsRegular() {
-			fullPath := filepath.Join(contextPath, path)
+		if replaceContent {
+			if _, err := tw.Write(content); err != nil {
+				return fmt.Errorf("failed to write file content for %s: %w", path, err)
+			}
+		} else if info.Mode().IsRegular() {
+			fullPath := filepath.Join(dir, filepath.FromSlash(path))
 			file, err := os.Open(fullPath)
 			if err != nil {
 				return fmt.Errorf("failed to open file %s: %w", path, err)
@@ -238,40 +1070,129 @@ func CreateTarContext(contextPath string, dockerfilePath string) (io.ReadSeeker,
 
 		return nil
 	})
+}
+
+// Terminate stops and removes the container. With a stop timeout, see
+// WithStopTimeout, the processes in the container first get SIGTERM and up to
+// the timeout to exit before the container is killed.
+func (c *TestContainer) Terminate(ctx context.Context) error {
+	if c.ctr == nil {
+		return nil
+	}
+	var opts []testcontainers.TerminateOption
+	if c.stopTimeout > 0 {
+		// Non-fatal: processes that did not exit are killed with the container
+		c.stopProcesses(ctx, c.stopTimeout)
+		opts = append(opts, testcontainers.StopTimeout(0))
+	}
+	err := c.ctr.Terminate(ctx, opts...)
+	c.detach()
 	if err != nil {
-		return nil, fmt.Errorf("failed to walk context directory: %w", err)
+		return fmt.Errorf("failed to terminate container: %w", err)
 	}
+	return nil
+}
 
-	// Add the Dockerfile to the tar archive
-	dockerfileHeader := &tar.Header{
-		Name: "Dockerfile",
-		Mode: 0644,
-		Size: int64(len(dockerfileContent)),
+// detach closes the provider of an attached container, leaving the container
+// itself running.
+func (c *TestContainer) detach() {
+	if c.closeProvider != nil {
+		c.closeProvider()
+		c.closeProvider = nil
 	}
-	if err := tw.WriteHeader(dockerfileHeader); err != nil {
-		return nil, fmt.Errorf("failed to write Dockerfile header: %w", err)
+}
+
+// Logs returns the output of the container's main process and its children,
+// with stdout and stderr combined. This includes output from background
+// processes that do not log through an exec session. The values of
+// WithSecretEnv are redacted.
+func (c *TestContainer) Logs(ctx context.Context) ([]byte, error) {
+	if c.ctr == nil {
+		return nil, fmt.Errorf("container is nil")
 	}
-	if _, err := tw.Write(dockerfileContent); err != nil {
-		return nil, fmt.Errorf("failed to write Dockerfile content: %w", err)
+	reader, err := c.ctr.Logs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container logs: %w", err)
 	}
+	defer func() {
+		_ = reader.Close()
+	}()
 
-	// Close the tar writer
-	if err := tw.Close(); err != nil {
-		return nil, fmt.Errorf("failed to close tar writer: %w", err)
+	logs, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read container logs: %w", err)
 	}
+	return c.redact(logs), nil
+}
 
-	return bytes.NewReader(buf.Bytes()), nil
+// OOMKilled reports whether a process in the container was killed by the
+// kernel out-of-memory killer.
+func (c *TestContainer) OOMKilled(ctx context.Context) (bool, error) {
+	if c.ctr == nil {
+		return false, fmt.Errorf("container is nil")
+	}
+	state, err := c.ctr.State(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get container state: %w", err)
+	}
+	return state.OOMKilled, nil
 }
 
-// Terminate stops and removes the container.
-func (c *TestContainer) Terminate(ctx context.Context) error {
+// Host returns the host to reach the published ports of the container from
+// the host, e.g. "localhost" or the address of a remote Docker daemon.
+func (c *TestContainer) Host(ctx context.Context) (string, error) {
 	if c.ctr == nil {
-		return nil
+		return "", fmt.Errorf("container is nil")
 	}
-	if err := c.ctr.Terminate(ctx); err != nil {
-		return fmt.Errorf("failed to terminate container: %w", err)
+	host, err := c.ctr.Host(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get container host: %w", err)
 	}
-	return nil
+	return host, nil
+}
+
+// ContainerIP returns the IP address of the container on the network of the
+// run, or on its first network if it was not created by this package.
+func (c *TestContainer) ContainerIP(ctx context.Context) (string, error) {
+	if c.ctr == nil {
+		return "", fmt.Errorf("container is nil")
+	}
+	if c.networkName == "" {
+		ip, err := c.ctr.ContainerIP(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to get container IP: %w", err)
+		}
+		return ip, nil
+	}
+	info, err := c.ctr.Inspect(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect container: %w", err)
+	}
+	if info.NetworkSettings != nil {
+		if endpoint, ok := info.NetworkSettings.Networks[c.networkName]; ok && endpoint.IPAddress != "" {
+			return endpoint.IPAddress, nil
+		}
+	}
+	return "", fmt.Errorf("container has no IP address on network %s", c.networkName)
+}
+
+// MappedPort returns the host port the container port is published on, e.g.
+// "49153" for "8080/tcp". The protocol defaults to tcp. It returns an error if
+// the port is not published.
+func (c *TestContainer) MappedPort(ctx context.Context, port string) (string, error) {
+	if c.ctr == nil {
+		return "", fmt.Errorf("container is nil")
+	}
+	proto, number := nat.SplitProtoPort(port)
+	containerPort, err := nat.NewPort(proto, number)
+	if err != nil {
+		return "", fmt.Errorf("invalid port %q: %w", port, err)
+	}
+	mapped, err := c.ctr.MappedPort(ctx, containerPort)
+	if err != nil {
+		return "", fmt.Errorf("failed to get mapped port %s: %w", containerPort, err)
+	}
+	return mapped.Port(), nil
 }
 
 // Container returns the underlying testcontainers.Container.
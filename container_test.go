@@ -4,8 +4,10 @@ package dockertesting
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -303,3 +305,63 @@ func TestCreateContainer_InvalidPackagePath(t *testing.T) {
 		t.Fatal("expected error for non-existent package path")
 	}
 }
+
+func TestCreateContainer_BuildError(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	// Create a package whose dependencies cannot be downloaded
+	tmpDir := t.TempDir()
+	goModContent := `module testpkg
+
+go 1.25.6
+
+require example.invalid/missing v1.0.0
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte(goModContent), 0644); err != nil {
+		t.Fatalf("failed to write go.mod: %v", err)
+	}
+
+	_, err := CreateContainer(ctx, CreateContainerConfig{PackagePath: tmpDir})
+	if err == nil {
+		t.Fatal("expected error for failing build")
+	}
+
+	var buildErr *BuildError
+	if !errors.As(err, &buildErr) {
+		t.Fatalf("expected BuildError, got: %v", err)
+	}
+	if !strings.Contains(string(buildErr.Log), "go mod download") {
+		t.Errorf("expected build log to mention go mod download, got:\n%s", string(buildErr.Log))
+	}
+}
+
+func TestTestContainer_Logs_NilContainer(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	container := &TestContainer{ctr: nil}
+
+	if _, err := container.Logs(ctx); err == nil {
+		t.Error("expected error for nil container")
+	}
+	if _, err := container.OOMKilled(ctx); err == nil {
+		t.Error("expected error for nil container")
+	}
+}
+
+func TestTestContainer_Endpoints_NilContainer(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	container := &TestContainer{ctr: nil}
+
+	if _, err := container.Host(ctx); err == nil {
+		t.Error("expected error for nil container")
+	}
+	if _, err := container.ContainerIP(ctx); err == nil {
+		t.Error("expected error for nil container")
+	}
+	if _, err := container.MappedPort(ctx, "8080/tcp"); err == nil {
+		t.Error("expected error for nil container")
+	}
+}
@@ -0,0 +1,53 @@
+package dockertesting
+
+import (
+	"path"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+)
+
+// containerdCompatSocketNames are the file names of the sockets served by
+// Docker-compatible APIs in front of containerd, such as finch-daemon for
+// nerdctl-managed containers.
+var containerdCompatSocketNames = []string{"finch.sock", "nerdctl.sock"}
+
+// isContainerdCompatHost reports whether dockerHost is the unix socket of a
+// Docker-compatible API in front of containerd, see containerdCompatSocketNames.
+func isContainerdCompatHost(dockerHost string) bool {
+	sock, ok := strings.CutPrefix(dockerHost, "unix://")
+	if !ok || sock == "" {
+		return false
+	}
+	name := path.Base(sock)
+	for _, compat := range containerdCompatSocketNames {
+		if name == compat {
+			return true
+		}
+	}
+	return false
+}
+
+// adaptHostConfigForContainerd rewrites hc for Docker-compatible APIs in front
+// of containerd, which implement HostConfig.Binds but ignore HostConfig.Mounts:
+// bind mounts are converted to binds, and mount types without a bind
+// equivalent (named pipes) are dropped instead of failing the create call.
+func adaptHostConfigForContainerd(hc *container.HostConfig) {
+	var mounts []mount.Mount
+	for _, m := range hc.Mounts {
+		switch m.Type {
+		case mount.TypeBind:
+			bind := m.Source + ":" + m.Target
+			if m.ReadOnly {
+				bind += ":ro"
+			}
+			hc.Binds = append(hc.Binds, bind)
+		case mount.TypeNamedPipe:
+			// Not supported by containerd on Linux
+		default:
+			mounts = append(mounts, m)
+		}
+	}
+	hc.Mounts = mounts
+}
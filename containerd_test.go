@@ -0,0 +1,54 @@
+package dockertesting
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+)
+
+func TestIsContainerdCompatHost(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		dockerHost string
+		expected   bool
+	}{
+		{dockerHost: "unix:///run/finch.sock", expected: true},
+		{dockerHost: "unix:///run/user/1000/nerdctl.sock", expected: true},
+		{dockerHost: "unix:///var/run/docker.sock", expected: false},
+		{dockerHost: "tcp://finch.sock:2375", expected: false},
+		{dockerHost: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		if got := isContainerdCompatHost(tt.dockerHost); got != tt.expected {
+			t.Errorf("isContainerdCompatHost(%q): expected %v, got %v", tt.dockerHost, tt.expected, got)
+		}
+	}
+}
+
+func TestAdaptHostConfigForContainerd(t *testing.T) {
+	t.Parallel()
+
+	hc := &container.HostConfig{
+		Binds: []string{"/src:/src"},
+		Mounts: []mount.Mount{
+			{Type: mount.TypeBind, Source: "/run/finch.sock", Target: "/var/run/docker.sock"},
+			{Type: mount.TypeBind, Source: "/certs", Target: "/certs", ReadOnly: true},
+			{Type: mount.TypeNamedPipe, Source: `\\.\pipe\docker_engine`, Target: `\\.\pipe\docker_engine`},
+			{Type: mount.TypeVolume, Source: "gomodcache", Target: "/go/pkg/mod"},
+		},
+	}
+	adaptHostConfigForContainerd(hc)
+
+	expectedBinds := []string{"/src:/src", "/run/finch.sock:/var/run/docker.sock", "/certs:/certs:ro"}
+	if !reflect.DeepEqual(hc.Binds, expectedBinds) {
+		t.Errorf("expected binds %v, got %v", expectedBinds, hc.Binds)
+	}
+	expectedMounts := []mount.Mount{{Type: mount.TypeVolume, Source: "gomodcache", Target: "/go/pkg/mod"}}
+	if !reflect.DeepEqual(hc.Mounts, expectedMounts) {
+		t.Errorf("expected mounts %v, got %v", expectedMounts, hc.Mounts)
+	}
+}
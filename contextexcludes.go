@@ -0,0 +1,38 @@
+package dockertesting
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// DefaultContextExcludes are the paths left out of the build context unless
+// overridden with WithContextExcludes: version control metadata, editor
+// settings and large dependency or output trees that go test never needs.
+var DefaultContextExcludes = []string{".git", ".idea", ".vscode", "node_modules", "bazel-*"}
+
+// validateContextExcludes checks that all patterns are valid path.Match patterns.
+func validateContextExcludes(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid context exclude pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// isContextExcluded reports whether the slash-separated relative path name
+// matches one of the patterns. Patterns containing a slash are matched against
+// the whole path, all others against its base name at any depth.
+func isContextExcluded(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		target := path.Base(name)
+		if strings.Contains(pattern, "/") {
+			target = name
+		}
+		if ok, _ := path.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
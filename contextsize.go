@@ -0,0 +1,108 @@
+package dockertesting
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// maxContextSizeErrorFiles is the number of largest files listed in a
+// ContextSizeError.
+const maxContextSizeErrorFiles = 10
+
+// ContextFile is a file of the build context.
+type ContextFile struct {
+	// Path is the slash-separated path relative to the package directory.
+	Path string
+
+	// Size is the size of the file in bytes.
+	Size int64
+}
+
+// ContextSizeError is returned when the build context exceeds the limit set
+// with WithMaxContextSize. Nothing is sent to the daemon in that case.
+type ContextSizeError struct {
+	// Limit is the maximum size of the build context in bytes.
+	Limit int64
+
+	// Size is the total size of the files in the build context in bytes.
+	Size int64
+
+	// Largest are the largest files of the build context, largest first.
+	Largest []ContextFile
+}
+
+func (e *ContextSizeError) Error() string {
+	files := make([]string, len(e.Largest))
+	for i, file := range e.Largest {
+		files[i] = fmt.Sprintf("%s (%s)", file.Path, formatBytes(file.Size))
+	}
+	return fmt.Sprintf("build context of %s exceeds the limit of %s; largest files: %s; exclude them with WithContextExcludes",
+		formatBytes(e.Size), formatBytes(e.Limit), strings.Join(files, ", "))
+}
+
+// contextSizer tracks the size of the build context against a limit.
+type contextSizer struct {
+	// limit is the maximum size in bytes, or 0 for no limit.
+	limit int64
+
+	size  int64
+	files []ContextFile
+}
+
+// add records a regular file of the build context.
+func (s *contextSizer) add(path string, size int64) {
+	if s.limit <= 0 {
+		return
+	}
+	s.size += size
+	s.files = append(s.files, ContextFile{Path: path, Size: size})
+}
+
+// exceeded reports whether the files added so far exceed the limit.
+func (s *contextSizer) exceeded() bool {
+	return s.limit > 0 && s.size > s.limit
+}
+
+// err returns a *ContextSizeError if the limit is exceeded, or nil otherwise.
+func (s *contextSizer) err() error {
+	if !s.exceeded() {
+		return nil
+	}
+	largest := slices.Clone(s.files)
+	slices.SortStableFunc(largest, func(a, b ContextFile) int {
+		return cmp.Compare(b.Size, a.Size)
+	})
+	if len(largest) > maxContextSizeErrorFiles {
+		largest = largest[:maxContextSizeErrorFiles]
+	}
+	return &ContextSizeError{Limit: s.limit, Size: s.size, Largest: largest}
+}
+
+// formatBytes formats n as a human-readable size with binary prefixes.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// archiveSize returns the size of archive and rewinds it to its start.
+func archiveSize(archive io.Seeker) (int64, error) {
+	size, err := archive.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
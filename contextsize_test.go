@@ -0,0 +1,124 @@
+package dockertesting
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCreateTarContext_MaxSize(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	files := map[string]int{
+		"go.mod":           20,
+		"small.go":         100,
+		"testdata/big.bin": 4096,
+		"testdata/mid.bin": 2048,
+	}
+	for name, size := range files {
+		full := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatalf("failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(full, make([]byte, size), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	_, err := createTarContext(tmpDir, "", tarContextOptions{
+		template:       dockerfileTemplate,
+		spillThreshold: tarSpillThreshold,
+		maxSize:        1024,
+	})
+	var sizeErr *ContextSizeError
+	if !errors.As(err, &sizeErr) {
+		t.Fatalf("expected *ContextSizeError, got %v", err)
+	}
+	if sizeErr.Size != 6264 {
+		t.Errorf("expected size 6264, got %d", sizeErr.Size)
+	}
+	if sizeErr.Limit != 1024 {
+		t.Errorf("expected limit 1024, got %d", sizeErr.Limit)
+	}
+	if len(sizeErr.Largest) != 4 || sizeErr.Largest[0].Path != "testdata/big.bin" || sizeErr.Largest[1].Path != "testdata/mid.bin" {
+		t.Errorf("expected files ordered by size, got %v", sizeErr.Largest)
+	}
+	if !strings.Contains(err.Error(), "testdata/big.bin (4.0 KiB)") {
+		t.Errorf("expected error to list the largest file, got %q", err.Error())
+	}
+
+	// The same context fits into a larger limit
+	reader, err := createTarContext(tmpDir, "", tarContextOptions{
+		template:       dockerfileTemplate,
+		spillThreshold: tarSpillThreshold,
+		maxSize:        8192,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := readTarContents(t, reader)["testdata/big.bin"]; !ok {
+		t.Error("testdata/big.bin not found in tar")
+	}
+}
+
+func TestContextSizer_LimitsListedFiles(t *testing.T) {
+	t.Parallel()
+
+	sizer := &contextSizer{limit: 1}
+	for i := range 20 {
+		sizer.add(string(rune('a'+i)), int64(i))
+	}
+	var sizeErr *ContextSizeError
+	if !errors.As(sizer.err(), &sizeErr) {
+		t.Fatal("expected *ContextSizeError")
+	}
+	if len(sizeErr.Largest) != maxContextSizeErrorFiles {
+		t.Errorf("expected %d files, got %d", maxContextSizeErrorFiles, len(sizeErr.Largest))
+	}
+	if sizeErr.Largest[0].Size != 19 {
+		t.Errorf("expected the largest file first, got %v", sizeErr.Largest[0])
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		n        int64
+		expected string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{100 << 20, "100.0 MiB"},
+		{5 << 30, "5.0 GiB"},
+	}
+	for _, tt := range tests {
+		if got := formatBytes(tt.n); got != tt.expected {
+			t.Errorf("formatBytes(%d): expected %q, got %q", tt.n, tt.expected, got)
+		}
+	}
+}
+
+func TestArchiveSize(t *testing.T) {
+	t.Parallel()
+	archive := strings.NewReader("0123456789")
+	if _, err := archive.Seek(4, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	size, err := archiveSize(archive)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if size != 10 {
+		t.Errorf("expected size 10, got %d", size)
+	}
+	if archive.Len() != 10 {
+		t.Errorf("expected the archive to be rewound, %d bytes left", archive.Len())
+	}
+}
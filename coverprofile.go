@@ -0,0 +1,177 @@
+package dockertesting
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// coverBlock is a single block line of a Go coverage profile.
+type coverBlock struct {
+	// position is the "file:startLine.startCol,endLine.endCol" part of the line.
+	position string
+
+	// numStmts is the number of statements in the block.
+	numStmts int
+
+	// count is the number of times the block was executed (0 or 1 in set mode).
+	count int
+}
+
+// coverProfile is a parsed Go coverage profile as written by -coverprofile.
+type coverProfile struct {
+	// mode is the coverage mode: set, count or atomic.
+	mode string
+
+	// blocks are the profile blocks in the order they appeared.
+	blocks []coverBlock
+}
+
+// parseCoverProfile parses a Go coverage profile.
+func parseCoverProfile(data []byte) (*coverProfile, error) {
+	profile := &coverProfile{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if mode, ok := strings.CutPrefix(line, "mode:"); ok {
+			mode = strings.TrimSpace(mode)
+			if profile.mode != "" && profile.mode != mode {
+				return nil, fmt.Errorf("line %d: conflicting coverage mode %q, expected %q", lineNum, mode, profile.mode)
+			}
+			profile.mode = mode
+			continue
+		}
+		if profile.mode == "" {
+			return nil, fmt.Errorf("line %d: missing mode line", lineNum)
+		}
+
+		// Format: file:startLine.startCol,endLine.endCol numStmts count
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: invalid coverage block %q", lineNum, line)
+		}
+		numStmts, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid statement count: %w", lineNum, err)
+		}
+		count, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid execution count: %w", lineNum, err)
+		}
+		profile.blocks = append(profile.blocks, coverBlock{
+			position: fields[0],
+			numStmts: numStmts,
+			count:    count,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read coverage profile: %w", err)
+	}
+	return profile, nil
+}
+
+// bytes formats the profile in the Go coverage profile format.
+func (p *coverProfile) bytes() []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "mode: %s\n", p.mode)
+	for _, b := range p.blocks {
+		fmt.Fprintf(&buf, "%s %d %d\n", b.position, b.numStmts, b.count)
+	}
+	return buf.Bytes()
+}
+
+// MergeCoverage combines multiple Go coverage profiles into a single profile.
+//
+// All profiles must use the same coverage mode. Blocks that appear in several
+// profiles are merged: in "set" mode a block is covered if it is covered in any
+// profile, in "count" and "atomic" mode the execution counts are summed.
+// Empty profiles (e.g. from packages whose tests failed early) are skipped.
+//
+// Example:
+//
+//	merged, err := dockertesting.MergeCoverage([][]byte{resultA.Coverage, resultB.Coverage})
+func MergeCoverage(profiles [][]byte) ([]byte, error) {
+	merged, err := mergeCoverProfiles(profiles)
+	if err != nil {
+		return nil, err
+	}
+	if merged.mode == "" {
+		return nil, nil
+	}
+	return merged.bytes(), nil
+}
+
+// mergeCoverProfiles parses and merges the given profiles. The mode of the
+// result is empty if all profiles were empty.
+func mergeCoverProfiles(profiles [][]byte) (*coverProfile, error) {
+	merged := &coverProfile{}
+	index := make(map[string]int)
+
+	for i, data := range profiles {
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+		profile, err := parseCoverProfile(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse coverage profile %d: %w", i, err)
+		}
+		if merged.mode == "" {
+			merged.mode = profile.mode
+		} else if profile.mode != merged.mode {
+			return nil, fmt.Errorf("coverage profile %d has mode %q, expected %q", i, profile.mode, merged.mode)
+		}
+
+		for _, block := range profile.blocks {
+			key := block.position + " " + strconv.Itoa(block.numStmts)
+			j, ok := index[key]
+			if !ok {
+				index[key] = len(merged.blocks)
+				merged.blocks = append(merged.blocks, block)
+				continue
+			}
+			if merged.mode == "set" {
+				merged.blocks[j].count = max(merged.blocks[j].count, block.count)
+			} else {
+				merged.blocks[j].count += block.count
+			}
+		}
+	}
+
+	return merged, nil
+}
+
+// CoveragePercent computes the percentage of statements covered by a Go
+// coverage profile, matching the total reported by `go tool cover -func`.
+// Blocks that appear multiple times are counted once. It returns 0 for an
+// empty profile.
+//
+// Example:
+//
+//	percent, err := dockertesting.CoveragePercent(result.Coverage)
+func CoveragePercent(profile []byte) (float64, error) {
+	// Merging a single profile deduplicates repeated blocks
+	merged, err := mergeCoverProfiles([][]byte{profile})
+	if err != nil {
+		return 0, err
+	}
+
+	var total, covered int
+	for _, block := range merged.blocks {
+		total += block.numStmts
+		if block.count > 0 {
+			covered += block.numStmts
+		}
+	}
+	if total == 0 {
+		return 0, nil
+	}
+	return float64(covered) / float64(total) * 100, nil
+}
@@ -0,0 +1,120 @@
+package dockertesting
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMergeCoverage_SetMode(t *testing.T) {
+	t.Parallel()
+	a := "mode: set\n" +
+		"example.com/pkg/math.go:3.24,5.2 1 1\n" +
+		"example.com/pkg/math.go:7.24,9.2 1 0\n"
+	b := "mode: set\n" +
+		"example.com/pkg/math.go:3.24,5.2 1 0\n" +
+		"example.com/pkg/math.go:7.24,9.2 1 1\n" +
+		"example.com/pkg/other.go:3.20,5.2 2 1\n"
+
+	merged, err := MergeCoverage([][]byte{[]byte(a), []byte(b)})
+	if err != nil {
+		t.Fatalf("MergeCoverage failed: %v", err)
+	}
+
+	expected := "mode: set\n" +
+		"example.com/pkg/math.go:3.24,5.2 1 1\n" +
+		"example.com/pkg/math.go:7.24,9.2 1 1\n" +
+		"example.com/pkg/other.go:3.20,5.2 2 1\n"
+	if string(merged) != expected {
+		t.Errorf("unexpected merged profile:\n%s\nexpected:\n%s", merged, expected)
+	}
+}
+
+func TestMergeCoverage_CountModeSums(t *testing.T) {
+	t.Parallel()
+	a := "mode: count\nexample.com/pkg/math.go:3.24,5.2 1 2\n"
+	b := "mode: count\nexample.com/pkg/math.go:3.24,5.2 1 3\n"
+
+	merged, err := MergeCoverage([][]byte{[]byte(a), []byte(b)})
+	if err != nil {
+		t.Fatalf("MergeCoverage failed: %v", err)
+	}
+
+	if !strings.Contains(string(merged), "example.com/pkg/math.go:3.24,5.2 1 5\n") {
+		t.Errorf("expected counts to be summed, got:\n%s", merged)
+	}
+}
+
+func TestMergeCoverage_ModeMismatch(t *testing.T) {
+	t.Parallel()
+	a := "mode: set\nexample.com/pkg/math.go:3.24,5.2 1 1\n"
+	b := "mode: atomic\nexample.com/pkg/math.go:3.24,5.2 1 1\n"
+
+	if _, err := MergeCoverage([][]byte{[]byte(a), []byte(b)}); err == nil {
+		t.Error("expected error for mismatched coverage modes")
+	}
+}
+
+func TestMergeCoverage_SkipsEmptyProfiles(t *testing.T) {
+	t.Parallel()
+	a := "mode: set\nexample.com/pkg/math.go:3.24,5.2 1 1\n"
+
+	merged, err := MergeCoverage([][]byte{nil, []byte(a), []byte("")})
+	if err != nil {
+		t.Fatalf("MergeCoverage failed: %v", err)
+	}
+	if string(merged) != a {
+		t.Errorf("expected merged profile to equal the single input, got:\n%s", merged)
+	}
+
+	merged, err = MergeCoverage(nil)
+	if err != nil {
+		t.Fatalf("MergeCoverage failed: %v", err)
+	}
+	if merged != nil {
+		t.Errorf("expected nil for no profiles, got %q", merged)
+	}
+}
+
+func TestMergeCoverage_InvalidProfile(t *testing.T) {
+	t.Parallel()
+	tests := []string{
+		"example.com/pkg/math.go:3.24,5.2 1 1\n",
+		"mode: set\nnot a block\n",
+		"mode: set\nexample.com/pkg/math.go:3.24,5.2 x 1\n",
+	}
+	for _, profile := range tests {
+		if _, err := MergeCoverage([][]byte{[]byte(profile)}); err == nil {
+			t.Errorf("expected error for invalid profile %q", profile)
+		}
+	}
+}
+
+func TestCoveragePercent(t *testing.T) {
+	t.Parallel()
+	profile := "mode: set\n" +
+		"example.com/pkg/math.go:3.24,5.2 1 1\n" +
+		"example.com/pkg/math.go:7.24,9.2 3 0\n" +
+		"example.com/pkg/math.go:3.24,5.2 1 0\n"
+
+	percent, err := CoveragePercent([]byte(profile))
+	if err != nil {
+		t.Fatalf("CoveragePercent failed: %v", err)
+	}
+	// 1 of 4 statements covered; the duplicated block is counted once
+	if percent != 25 {
+		t.Errorf("expected 25%%, got %v", percent)
+	}
+}
+
+func TestCoveragePercent_Empty(t *testing.T) {
+	t.Parallel()
+	for _, profile := range [][]byte{nil, []byte("mode: set\n")} {
+		percent, err := CoveragePercent(profile)
+		if err != nil {
+			t.Fatalf("CoveragePercent failed: %v", err)
+		}
+		if percent != 0 {
+			t.Errorf("expected 0%% for %q, got %v", profile, percent)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package dockertesting
+
+import (
+	"bufio"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// CrashKind is the kind of a Crash.
+type CrashKind string
+
+const (
+	// CrashPanic is a panic that was not recovered.
+	CrashPanic CrashKind = "panic"
+
+	// CrashFatalError is a fatal error of the Go runtime, e.g. "fatal error:
+	// all goroutines are asleep - deadlock!" or "fatal error: concurrent map
+	// writes".
+	CrashFatalError CrashKind = "fatal error"
+
+	// CrashKilled is a test binary killed by a signal, reported by go test as
+	// "signal: killed".
+	CrashKilled CrashKind = "killed"
+
+	// CrashOOMKilled is a test binary or go test killed by the out-of-memory
+	// killer of the container, see Result.OOMKilled.
+	CrashOOMKilled CrashKind = "oom killed"
+)
+
+var (
+	// goroutineHeaderPattern matches the headers of goroutine stacks, e.g.
+	// "goroutine 7 [running]:".
+	goroutineHeaderPattern = regexp.MustCompile(`^goroutine \d+ \[[^\]]*\]:$`)
+
+	// testFunctionPattern matches the test functions of stack frames, e.g.
+	// "example.com/mod.TestDiv.func1", after the last "/".
+	testFunctionPattern = regexp.MustCompile(`^[^.]+\.((?:Test|Benchmark|Fuzz|Example)\w*)`)
+)
+
+// Crash is a test binary that crashed, see Result.Crashes.
+type Crash struct {
+	// Package is the import path of the package of the test binary. It is
+	// empty if the output does not name it.
+	Package string `json:"package,omitempty"`
+
+	// Test is the test that crashed, best-effort. It is empty if neither
+	// the output nor the stacks name it.
+	Test string `json:"test,omitempty"`
+
+	// Kind is the kind of the crash.
+	Kind CrashKind `json:"kind"`
+
+	// Message is the message of the crash, e.g. "panic: runtime error:
+	// index out of range [3] with length 3 [recovered]".
+	Message string `json:"message"`
+
+	// Stack is the stack of the crashing goroutine, innermost frame first.
+	// It is empty for killed test binaries.
+	Stack []StackFrame `json:"stack,omitempty"`
+}
+
+// Location returns the innermost frame of the stack in a file of the tested
+// module, e.g. the line that panicked, and whether there is one.
+func (c Crash) Location() (StackFrame, bool) {
+	for _, frame := range c.Stack {
+		if frame.File != "" && !path.IsAbs(frame.File) {
+			return frame, true
+		}
+	}
+	return StackFrame{}, false
+}
+
+// ParseCrashes returns the panics, fatal runtime errors and killed test
+// binaries in output, the plain or -json output of go test. The crashing
+// test is taken from the output of go test, or from the stacks if the output
+// does not name it, e.g. without -v.
+//
+// Example:
+//
+//	for _, crash := range dockertesting.ParseCrashes(result.Stdout) {
+//	    fmt.Printf("%s %s: %s\n", crash.Package, crash.Test, crash.Message)
+//	}
+func ParseCrashes(output []byte) []Crash {
+	var crashes []Crash
+	for _, out := range packageOutputs(output) {
+		var (
+			test    string
+			report  []string
+			pending int // crashes of plain output waiting for their package
+		)
+		flush := func() {
+			if report != nil {
+				crash := parseCrash(report, test)
+				crash.Package = out.pkg
+				crashes = append(crashes, crash)
+				pending++
+			}
+			report = nil
+		}
+		scanner := bufio.NewScanner(strings.NewReader(out.text))
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			line := strings.TrimRight(scanner.Text(), "\r")
+			switch {
+			case report == nil && (strings.HasPrefix(line, "panic: ") || strings.HasPrefix(line, "fatal error: ")):
+				report = []string{line}
+			case line == "signal: killed":
+				flush()
+				crashes = append(crashes, Crash{Package: out.pkg, Test: test, Kind: CrashKilled, Message: line})
+				pending++
+			case packageResultPattern.MatchString(line) || strings.HasPrefix(line, "exit status "):
+				flush()
+				if out.pkg == "" && packageResultPattern.MatchString(line) {
+					pkg := packageResultPattern.FindStringSubmatch(line)[1]
+					for i := len(crashes) - pending; i < len(crashes); i++ {
+						crashes[i].Package = pkg
+					}
+					pending = 0
+				}
+			case report != nil:
+				report = append(report, line)
+			default:
+				if match := testStatePattern.FindStringSubmatch(line); match != nil {
+					test = match[2]
+				}
+			}
+		}
+		flush()
+	}
+	return crashes
+}
+
+// parseCrash parses the lines of a panic or fatal error, its message followed
+// by the goroutine stacks. test is the test the output last named.
+func parseCrash(lines []string, test string) Crash {
+	crash := Crash{Kind: CrashPanic}
+	if strings.HasPrefix(lines[0], "fatal error: ") {
+		crash.Kind = CrashFatalError
+	}
+
+	var message []string
+	var stacks [][]StackFrame
+	var stack []string
+	inMessage := true
+	flush := func() {
+		if stack != nil {
+			stacks = append(stacks, parseStack(stack))
+		}
+		stack = nil
+	}
+	for _, line := range lines {
+		switch {
+		case goroutineHeaderPattern.MatchString(line):
+			flush()
+			inMessage = false
+			stack = []string{}
+		case strings.TrimSpace(line) == "":
+			flush()
+		case stack != nil:
+			stack = append(stack, line)
+		case inMessage:
+			message = append(message, line)
+		}
+	}
+	flush()
+
+	crash.Message = strings.TrimSpace(strings.Join(message, "\n"))
+	if len(stacks) > 0 {
+		crash.Stack = stacks[0]
+	}
+	crash.Test = crashedTest(stacks, test)
+	return crash
+}
+
+// crashedTest returns the test that crashed: test if the stacks run it or
+// one of its parents, otherwise the first test function of the stacks, or
+// test if the stacks run none.
+func crashedTest(stacks [][]StackFrame, test string) string {
+	for _, stack := range stacks {
+		for _, frame := range stack {
+			function := frame.Function[strings.LastIndex(frame.Function, "/")+1:]
+			match := testFunctionPattern.FindStringSubmatch(function)
+			if match == nil {
+				continue
+			}
+			if top, _, _ := strings.Cut(test, "/"); top == match[1] {
+				return test
+			}
+			return match[1]
+		}
+	}
+	return test
+}
+
+// withOOMKilled marks crashes as caused by the out-of-memory killer, for a
+// container in which it fired: killed test binaries become CrashOOMKilled,
+// and if none was, go test itself was killed before it could report it.
+func withOOMKilled(crashes []Crash) []Crash {
+	killed := false
+	for i := range crashes {
+		if crashes[i].Kind == CrashKilled {
+			crashes[i].Kind = CrashOOMKilled
+			killed = true
+		}
+	}
+	if !killed {
+		crashes = append(crashes, Crash{Kind: CrashOOMKilled, Message: "killed by the out-of-memory killer"})
+	}
+	return crashes
+}
@@ -0,0 +1,104 @@
+package dockertesting
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCrashes_Panic(t *testing.T) {
+	t.Parallel()
+	output := `=== RUN   TestDiv
+=== RUN   TestDiv/by_zero
+--- FAIL: TestDiv/by_zero (0.00s)
+panic: runtime error: integer divide by zero [recovered]
+	panic: runtime error: integer divide by zero
+
+goroutine 8 [running]:
+testing.tRunner.func1.2({0x5a4f20, 0x6e8b40})
+	/usr/local/go/src/testing/testing.go:1734 +0x21c
+panic({0x5a4f20?, 0x6e8b40?})
+	/usr/local/go/src/runtime/panic.go:792 +0x132
+example.com/mod/calc.Div(...)
+	/app/calc/div.go:4
+example.com/mod/calc.TestDiv.func1(0xc000003880)
+	/app/calc/div_test.go:9 +0x1d
+testing.tRunner(0xc000003880, 0x5d1e08)
+	/usr/local/go/src/testing/testing.go:1792 +0xf4
+created by testing.(*T).Run in goroutine 7
+	/usr/local/go/src/testing/testing.go:1851 +0x413
+exit status 2
+FAIL	example.com/mod/calc	0.004s
+FAIL
+`
+
+	crashes := ParseCrashes([]byte(output))
+	if len(crashes) != 1 {
+		t.Fatalf("expected 1 crash, got %+v", crashes)
+	}
+	crash := crashes[0]
+	if crash.Package != "example.com/mod/calc" || crash.Test != "TestDiv/by_zero" || crash.Kind != CrashPanic {
+		t.Errorf("expected a panic of example.com/mod/calc TestDiv/by_zero, got %+v", crash)
+	}
+	if expected := "panic: runtime error: integer divide by zero [recovered]\n\tpanic: runtime error: integer divide by zero"; crash.Message != expected {
+		t.Errorf("expected message %q, got %q", expected, crash.Message)
+	}
+	if len(crash.Stack) != 6 || crash.Stack[5] != (StackFrame{Function: "testing.(*T).Run", File: "/usr/local/go/src/testing/testing.go", Line: 1851}) {
+		t.Errorf("unexpected stack %+v", crash.Stack)
+	}
+	if frame, ok := crash.Location(); !ok || frame != (StackFrame{Function: "example.com/mod/calc.Div", File: "calc/div.go", Line: 4}) {
+		t.Errorf("expected the location in calc/div.go, got %+v", frame)
+	}
+}
+
+func TestParseCrashes_Deadlock(t *testing.T) {
+	t.Parallel()
+	// Without -v, the output does not name the test
+	output := `{"Action":"start","Package":"example.com/mod"}
+{"Action":"output","Package":"example.com/mod","Output":"fatal error: all goroutines are asleep - deadlock!\n"}
+{"Action":"output","Package":"example.com/mod","Output":"\n"}
+{"Action":"output","Package":"example.com/mod","Output":"goroutine 1 [chan receive]:\n"}
+{"Action":"output","Package":"example.com/mod","Output":"testing.(*T).Run(0xc000003500, {0x5b3c4e, 0xc}, 0x5d1e10)\n"}
+{"Action":"output","Package":"example.com/mod","Output":"\t/usr/local/go/src/testing/testing.go:1859 +0x431\n"}
+{"Action":"output","Package":"example.com/mod","Output":"\n"}
+{"Action":"output","Package":"example.com/mod","Output":"goroutine 7 [chan receive]:\n"}
+{"Action":"output","Package":"example.com/mod","Output":"example.com/mod.TestWait(0xc000003880)\n"}
+{"Action":"output","Package":"example.com/mod","Output":"\t/app/wait_test.go:6 +0x25\n"}
+{"Action":"output","Package":"example.com/mod","Output":"exit status 2\n"}
+{"Action":"fail","Package":"example.com/mod"}
+`
+
+	expected := []Crash{{
+		Package: "example.com/mod",
+		Test:    "TestWait",
+		Kind:    CrashFatalError,
+		Message: "fatal error: all goroutines are asleep - deadlock!",
+		Stack:   []StackFrame{{Function: "testing.(*T).Run", File: "/usr/local/go/src/testing/testing.go", Line: 1859}},
+	}}
+	if crashes := ParseCrashes([]byte(output)); !reflect.DeepEqual(crashes, expected) {
+		t.Errorf("expected %+v, got %+v", expected, crashes)
+	}
+}
+
+func TestParseCrashes_Killed(t *testing.T) {
+	t.Parallel()
+	output := "=== RUN   TestAlloc\nsignal: killed\nFAIL\texample.com/mod\t3.001s\nFAIL\n"
+	expected := []Crash{{Package: "example.com/mod", Test: "TestAlloc", Kind: CrashKilled, Message: "signal: killed"}}
+	crashes := ParseCrashes([]byte(output))
+	if !reflect.DeepEqual(crashes, expected) {
+		t.Errorf("expected %+v, got %+v", expected, crashes)
+	}
+
+	if oom := withOOMKilled(crashes); len(oom) != 1 || oom[0].Kind != CrashOOMKilled {
+		t.Errorf("expected the kill to be attributed to the OOM killer, got %+v", oom)
+	}
+	if oom := withOOMKilled(nil); len(oom) != 1 || oom[0].Kind != CrashOOMKilled {
+		t.Errorf("expected a crash for go test killed by the OOM killer, got %+v", oom)
+	}
+}
+
+func TestParseCrashes_Passed(t *testing.T) {
+	t.Parallel()
+	if crashes := ParseCrashes([]byte("PASS\nok  \texample.com/mod\t0.001s\n")); crashes != nil {
+		t.Errorf("expected no crashes, got %+v", crashes)
+	}
+}
@@ -0,0 +1,70 @@
+package dockertesting
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// DebugImagePrefix is the prefix of the tag given to the last successfully
+// built layer of a failed image build when WithKeepFailedBuild is set.
+const DebugImagePrefix = "dockertesting-debug-"
+
+// buildImageIDPattern matches the lines of the classic docker builder that
+// report the image committed for a build step, e.g. " ---> 3f4d5c6b7a8e".
+var buildImageIDPattern = regexp.MustCompile(`^ ---> ([0-9a-f]{12,64})$`)
+
+// lastBuildImageID returns the ID of the image committed by the last
+// successful build step in the build log, or "" if there is none.
+func lastBuildImageID(buildLog []byte) string {
+	var id string
+	scanner := bufio.NewScanner(bytes.NewReader(buildLog))
+	for scanner.Scan() {
+		if match := buildImageIDPattern.FindStringSubmatch(scanner.Text()); match != nil {
+			id = match[1]
+		}
+	}
+	return id
+}
+
+// newDebugImageTag returns a unique tag for a retained debug image.
+func newDebugImageTag() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate run id: %w", err)
+	}
+	return DebugImagePrefix + hex.EncodeToString(b), nil
+}
+
+// tagDebugImage tags the last successful intermediate image found in the build
+// log so it survives the failed build and can be inspected with `docker run`.
+// It returns the tag, or "" if the build log contains no intermediate image.
+// The image is tagged through provider's client if provider is non-nil.
+func tagDebugImage(ctx context.Context, provider *testcontainers.DockerProvider, buildLog []byte) (string, error) {
+	id := lastBuildImageID(buildLog)
+	if id == "" {
+		return "", nil
+	}
+
+	tag, err := newDebugImageTag()
+	if err != nil {
+		return "", err
+	}
+
+	cli, closeClient, err := dockerClient(ctx, provider)
+	if err != nil {
+		return "", err
+	}
+	defer closeClient()
+
+	if err := cli.ImageTag(ctx, id, tag); err != nil {
+		return "", fmt.Errorf("failed to tag image %s: %w", id, err)
+	}
+	return tag, nil
+}
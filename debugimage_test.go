@@ -0,0 +1,89 @@
+package dockertesting
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLastBuildImageID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		log      string
+		expected string
+	}{
+		{
+			name:     "empty log",
+			log:      "",
+			expected: "",
+		},
+		{
+			name: "failure in later step",
+			log: `Step 1/4 : FROM golang:1.25
+ ---> 1a2b3c4d5e6f
+Step 2/4 : WORKDIR /app
+ ---> Running in 0f0f0f0f0f0f
+ ---> 7a8b9c0d1e2f
+Step 3/4 : COPY . .
+ ---> Using cache
+ ---> aabbccddeeff
+Step 4/4 : RUN go mod download
+ ---> Running in 123456789abc
+go: example.com/missing: not found
+`,
+			expected: "aabbccddeeff",
+		},
+		{
+			name: "failure in first step",
+			log: `Step 1/4 : FROM golang:does-not-exist
+manifest unknown
+`,
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := lastBuildImageID([]byte(tt.log)); got != tt.expected {
+				t.Errorf("expected image ID %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewDebugImageTag(t *testing.T) {
+	t.Parallel()
+
+	tag, err := newDebugImageTag()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(tag, DebugImagePrefix) {
+		t.Errorf("expected tag to start with %q, got %q", DebugImagePrefix, tag)
+	}
+
+	other, err := newDebugImageTag()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tag == other {
+		t.Errorf("expected unique tags, got %q twice", tag)
+	}
+}
+
+func TestBuildError_DebugImage(t *testing.T) {
+	t.Parallel()
+
+	err := &BuildError{Err: errors.New("build failed"), DebugImage: "dockertesting-debug-abc"}
+	if !strings.Contains(err.Error(), "dockertesting-debug-abc") {
+		t.Errorf("expected error to mention debug image, got %q", err.Error())
+	}
+
+	err = &BuildError{Err: errors.New("build failed")}
+	if strings.Contains(err.Error(), "debug image") {
+		t.Errorf("expected no debug image in error, got %q", err.Error())
+	}
+}
@@ -0,0 +1,136 @@
+package dockertesting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// diagnosticOutputLines is the number of output lines kept in a
+// DiagnosticBundle.
+const diagnosticOutputLines = 200
+
+// DiagnosticBundle describes a failed run whose resources were kept by
+// WithKeepOnFailure, with what is needed to investigate it.
+type DiagnosticBundle struct {
+	// Name is the name of the run, see WithName.
+	Name string
+
+	// ContainerID is the ID of the kept test container.
+	ContainerID string
+
+	// ContainerName is the name of the kept test container.
+	ContainerName string
+
+	// NetworkName is the name of the kept network.
+	NetworkName string
+
+	// SharedNetwork reports that the network was passed with WithNetwork, so
+	// it is left to its creator instead of being removed by Commands.
+	SharedNetwork bool
+
+	// SidecarIDs are the IDs of the kept sidecar containers.
+	SidecarIDs []string
+
+	// Inspect is the output of `docker inspect` for the test container.
+	Inspect []byte
+
+	// Logs contains the logs of the test container's main process.
+	Logs []byte
+
+	// Env is the environment of the test container as KEY=value pairs.
+	Env []string
+
+	// Output contains the last lines of the output of the commands run in
+	// the test container, e.g. go test.
+	Output []string
+}
+
+// Commands returns the docker commands to investigate and finally remove the
+// kept resources.
+func (b *DiagnosticBundle) Commands() []string {
+	commands := []string{
+		"docker exec -it " + b.ContainerID + " sh",
+		"docker logs " + b.ContainerID,
+		"docker inspect " + b.ContainerID,
+		"docker rm -f " + strings.Join(append([]string{b.ContainerID}, b.SidecarIDs...), " "),
+	}
+	if b.NetworkName != "" && !b.SharedNetwork {
+		commands = append(commands, "docker network rm "+b.NetworkName)
+	}
+	return commands
+}
+
+// writeReport writes a summary of the bundle with the commands to investigate
+// the kept resources to w.
+func (b *DiagnosticBundle) writeReport(w io.Writer) {
+	run := "run"
+	if b.Name != "" {
+		run = "run " + b.Name
+	}
+	fmt.Fprintf(w, "\nTest container %s kept after the failed %s (WithKeepOnFailure):\n", b.ContainerName, run)
+	for _, command := range b.Commands() {
+		fmt.Fprintf(w, "  %s\n", command)
+	}
+	if len(b.Output) > 0 {
+		fmt.Fprintf(w, "Last %d lines of output:\n", len(b.Output))
+		for _, line := range b.Output {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+}
+
+// collectDiagnostics collects the DiagnosticBundle of a kept run.
+// Failures to collect a part leave it empty, as the bundle is best-effort.
+func collectDiagnostics(ctx context.Context, ctr *TestContainer, network *DockerNetwork, sidecars []*Sidecar, output []string) *DiagnosticBundle {
+	b := &DiagnosticBundle{
+		ContainerID: ctr.ctr.GetContainerID(),
+		Output:      output,
+	}
+	b.ContainerName = b.ContainerID
+	if network != nil {
+		b.NetworkName = network.Name
+	}
+	for _, sidecar := range sidecars {
+		b.SidecarIDs = append(b.SidecarIDs, sidecar.ctr.GetContainerID())
+	}
+
+	if info, err := ctr.ctr.Inspect(ctx); err == nil {
+		b.ContainerName = strings.TrimPrefix(info.Name, "/")
+		if info.Config != nil {
+			b.Env = info.Config.Env
+		}
+		// Non-fatal: the response always marshals
+		b.Inspect, _ = json.MarshalIndent(info, "", "  ")
+	}
+	// Non-fatal: logs are best-effort diagnostics
+	b.Logs, _ = ctr.Logs(ctx)
+	return b
+}
+
+// outputTail keeps the last lines written to it through a lineWriter.
+type outputTail struct {
+	mu    sync.Mutex
+	max   int
+	lines []string
+}
+
+// add records line, dropping the oldest line once max lines are kept.
+func (t *outputTail) add(line OutputLine) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.lines) == t.max {
+		t.lines = append(t.lines[:0], t.lines[1:]...)
+	}
+	t.lines = append(t.lines, line.Text)
+}
+
+// snapshot returns a copy of the kept lines.
+func (t *outputTail) snapshot() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.lines...)
+}
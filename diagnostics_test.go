@@ -0,0 +1,80 @@
+package dockertesting
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestOutputTail(t *testing.T) {
+	t.Parallel()
+	tail := &outputTail{max: 3}
+	for i := range 5 {
+		tail.add(OutputLine{Source: OutputSourceExec, Text: fmt.Sprintf("line %d", i)})
+	}
+
+	want := []string{"line 2", "line 3", "line 4"}
+	if got := tail.snapshot(); !slices.Equal(got, want) {
+		t.Errorf("expected lines %q, got %q", want, got)
+	}
+}
+
+func TestDiagnosticBundle_Commands(t *testing.T) {
+	t.Parallel()
+	b := &DiagnosticBundle{
+		ContainerID: "abc123",
+		NetworkName: "testnet",
+		SidecarIDs:  []string{"def456"},
+	}
+
+	want := []string{
+		"docker exec -it abc123 sh",
+		"docker logs abc123",
+		"docker inspect abc123",
+		"docker rm -f abc123 def456",
+		"docker network rm testnet",
+	}
+	if got := b.Commands(); !slices.Equal(got, want) {
+		t.Errorf("expected commands %q, got %q", want, got)
+	}
+
+	// A shared network is left to its creator
+	b.SharedNetwork = true
+	if got := b.Commands(); !slices.Equal(got, want[:4]) {
+		t.Errorf("expected commands %q, got %q", want[:4], got)
+	}
+
+	// Without a network there is nothing to remove
+	b.SharedNetwork = false
+	b.NetworkName = ""
+	if got := b.Commands(); !slices.Equal(got, want[:4]) {
+		t.Errorf("expected commands %q, got %q", want[:4], got)
+	}
+}
+
+func TestDiagnosticBundle_WriteReport(t *testing.T) {
+	t.Parallel()
+	b := &DiagnosticBundle{
+		ContainerID:   "abc123",
+		ContainerName: "dockertesting-mypackage",
+		Output:        []string{"--- FAIL: TestSomething"},
+	}
+
+	var buf bytes.Buffer
+	b.writeReport(&buf)
+	report := buf.String()
+	for _, want := range []string{"dockertesting-mypackage", "docker exec -it abc123 sh", "--- FAIL: TestSomething"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("expected report to contain %q, got %q", want, report)
+		}
+	}
+
+	buf.Reset()
+	b.Name = "payments-integration"
+	b.writeReport(&buf)
+	if !strings.Contains(buf.String(), "failed run payments-integration") {
+		t.Errorf("expected report to name the run, got %q", buf.String())
+	}
+}
@@ -0,0 +1,198 @@
+package dockertesting
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// SlowdownRatio is how many times longer than in the previous run a test
+	// must take to be reported as slower by CompareResults.
+	SlowdownRatio = 1.5
+
+	// SlowdownThreshold is how much longer than in the previous run a test
+	// must take to be reported as slower by CompareResults, so that the
+	// jitter of fast tests is not reported.
+	SlowdownThreshold = 100 * time.Millisecond
+)
+
+// TestOutcome is the final outcome of a test of a run.
+type TestOutcome struct {
+	// Package is the import path of the package of the test.
+	Package string `json:"package"`
+
+	// Test is the name of the test, e.g. "TestAdd/negative".
+	Test string `json:"test"`
+
+	// Action is the outcome: "pass", "fail" or "skip".
+	Action string `json:"action"`
+
+	// Elapsed is how long the test ran in seconds.
+	Elapsed float64 `json:"elapsed_seconds"`
+}
+
+// Duration returns how long the test ran.
+func (o TestOutcome) Duration() time.Duration {
+	return time.Duration(o.Elapsed * float64(time.Second))
+}
+
+// testOutcomes returns the final outcomes of the tests of events, sorted by
+// package and test.
+func testOutcomes(events []TestEvent) []TestOutcome {
+	type test struct{ pkg, name string }
+	outcomes := make(map[test]TestOutcome)
+	for _, event := range events {
+		switch event.Action {
+		case "pass", "fail", "skip":
+			if event.Test != "" {
+				outcomes[test{event.Package, event.Test}] = TestOutcome{
+					Package: event.Package,
+					Test:    event.Test,
+					Action:  event.Action,
+					Elapsed: event.Elapsed,
+				}
+			}
+		}
+	}
+	sorted := make([]TestOutcome, 0, len(outcomes))
+	for _, outcome := range outcomes {
+		sorted = append(sorted, outcome)
+	}
+	slices.SortFunc(sorted, func(a, b TestOutcome) int {
+		if c := strings.Compare(a.Package, b.Package); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Test, b.Test)
+	})
+	return sorted
+}
+
+// Slowdown is a test that took significantly longer than in the previous run.
+type Slowdown struct {
+	// Previous is the outcome of the test in the previous run.
+	Previous TestOutcome `json:"previous"`
+
+	// Current is the outcome of the test in the current run.
+	Current TestOutcome `json:"current"`
+}
+
+// Diff is the difference between the test outcomes of two runs, see
+// CompareResults. Each list is sorted by package and test.
+type Diff struct {
+	// NewlyFailing are the tests that failed in the current run, but not in
+	// the previous one, including new tests.
+	NewlyFailing []TestOutcome `json:"newly_failing,omitempty"`
+
+	// NewlyPassing are the tests that passed in the current run, but failed
+	// or were skipped in the previous one.
+	NewlyPassing []TestOutcome `json:"newly_passing,omitempty"`
+
+	// NewlySkipped are the tests that were skipped in the current run, but
+	// not in the previous one, including new tests.
+	NewlySkipped []TestOutcome `json:"newly_skipped,omitempty"`
+
+	// Slower are the tests that passed in both runs and took at least
+	// SlowdownRatio times and SlowdownThreshold longer in the current one.
+	Slower []Slowdown `json:"slower,omitempty"`
+}
+
+// Regressed reports whether tests newly fail, are newly skipped or got
+// slower.
+func (d Diff) Regressed() bool {
+	return len(d.NewlyFailing) > 0 || len(d.NewlySkipped) > 0 || len(d.Slower) > 0
+}
+
+// CompareResults compares the test outcomes of two runs, e.g. of the main
+// branch and a pull request, for regression dashboards. The outcomes are read
+// from the -json output of go test, see WithArgs; without it the Diff is
+// empty. A nil Result, e.g. of a first run without a baseline, has no
+// outcomes. To compare with a run of an earlier process, store its Summary
+// with Result.WriteJSON and compare with CompareSummaries.
+//
+// Example:
+//
+//	diff := dockertesting.CompareResults(previous, current)
+//	for _, outcome := range diff.NewlyFailing {
+//	    fmt.Printf("%s %s now fails\n", outcome.Package, outcome.Test)
+//	}
+func CompareResults(previous, current *Result) Diff {
+	return compareOutcomes(resultOutcomes(previous), resultOutcomes(current))
+}
+
+// resultOutcomes returns the sorted outcomes of r, or none if r is nil.
+func resultOutcomes(r *Result) []TestOutcome {
+	if r == nil {
+		return nil
+	}
+	return testOutcomes(parseTestEvents(r.Stdout))
+}
+
+// CompareSummaries compares the test outcomes of two runs like
+// CompareResults, from their summaries, e.g. read with ReadSummary. A nil
+// Summary has no outcomes.
+//
+// Example:
+//
+//	previous, err := dockertesting.ReadSummary(f)
+//	if err != nil {
+//	    return err
+//	}
+//	current := result.Summary()
+//	diff := dockertesting.CompareSummaries(previous, &current)
+func CompareSummaries(previous, current *Summary) Diff {
+	var before, after []TestOutcome
+	if previous != nil {
+		before = previous.Outcomes
+	}
+	if current != nil {
+		after = current.Outcomes
+	}
+	return compareOutcomes(before, after)
+}
+
+// compareOutcomes compares the sorted outcomes of two runs.
+func compareOutcomes(previous, current []TestOutcome) Diff {
+	type test struct{ pkg, name string }
+	before := make(map[test]TestOutcome, len(previous))
+	for _, outcome := range previous {
+		before[test{outcome.Package, outcome.Test}] = outcome
+	}
+
+	var diff Diff
+	for _, outcome := range current {
+		prev, ok := before[test{outcome.Package, outcome.Test}]
+		switch {
+		case outcome.Action == "fail" && (!ok || prev.Action != "fail"):
+			diff.NewlyFailing = append(diff.NewlyFailing, outcome)
+		case outcome.Action == "skip" && (!ok || prev.Action != "skip"):
+			diff.NewlySkipped = append(diff.NewlySkipped, outcome)
+		case outcome.Action == "pass" && ok && prev.Action != "pass":
+			diff.NewlyPassing = append(diff.NewlyPassing, outcome)
+		case outcome.Action == "pass" && ok && slower(prev.Duration(), outcome.Duration()):
+			diff.Slower = append(diff.Slower, Slowdown{Previous: prev, Current: outcome})
+		}
+	}
+	return diff
+}
+
+// slower reports whether current is significantly longer than previous.
+func slower(previous, current time.Duration) bool {
+	return current-previous >= SlowdownThreshold && float64(current) >= SlowdownRatio*float64(previous)
+}
+
+// ReadSummary reads a Summary written by Result.WriteJSON, e.g. of an earlier
+// run to compare with, see CompareSummaries.
+func ReadSummary(r io.Reader) (*Summary, error) {
+	var summary Summary
+	if err := json.NewDecoder(r).Decode(&summary); err != nil {
+		return nil, fmt.Errorf("failed to read summary: %w", err)
+	}
+	if summary.Version > SummaryVersion {
+		return nil, fmt.Errorf("failed to read summary: version %d is newer than %d", summary.Version, SummaryVersion)
+	}
+	return &summary, nil
+}
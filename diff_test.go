@@ -0,0 +1,132 @@
+package dockertesting
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// outcomesOutput returns -json output with an event per outcome.
+func outcomesOutput(outcomes ...string) []byte {
+	var b strings.Builder
+	for _, outcome := range outcomes {
+		b.WriteString(`{"Action":"run","Package":"example.com/pkg","Test":"x"}` + "\n")
+		b.WriteString(outcome + "\n")
+	}
+	return []byte(b.String())
+}
+
+func TestCompareResults(t *testing.T) {
+	t.Parallel()
+	previous := &Result{Stdout: outcomesOutput(
+		`{"Action":"pass","Package":"example.com/pkg","Test":"TestFixed","Elapsed":0.1}`,
+		`{"Action":"fail","Package":"example.com/pkg","Test":"TestFixed","Elapsed":0.1}`,
+		`{"Action":"pass","Package":"example.com/pkg","Test":"TestBroken","Elapsed":0.1}`,
+		`{"Action":"pass","Package":"example.com/pkg","Test":"TestDocker","Elapsed":0.1}`,
+		`{"Action":"pass","Package":"example.com/pkg","Test":"TestSlow","Elapsed":1}`,
+		`{"Action":"pass","Package":"example.com/pkg","Test":"TestJitter","Elapsed":0.01}`,
+		`{"Action":"fail","Package":"example.com/pkg","Test":"TestFlaky","Elapsed":0.1}`,
+	)}
+	current := &Result{Stdout: outcomesOutput(
+		`{"Action":"pass","Package":"example.com/pkg","Test":"TestFixed","Elapsed":0.1}`,
+		`{"Action":"fail","Package":"example.com/pkg","Test":"TestBroken","Elapsed":0.2}`,
+		`{"Action":"skip","Package":"example.com/pkg","Test":"TestDocker","Elapsed":0}`,
+		`{"Action":"pass","Package":"example.com/pkg","Test":"TestSlow","Elapsed":2}`,
+		`{"Action":"pass","Package":"example.com/pkg","Test":"TestJitter","Elapsed":0.05}`,
+		`{"Action":"fail","Package":"example.com/pkg","Test":"TestFlaky","Elapsed":0.1}`,
+		`{"Action":"fail","Package":"example.com/pkg","Test":"TestNew","Elapsed":0.1}`,
+	)}
+
+	diff := CompareResults(previous, current)
+	expected := Diff{
+		NewlyFailing: []TestOutcome{
+			{Package: "example.com/pkg", Test: "TestBroken", Action: "fail", Elapsed: 0.2},
+			{Package: "example.com/pkg", Test: "TestNew", Action: "fail", Elapsed: 0.1},
+		},
+		NewlyPassing: []TestOutcome{{Package: "example.com/pkg", Test: "TestFixed", Action: "pass", Elapsed: 0.1}},
+		NewlySkipped: []TestOutcome{{Package: "example.com/pkg", Test: "TestDocker", Action: "skip"}},
+		Slower: []Slowdown{{
+			Previous: TestOutcome{Package: "example.com/pkg", Test: "TestSlow", Action: "pass", Elapsed: 1},
+			Current:  TestOutcome{Package: "example.com/pkg", Test: "TestSlow", Action: "pass", Elapsed: 2},
+		}},
+	}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("expected %+v, got %+v", expected, diff)
+	}
+	if !diff.Regressed() {
+		t.Error("expected the diff to report a regression")
+	}
+	if CompareResults(current, current).Regressed() {
+		t.Error("expected no regression comparing a run with itself")
+	}
+}
+
+func TestCompareResults_Nil(t *testing.T) {
+	t.Parallel()
+	failing := &Result{Stdout: outcomesOutput(`{"Action":"fail","Package":"example.com/pkg","Test":"TestBroken","Elapsed":0.1}`)}
+	broken := TestOutcome{Package: "example.com/pkg", Test: "TestBroken", Action: "fail", Elapsed: 0.1}
+
+	tests := []struct {
+		name     string
+		previous *Result
+		current  *Result
+		expected Diff
+	}{
+		{name: "both nil"},
+		{name: "nil previous", current: failing, expected: Diff{NewlyFailing: []TestOutcome{broken}}},
+		{name: "nil current", previous: failing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if diff := CompareResults(tt.previous, tt.current); !reflect.DeepEqual(diff, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, diff)
+			}
+			var previous, current *Summary
+			if tt.previous != nil {
+				summary := tt.previous.Summary()
+				previous = &summary
+			}
+			if tt.current != nil {
+				summary := tt.current.Summary()
+				current = &summary
+			}
+			if diff := CompareSummaries(previous, current); !reflect.DeepEqual(diff, tt.expected) {
+				t.Errorf("expected summaries to compare as %+v, got %+v", tt.expected, diff)
+			}
+		})
+	}
+}
+
+func TestCompareSummaries_RoundTrip(t *testing.T) {
+	t.Parallel()
+	previous := &Result{Stdout: []byte(sampleJSONOutput)}
+	var buf bytes.Buffer
+	if err := previous.WriteJSON(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stored, err := ReadSummary(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stored.Outcomes) != 3 {
+		t.Fatalf("expected 3 stored outcomes, got %+v", stored.Outcomes)
+	}
+
+	current := (&Result{Stdout: []byte(strings.ReplaceAll(sampleJSONOutput, `"fail"`, `"pass"`))}).Summary()
+	diff := CompareSummaries(stored, &current)
+	if len(diff.NewlyPassing) != 2 || diff.Regressed() {
+		t.Errorf("expected TestDiv and its subtest to newly pass, got %+v", diff)
+	}
+}
+
+func TestReadSummary_Errors(t *testing.T) {
+	t.Parallel()
+	if _, err := ReadSummary(strings.NewReader("not json")); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+	if _, err := ReadSummary(strings.NewReader(`{"version": 99}`)); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("expected error for a newer version, got %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package dockertesting
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// ImageFlavor is the variant of the golang base image of the embedded
+// Dockerfile template, see WithImageFlavor.
+type ImageFlavor string
+
+const (
+	// ImageFlavorDefault uses the default variant of the golang image, which
+	// is based on the current Debian release.
+	ImageFlavorDefault ImageFlavor = ""
+
+	// ImageFlavorAlpine uses the Alpine Linux variant with musl libc.
+	ImageFlavorAlpine ImageFlavor = "alpine"
+
+	// ImageFlavorBookworm uses the Debian bookworm variant with glibc.
+	ImageFlavorBookworm ImageFlavor = "bookworm"
+)
+
+// TestUser is the unprivileged user the embedded templates create and run
+// the tests as, unless WithRoot is set. Its UID is 1000.
+const TestUser = "tester"
+
+// DockerfileGenerator generates the Dockerfile of the test image, see
+// WithDockerfileGenerator. The build context, build args, caching and the
+// rest of the pipeline stay the same.
+type DockerfileGenerator interface {
+	// Generate returns the Dockerfile for options. options.BuildKit reports
+	// whether the image is built with BuildKit, which is false if BuildKit
+	// was requested but is not available.
+	Generate(options Options) ([]byte, error)
+}
+
+// TemplateGenerator is the default DockerfileGenerator. It renders the
+// embedded Dockerfile template, or the template of WithTemplate, with the
+// data of WithTemplateData, WithImageFlavor and WithGoTools.
+type TemplateGenerator struct{}
+
+// Generate renders the Dockerfile template for options.
+func (TemplateGenerator) Generate(options Options) ([]byte, error) {
+	source := dockerfileTemplate
+	if options.BuildKit {
+		source = buildKitDockerfileTemplate
+	}
+	if options.Template != "" {
+		source = options.Template
+	}
+	dockerfile, err := renderDockerfile(source, templateParams{
+		buildKit:  options.BuildKit,
+		flavor:    options.ImageFlavor,
+		goTools:   options.GoTools,
+		tlsBundle: options.TLSBundle != "",
+		user:      templateUser(options.Root),
+		data:      options.TemplateData,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []byte(dockerfile), nil
+}
+
+// templateFuncs are the functions available to Dockerfile templates.
+var templateFuncs = template.FuncMap{
+	// quote quotes a value for ENV and LABEL instructions
+	"quote": func(value any) string {
+		return strconv.Quote(fmt.Sprint(value))
+	},
+	"join": strings.Join,
+}
+
+// templateParams are the parameters a Dockerfile template is rendered with.
+type templateParams struct {
+	// buildKit reports whether the image is built with BuildKit.
+	buildKit bool
+
+	// flavor is the variant of the golang base image, see WithImageFlavor.
+	flavor ImageFlavor
+
+	// goTools are the tools to go install, see WithGoTools.
+	goTools []string
+
+	// tlsBundle reports whether the build context holds CA certificates to
+	// trust, see WithTLSBundle.
+	tlsBundle bool
+
+	// user is the user the tests run as, empty for root, see WithRoot.
+	user string
+
+	// data is the data of WithTemplateData.
+	data map[string]any
+}
+
+// templateUser returns the user the embedded templates run the tests as.
+func templateUser(root bool) string {
+	if root {
+		return ""
+	}
+	return TestUser
+}
+
+// templateData returns the data a Dockerfile template is executed with: the
+// keys the embedded templates use, overridden by data, see WithTemplateData.
+func templateData(params templateParams) map[string]any {
+	merged := map[string]any{
+		"BuildKit":  params.buildKit,
+		"Flavor":    params.flavor,
+		"GoTools":   params.goTools,
+		"TLSBundle": params.tlsBundle,
+		"User":      params.user,
+		"Packages":  []string(nil),
+		"Env":       map[string]string(nil),
+		"PreRun":    []string(nil),
+		"PostRun":   []string(nil),
+	}
+	maps.Copy(merged, params.data)
+	return merged
+}
+
+// parseDockerfileTemplate parses the Dockerfile template source. Executing it
+// fails on keys missing from the data, so that typos do not go unnoticed.
+func parseDockerfileTemplate(source string) (*template.Template, error) {
+	tmpl, err := template.New("Dockerfile").Option("missingkey=error").Funcs(templateFuncs).Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Dockerfile template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// renderDockerfile executes the Dockerfile template source with params, see
+// templateData.
+func renderDockerfile(source string, params templateParams) (string, error) {
+	tmpl, err := parseDockerfileTemplate(source)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, templateData(params)); err != nil {
+		return "", fmt.Errorf("failed to render Dockerfile template: %w", err)
+	}
+	return b.String(), nil
+}
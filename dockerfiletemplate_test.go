@@ -0,0 +1,172 @@
+package dockertesting
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderDockerfile_Defaults(t *testing.T) {
+	t.Parallel()
+	for name, source := range map[string]string{"classic": dockerfileTemplate, "buildkit": buildKitDockerfileTemplate} {
+		got, err := renderDockerfile(source, templateParams{buildKit: name == "buildkit"})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if strings.Contains(got, "{{") || strings.Contains(got, "ENV ") {
+			t.Errorf("%s: expected no template actions or ENV instructions, got:\n%s", name, got)
+		}
+		if !strings.Contains(got, "WORKDIR /app\n\n# Copy the entire package") {
+			t.Errorf("%s: expected the layout of the template to be kept, got:\n%s", name, got)
+		}
+	}
+}
+
+func TestRenderDockerfile_TemplateData(t *testing.T) {
+	t.Parallel()
+	got, err := renderDockerfile(dockerfileTemplate, templateParams{data: map[string]any{
+		"Env":     map[string]string{"GOFLAGS": "-tags=integration", "CGO_ENABLED": "1"},
+		"PreRun":  []string{"apt-get update"},
+		"PostRun": []string{"go build ./..."},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		"WORKDIR /app\nENV CGO_ENABLED=\"1\"\nENV GOFLAGS=\"-tags=integration\"\nRUN apt-get update\n\n# Copy",
+		"go mod download; fi\nRUN go build ./...\n\n# Keep container alive",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected Dockerfile to contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestRenderDockerfile_Custom(t *testing.T) {
+	t.Parallel()
+	source := "FROM golang:{{.Version}}\n{{if .BuildKit}}# buildkit\n{{end}}"
+
+	got, err := renderDockerfile(source, templateParams{buildKit: true, data: map[string]any{"Version": "1.24"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "FROM golang:1.24\n# buildkit\n" {
+		t.Errorf("unexpected Dockerfile:\n%s", got)
+	}
+
+	if _, err := renderDockerfile(source, templateParams{}); err == nil || !strings.Contains(err.Error(), "Version") {
+		t.Errorf("expected error for the missing key, got %v", err)
+	}
+	if _, err := renderDockerfile("FROM {{.Broken", templateParams{}); err == nil {
+		t.Error("expected error for an invalid template")
+	}
+}
+
+func TestRenderDockerfile_ImageFlavor(t *testing.T) {
+	t.Parallel()
+	packages := map[string]any{"Packages": []string{"gcc", "musl-dev"}}
+	for _, tt := range []struct {
+		flavor ImageFlavor
+		want   []string
+	}{
+		{ImageFlavorDefault, []string{"FROM golang:${GO_VERSION}\n", "RUN apt-get update && apt-get install -y --no-install-recommends gcc musl-dev"}},
+		{ImageFlavorBookworm, []string{"FROM golang:${GO_VERSION}-bookworm\n", "apt-get install"}},
+		{ImageFlavorAlpine, []string{"FROM golang:${GO_VERSION}-alpine\n", "RUN apk add --no-cache gcc musl-dev\n"}},
+	} {
+		for name, source := range map[string]string{"classic": dockerfileTemplate, "buildkit": buildKitDockerfileTemplate} {
+			got, err := renderDockerfile(source, templateParams{buildKit: name == "buildkit", flavor: tt.flavor, data: packages})
+			if err != nil {
+				t.Fatalf("%s %q: unexpected error: %v", name, tt.flavor, err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("%s %q: expected Dockerfile to contain %q, got:\n%s", name, tt.flavor, want, got)
+				}
+			}
+		}
+	}
+}
+
+func TestRenderDockerfile_GoTools(t *testing.T) {
+	t.Parallel()
+	tools := []string{"golang.org/x/tools/cmd/stringer@latest", "github.com/golang-migrate/migrate/v4/cmd/migrate@v4.18.1"}
+	for name, source := range map[string]string{"classic": dockerfileTemplate, "buildkit": buildKitDockerfileTemplate} {
+		got, err := renderDockerfile(source, templateParams{buildKit: name == "buildkit", goTools: tools})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		copyAt := strings.Index(got, "COPY . .")
+		for _, tool := range tools {
+			at := strings.Index(got, "go install "+tool+"\n")
+			if at < 0 || at > copyAt {
+				t.Errorf("%s: expected %s to be installed before the package is copied, got:\n%s", name, tool, got)
+			}
+		}
+	}
+}
+
+func TestTemplateGenerator(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		name    string
+		options Options
+		want    string
+	}{
+		{"classic", Options{}, "RUN if [ -z \"$LAZY_MOD_DOWNLOAD\" ]"},
+		{"buildkit", Options{BuildKit: true}, "--mount=type=cache"},
+		{"tls bundle", Options{TLSBundle: "/certs"}, "COPY .dockertesting/certs/ /usr/local/share/ca-certificates/dockertesting/\nRUN update-ca-certificates\n"},
+		{"non-root", Options{}, "COPY --chown=tester:tester . .\nUSER tester\n"},
+		{"non-root alpine", Options{ImageFlavor: ImageFlavorAlpine}, "RUN adduser -D -u 1000 tester"},
+		{"non-root buildkit", Options{BuildKit: true}, "id=dockertesting-gomodcache-tester,uid=1000,gid=1000,target=/tmp/gomodcache"},
+		{"root", Options{Root: true}, "\nCOPY . .\n"},
+		{"buildkit tls bundle", Options{BuildKit: true, TLSBundle: "/certs"}, "RUN update-ca-certificates\n"},
+		{"template", Options{BuildKit: true, Template: "FROM golang:{{if .BuildKit}}1.24{{end}}\n"}, "FROM golang:1.24\n"},
+	} {
+		got, err := TemplateGenerator{}.Generate(tt.options)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if !strings.Contains(string(got), tt.want) {
+			t.Errorf("%s: expected Dockerfile to contain %q, got:\n%s", tt.name, tt.want, got)
+		}
+	}
+}
+
+// staticGenerator is a DockerfileGenerator recording the options it was
+// called with.
+type staticGenerator struct {
+	dockerfile string
+	options    *Options
+}
+
+func (g staticGenerator) Generate(options Options) ([]byte, error) {
+	*g.options = options
+	return []byte(g.dockerfile), nil
+}
+
+func TestGenerateDockerfile(t *testing.T) {
+	t.Parallel()
+	var options Options
+	cfg := CreateContainerConfig{
+		PackagePath:         "/path/to/package",
+		Vendored:            true,
+		GoTools:             []string{"golang.org/x/tools/cmd/stringer@latest"},
+		DockerfileGenerator: staticGenerator{dockerfile: "FROM golang:1.24\n", options: &options},
+	}
+
+	got, err := generateDockerfile(cfg, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "FROM golang:1.24\n" {
+		t.Errorf("expected the generated Dockerfile, got %q", got)
+	}
+	if options.PackagePath != "/path/to/package" || !options.BuildKit || options.Vendor != VendorEnabled || len(options.GoTools) != 1 {
+		t.Errorf("expected the generator to get the build options, got %+v", options)
+	}
+
+	cfg.DockerfileGenerator = staticGenerator{options: &options}
+	if _, err := generateDockerfile(cfg, false); err == nil || !strings.Contains(err.Error(), "empty Dockerfile") {
+		t.Errorf("expected error for an empty Dockerfile, got %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package dockertesting
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/client"
+	"github.com/testcontainers/testcontainers-go"
+	tclog "github.com/testcontainers/testcontainers-go/log"
+)
+
+// remoteCertPath is where the TLS client certificates of a remote Docker host
+// are copied inside the container.
+const remoteCertPath = "/etc/dockertesting/certs"
+
+// DockerHostConfig describes how to reach the Docker daemon, as configured by
+// the standard DOCKER_HOST, DOCKER_TLS_VERIFY and DOCKER_CERT_PATH variables.
+type DockerHostConfig struct {
+	// Host is the daemon address, e.g. "tcp://docker.example.com:2376".
+	// Empty means the local default socket.
+	Host string
+
+	// TLSVerify enables TLS with verification of the daemon certificate.
+	TLSVerify bool
+
+	// CertPath is the directory containing ca.pem, cert.pem and key.pem.
+	// Defaults to ~/.docker when TLSVerify is set.
+	CertPath string
+}
+
+// dockerHostConfigFromEnv reads the DockerHostConfig from the environment.
+func dockerHostConfigFromEnv() DockerHostConfig {
+	return DockerHostConfig{
+		Host:      os.Getenv("DOCKER_HOST"),
+		TLSVerify: os.Getenv("DOCKER_TLS_VERIFY") != "",
+		CertPath:  os.Getenv("DOCKER_CERT_PATH"),
+	}
+}
+
+// dockerHostConfigFor returns the DockerHostConfig of the environment, with the
+// daemon address taken from provider's client if provider is non-nil.
+func dockerHostConfigFor(provider *testcontainers.DockerProvider) DockerHostConfig {
+	cfg := dockerHostConfigFromEnv()
+	if provider != nil && provider.Client() != nil {
+		cfg.Host = provider.Client().DaemonHost()
+	}
+	return cfg
+}
+
+// dockerClient returns the client of provider, or a client configured from the
+// environment if provider is nil. The returned function releases the client if
+// it was created here.
+func dockerClient(ctx context.Context, provider *testcontainers.DockerProvider) (client.APIClient, func(), error) {
+	if provider != nil {
+		return provider.Client(), func() {}, nil
+	}
+	cli, err := testcontainers.NewDockerClientWithOpts(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create docker client: %w", err)
+	}
+	return cli, func() {
+		_ = cli.Close()
+	}, nil
+}
+
+// runProvider returns the provider to use for a run: options.Provider if set,
+// otherwise a provider sending its API calls through options.DockerClient.
+// It returns nil if neither is set, leaving the configuration to testcontainers.
+func runProvider(options *Options, logger tclog.Logger) (*testcontainers.DockerProvider, error) {
+	if options.Provider != nil || options.DockerClient == nil {
+		return options.Provider, nil
+	}
+
+	provider, err := newDockerProvider(logger)
+	if err != nil {
+		return nil, err
+	}
+	// Non-fatal: the client configured from the environment is replaced
+	_ = provider.Close()
+	provider.SetClient(options.DockerClient)
+	return provider, nil
+}
+
+// newDockerProvider creates a provider configured from the environment that
+// logs to logger, or to the testcontainers default logger if logger is nil.
+func newDockerProvider(logger tclog.Logger) (*testcontainers.DockerProvider, error) {
+	var providerOpts []testcontainers.DockerProviderOption
+	if logger != nil {
+		providerOpts = append(providerOpts, testcontainers.WithLogger(logger))
+	}
+	provider, err := testcontainers.NewDockerProvider(providerOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create docker provider: %w", err)
+	}
+	return provider, nil
+}
+
+// IsRemote reports whether the daemon is reached over the network rather than
+// through a local socket, in which case the socket cannot be mounted.
+func (c DockerHostConfig) IsRemote() bool {
+	scheme, _, _ := strings.Cut(c.Host, "://")
+	switch scheme {
+	case "tcp", "http", "https", "ssh":
+		return true
+	default:
+		return false
+	}
+}
+
+// remoteDockerAccess returns the env vars and files that give the container
+// access to a remote daemon over TCP, as the replacement for mounting the
+// Docker socket. It errors for daemons the container cannot reach directly.
+func remoteDockerAccess(cfg DockerHostConfig) (map[string]string, []testcontainers.ContainerFile, error) {
+	u, err := url.Parse(cfg.Host)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid DOCKER_HOST %q: %w", cfg.Host, err)
+	}
+	if u.Scheme == "ssh" {
+		return nil, nil, fmt.Errorf("WithVarSock does not support ssh:// DOCKER_HOST %q, use tcp:// instead", cfg.Host)
+	}
+	if host := u.Hostname(); host == "localhost" || net.ParseIP(host).IsLoopback() {
+		return nil, nil, fmt.Errorf("WithVarSock cannot use DOCKER_HOST %q: it points to the loopback interface, which is not reachable from inside the container", cfg.Host)
+	}
+
+	env := map[string]string{"DOCKER_HOST": cfg.Host}
+	if !cfg.TLSVerify {
+		return env, nil, nil
+	}
+
+	certPath := cfg.CertPath
+	if certPath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to determine DOCKER_CERT_PATH: %w", err)
+		}
+		certPath = filepath.Join(home, ".docker")
+	}
+
+	var files []testcontainers.ContainerFile
+	for _, name := range []string{"ca.pem", "cert.pem", "key.pem"} {
+		hostPath := filepath.Join(certPath, name)
+		if _, err := os.Stat(hostPath); err != nil {
+			return nil, nil, fmt.Errorf("missing TLS certificate for DOCKER_HOST: %w", err)
+		}
+		files = append(files, testcontainers.ContainerFile{
+			HostFilePath:      hostPath,
+			ContainerFilePath: path.Join(remoteCertPath, name),
+			FileMode:          0600,
+		})
+	}
+	env["DOCKER_TLS_VERIFY"] = "1"
+	env["DOCKER_CERT_PATH"] = remoteCertPath
+	return env, files, nil
+}
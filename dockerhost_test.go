@@ -0,0 +1,177 @@
+package dockertesting
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/client"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestDockerHostConfig_IsRemote(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		host     string
+		expected bool
+	}{
+		{host: "", expected: false},
+		{host: "unix:///var/run/docker.sock", expected: false},
+		{host: "npipe:////./pipe/docker_engine", expected: false},
+		{host: "tcp://docker.example.com:2376", expected: true},
+		{host: "ssh://user@docker.example.com", expected: true},
+	}
+
+	for _, tt := range tests {
+		if got := (DockerHostConfig{Host: tt.host}).IsRemote(); got != tt.expected {
+			t.Errorf("IsRemote(%q): expected %v, got %v", tt.host, tt.expected, got)
+		}
+	}
+}
+
+func TestRemoteDockerAccess_PlainTCP(t *testing.T) {
+	t.Parallel()
+
+	env, files, err := remoteDockerAccess(DockerHostConfig{Host: "tcp://10.0.0.5:2375"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env["DOCKER_HOST"] != "tcp://10.0.0.5:2375" {
+		t.Errorf("expected DOCKER_HOST 'tcp://10.0.0.5:2375', got %q", env["DOCKER_HOST"])
+	}
+	if _, ok := env["DOCKER_TLS_VERIFY"]; ok {
+		t.Error("expected DOCKER_TLS_VERIFY to be unset")
+	}
+	if len(files) != 0 {
+		t.Errorf("expected no files, got %d", len(files))
+	}
+}
+
+func TestRemoteDockerAccess_TLS(t *testing.T) {
+	t.Parallel()
+	certPath := t.TempDir()
+	for _, name := range []string{"ca.pem", "cert.pem", "key.pem"} {
+		if err := os.WriteFile(filepath.Join(certPath, name), []byte(name), 0600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	env, files, err := remoteDockerAccess(DockerHostConfig{
+		Host:      "tcp://docker.example.com:2376",
+		TLSVerify: true,
+		CertPath:  certPath,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env["DOCKER_TLS_VERIFY"] != "1" {
+		t.Errorf("expected DOCKER_TLS_VERIFY '1', got %q", env["DOCKER_TLS_VERIFY"])
+	}
+	if env["DOCKER_CERT_PATH"] != remoteCertPath {
+		t.Errorf("expected DOCKER_CERT_PATH %q, got %q", remoteCertPath, env["DOCKER_CERT_PATH"])
+	}
+	if len(files) != 3 {
+		t.Fatalf("expected 3 files, got %d", len(files))
+	}
+	if files[0].ContainerFilePath != remoteCertPath+"/ca.pem" {
+		t.Errorf("expected ca.pem at %q, got %q", remoteCertPath+"/ca.pem", files[0].ContainerFilePath)
+	}
+}
+
+func TestRemoteDockerAccess_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		cfg    DockerHostConfig
+		errMsg string
+	}{
+		{
+			name:   "ssh",
+			cfg:    DockerHostConfig{Host: "ssh://user@docker.example.com"},
+			errMsg: "does not support ssh://",
+		},
+		{
+			name:   "localhost",
+			cfg:    DockerHostConfig{Host: "tcp://localhost:2375"},
+			errMsg: "loopback",
+		},
+		{
+			name:   "loopback ip",
+			cfg:    DockerHostConfig{Host: "tcp://127.0.0.1:2375"},
+			errMsg: "loopback",
+		},
+		{
+			name:   "missing certificates",
+			cfg:    DockerHostConfig{Host: "tcp://docker.example.com:2376", TLSVerify: true, CertPath: "/nonexistent"},
+			errMsg: "missing TLS certificate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, _, err := remoteDockerAccess(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
+
+func TestDockerHostConfigFor_Provider(t *testing.T) {
+	t.Parallel()
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://docker.example.com:2376"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	provider := &testcontainers.DockerProvider{}
+	provider.SetClient(cli)
+
+	cfg := dockerHostConfigFor(provider)
+	if cfg.Host != "tcp://docker.example.com:2376" {
+		t.Errorf("expected host %q, got %q", "tcp://docker.example.com:2376", cfg.Host)
+	}
+	if !cfg.IsRemote() {
+		t.Error("expected the provider's daemon to be remote")
+	}
+}
+
+func TestRunProvider_Injected(t *testing.T) {
+	t.Parallel()
+	provider := &testcontainers.DockerProvider{}
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://docker.example.com:2376"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	opts, err := NewOptions("/path/to/package", WithDockerProvider(provider), WithDockerClient(cli))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := runProvider(opts, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != provider {
+		t.Error("expected the injected provider to take precedence over the client")
+	}
+}
+
+func TestRunProvider_Default(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := runProvider(opts, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != nil {
+		t.Error("expected no provider when nothing is injected")
+	}
+}
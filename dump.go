@@ -0,0 +1,73 @@
+package dockertesting
+
+import (
+	"bytes"
+	"context"
+	"time"
+)
+
+// DefaultTestOutputFile is the path inside the container where the go test
+// output is logged, so it survives when the exec is abandoned on timeout.
+const DefaultTestOutputFile = "/tmp/gotest.log"
+
+// goroutineDumpTimeout bounds how long capturing a goroutine dump may take
+// after the run context has already expired.
+const goroutineDumpTimeout = 30 * time.Second
+
+// withOutputLog wraps cmd so that its combined output is also written to logFile
+// while preserving its exit code. The exit code is written to logFile + ".exit"
+// once the command has finished.
+func withOutputLog(cmd []string, logFile string) []string {
+	// $0 is the log file and "$@" the wrapped command
+	script := `rm -f "$0.exit"; { "$@" 2>&1; echo $? > "$0.exit"; } | tee "$0"; exit "$(cat "$0.exit")"`
+	return append([]string{"sh", "-c", script, logFile}, cmd...)
+}
+
+// quitTestProcessesScript sends SIGQUIT to all running test binaries, which makes
+// them print a goroutine dump and exit, then waits for the wrapped go test
+// command to finish writing its output.
+const quitTestProcessesScript = `
+for p in /proc/[0-9]*; do
+	if tr '\0' ' ' < "$p/cmdline" 2>/dev/null | grep -q -- ' -test\.'; then
+		kill -QUIT "${p#/proc/}" 2>/dev/null
+	fi
+done
+i=0
+while [ ! -f "$0.exit" ] && [ $i -lt 50 ]; do
+	sleep 0.2
+	i=$((i+1))
+done
+`
+
+// dumpGoroutines sends SIGQUIT to the test binaries running in the container
+// and returns the resulting goroutine dump read from the go test output log.
+// It uses its own context, as it is meant to run after ctx has expired.
+// It returns nil if no dump was produced.
+func (c *TestContainer) dumpGoroutines(ctx context.Context) []byte {
+	if c.ctr == nil {
+		return nil
+	}
+
+	dumpCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), goroutineDumpTimeout)
+	defer cancel()
+
+	if _, err := c.ExecCommand(dumpCtx, []string{"sh", "-c", quitTestProcessesScript, DefaultTestOutputFile}, ExecOptions{}); err != nil {
+		return nil
+	}
+
+	output, err := c.CopyFileFromContainer(dumpCtx, DefaultTestOutputFile)
+	if err != nil {
+		return nil
+	}
+	return extractGoroutineDump(c.redact(output))
+}
+
+// extractGoroutineDump returns the part of the go test output starting at the
+// SIGQUIT goroutine dump, or nil if the output contains no dump.
+func extractGoroutineDump(output []byte) []byte {
+	i := bytes.Index(output, []byte("SIGQUIT: quit"))
+	if i < 0 {
+		return nil
+	}
+	return output[i:]
+}
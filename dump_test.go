@@ -0,0 +1,66 @@
+package dockertesting
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWithOutputLog_PreservesOutputAndExitCode(t *testing.T) {
+	t.Parallel()
+	logFile := filepath.Join(t.TempDir(), "gotest.log")
+
+	cmd := withOutputLog([]string{"sh", "-c", "echo out; echo err >&2; exit 3"}, logFile)
+	output, err := exec.Command(cmd[0], cmd[1:]...).CombinedOutput()
+
+	var exitErr *exec.ExitError
+	if err == nil || !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Fatalf("expected exit code 3, got err=%v", err)
+	}
+	if !strings.Contains(string(output), "out") || !strings.Contains(string(output), "err") {
+		t.Errorf("expected combined output to be forwarded, got %q", output)
+	}
+
+	logged, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	if string(logged) != string(output) {
+		t.Errorf("expected log file to match output, got %q", logged)
+	}
+	if _, err := os.Stat(logFile + ".exit"); err != nil {
+		t.Errorf("expected exit marker file to be written: %v", err)
+	}
+}
+
+func TestExtractGoroutineDump(t *testing.T) {
+	t.Parallel()
+	output := "=== RUN   TestStuck\n" +
+		"SIGQUIT: quit\n" +
+		"PC=0x46e3c1 m=0 sigcode=0\n\n" +
+		"goroutine 6 [chan receive]:\n"
+
+	dump := extractGoroutineDump([]byte(output))
+	if !strings.HasPrefix(string(dump), "SIGQUIT: quit") {
+		t.Errorf("expected dump to start at SIGQUIT, got %q", dump)
+	}
+	if !strings.Contains(string(dump), "goroutine 6 [chan receive]") {
+		t.Errorf("expected dump to contain goroutine stacks, got %q", dump)
+	}
+
+	if extractGoroutineDump([]byte("ok  \texample.com/pkg\t0.01s\n")) != nil {
+		t.Error("expected nil dump for output without SIGQUIT")
+	}
+}
+
+func TestDumpGoroutines_NilContainer(t *testing.T) {
+	t.Parallel()
+	container := &TestContainer{ctr: nil}
+
+	if dump := container.dumpGoroutines(t.Context()); dump != nil {
+		t.Errorf("expected nil dump for nil container, got %q", dump)
+	}
+}
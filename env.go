@@ -0,0 +1,56 @@
+package dockertesting
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Environment variables overriding the defaults of the options, so that CI can
+// tweak runs without code changes. Options passed to NewOptions still take
+// precedence.
+const (
+	// EnvGoVersion sets the version of the golang base image, see WithGoVersion.
+	EnvGoVersion = "DOCKERTESTING_GO_VERSION"
+
+	// EnvTimeout sets the maximum duration of the run as a Go duration, e.g.
+	// "15m", see WithTimeout.
+	EnvTimeout = "DOCKERTESTING_TIMEOUT"
+
+	// EnvSockPath sets the path of the Docker socket on the host, see
+	// WithSockPath.
+	EnvSockPath = "DOCKERTESTING_SOCK_PATH"
+
+	// EnvQuiet selects VerbosityQuiet when set to a true value such as "1" or
+	// "true", see WithVerbosity.
+	EnvQuiet = "DOCKERTESTING_QUIET"
+)
+
+// applyEnv applies the environment overrides to o. Unset and empty variables
+// are ignored; invalid values are returned as an error naming the variable.
+func applyEnv(o *Options) error {
+	if version := os.Getenv(EnvGoVersion); version != "" {
+		o.GoVersion = version
+	}
+	if value := os.Getenv(EnvTimeout); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvTimeout, err)
+		}
+		o.Timeout = timeout
+	}
+	if path := os.Getenv(EnvSockPath); path != "" {
+		o.SockPath = path
+	}
+	if value := os.Getenv(EnvQuiet); value != "" {
+		quiet, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvQuiet, err)
+		}
+		if quiet {
+			o.Verbosity = VerbosityQuiet
+		}
+	}
+	return nil
+}
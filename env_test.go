@@ -0,0 +1,69 @@
+package dockertesting
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewOptions_EnvOverrides(t *testing.T) {
+	t.Setenv(EnvGoVersion, "1.23")
+	t.Setenv(EnvTimeout, "15m")
+	t.Setenv(EnvSockPath, "/run/user/1000/docker.sock")
+	t.Setenv(EnvQuiet, "1")
+
+	opts, err := NewOptions("/path/to/package")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.GoVersion != "1.23" {
+		t.Errorf("expected go version %q, got %q", "1.23", opts.GoVersion)
+	}
+	if opts.Timeout != 15*time.Minute {
+		t.Errorf("expected timeout %v, got %v", 15*time.Minute, opts.Timeout)
+	}
+	if opts.SockPath != "/run/user/1000/docker.sock" {
+		t.Errorf("expected sock path %q, got %q", "/run/user/1000/docker.sock", opts.SockPath)
+	}
+	if opts.Verbosity != VerbosityQuiet {
+		t.Errorf("expected verbosity %v, got %v", VerbosityQuiet, opts.Verbosity)
+	}
+}
+
+func TestNewOptions_EnvOverridesBeforeOptions(t *testing.T) {
+	t.Setenv(EnvGoVersion, "1.23")
+	t.Setenv(EnvTimeout, "15m")
+	t.Setenv(EnvQuiet, "false")
+
+	opts, err := NewOptions("/path/to/package", WithGoVersion("1.24"), WithTimeout(time.Minute))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.GoVersion != "1.24" {
+		t.Errorf("expected go version %q, got %q", "1.24", opts.GoVersion)
+	}
+	if opts.Timeout != time.Minute {
+		t.Errorf("expected timeout %v, got %v", time.Minute, opts.Timeout)
+	}
+	if opts.Verbosity != DefaultVerbosity {
+		t.Errorf("expected verbosity %v, got %v", DefaultVerbosity, opts.Verbosity)
+	}
+}
+
+func TestNewOptions_InvalidEnv(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value string
+	}{
+		{"timeout", EnvTimeout, "soon"},
+		{"quiet", EnvQuiet, "maybe"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.key, tt.value)
+			if _, err := NewOptions("/path/to/package"); err == nil {
+				t.Errorf("expected error for %s=%q", tt.key, tt.value)
+			}
+		})
+	}
+}
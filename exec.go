@@ -2,10 +2,12 @@ package dockertesting
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"time"
 
+	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/exec"
 )
 
@@ -25,6 +27,16 @@ type ExecConfig struct {
 
 	// Timeout is the maximum duration for test execution.
 	Timeout time.Duration
+
+	// WorkingDir is the directory to run go test in (optional).
+	// Defaults to the working directory of the container.
+	WorkingDir string
+
+	// Output receives the test output as it is produced (optional).
+	Output io.Writer
+
+	// Env are additional environment variables for go test in KEY=value form.
+	Env []string
 }
 
 // ExecResult holds the result of test execution.
@@ -37,11 +49,36 @@ type ExecResult struct {
 	ExitCode int
 }
 
+// ExecOptions configures a command executed with ExecCommand.
+type ExecOptions struct {
+	// Output receives the combined stdout/stderr of the command as it is read.
+	// If nil, the output is only captured in the returned ExecResult.
+	Output io.Writer
+
+	// Env are additional environment variables in KEY=value form.
+	Env []string
+
+	// WorkingDir overrides the working directory of the command.
+	// If empty, the container's working directory is used.
+	WorkingDir string
+
+	// User overrides the user the command runs as.
+	// If empty, the container's default user is used.
+	User string
+
+	// Timeout is the maximum duration for the command. Zero means no timeout
+	// beyond the one carried by the context.
+	Timeout time.Duration
+}
+
 // ExecTest runs `go test` inside the container and returns the result.
 // The command includes coverage profiling to /tmp/coverage.txt by default.
 //
 // The method captures stdout/stderr and returns them along with the exit code.
 // A non-zero exit code typically indicates test failures.
+//
+// If the timeout fires, the test binaries are sent SIGQUIT and a *TimeoutError
+// carrying their goroutine dump is returned.
 func (c *TestContainer) ExecTest(ctx context.Context, cfg ExecConfig) (*ExecResult, error) {
 	if c.ctr == nil {
 		return nil, fmt.Errorf("container is nil")
@@ -66,28 +103,111 @@ func (c *TestContainer) ExecTest(ctx context.Context, cfg ExecConfig) (*ExecResu
 	}
 	// Append additional arguments
 	cmd = append(cmd, cfg.Args...)
+	return c.execTest(ctx, cfg, cmd)
+}
+
+// execTest runs the go test command cmd like ExecTest, with the timeout,
+// environment, working directory and output of cfg.
+func (c *TestContainer) execTest(ctx context.Context, cfg ExecConfig, cmd []string) (*ExecResult, error) {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultExecTimeout
+	}
 
 	// Create a context with timeout
 	execCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
 	defer cancel()
 
-	// Execute the command in the container
+	// Log the output inside the container so a goroutine dump can be read on timeout
+	result, err := c.ExecCommand(execCtx, withOutputLog(cmd, DefaultTestOutputFile), ExecOptions{
+		Output:     cfg.Output,
+		Env:        cfg.Env,
+		WorkingDir: cfg.WorkingDir,
+	})
+	if err != nil {
+		// Check if this is a context timeout error
+		if errors.Is(execCtx.Err(), context.DeadlineExceeded) {
+			return nil, &TimeoutError{
+				Operation:     "execute tests",
+				Err:           fmt.Errorf("test execution timed out after %v: %w", cfg.Timeout, err),
+				GoroutineDump: c.dumpGoroutines(execCtx),
+			}
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+// ExecCommand runs an arbitrary command inside the container and returns the result.
+// This allows running commands such as go generate, migrations or debugging tools
+// without reaching into the underlying testcontainers API.
+//
+// Stdout and stderr are combined into a single stream. If opts.Output is set,
+// the output is forwarded to it while also being captured in the returned ExecResult.
+// A non-zero exit code is not treated as an error. The secrets of
+// WithSecretEnv are passed to the command and redacted from its output.
+//
+// Example:
+//
+//	result, err := container.ExecCommand(ctx, []string{"go", "generate", "./..."},
+//	    dockertesting.ExecOptions{Output: os.Stdout},
+//	)
+func (c *TestContainer) ExecCommand(ctx context.Context, cmd []string, opts ExecOptions) (*ExecResult, error) {
+	if len(c.secretEnv) > 0 {
+		return c.execSecretCommand(ctx, cmd, opts)
+	}
+	return execCommand(ctx, c.ctr, cmd, opts)
+}
+
+// commandExecutor runs commands inside a container.
+// It is implemented by TestContainer and Sidecar.
+type commandExecutor interface {
+	ExecCommand(ctx context.Context, cmd []string, opts ExecOptions) (*ExecResult, error)
+}
+
+// execCommand executes cmd inside ctr, see TestContainer.ExecCommand.
+func execCommand(ctx context.Context, ctr testcontainers.Container, cmd []string, opts ExecOptions) (*ExecResult, error) {
+	if ctr == nil {
+		return nil, fmt.Errorf("container is nil")
+	}
+
+	// Create a context with timeout if configured
+	execCtx := ctx
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		execCtx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
 	// Using Multiplexed() to combine stdout and stderr into a single stream
-	exitCode, reader, err := c.ctr.Exec(execCtx, cmd, exec.Multiplexed())
+	processOpts := []exec.ProcessOption{exec.Multiplexed()}
+	if len(opts.Env) > 0 {
+		processOpts = append(processOpts, exec.WithEnv(opts.Env))
+	}
+	if opts.WorkingDir != "" {
+		processOpts = append(processOpts, exec.WithWorkingDir(opts.WorkingDir))
+	}
+	if opts.User != "" {
+		processOpts = append(processOpts, exec.WithUser(opts.User))
+	}
+
+	exitCode, reader, err := ctr.Exec(execCtx, cmd, processOpts...)
 	if err != nil {
 		// Check if this is a context timeout error
-		if execCtx.Err() == context.DeadlineExceeded {
-			return nil, fmt.Errorf("test execution timed out after %v: %w", cfg.Timeout, err)
+		if opts.Timeout > 0 && execCtx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("execution timed out after %v: %w", opts.Timeout, err)
 		}
-		return nil, fmt.Errorf("failed to execute test command: %w", err)
+		return nil, fmt.Errorf("failed to execute command: %w", err)
 	}
 
-	// Read all output
+	// Read all output, forwarding it to opts.Output if set
 	var output []byte
 	if reader != nil {
+		if opts.Output != nil {
+			reader = io.TeeReader(reader, opts.Output)
+		}
 		output, err = io.ReadAll(reader)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read test output: %w", err)
+			return nil, fmt.Errorf("failed to read command output: %w", err)
 		}
 	}
 
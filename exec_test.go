@@ -7,6 +7,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/testcontainers/testcontainers-go"
 )
 
 func TestExecTest_SimplePackage(t *testing.T) {
@@ -221,3 +223,160 @@ func TestExecTest_DefaultValues(t *testing.T) {
 		t.Logf("output: %s", string(result.Stdout))
 	}
 }
+
+func TestExecCommand_NilContainer(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	container := &TestContainer{ctr: nil}
+
+	_, err := container.ExecCommand(ctx, []string{"echo", "hello"}, ExecOptions{})
+	if err == nil {
+		t.Fatal("expected error for nil container")
+	}
+
+	if !strings.Contains(err.Error(), "container is nil") {
+		t.Errorf("expected error about nil container, got: %v", err)
+	}
+}
+
+func TestExecCommand_EnvAndWorkingDir(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	// Create network
+	network, cleanup, err := CreateNetwork(ctx)
+	if err != nil {
+		t.Fatalf("failed to create network: %v", err)
+	}
+	defer func() { _ = cleanup(ctx) }()
+
+	// Create container with the testdata/simple package
+	cfg := CreateContainerConfig{
+		PackagePath: "testdata/simple",
+		Network:     network,
+		NetworkName: network.Name,
+	}
+
+	container, err := CreateContainer(ctx, cfg)
+	if err != nil {
+		t.Fatalf("failed to create container: %v", err)
+	}
+	defer func() {
+		if err := container.Terminate(ctx); err != nil {
+			t.Errorf("failed to terminate container: %v", err)
+		}
+	}()
+
+	// Execute a command with custom env and working directory, streaming the output
+	var streamed strings.Builder
+	result, err := container.ExecCommand(ctx, []string{"sh", "-c", "echo $GREETING && pwd"}, ExecOptions{
+		Output:     &streamed,
+		Env:        []string{"GREETING=hello"},
+		WorkingDir: "/tmp",
+		Timeout:    time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("failed to execute command: %v", err)
+	}
+
+	if result.ExitCode != 0 {
+		t.Errorf("expected exit code 0, got %d", result.ExitCode)
+	}
+
+	output := string(result.Stdout)
+	if !strings.Contains(output, "hello") || !strings.Contains(output, "/tmp") {
+		t.Errorf("expected output to contain env value and working dir, got: %s", output)
+	}
+	if streamed.String() != output {
+		t.Errorf("expected streamed output to match captured output, got %q", streamed.String())
+	}
+}
+
+func TestRunInContainer_ExistingContainer(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	// Create a container that plays the role of a user-managed one
+	container, err := CreateContainer(ctx, CreateContainerConfig{
+		PackagePath: "testdata/simple",
+	})
+	if err != nil {
+		t.Fatalf("failed to create container: %v", err)
+	}
+	defer func() {
+		if err := container.Terminate(ctx); err != nil {
+			t.Errorf("failed to terminate container: %v", err)
+		}
+	}()
+
+	result, err := RunInContainer(ctx, container.Container().GetContainerID(), ExecConfig{
+		Pattern: "./...",
+		Timeout: 5 * time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("RunInContainer() returned error: %v", err)
+	}
+
+	if result.ExitCode != 0 {
+		t.Errorf("expected exit code 0, got %d", result.ExitCode)
+		t.Logf("output: %s", string(result.Stdout))
+	}
+	if !strings.HasPrefix(string(result.Coverage), "mode:") {
+		t.Errorf("expected coverage profile, got: %q", result.Coverage)
+	}
+}
+
+func TestRunInContainer_DockerProvider(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	container, err := CreateContainer(ctx, CreateContainerConfig{
+		PackagePath: "testdata/simple",
+	})
+	if err != nil {
+		t.Fatalf("failed to create container: %v", err)
+	}
+	defer func() {
+		if err := container.Terminate(ctx); err != nil {
+			t.Errorf("failed to terminate container: %v", err)
+		}
+	}()
+
+	provider, err := testcontainers.NewDockerProvider()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	result, err := RunInContainer(ctx, container.Container().GetContainerID(), ExecConfig{
+		Pattern: "./...",
+		Timeout: 5 * time.Minute,
+	}, WithDockerProvider(provider))
+	if err != nil {
+		t.Fatalf("RunInContainer() returned error: %v", err)
+	}
+	if result.ExitCode != 0 {
+		t.Errorf("expected exit code 0, got %d", result.ExitCode)
+	}
+
+	// The injected provider is neither replaced nor closed
+	if _, err := RunInContainer(ctx, "dockertesting-does-not-exist", ExecConfig{}, WithDockerProvider(provider)); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+	if _, err := provider.Client().Ping(ctx); err != nil {
+		t.Errorf("expected the injected provider to stay open, got %v", err)
+	}
+}
+
+func TestRunInContainer_NotFound(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	_, err := RunInContainer(ctx, "dockertesting-does-not-exist", ExecConfig{})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
@@ -0,0 +1,277 @@
+package dockertesting
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// TestFailure is a failed test of a run, see Result.Failures.
+type TestFailure struct {
+	// Package is the import path of the package of the test. It is empty if
+	// the output does not name it, e.g. when go test was killed.
+	Package string `json:"package,omitempty"`
+
+	// Test is the name of the test, e.g. "TestAdd/negative".
+	Test string `json:"test"`
+
+	// Message is the output of the test, e.g. the messages of t.Error or a
+	// panic, without the lines go test frames it with.
+	Message string `json:"message,omitempty"`
+
+	// File is the path of the first location the test logged, relative to
+	// the module root if the package is in the module, otherwise as reported
+	// by go test. It is empty if the test logged no location.
+	File string `json:"file,omitempty"`
+
+	// Line is the line in File, or 0 if unknown.
+	Line int `json:"line,omitempty"`
+}
+
+// ParseFailures returns the failed tests in output, the plain or -json output
+// of go test, in the order they failed. modulePath is the path of the tested
+// module from its go.mod, which locates the files of the failures by the
+// import path of their package; with an empty modulePath, File is relative
+// to the package directory. Tests that only failed because one of their
+// subtests did are left out.
+//
+// Example:
+//
+//	for _, f := range dockertesting.ParseFailures(result.Stdout, "example.com/mymodule") {
+//	    fmt.Printf("%s %s (%s:%d): %s\n", f.Package, f.Test, f.File, f.Line, f.Message)
+//	}
+func ParseFailures(output []byte, modulePath string) []TestFailure {
+	return parseOutcomes(output, modulePath).failures
+}
+
+// SkippedTest is a skipped test of a run, see Result.Skipped.
+type SkippedTest struct {
+	// Package is the import path of the package of the test. It is empty if
+	// the output does not name it.
+	Package string `json:"package,omitempty"`
+
+	// Test is the name of the test, e.g. "TestDocker".
+	Test string `json:"test"`
+
+	// Reason is the message the test skipped with, e.g. the arguments of
+	// t.Skip, without its location.
+	Reason string `json:"reason,omitempty"`
+}
+
+// ParseSkipped returns the skipped tests in output, the plain or -json output
+// of go test, with the messages they skipped with. Plain output only reports
+// skipped tests with the -v flag.
+//
+// Example:
+//
+//	for _, s := range dockertesting.ParseSkipped(result.Stdout) {
+//	    fmt.Printf("%s skipped: %s\n", s.Test, s.Reason)
+//	}
+func ParseSkipped(output []byte) []SkippedTest {
+	return parseOutcomes(output, "").skipped
+}
+
+// parseOutcomes parses the failed and skipped tests in output.
+func parseOutcomes(output []byte, modulePath string) *failureParser {
+	p := &failureParser{modulePath: modulePath, output: make(map[failureKey][]string)}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		var event TestEvent
+		if strings.HasPrefix(line, "{") && json.Unmarshal([]byte(line), &event) == nil && event.Action != "" {
+			p.event(event)
+			continue
+		}
+		p.line(line)
+	}
+	p.flush("")
+	return p
+}
+
+// failureKey identifies a test across the packages of a -json stream, whose
+// events may interleave.
+type failureKey struct {
+	pkg, test string
+}
+
+// failureParser collects the failed and skipped tests of go test output line
+// by line. The output of plain go test only names the package after its
+// tests, so the outcomes of a package are kept until its result line.
+type failureParser struct {
+	modulePath string
+	failures   []TestFailure
+	skipped    []SkippedTest
+
+	// output is the output of the tests by package and test.
+	output map[failureKey][]string
+
+	// failed are the tests that failed, by package.
+	failed map[failureKey]bool
+
+	// test is the test the current line of plain output belongs to.
+	test string
+
+	// order are the tests of plain output in the order they failed.
+	order []string
+
+	// skipOrder are the tests of plain output in the order they skipped.
+	skipOrder []string
+}
+
+// event processes a go test -json event.
+func (p *failureParser) event(event TestEvent) {
+	key := failureKey{event.Package, event.Test}
+	switch {
+	case event.Test == "":
+		if event.Action == "pass" || event.Action == "fail" || event.Action == "skip" {
+			p.forget(event.Package)
+		}
+	case event.Action == "output":
+		line := strings.TrimSuffix(event.Output, "\n")
+		if !testStatePattern.MatchString(line) {
+			p.output[key] = append(p.output[key], line)
+		}
+	case event.Action == "fail":
+		p.markFailed(key)
+		p.add(key)
+	case event.Action == "skip":
+		p.addSkipped(key)
+	}
+}
+
+// line processes a line of plain output.
+func (p *failureParser) line(line string) {
+	if match := testStatePattern.FindStringSubmatch(line); match != nil {
+		p.test = match[2]
+		switch match[1] {
+		case "--- FAIL:":
+			p.order = append(p.order, p.test)
+		case "--- SKIP:":
+			p.skipOrder = append(p.skipOrder, p.test)
+		}
+		return
+	}
+	if match := packageResultPattern.FindStringSubmatch(line); match != nil {
+		p.flush(match[1])
+		return
+	}
+	if line == "FAIL" || line == "PASS" || strings.HasPrefix(line, "exit status ") {
+		return
+	}
+	if p.test != "" {
+		key := failureKey{test: p.test}
+		p.output[key] = append(p.output[key], line)
+	}
+}
+
+// flush adds the outcomes of plain output to those of pkg and starts a new
+// package.
+func (p *failureParser) flush(pkg string) {
+	for _, test := range slices.Concat(p.order, p.skipOrder) {
+		if lines, ok := p.output[failureKey{test: test}]; ok {
+			delete(p.output, failureKey{test: test})
+			p.output[failureKey{pkg, test}] = lines
+		}
+	}
+	// Parents fail before their subtests in plain output
+	for _, test := range p.order {
+		p.markFailed(failureKey{pkg, test})
+	}
+	for _, test := range p.order {
+		p.add(failureKey{pkg, test})
+	}
+	for _, test := range p.skipOrder {
+		p.addSkipped(failureKey{pkg, test})
+	}
+	p.forget(pkg)
+	p.forget("")
+	p.test, p.order, p.skipOrder = "", nil, nil
+}
+
+// markFailed records that the test key failed.
+func (p *failureParser) markFailed(key failureKey) {
+	if p.failed == nil {
+		p.failed = make(map[failureKey]bool)
+	}
+	p.failed[key] = true
+}
+
+// add adds the failure of the test key, unless it only failed because of a
+// subtest.
+func (p *failureParser) add(key failureKey) {
+	message := failureMessage(p.output[key])
+	if message == "" {
+		for failed := range p.failed {
+			if failed.pkg == key.pkg && strings.HasPrefix(failed.test, key.test+"/") {
+				return
+			}
+		}
+	}
+	failure := TestFailure{Package: key.pkg, Test: key.test, Message: message}
+	for _, line := range p.output[key] {
+		if match := testLogPattern.FindStringSubmatch(line); match != nil {
+			failure.File = match[2]
+			failure.Line, _ = strconv.Atoi(match[3])
+			if dir := packageDir(key.pkg, p.modulePath); dir != "" {
+				failure.File = path.Join(dir, failure.File)
+			}
+			break
+		}
+	}
+	p.failures = append(p.failures, failure)
+}
+
+// addSkipped adds the skipped test key with the messages it logged.
+func (p *failureParser) addSkipped(key failureKey) {
+	var reasons []string
+	for _, line := range p.output[key] {
+		if match := testLogPattern.FindStringSubmatch(line); match != nil {
+			reasons = append(reasons, match[4])
+		} else if trimmed := strings.TrimSpace(line); trimmed != "" && len(reasons) > 0 {
+			// Continuation of a multi-line message
+			reasons[len(reasons)-1] += "\n" + trimmed
+		}
+	}
+	p.skipped = append(p.skipped, SkippedTest{Package: key.pkg, Test: key.test, Reason: strings.Join(reasons, "\n")})
+}
+
+// forget drops the output and failed tests of pkg.
+func (p *failureParser) forget(pkg string) {
+	for key := range p.output {
+		if key.pkg == pkg {
+			delete(p.output, key)
+		}
+	}
+	for key := range p.failed {
+		if key.pkg == pkg {
+			delete(p.failed, key)
+		}
+	}
+}
+
+// failureMessage joins the output lines of a test, removing the indentation
+// they share.
+func failureMessage(lines []string) string {
+	indent := -1
+	for _, line := range lines {
+		if trimmed := strings.TrimLeft(line, " \t"); trimmed != "" {
+			if n := len(line) - len(trimmed); indent < 0 || n < indent {
+				indent = n
+			}
+		}
+	}
+	var b strings.Builder
+	for _, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			line = line[indent:]
+		}
+		b.WriteString(strings.TrimRight(line, " \t"))
+		b.WriteByte('\n')
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,98 @@
+package dockertesting
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFailures_PlainOutput(t *testing.T) {
+	t.Parallel()
+	output := `--- FAIL: TestDiv (0.00s)
+    --- FAIL: TestDiv/by_zero (0.00s)
+        div_test.go:12: expected error
+            got: <nil>
+--- PASS: TestAdd (0.00s)
+    add_test.go:5: logged by a passing test
+FAIL
+FAIL	example.com/mod/calc	0.002s
+=== RUN   TestRoot
+    root_test.go:7: root failure
+--- FAIL: TestRoot (0.00s)
+=== RUN   TestPanic
+--- FAIL: TestPanic (0.00s)
+panic: boom [recovered]
+FAIL
+exit status 2
+FAIL	example.com/mod	0.001s
+FAIL
+`
+
+	expected := []TestFailure{
+		{Package: "example.com/mod/calc", Test: "TestDiv/by_zero", File: "calc/div_test.go", Line: 12, Message: "div_test.go:12: expected error\n    got: <nil>"},
+		{Package: "example.com/mod", Test: "TestRoot", File: "root_test.go", Line: 7, Message: "root_test.go:7: root failure"},
+		{Package: "example.com/mod", Test: "TestPanic", Message: "panic: boom [recovered]"},
+	}
+	if failures := ParseFailures([]byte(output), "example.com/mod"); !reflect.DeepEqual(failures, expected) {
+		t.Errorf("expected %+v, got %+v", expected, failures)
+	}
+}
+
+func TestParseFailures_JSONOutput(t *testing.T) {
+	t.Parallel()
+	output := `{"Action":"run","Package":"example.com/mod/calc","Test":"TestDiv"}
+{"Action":"output","Package":"example.com/mod/calc","Test":"TestDiv","Output":"=== RUN   TestDiv\n"}
+{"Action":"output","Package":"example.com/mod/calc","Test":"TestDiv/by_zero","Output":"    div_test.go:12: expected error\n"}
+{"Action":"output","Package":"example.com/other","Test":"TestOther","Output":"    other_test.go:3: interleaved\n"}
+{"Action":"output","Package":"example.com/mod/calc","Test":"TestDiv/by_zero","Output":"    --- FAIL: TestDiv/by_zero (0.00s)\n"}
+{"Action":"fail","Package":"example.com/mod/calc","Test":"TestDiv/by_zero"}
+{"Action":"output","Package":"example.com/mod/calc","Test":"TestDiv","Output":"--- FAIL: TestDiv (0.00s)\n"}
+{"Action":"fail","Package":"example.com/mod/calc","Test":"TestDiv"}
+{"Action":"output","Package":"example.com/mod/calc","Test":"TestAdd","Output":"    add_test.go:5: logged\n"}
+{"Action":"pass","Package":"example.com/mod/calc","Test":"TestAdd"}
+{"Action":"fail","Package":"example.com/mod/calc"}
+{"Action":"fail","Package":"example.com/other","Test":"TestOther"}
+{"Action":"fail","Package":"example.com/other"}
+`
+
+	expected := []TestFailure{
+		{Package: "example.com/mod/calc", Test: "TestDiv/by_zero", File: "calc/div_test.go", Line: 12, Message: "div_test.go:12: expected error"},
+		{Package: "example.com/other", Test: "TestOther", File: "other_test.go", Line: 3, Message: "other_test.go:3: interleaved"},
+	}
+	if failures := ParseFailures([]byte(output), "example.com/mod"); !reflect.DeepEqual(failures, expected) {
+		t.Errorf("expected %+v, got %+v", expected, failures)
+	}
+}
+
+func TestParseFailures_Passed(t *testing.T) {
+	t.Parallel()
+	output := "--- PASS: TestAdd (0.00s)\nPASS\nok  \texample.com/mod\t0.001s\n"
+	if failures := ParseFailures([]byte(output), "example.com/mod"); failures != nil {
+		t.Errorf("expected no failures, got %+v", failures)
+	}
+}
+
+func TestParseSkipped(t *testing.T) {
+	t.Parallel()
+	plain := `=== RUN   TestDocker
+    docker_test.go:15: no Docker socket, use WithVarSock
+--- SKIP: TestDocker (0.00s)
+=== RUN   TestAdd
+--- PASS: TestAdd (0.00s)
+PASS
+ok  	example.com/mod	0.001s
+`
+	json := `{"Action":"run","Package":"example.com/mod","Test":"TestDocker"}
+{"Action":"output","Package":"example.com/mod","Test":"TestDocker","Output":"=== RUN   TestDocker\n"}
+{"Action":"output","Package":"example.com/mod","Test":"TestDocker","Output":"    docker_test.go:15: no Docker socket, use WithVarSock\n"}
+{"Action":"output","Package":"example.com/mod","Test":"TestDocker","Output":"--- SKIP: TestDocker (0.00s)\n"}
+{"Action":"skip","Package":"example.com/mod","Test":"TestDocker"}
+{"Action":"pass","Package":"example.com/mod"}
+`
+
+	expected := []SkippedTest{{Package: "example.com/mod", Test: "TestDocker", Reason: "no Docker socket, use WithVarSock"}}
+	for name, output := range map[string]string{"plain": plain, "json": json} {
+		if skipped := ParseSkipped([]byte(output)); !reflect.DeepEqual(skipped, expected) {
+			t.Errorf("%s: expected %+v, got %+v", name, expected, skipped)
+		}
+	}
+}
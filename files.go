@@ -0,0 +1,52 @@
+package dockertesting
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// writeFileAtomic writes data to path by writing to a temporary file in the
+// same directory and renaming it into place, so readers never observe a
+// partially written file. Parent directories are created as needed.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	return writeFileAtomicFrom(path, bytes.NewReader(data), perm)
+}
+
+// writeFileAtomicFrom is writeFileAtomic for content read from r, so large
+// files need not be held in memory.
+func writeFileAtomicFrom(path string, r io.Reader, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	// Remove the temporary file if anything fails before the rename
+	defer func() {
+		_ = os.Remove(tmpPath)
+	}()
+
+	_, writeErr := io.Copy(tmp, r)
+	closeErr := tmp.Close()
+	if writeErr != nil {
+		return fmt.Errorf("failed to write %s: %w", tmpPath, writeErr)
+	}
+	if closeErr != nil {
+		return fmt.Errorf("failed to close %s: %w", tmpPath, closeErr)
+	}
+
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return fmt.Errorf("failed to set permissions on %s: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %w", tmpPath, path, err)
+	}
+	return nil
+}
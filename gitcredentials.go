@@ -0,0 +1,165 @@
+package dockertesting
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// goPrivateEnv lists the module path patterns go fetches directly from
+	// version control, see WithGitCredentials.
+	goPrivateEnv = "GOPRIVATE"
+
+	// gitConfigSecretID is the id of the BuildKit secret carrying the git
+	// configuration of WithGitCredentials for go mod download.
+	gitConfigSecretID = "gitconfig"
+
+	// defaultGitUsername is the user name sent along with a token, which is
+	// accepted by GitHub and ignored by most other hosts.
+	defaultGitUsername = "x-access-token"
+)
+
+// GitCredentials authenticate git inside the test container against a host
+// serving private modules, see WithGitCredentials. Either Token or Helper
+// must be set.
+type GitCredentials struct {
+	// Host is the host of the repositories, e.g. "github.com".
+	Host string
+
+	// Token authenticates HTTPS requests to Host. SSH URLs of Host are
+	// rewritten to HTTPS, so modules fetched with SSH on the host work too.
+	Token string
+
+	// Username is sent along with Token (default: "x-access-token"). Some
+	// hosts require a specific one, e.g. "oauth2" for GitLab.
+	Username string
+
+	// Helper is a git credential helper for Host, run inside the container,
+	// e.g. "!f() { echo username=bot; echo password=$GIT_TOKEN; }; f".
+	Helper string
+
+	// Modules are the GOPRIVATE patterns of the private modules, e.g.
+	// "github.com/myorg/*" (default: Host).
+	Modules []string
+}
+
+// urlPrefix returns the prefix of the HTTPS URLs of the host.
+func (c GitCredentials) urlPrefix() string {
+	return "https://" + c.Host + "/"
+}
+
+// tokenURLPrefix returns the prefix of the HTTPS URLs of the host carrying
+// the token.
+func (c GitCredentials) tokenURLPrefix() string {
+	username := c.Username
+	if username == "" {
+		username = defaultGitUsername
+	}
+	u := url.URL{Scheme: "https", User: url.UserPassword(username, c.Token), Host: c.Host, Path: "/"}
+	return u.String()
+}
+
+// insteadOf returns the URL prefixes of the host that are rewritten to the
+// URL carrying the token.
+func (c GitCredentials) insteadOf() []string {
+	return []string{c.urlPrefix(), "git@" + c.Host + ":", "ssh://git@" + c.Host + "/"}
+}
+
+// goPrivate returns the GOPRIVATE value of creds.
+func goPrivate(creds []GitCredentials) string {
+	var patterns []string
+	for _, c := range creds {
+		modules := c.Modules
+		if len(modules) == 0 {
+			modules = []string{c.Host}
+		}
+		for _, module := range modules {
+			if !slices.Contains(patterns, module) {
+				patterns = append(patterns, module)
+			}
+		}
+	}
+	return strings.Join(patterns, ",")
+}
+
+// gitConfig returns creds as git configuration file, which the Dockerfile
+// templates mount as BuildKit secret for go mod download.
+func gitConfig(creds []GitCredentials) string {
+	var b strings.Builder
+	for _, c := range creds {
+		if c.Helper != "" {
+			fmt.Fprintf(&b, "[credential %s]\n\thelper = %s\n", quoteGitConfig(c.urlPrefix()), quoteGitConfig(c.Helper))
+		}
+		if c.Token != "" {
+			fmt.Fprintf(&b, "[url %s]\n", quoteGitConfig(c.tokenURLPrefix()))
+			for _, prefix := range c.insteadOf() {
+				fmt.Fprintf(&b, "\tinsteadOf = %s\n", quoteGitConfig(prefix))
+			}
+		}
+	}
+	return b.String()
+}
+
+// gitConfigEnv returns creds as environment variables of git, which are
+// passed to the commands run in the test container like the secrets of
+// WithSecretEnv.
+func gitConfigEnv(creds []GitCredentials) map[string]string {
+	env := make(map[string]string)
+	count := 0
+	set := func(key, value string) {
+		env["GIT_CONFIG_KEY_"+strconv.Itoa(count)] = key
+		env["GIT_CONFIG_VALUE_"+strconv.Itoa(count)] = value
+		count++
+	}
+	for _, c := range creds {
+		if c.Helper != "" {
+			set("credential."+c.urlPrefix()+".helper", c.Helper)
+		}
+		if c.Token != "" {
+			for _, prefix := range c.insteadOf() {
+				set("url."+c.tokenURLPrefix()+".insteadOf", prefix)
+			}
+		}
+	}
+	if count > 0 {
+		env["GIT_CONFIG_COUNT"] = strconv.Itoa(count)
+	}
+	return env
+}
+
+// gitTokens returns the tokens of creds, which are redacted like the secrets
+// of WithSecretEnv. Besides the raw token, the escaped forms git may print in
+// URLs are returned: the user information of tokenURLPrefix and the path and
+// query escaped token.
+func gitTokens(creds []GitCredentials) []string {
+	var tokens []string
+	for _, c := range creds {
+		if c.Token == "" {
+			continue
+		}
+		username := c.Username
+		if username == "" {
+			username = defaultGitUsername
+		}
+		for _, token := range []string{
+			c.Token,
+			url.UserPassword(username, c.Token).String(),
+			url.PathEscape(c.Token),
+			url.QueryEscape(c.Token),
+		} {
+			if !slices.Contains(tokens, token) {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens
+}
+
+// quoteGitConfig quotes s as value or subsection name of a git configuration
+// file.
+func quoteGitConfig(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,73 @@
+package dockertesting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SetupError represents a failure of a setup command executed inside the
+// container before the tests run.
+type SetupError struct {
+	// Command is the setup command that failed.
+	Command []string
+
+	// ExitCode is the exit code of the command (0 if it could not be executed).
+	ExitCode int
+
+	// Output contains the combined stdout/stderr of the command.
+	Output []byte
+
+	// Err is the underlying error if the command could not be executed.
+	Err error
+
+	// ContainerLogs contains the logs of the container's main process at the
+	// time of the failure, e.g. from background processes started by setup.
+	ContainerLogs []byte
+}
+
+func (e *SetupError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("setup command %q failed: %v", strings.Join(e.Command, " "), e.Err)
+	}
+	return fmt.Sprintf("setup command %q exited with code %d", strings.Join(e.Command, " "), e.ExitCode)
+}
+
+func (e *SetupError) Unwrap() error {
+	return e.Err
+}
+
+// runSetupCommands executes the setup commands in order, streaming their output to w.
+// It stops at the first command that fails to execute or exits with a non-zero code.
+func runSetupCommands(ctx context.Context, container commandExecutor, commands [][]string, w io.Writer) error {
+	for _, cmd := range commands {
+		result, err := container.ExecCommand(ctx, cmd, ExecOptions{Output: w})
+		if err != nil {
+			return &SetupError{Command: cmd, Err: wrapTimeoutError(ctx, err, "run setup command")}
+		}
+		if result.ExitCode != 0 {
+			return &SetupError{Command: cmd, ExitCode: result.ExitCode, Output: result.Stdout}
+		}
+	}
+	return nil
+}
+
+// runTeardownCommands executes the teardown commands in order, streaming their output to w.
+// Unlike setup commands, every command is attempted even if an earlier one fails,
+// and all failures are returned joined together.
+func runTeardownCommands(ctx context.Context, container commandExecutor, commands [][]string, w io.Writer) error {
+	var errs []error
+	for _, cmd := range commands {
+		result, err := container.ExecCommand(ctx, cmd, ExecOptions{Output: w})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("teardown command %q failed: %w", strings.Join(cmd, " "), err))
+			continue
+		}
+		if result.ExitCode != 0 {
+			errs = append(errs, fmt.Errorf("teardown command %q exited with code %d", strings.Join(cmd, " "), result.ExitCode))
+		}
+	}
+	return errors.Join(errs...)
+}
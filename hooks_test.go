@@ -0,0 +1,75 @@
+package dockertesting
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSetupError_ExitCode(t *testing.T) {
+	t.Parallel()
+	err := &SetupError{
+		Command:  []string{"go", "generate", "./..."},
+		ExitCode: 2,
+	}
+
+	expected := `setup command "go generate ./..." exited with code 2`
+	if err.Error() != expected {
+		t.Errorf("expected error message %q, got %q", expected, err.Error())
+	}
+}
+
+func TestSetupError_Unwrap(t *testing.T) {
+	t.Parallel()
+	innerErr := errors.New("exec failed")
+	err := &SetupError{
+		Command: []string{"migrate"},
+		Err:     innerErr,
+	}
+
+	if !errors.Is(err, innerErr) {
+		t.Error("expected SetupError to unwrap to inner error")
+	}
+
+	expected := `setup command "migrate" failed: exec failed`
+	if err.Error() != expected {
+		t.Errorf("expected error message %q, got %q", expected, err.Error())
+	}
+}
+
+func TestRunSetupCommands_NilContainer(t *testing.T) {
+	t.Parallel()
+	container := &TestContainer{ctr: nil}
+
+	err := runSetupCommands(t.Context(), container, [][]string{{"true"}}, io.Discard)
+
+	var setupErr *SetupError
+	if !errors.As(err, &setupErr) {
+		t.Fatalf("expected SetupError, got %v", err)
+	}
+}
+
+func TestRunSetupCommands_NoCommands(t *testing.T) {
+	t.Parallel()
+	container := &TestContainer{ctr: nil}
+
+	if err := runSetupCommands(t.Context(), container, nil, io.Discard); err != nil {
+		t.Errorf("expected nil error without commands, got %v", err)
+	}
+}
+
+func TestRunTeardownCommands_AttemptsAllCommands(t *testing.T) {
+	t.Parallel()
+	container := &TestContainer{ctr: nil}
+
+	err := runTeardownCommands(t.Context(), container, [][]string{{"first"}, {"second"}}, io.Discard)
+	if err == nil {
+		t.Fatal("expected error for nil container")
+	}
+
+	msg := err.Error()
+	if !strings.Contains(msg, `"first"`) || !strings.Contains(msg, `"second"`) {
+		t.Errorf("expected both commands to be attempted, got: %v", msg)
+	}
+}
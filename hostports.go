@@ -0,0 +1,22 @@
+package dockertesting
+
+import (
+	"strings"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// HostAlias is the host name of the host in the test container, see
+// WithHostPorts.
+const HostAlias = "host.dockertesting.internal"
+
+// withHostAlias returns extraHosts with HostAlias for the address of
+// testcontainers.HostInternal, which testcontainers adds for host ports.
+func withHostAlias(extraHosts []string) []string {
+	for _, host := range extraHosts {
+		if ip, ok := strings.CutPrefix(host, testcontainers.HostInternal+":"); ok {
+			return append(extraHosts, HostAlias+":"+ip)
+		}
+	}
+	return extraHosts
+}
@@ -0,0 +1,21 @@
+package dockertesting
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestWithHostAlias(t *testing.T) {
+	t.Parallel()
+	hosts := withHostAlias([]string{"db.local:10.0.0.5", testcontainers.HostInternal + ":172.18.0.3"})
+	expected := []string{"db.local:10.0.0.5", testcontainers.HostInternal + ":172.18.0.3", HostAlias + ":172.18.0.3"}
+	if !slices.Equal(hosts, expected) {
+		t.Errorf("expected %v, got %v", expected, hosts)
+	}
+
+	if hosts := withHostAlias([]string{"db.local:10.0.0.5"}); len(hosts) != 1 {
+		t.Errorf("expected no alias without forwarded ports, got %v", hosts)
+	}
+}
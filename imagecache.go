@@ -0,0 +1,162 @@
+package dockertesting
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// ImageCacheRepository is the repository under which images built with
+// WithImageCache are kept, tagged with the digest of their build context.
+const ImageCacheRepository = "dockertesting-cache"
+
+// contextDigest computes a digest of a build context archive, the build args
+// and the build target from the names, types, modes, link targets and
+// contents of its entries.
+// Modification times and ownership are ignored, so re-checking out unchanged
+// files does not change the digest. The archive is rewound afterwards.
+func contextDigest(archive io.ReadSeeker, buildArgs map[string]*string, target string) (string, error) {
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to rewind build context: %w", err)
+	}
+
+	h := sha256.New()
+	for _, name := range slices.Sorted(maps.Keys(buildArgs)) {
+		writeDigestField(h, []byte(name))
+		if value := buildArgs[name]; value != nil {
+			writeDigestField(h, []byte(*value))
+		}
+	}
+	// Leave the digests of builds without target as they were
+	if target != "" {
+		writeDigestField(h, []byte("target="+target))
+	}
+	tr := tar.NewReader(archive)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read build context: %w", err)
+		}
+		writeDigestField(h, []byte(header.Name))
+		writeDigestField(h, []byte{header.Typeflag})
+		writeDigestField(h, binary.BigEndian.AppendUint32(nil, uint32(header.FileInfo().Mode())))
+		writeDigestField(h, []byte(header.Linkname))
+		// Length-prefix the content so that entry boundaries are unambiguous
+		writeDigestField(h, binary.BigEndian.AppendUint64(nil, uint64(header.Size)))
+		if _, err := io.Copy(h, tr); err != nil {
+			return "", fmt.Errorf("failed to read build context entry %s: %w", header.Name, err)
+		}
+	}
+
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to rewind build context: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeDigestField writes a length-prefixed field to h.
+func writeDigestField(h hash.Hash, field []byte) {
+	h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(field))))
+	h.Write(field)
+}
+
+// lookupCachedImage returns the digest of the build context archive, build
+// args and build target, and whether an image built from an identical context is present on the
+// daemon.
+func lookupCachedImage(ctx context.Context, provider *testcontainers.DockerProvider, archive io.ReadSeeker, buildArgs map[string]*string, target string) (string, bool, error) {
+	digest, err := contextDigest(archive, buildArgs, target)
+	if err != nil {
+		return "", false, err
+	}
+
+	cli, closeClient, err := dockerClient(ctx, provider)
+	if err != nil {
+		return "", false, err
+	}
+	defer closeClient()
+
+	exists, err := imageExists(ctx, cli, imageCacheRef(digest))
+	if err != nil {
+		return "", false, err
+	}
+	return digest, exists, nil
+}
+
+// imageCacheRef returns the image reference for a build context digest.
+func imageCacheRef(digest string) string {
+	return ImageCacheRepository + ":" + digest
+}
+
+// imageExists reports whether the image ref is present on the daemon.
+func imageExists(ctx context.Context, cli client.APIClient, ref string) (bool, error) {
+	if _, err := cli.ImageInspect(ctx, ref); err != nil {
+		if client.IsErrNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to inspect image %s: %w", ref, err)
+	}
+	return true, nil
+}
+
+// PruneImageCache removes the images kept by WithImageCache that were created
+// more than olderThan ago, or all of them if olderThan is 0, and returns the
+// removed image references. Images still used by a container are skipped and
+// reported in the returned error, after the remaining images are removed.
+//
+// Example:
+//
+//	// Remove cached images older than a week
+//	removed, err := dockertesting.PruneImageCache(ctx, 7*24*time.Hour)
+func PruneImageCache(ctx context.Context, olderThan time.Duration) ([]string, error) {
+	cli, closeClient, err := dockerClient(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer closeClient()
+
+	images, err := cli.ImageList(ctx, image.ListOptions{
+		Filters: filters.NewArgs(filters.Arg("reference", ImageCacheRepository)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cached images: %w", err)
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	var removed []string
+	var errs []error
+	for _, img := range images {
+		if olderThan > 0 && time.Unix(img.Created, 0).After(cutoff) {
+			continue
+		}
+		for _, ref := range img.RepoTags {
+			// The image may also be tagged outside of the cache
+			if !strings.HasPrefix(ref, ImageCacheRepository+":") {
+				continue
+			}
+			if _, err := cli.ImageRemove(ctx, ref, image.RemoveOptions{PruneChildren: true}); err != nil {
+				errs = append(errs, fmt.Errorf("failed to remove cached image %s: %w", ref, err))
+				continue
+			}
+			removed = append(removed, ref)
+		}
+	}
+	return removed, errors.Join(errs...)
+}
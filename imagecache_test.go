@@ -0,0 +1,135 @@
+package dockertesting
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestContextDigest(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	mainPath := filepath.Join(tmpDir, "main.go")
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module test\n\ngo 1.25.6\n"), 0644); err != nil {
+		t.Fatalf("failed to write go.mod: %v", err)
+	}
+	if err := os.WriteFile(mainPath, []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("failed to write main.go: %v", err)
+	}
+
+	digest := func() string {
+		t.Helper()
+		archive, err := CreateTarContext(tmpDir, "")
+		if err != nil {
+			t.Fatalf("CreateTarContext failed: %v", err)
+		}
+		d, err := contextDigest(archive, nil, "")
+		if err != nil {
+			t.Fatalf("contextDigest failed: %v", err)
+		}
+		// The archive must be rewound for the build
+		if pos, _ := archive.Seek(0, io.SeekCurrent); pos != 0 {
+			t.Errorf("expected archive to be rewound, got offset %d", pos)
+		}
+		return d
+	}
+
+	original := digest()
+	if len(original) != 64 {
+		t.Errorf("expected a sha256 hex digest, got %q", original)
+	}
+
+	// Touching a file does not change the digest
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(mainPath, later, later); err != nil {
+		t.Fatalf("failed to touch main.go: %v", err)
+	}
+	if got := digest(); got != original {
+		t.Errorf("expected digest to ignore modification times, got %q and %q", original, got)
+	}
+
+	// Changing file contents does
+	if err := os.WriteFile(mainPath, []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatalf("failed to write main.go: %v", err)
+	}
+	if got := digest(); got == original {
+		t.Error("expected digest to change with file contents")
+	}
+}
+
+func TestContextDigest_Dockerfile(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module test\n\ngo 1.25.6\n"), 0644); err != nil {
+		t.Fatalf("failed to write go.mod: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "custom.Dockerfile"), []byte("FROM golang:1.25\n"), 0644); err != nil {
+		t.Fatalf("failed to write custom.Dockerfile: %v", err)
+	}
+
+	defaultArchive, err := CreateTarContext(tmpDir, "")
+	if err != nil {
+		t.Fatalf("CreateTarContext failed: %v", err)
+	}
+	customArchive, err := CreateTarContext(tmpDir, "custom.Dockerfile")
+	if err != nil {
+		t.Fatalf("CreateTarContext failed: %v", err)
+	}
+
+	defaultDigest, err := contextDigest(defaultArchive, nil, "")
+	if err != nil {
+		t.Fatalf("contextDigest failed: %v", err)
+	}
+	customDigest, err := contextDigest(customArchive, nil, "")
+	if err != nil {
+		t.Fatalf("contextDigest failed: %v", err)
+	}
+	if defaultDigest == customDigest {
+		t.Error("expected digest to depend on the Dockerfile")
+	}
+}
+
+func TestImageCacheRef(t *testing.T) {
+	t.Parallel()
+
+	if got := imageCacheRef("abc123"); got != "dockertesting-cache:abc123" {
+		t.Errorf("expected ref %q, got %q", "dockertesting-cache:abc123", got)
+	}
+}
+
+func TestContextDigest_BuildArgs(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module test\n\ngo 1.25.6\n"), 0644); err != nil {
+		t.Fatalf("failed to write go.mod: %v", err)
+	}
+	archive, err := CreateTarContext(tmpDir, "")
+	if err != nil {
+		t.Fatalf("CreateTarContext failed: %v", err)
+	}
+
+	plain, err := contextDigest(archive, nil, "")
+	if err != nil {
+		t.Fatalf("contextDigest failed: %v", err)
+	}
+	lazy := "1"
+	withArgs, err := contextDigest(archive, map[string]*string{"LAZY_MOD_DOWNLOAD": &lazy}, "")
+	if err != nil {
+		t.Fatalf("contextDigest failed: %v", err)
+	}
+	if plain == withArgs {
+		t.Error("expected digest to depend on the build args")
+	}
+	withTarget, err := contextDigest(archive, nil, "test")
+	if err != nil {
+		t.Fatalf("contextDigest failed: %v", err)
+	}
+	if plain == withTarget {
+		t.Error("expected digest to depend on the build target")
+	}
+}
@@ -0,0 +1,106 @@
+package dockertesting
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// ContainerInfo is the part of `docker inspect` for a container that tooling
+// such as log collectors or cAdvisor queries needs to reference it.
+type ContainerInfo struct {
+	// ID is the full container ID.
+	ID string
+
+	// Name is the container name without the leading slash.
+	Name string
+
+	// Image is the image reference the container was created from.
+	Image string
+
+	// ImageID is the ID of the image, e.g. "sha256:...".
+	ImageID string
+
+	// Created is when the container was created.
+	Created time.Time
+
+	// StartedAt is when the container was last started.
+	StartedAt time.Time
+
+	// Status is the container state, e.g. "running" or "exited".
+	Status string
+
+	// Pid is the host PID of the container's main process, 0 if it is not
+	// running.
+	Pid int
+
+	// ExitCode is the exit code of the main process once it exited.
+	ExitCode int
+
+	// OOMKilled reports whether a process was killed by the out-of-memory
+	// killer.
+	OOMKilled bool
+
+	// Labels are the labels of the container.
+	Labels map[string]string
+
+	// Networks maps the names of the container's networks to its IP address
+	// on them.
+	Networks map[string]string
+}
+
+// ID returns the ID of the container, or an empty string if there is none.
+func (c *TestContainer) ID() string {
+	if c.ctr == nil {
+		return ""
+	}
+	return c.ctr.GetContainerID()
+}
+
+// Inspect returns the inspect information of the container.
+func (c *TestContainer) Inspect(ctx context.Context) (*ContainerInfo, error) {
+	if c.ctr == nil {
+		return nil, fmt.Errorf("container is nil")
+	}
+	resp, err := c.ctr.Inspect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container: %w", err)
+	}
+	return containerInfo(resp), nil
+}
+
+// containerInfo trims resp to a ContainerInfo. Timestamps that cannot be
+// parsed are left zero.
+func containerInfo(resp *container.InspectResponse) *ContainerInfo {
+	info := &ContainerInfo{
+		Networks: make(map[string]string),
+	}
+	if base := resp.ContainerJSONBase; base != nil {
+		info.ID = base.ID
+		info.Name = strings.TrimPrefix(base.Name, "/")
+		info.ImageID = base.Image
+		info.Created, _ = time.Parse(time.RFC3339Nano, base.Created)
+		if state := base.State; state != nil {
+			info.Status = string(state.Status)
+			info.Pid = state.Pid
+			info.ExitCode = state.ExitCode
+			info.OOMKilled = state.OOMKilled
+			info.StartedAt, _ = time.Parse(time.RFC3339Nano, state.StartedAt)
+		}
+	}
+	if resp.Config != nil {
+		info.Image = resp.Config.Image
+		info.Labels = resp.Config.Labels
+	}
+	if resp.NetworkSettings != nil {
+		for name, endpoint := range resp.NetworkSettings.Networks {
+			if endpoint != nil {
+				info.Networks[name] = endpoint.IPAddress
+			}
+		}
+	}
+	return info
+}
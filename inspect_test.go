@@ -0,0 +1,68 @@
+package dockertesting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+)
+
+func TestContainerInfo(t *testing.T) {
+	t.Parallel()
+	resp := &container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			ID:      "abc123",
+			Name:    "/dockertesting-mypackage",
+			Image:   "sha256:def456",
+			Created: "2025-01-01T10:00:00.123456789Z",
+			State: &container.State{
+				Status:    container.StateRunning,
+				Pid:       4242,
+				StartedAt: "2025-01-01T10:00:01Z",
+			},
+		},
+		Config: &container.Config{
+			Image:  "dockertesting-build:latest",
+			Labels: map[string]string{LabelManaged: "true"},
+		},
+		NetworkSettings: &container.NetworkSettings{
+			Networks: map[string]*network.EndpointSettings{
+				"testnet": {IPAddress: "172.18.0.2"},
+			},
+		},
+	}
+
+	info := containerInfo(resp)
+	if info.ID != "abc123" || info.Name != "dockertesting-mypackage" {
+		t.Errorf("expected ID %q and name %q, got %q and %q", "abc123", "dockertesting-mypackage", info.ID, info.Name)
+	}
+	if info.Image != "dockertesting-build:latest" || info.ImageID != "sha256:def456" {
+		t.Errorf("unexpected image %q with ID %q", info.Image, info.ImageID)
+	}
+	if want := time.Date(2025, 1, 1, 10, 0, 0, 123456789, time.UTC); !info.Created.Equal(want) {
+		t.Errorf("expected created %v, got %v", want, info.Created)
+	}
+	if info.Status != "running" || info.Pid != 4242 {
+		t.Errorf("expected running with pid 4242, got %q with pid %d", info.Status, info.Pid)
+	}
+	if info.Networks["testnet"] != "172.18.0.2" {
+		t.Errorf("expected IP %q on testnet, got %q", "172.18.0.2", info.Networks["testnet"])
+	}
+	if info.Labels[LabelManaged] != "true" {
+		t.Errorf("expected labels to be kept, got %v", info.Labels)
+	}
+}
+
+func TestTestContainer_Inspect_NilContainer(t *testing.T) {
+	t.Parallel()
+	container := &TestContainer{ctr: nil}
+
+	if id := container.ID(); id != "" {
+		t.Errorf("expected empty ID, got %q", id)
+	}
+	if _, err := container.Inspect(context.Background()); err == nil {
+		t.Error("expected error for nil container")
+	}
+}
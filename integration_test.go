@@ -3,11 +3,19 @@
 package dockertesting
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -61,6 +69,50 @@ func TestRun_SimplePackage(t *testing.T) {
 	t.Logf("stdout:\n%s", stdout)
 }
 
+func TestRun_Hooks(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	packagePath, err := filepath.Abs("testdata/simple")
+	if err != nil {
+		t.Fatalf("failed to get absolute path: %v", err)
+	}
+
+	var mu sync.Mutex
+	var order []string
+	var started, cleaned HookEvent
+	record := func(name string, target *HookEvent) func(context.Context, HookEvent) {
+		return func(ctx context.Context, event HookEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			if target != nil {
+				*target = event
+			}
+		}
+	}
+	result, err := Run(ctx, packagePath, WithHooks(Hooks{
+		OnBuildStart:       record("build", nil),
+		OnContainerStarted: record("started", &started),
+		OnTestsFinished:    record("finished", nil),
+		OnCleanup:          record("cleanup", &cleaned),
+	}))
+	if err != nil {
+		t.Fatalf("Run() returned error: %v", err)
+	}
+
+	if got := strings.Join(order, ","); got != "build,started,finished,cleanup" {
+		t.Errorf("unexpected hook order %q", got)
+	}
+	if started.ContainerID != result.ContainerID || started.ImageTag == "" {
+		t.Errorf("expected the container and image in OnContainerStarted, got %+v", started)
+	}
+	if cleaned.Result != result || cleaned.Err != nil || cleaned.Kept {
+		t.Errorf("expected the result and a clean removal in OnCleanup, got %+v", cleaned)
+	}
+}
+
 func TestRun_DNSAlias(t *testing.T) {
 	t.Parallel()
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
@@ -104,6 +156,565 @@ func TestRun_DNSAlias(t *testing.T) {
 	t.Logf("stdout:\n%s", stdout)
 }
 
+func TestRun_Sidecar(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	// Get absolute path to testdata/sidecar
+	packagePath, err := filepath.Abs("testdata/sidecar")
+	if err != nil {
+		t.Fatalf("failed to get absolute path: %v", err)
+	}
+
+	// Run tests in Docker container with an nginx sidecar aliased "nginx.test"
+	result, err := Run(ctx, packagePath, WithSidecar(SidecarSpec{
+		Name:    "nginx",
+		Image:   "nginx:alpine",
+		Aliases: []string{"nginx.test"},
+		Port:    80,
+		WaitFor: WaitForPort("80/tcp"),
+	}), WithProbe(ProbeTarget{Alias: "nginx.test", Port: 80, HTTPPath: "/"}))
+	if err != nil {
+		t.Fatalf("Run() returned error: %v", err)
+	}
+
+	// Verify exit code is 0 (the sidecar was reachable)
+	if result.ExitCode != 0 {
+		t.Errorf("expected exit code 0, got %d", result.ExitCode)
+		t.Logf("stdout:\n%s", string(result.Stdout))
+	}
+}
+
+func TestRunner_TestMany(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	packagePath, err := filepath.Abs("testdata/simple")
+	if err != nil {
+		t.Fatalf("failed to get absolute path: %v", err)
+	}
+
+	runner, err := NewRunner(ctx, packagePath)
+	if err != nil {
+		t.Fatalf("NewRunner() returned error: %v", err)
+	}
+	defer func() {
+		if err := runner.Close(ctx); err != nil {
+			t.Errorf("Close() returned error: %v", err)
+		}
+	}()
+
+	ip, err := runner.Container().ContainerIP(ctx)
+	if err != nil {
+		t.Fatalf("ContainerIP() returned error: %v", err)
+	}
+	if net.ParseIP(ip) == nil {
+		t.Errorf("expected an IP address, got %q", ip)
+	}
+	if _, err := runner.Container().Host(ctx); err != nil {
+		t.Errorf("Host() returned error: %v", err)
+	}
+
+	// Each invocation reuses the container built once by NewRunner
+	for _, filter := range []string{"TestAdd", "TestSubtract"} {
+		result, err := runner.Test(ctx, ExecConfig{Args: []string{"-run", filter, "-v"}})
+		if err != nil {
+			t.Fatalf("Test(%s) returned error: %v", filter, err)
+		}
+		if result.ExitCode != 0 {
+			t.Errorf("expected exit code 0 for %s, got %d", filter, result.ExitCode)
+			t.Logf("stdout:\n%s", string(result.Stdout))
+		}
+		if !strings.Contains(string(result.Stdout), "--- PASS: "+filter) {
+			t.Errorf("expected output to contain %s passing", filter)
+		}
+		if len(result.Coverage) == 0 {
+			t.Errorf("expected coverage for %s", filter)
+		}
+	}
+}
+
+func TestRunner_Events(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	packagePath, err := filepath.Abs("testdata/simple")
+	if err != nil {
+		t.Fatalf("failed to get absolute path: %v", err)
+	}
+
+	runner, err := NewRunner(ctx, packagePath)
+	if err != nil {
+		t.Fatalf("NewRunner() returned error: %v", err)
+	}
+
+	events := runner.Events()
+	actions := make(chan map[string]int)
+	go func() {
+		counts := make(map[string]int)
+		for event := range events {
+			counts[event.Action]++
+		}
+		actions <- counts
+	}()
+
+	if _, err := runner.Test(ctx, ExecConfig{Args: []string{"-json"}}); err != nil {
+		t.Fatalf("Test() returned error: %v", err)
+	}
+	if err := runner.Close(ctx); err != nil {
+		t.Errorf("Close() returned error: %v", err)
+	}
+
+	counts := <-actions
+	if counts["run"] == 0 || counts["pass"] == 0 || counts["output"] == 0 {
+		t.Errorf("expected run, pass and output events, got %v", counts)
+	}
+}
+
+func TestRunner_ProgressCoverageCopied(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	packagePath, err := filepath.Abs("testdata/simple")
+	if err != nil {
+		t.Fatalf("failed to get absolute path: %v", err)
+	}
+
+	var mu sync.Mutex
+	var stages []ProgressStage
+	reporter := ProgressReporterFunc(func(event ProgressEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		stages = append(stages, event.Stage)
+	})
+	runner, err := NewRunner(ctx, packagePath, WithProgressReporter(reporter))
+	if err != nil {
+		t.Fatalf("NewRunner() returned error: %v", err)
+	}
+	defer func() {
+		if err := runner.Close(ctx); err != nil {
+			t.Errorf("Close() returned error: %v", err)
+		}
+	}()
+
+	if _, err := runner.Test(ctx, ExecConfig{}); err != nil {
+		t.Fatalf("Test() returned error: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	running := slices.Index(stages, StageTestsRunning)
+	if running < 0 || !slices.Contains(stages[running:], StageCoverageCopied) {
+		t.Errorf("expected coverage_copied after tests_running, got %v", stages)
+	}
+}
+
+func TestRunner_SharedNetwork(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	packagePath, err := filepath.Abs("testdata/simple")
+	if err != nil {
+		t.Fatalf("failed to get absolute path: %v", err)
+	}
+
+	network, cleanupNetwork, err := CreateNetwork(ctx)
+	if err != nil {
+		t.Fatalf("failed to create network: %v", err)
+	}
+	defer func() {
+		if err := cleanupNetwork(ctx); err != nil {
+			t.Errorf("failed to remove shared network: %v", err)
+		}
+	}()
+
+	server, err := NewRunner(ctx, packagePath, WithNetwork(network), WithAliases("server.test"))
+	if err != nil {
+		t.Fatalf("NewRunner() returned error: %v", err)
+	}
+	defer func() {
+		_ = server.Close(ctx)
+	}()
+
+	client, err := NewRunner(ctx, packagePath, WithNetwork(network))
+	if err != nil {
+		t.Fatalf("NewRunner() returned error: %v", err)
+	}
+
+	// The second run reaches the first one through its alias
+	result, err := client.Container().ExecCommand(ctx, []string{"getent", "hosts", "server.test"}, ExecOptions{})
+	if err != nil {
+		t.Fatalf("ExecCommand() returned error: %v", err)
+	}
+	if result.ExitCode != 0 {
+		t.Errorf("expected server.test to resolve on the shared network, got exit code %d", result.ExitCode)
+	}
+
+	// Closing the runs leaves the shared network to its creator, so removing
+	// it above still succeeds
+	if err := client.Close(ctx); err != nil {
+		t.Errorf("Close() returned error: %v", err)
+	}
+}
+
+func TestRunner_StaticIP(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	packagePath, err := filepath.Abs("testdata/simple")
+	if err != nil {
+		t.Fatalf("failed to get absolute path: %v", err)
+	}
+
+	runner, err := NewRunner(ctx, packagePath, WithNetworkCIDR("172.28.42.0/24"), WithStaticIP("172.28.42.10"))
+	if err != nil {
+		t.Fatalf("NewRunner() returned error: %v", err)
+	}
+	defer func() {
+		_ = runner.Close(ctx)
+	}()
+
+	result, err := runner.Container().ExecCommand(ctx, []string{"hostname", "-i"}, ExecOptions{})
+	if err != nil {
+		t.Fatalf("ExecCommand() returned error: %v", err)
+	}
+	if result.ExitCode != 0 || !strings.Contains(string(result.Stdout), "172.28.42.10") {
+		t.Errorf("expected the container to have the address 172.28.42.10, got %q (exit code %d)", result.Stdout, result.ExitCode)
+	}
+}
+
+func TestRunner_NonRoot(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	packagePath, err := filepath.Abs("testdata/simple")
+	if err != nil {
+		t.Fatalf("failed to get absolute path: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"default", nil, "1000"},
+		{"root", []Option{WithRoot()}, "0"},
+	} {
+		runner, err := NewRunner(ctx, packagePath, tt.opts...)
+		if err != nil {
+			t.Fatalf("%s: NewRunner() returned error: %v", tt.name, err)
+		}
+		result, err := runner.Container().ExecCommand(ctx, []string{"id", "-u"}, ExecOptions{})
+		_ = runner.Close(ctx)
+		if err != nil {
+			t.Fatalf("%s: ExecCommand() returned error: %v", tt.name, err)
+		}
+		if got := strings.TrimSpace(string(result.Stdout)); result.ExitCode != 0 || got != tt.want {
+			t.Errorf("%s: expected user ID %s, got %q (exit code %d)", tt.name, tt.want, got, result.ExitCode)
+		}
+	}
+}
+
+func TestRunner_ReadOnlySource(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	packagePath, err := filepath.Abs("testdata/simple")
+	if err != nil {
+		t.Fatalf("failed to get absolute path: %v", err)
+	}
+
+	runner, err := NewRunner(ctx, packagePath, WithReadOnlySource(), WithRoot())
+	if err != nil {
+		t.Fatalf("NewRunner() returned error: %v", err)
+	}
+	defer func() { _ = runner.Close(ctx) }()
+
+	// Even root cannot write to the source, but the tests still run
+	result, err := runner.Container().ExecCommand(ctx, []string{"touch", "/app/mutated"}, ExecOptions{})
+	if err != nil {
+		t.Fatalf("ExecCommand() returned error: %v", err)
+	}
+	if result.ExitCode == 0 {
+		t.Error("expected writing to the source to fail")
+	}
+	testResult, err := runner.Test(ctx, ExecConfig{})
+	if err != nil {
+		t.Fatalf("Test() returned error: %v", err)
+	}
+	if testResult.ExitCode != 0 || len(testResult.Coverage) == 0 {
+		t.Errorf("expected passing tests with coverage, got exit code %d\n%s", testResult.ExitCode, testResult.Stdout)
+	}
+}
+
+func TestRunner_StopTimeout_Init(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	packagePath, err := filepath.Abs("testdata/simple")
+	if err != nil {
+		t.Fatalf("failed to get absolute path: %v", err)
+	}
+
+	runner, err := NewRunner(ctx, packagePath, WithInit(true), WithStopTimeout(30*time.Second))
+	if err != nil {
+		t.Fatalf("NewRunner() returned error: %v", err)
+	}
+
+	// A command that needs a while to clean up after SIGTERM, like a test
+	// removing the containers it started
+	var output bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = runner.Container().ExecCommand(ctx, []string{"sh", "-c",
+			`trap 'sleep 2; echo cleaned up; exit 0' TERM; touch /tmp/started; while :; do sleep 0.1; done`,
+		}, ExecOptions{Output: &output})
+	}()
+	for {
+		result, err := runner.Container().ExecCommand(ctx, []string{"test", "-e", "/tmp/started"}, ExecOptions{})
+		if err != nil {
+			t.Fatalf("ExecCommand() returned error: %v", err)
+		}
+		if result.ExitCode == 0 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// The init process and the entrypoint outlive the SIGTERM, so the
+	// container is only killed after the command exited
+	start := time.Now()
+	if err := runner.Close(ctx); err != nil {
+		t.Fatalf("Close() returned error: %v", err)
+	}
+	<-done
+	if !strings.Contains(output.String(), "cleaned up") {
+		t.Errorf("expected the command to finish its cleanup, got output %q", output.String())
+	}
+	if elapsed := time.Since(start); elapsed < 2*time.Second {
+		t.Errorf("expected Close to wait for the cleanup, took %v", elapsed)
+	}
+}
+
+func TestRunner_SecretEnv(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	packagePath, err := filepath.Abs("testdata/simple")
+	if err != nil {
+		t.Fatalf("failed to get absolute path: %v", err)
+	}
+
+	runner, err := NewRunner(ctx, packagePath, WithSecretEnv("DOCKERTESTING_SECRET", "s3cr3t-value"))
+	if err != nil {
+		t.Fatalf("NewRunner() returned error: %v", err)
+	}
+	defer func() {
+		_ = runner.Close(ctx)
+	}()
+
+	var streamed bytes.Buffer
+	result, err := runner.Container().ExecCommand(ctx, []string{"sh", "-c", "echo secret=$DOCKERTESTING_SECRET"}, ExecOptions{Output: &streamed})
+	if err != nil {
+		t.Fatalf("ExecCommand() returned error: %v", err)
+	}
+	for name, output := range map[string][]byte{"result": result.Stdout, "streamed": streamed.Bytes()} {
+		if !strings.Contains(string(output), "secret="+Redacted) || strings.Contains(string(output), "s3cr3t-value") {
+			t.Errorf("expected the %s output to redact the secret, got %q", name, output)
+		}
+	}
+
+	// The secret is passed at exec time only, not to the container
+	info, err := runner.Container().ctr.Inspect(ctx)
+	if err != nil {
+		t.Fatalf("Inspect() returned error: %v", err)
+	}
+	for _, env := range info.Config.Env {
+		if strings.HasPrefix(env, "DOCKERTESTING_SECRET=") {
+			t.Errorf("expected the secret not to be in the container environment, got %q", env)
+		}
+	}
+}
+
+func TestRunner_NetworkDriverOptions(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	packagePath, err := filepath.Abs("testdata/simple")
+	if err != nil {
+		t.Fatalf("failed to get absolute path: %v", err)
+	}
+
+	runner, err := NewRunner(ctx, packagePath, WithNetworkDriver("bridge", map[string]string{NetworkOptionMTU: "1400"}))
+	if err != nil {
+		t.Fatalf("NewRunner() returned error: %v", err)
+	}
+	defer func() {
+		if err := runner.Close(ctx); err != nil {
+			t.Errorf("Close() returned error: %v", err)
+		}
+	}()
+
+	result, err := runner.Container().ExecCommand(ctx, []string{"cat", "/sys/class/net/eth0/mtu"}, ExecOptions{})
+	if err != nil {
+		t.Fatalf("ExecCommand() returned error: %v", err)
+	}
+	if strings.TrimSpace(string(result.Stdout)) != "1400" {
+		t.Errorf("expected MTU 1400 in the container, got %q", result.Stdout)
+	}
+}
+
+func TestRunner_HostPorts(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	packagePath, err := filepath.Abs("testdata/simple")
+	if err != nil {
+		t.Fatalf("failed to get absolute path: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("hello from the host"))
+	}))
+	defer server.Close()
+	port := server.Listener.Addr().(*net.TCPAddr).Port
+
+	runner, err := NewRunner(ctx, packagePath, WithHostPorts(port))
+	if err != nil {
+		t.Fatalf("NewRunner() returned error: %v", err)
+	}
+	defer func() {
+		_ = runner.Close(ctx)
+	}()
+
+	url := fmt.Sprintf("http://%s:%d", HostAlias, port)
+	result, err := runner.Container().ExecCommand(ctx, []string{"curl", "-sf", url}, ExecOptions{})
+	if err != nil {
+		t.Fatalf("ExecCommand() returned error: %v", err)
+	}
+	if result.ExitCode != 0 || !strings.Contains(string(result.Stdout), "hello from the host") {
+		t.Errorf("expected the host server to answer at %s, got %q (exit code %d)", url, result.Stdout, result.ExitCode)
+	}
+}
+
+func TestWarmup_ImageCache(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	packagePath, err := filepath.Abs("testdata/simple")
+	if err != nil {
+		t.Fatalf("failed to get absolute path: %v", err)
+	}
+
+	if err := Warmup(ctx, packagePath); err != nil {
+		t.Fatalf("Warmup() returned error: %v", err)
+	}
+
+	// A run with the image cache starts from the image built by Warmup
+	var mu sync.Mutex
+	var buildLines []string
+	result, err := Run(ctx, packagePath, WithImageCache(true), WithOutputCallback(func(line OutputLine) {
+		if line.Source == OutputSourceBuild {
+			mu.Lock()
+			buildLines = append(buildLines, line.Text)
+			mu.Unlock()
+		}
+	}))
+	if err != nil {
+		t.Fatalf("Run() returned error: %v", err)
+	}
+	if result.ExitCode != 0 {
+		t.Errorf("expected exit code 0, got %d", result.ExitCode)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(buildLines) == 0 || !strings.HasPrefix(buildLines[0], "Using cached image "+ImageCacheRepository+":") {
+		t.Errorf("expected the cached image to be used, got build log %q", buildLines)
+	}
+}
+
+func TestRun_NestedTestcontainers_SocketProxy(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	packagePath, err := filepath.Abs("testdata/nested")
+	if err != nil {
+		t.Fatalf("failed to get absolute path: %v", err)
+	}
+
+	// The nested package reaches the daemon through the proxy only
+	result, err := Run(ctx, packagePath, WithSocketProxy(SocketProxyTestcontainers...))
+	if err != nil {
+		t.Fatalf("Run() returned error: %v", err)
+	}
+	if result.ExitCode != 0 {
+		t.Errorf("expected exit code 0, got %d", result.ExitCode)
+		t.Logf("stdout:\n%s", result.Stdout)
+	}
+}
+
+func TestRun_NestedTestcontainers_SocketProxyReadOnly(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	packagePath, err := filepath.Abs("testdata/nested")
+	if err != nil {
+		t.Fatalf("failed to get absolute path: %v", err)
+	}
+
+	// Without write access the nested package cannot start its container
+	result, err := Run(ctx, packagePath, WithSocketProxy())
+	if err != nil {
+		t.Fatalf("Run() returned error: %v", err)
+	}
+	if result.ExitCode == 0 {
+		t.Errorf("expected the tests to fail through the read-only proxy")
+		t.Logf("stdout:\n%s", result.Stdout)
+	}
+}
+
+func TestRun_NestedTestcontainers_SockGuard(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	packagePath, err := filepath.Abs("testdata/nested")
+	if err != nil {
+		t.Fatalf("failed to get absolute path: %v", err)
+	}
+
+	// The nested package starts nginx from Docker Hub without the run label
+	_, err = Run(ctx, packagePath, WithVarSock(), WithSockGuard(SockGuard{
+		TrustedRegistries: []string{"registry.example.com"},
+		RequireLabel:      true,
+		Cleanup:           true,
+		Mode:              SockGuardFail,
+	}))
+	var guardErr *SockGuardError
+	if !errors.As(err, &guardErr) {
+		t.Fatalf("expected a SockGuardError, got %v", err)
+	}
+	if !slices.ContainsFunc(guardErr.Violations, func(v string) bool { return strings.Contains(v, "created without the label") }) {
+		t.Errorf("expected an unlabelled container, got %v", guardErr.Violations)
+	}
+}
+
 func TestRun_NestedTestcontainers(t *testing.T) {
 	t.Parallel()
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
@@ -370,3 +981,124 @@ func TestRun_CustomDockerfile_VerifyMarker(t *testing.T) {
 		t.Log("marker file found - custom Dockerfile was used successfully")
 	}
 }
+
+// TestRun_CancelAbortsBuild verifies that canceling the context aborts an
+// ongoing image build instead of waiting for it to finish.
+func TestRun_CancelAbortsBuild(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	packagePath, err := filepath.Abs("testdata/simple")
+	if err != nil {
+		t.Fatalf("failed to get absolute path: %v", err)
+	}
+	dockerfile := filepath.Join(t.TempDir(), "slow.Dockerfile")
+	if err := os.WriteFile(dockerfile, []byte("FROM golang:1.25-alpine\nRUN sleep 600\n"), 0644); err != nil {
+		t.Fatalf("failed to write Dockerfile: %v", err)
+	}
+
+	time.AfterFunc(10*time.Second, cancel)
+	start := time.Now()
+	_, err = Run(ctx, packagePath, WithDockerfilePath(dockerfile))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Minute {
+		t.Errorf("expected the build to be aborted, Run returned after %v", elapsed)
+	}
+}
+
+func TestRunT_SimplePackage(t *testing.T) {
+	t.Parallel()
+
+	packagePath, err := filepath.Abs("testdata/simple")
+	if err != nil {
+		t.Fatalf("failed to get absolute path: %v", err)
+	}
+
+	result := RunT(t, packagePath)
+	if len(result.Coverage) == 0 {
+		t.Error("expected coverage to be non-empty")
+	}
+}
+
+func TestRunAllModules(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	root := t.TempDir()
+	for _, dir := range []string{"a", "b"} {
+		if err := os.CopyFS(filepath.Join(root, dir), os.DirFS("testdata/simple")); err != nil {
+			t.Fatalf("failed to copy module: %v", err)
+		}
+	}
+
+	result, err := RunAllModules(ctx, root, WithParallelModules(2))
+	if err != nil {
+		t.Fatalf("RunAllModules() returned error: %v", err)
+	}
+	if len(result.Modules) != 2 || !result.Passed() {
+		t.Fatalf("expected 2 passing modules, got %+v", result.Modules)
+	}
+	if result.Modules[0].Result.Name != "a" {
+		t.Errorf("expected run name %q, got %q", "a", result.Modules[0].Result.Name)
+	}
+	if len(result.Coverage) == 0 || result.CoveragePercent == 0 {
+		t.Errorf("expected merged coverage, got %.1f%%", result.CoveragePercent)
+	}
+}
+
+func TestRunAllModules_Outputs(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	root := t.TempDir()
+	for _, dir := range []string{"a", "b"} {
+		if err := os.CopyFS(filepath.Join(root, dir), os.DirFS("testdata/simple")); err != nil {
+			t.Fatalf("failed to copy module: %v", err)
+		}
+		goMod := "module example.com/" + dir + "\n\ngo 1.25.6\n"
+		if err := os.WriteFile(filepath.Join(root, dir, "go.mod"), []byte(goMod), 0644); err != nil {
+			t.Fatalf("failed to write go.mod: %v", err)
+		}
+	}
+
+	out := t.TempDir()
+	coverageOutput := filepath.Join(out, "coverage.out")
+	artifactsDir := filepath.Join(out, "artifacts")
+	result, err := RunAllModules(ctx, root,
+		WithCoverageOutput(coverageOutput),
+		WithArtifacts("/app/go.mod"),
+		WithArtifactsDir(artifactsDir),
+	)
+	if err != nil {
+		t.Fatalf("RunAllModules() returned error: %v", err)
+	}
+	if !result.Passed() {
+		t.Fatalf("expected the modules to pass, got %+v", result.Modules)
+	}
+
+	// Both modules end up in the coverage file rather than the last one
+	coverage, err := os.ReadFile(coverageOutput)
+	if err != nil {
+		t.Fatalf("failed to read coverage output: %v", err)
+	}
+	if !bytes.Equal(coverage, result.Coverage) {
+		t.Errorf("expected the merged coverage in %s, got:\n%s", coverageOutput, coverage)
+	}
+	for _, dir := range []string{"a", "b"} {
+		if !strings.Contains(string(coverage), "example.com/"+dir+"/") {
+			t.Errorf("expected the coverage of module %s, got:\n%s", dir, coverage)
+		}
+		goMod, err := os.ReadFile(filepath.Join(artifactsDir, dir, "app", "go.mod"))
+		if err != nil {
+			t.Fatalf("failed to read the artifact of module %s: %v", dir, err)
+		}
+		if !strings.HasPrefix(string(goMod), "module example.com/"+dir+"\n") {
+			t.Errorf("expected the go.mod of module %s, got %q", dir, goMod)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package dockertesting
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// junitTestSuites is the root element of a JUnit XML report.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+	SystemOut string          `xml:"system-out,omitempty"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Output  string `xml:",chardata"`
+}
+
+// ConvertToJUnit converts the output of `go test -json` to a JUnit XML report,
+// the format understood by Jenkins, GitLab, GitHub Actions reporters and most
+// other CI systems. Run the tests with WithArgs("-json") to produce it.
+//
+// Each package becomes a test suite and each test, including subtests, a test
+// case with the output of the test attached to its failure. A package that
+// fails without a failed test, e.g. because it does not compile, is reported
+// as a failed test case named after the package. Lines that are not JSON
+// events are ignored; an error is returned if there are no events at all.
+//
+// Example:
+//
+//	report, err := dockertesting.ConvertToJUnit(result.Stdout)
+func ConvertToJUnit(output []byte) ([]byte, error) {
+	events := parseTestEvents(output)
+	if len(events) == 0 {
+		return nil, fmt.Errorf("no go test -json events in output")
+	}
+
+	type testState struct {
+		testCase junitTestCase
+		output   strings.Builder
+	}
+	type packageState struct {
+		suite   junitTestSuite
+		tests   map[string]*testState
+		order   []string
+		output  strings.Builder
+		failed  bool
+		elapsed float64
+	}
+	packages := make(map[string]*packageState)
+	var packageOrder []string
+
+	for _, event := range events {
+		pkg, ok := packages[event.Package]
+		if !ok {
+			pkg = &packageState{
+				suite: junitTestSuite{Name: event.Package},
+				tests: make(map[string]*testState),
+			}
+			if !event.Time.IsZero() {
+				pkg.suite.Timestamp = event.Time.UTC().Format("2006-01-02T15:04:05")
+			}
+			packages[event.Package] = pkg
+			packageOrder = append(packageOrder, event.Package)
+		}
+
+		if event.Test == "" {
+			switch event.Action {
+			case "output":
+				pkg.output.WriteString(event.Output)
+			case "pass", "fail", "skip":
+				pkg.failed = event.Action == "fail"
+				pkg.elapsed = event.Elapsed
+			}
+			continue
+		}
+
+		test, ok := pkg.tests[event.Test]
+		if !ok {
+			test = &testState{testCase: junitTestCase{
+				Name:      event.Test,
+				Classname: event.Package,
+				Time:      junitSeconds(0),
+			}}
+			pkg.tests[event.Test] = test
+			pkg.order = append(pkg.order, event.Test)
+		}
+		switch event.Action {
+		case "output":
+			test.output.WriteString(event.Output)
+		case "pass":
+			test.testCase.Time = junitSeconds(event.Elapsed)
+		case "fail":
+			test.testCase.Time = junitSeconds(event.Elapsed)
+			test.testCase.Failure = &junitMessage{Message: "Failed", Output: test.output.String()}
+		case "skip":
+			test.testCase.Time = junitSeconds(event.Elapsed)
+			test.testCase.Skipped = &junitMessage{Message: "Skipped", Output: test.output.String()}
+		}
+	}
+
+	report := junitTestSuites{}
+	var total float64
+	for _, name := range packageOrder {
+		pkg := packages[name]
+		suite := pkg.suite
+		for _, testName := range pkg.order {
+			testCase := pkg.tests[testName].testCase
+			suite.Cases = append(suite.Cases, testCase)
+			switch {
+			case testCase.Failure != nil:
+				suite.Failures++
+			case testCase.Skipped != nil:
+				suite.Skipped++
+			}
+		}
+		if pkg.failed && suite.Failures == 0 {
+			// Report packages failing outside of a test, e.g. build errors
+			suite.Cases = append(suite.Cases, junitTestCase{
+				Name:      name,
+				Classname: name,
+				Time:      junitSeconds(pkg.elapsed),
+				Failure:   &junitMessage{Message: "Failed", Output: pkg.output.String()},
+			})
+			suite.Failures++
+		}
+		suite.Tests = len(suite.Cases)
+		suite.Time = junitSeconds(pkg.elapsed)
+		suite.SystemOut = pkg.output.String()
+
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+		total += pkg.elapsed
+		report.Suites = append(report.Suites, suite)
+	}
+	report.Time = junitSeconds(total)
+
+	out, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JUnit report: %w", err)
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
+
+// junitSeconds formats a duration in seconds for JUnit time attributes.
+func junitSeconds(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', 3, 64)
+}
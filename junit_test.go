@@ -0,0 +1,87 @@
+package dockertesting
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestConvertToJUnit(t *testing.T) {
+	t.Parallel()
+	output := sampleJSONOutput +
+		`{"Time":"2025-01-01T00:00:03Z","Action":"run","Package":"example.com/other","Test":"TestSkip"}
+{"Time":"2025-01-01T00:00:03Z","Action":"output","Package":"example.com/other","Test":"TestSkip","Output":"    skip_test.go:5: not on CI\n"}
+{"Time":"2025-01-01T00:00:03Z","Action":"skip","Package":"example.com/other","Test":"TestSkip","Elapsed":0}
+{"Time":"2025-01-01T00:00:03Z","Action":"pass","Package":"example.com/other","Elapsed":0.2}
+`
+
+	out, err := ConvertToJUnit([]byte(output))
+	if err != nil {
+		t.Fatalf("ConvertToJUnit failed: %v", err)
+	}
+
+	var report junitTestSuites
+	if err := xml.Unmarshal(out, &report); err != nil {
+		t.Fatalf("failed to parse generated XML: %v\n%s", err, out)
+	}
+	if report.Tests != 4 || report.Failures != 2 || report.Skipped != 1 {
+		t.Errorf("expected 4 tests, 2 failures and 1 skipped, got %d, %d and %d", report.Tests, report.Failures, report.Skipped)
+	}
+	if len(report.Suites) != 2 {
+		t.Fatalf("expected 2 suites, got %d", len(report.Suites))
+	}
+
+	suite := report.Suites[0]
+	if suite.Name != "example.com/pkg" || suite.Time != "0.100" {
+		t.Errorf("unexpected suite %q with time %q", suite.Name, suite.Time)
+	}
+	names := make([]string, 0, len(suite.Cases))
+	for _, testCase := range suite.Cases {
+		names = append(names, testCase.Name)
+	}
+	if got := strings.Join(names, ","); got != "TestAdd,TestDiv,TestDiv/by_zero" {
+		t.Errorf("unexpected test cases %q", got)
+	}
+	if suite.Cases[0].Failure != nil || suite.Cases[0].Time != "0.010" {
+		t.Errorf("expected TestAdd to pass in 0.010s, got %+v", suite.Cases[0])
+	}
+	if suite.Cases[2].Failure == nil {
+		t.Error("expected TestDiv/by_zero to fail")
+	}
+
+	skipped := report.Suites[1].Cases[0]
+	if skipped.Skipped == nil || !strings.Contains(skipped.Skipped.Output, "not on CI") {
+		t.Errorf("expected TestSkip to be skipped with its output, got %+v", skipped)
+	}
+}
+
+func TestConvertToJUnit_PackageFailure(t *testing.T) {
+	t.Parallel()
+	output := `{"Action":"output","Package":"example.com/broken","Output":"broken.go:3:1: syntax error\n"}
+{"Action":"fail","Package":"example.com/broken","Elapsed":0}
+`
+
+	out, err := ConvertToJUnit([]byte(output))
+	if err != nil {
+		t.Fatalf("ConvertToJUnit failed: %v", err)
+	}
+
+	var report junitTestSuites
+	if err := xml.Unmarshal(out, &report); err != nil {
+		t.Fatalf("failed to parse generated XML: %v\n%s", err, out)
+	}
+	if report.Failures != 1 {
+		t.Fatalf("expected 1 failure, got %d", report.Failures)
+	}
+	failure := report.Suites[0].Cases[0].Failure
+	if failure == nil || !strings.Contains(failure.Output, "syntax error") {
+		t.Errorf("expected the package output in the failure, got %+v", failure)
+	}
+}
+
+func TestConvertToJUnit_NoEvents(t *testing.T) {
+	t.Parallel()
+	if _, err := ConvertToJUnit([]byte("ok  \texample.com/pkg\t0.1s\n")); err == nil {
+		t.Error("expected error for output without events")
+	}
+}
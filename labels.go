@@ -0,0 +1,138 @@
+package dockertesting
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/google/uuid"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+const (
+	// LabelManaged marks the images, containers, networks and volumes
+	// created by dockertesting.
+	LabelManaged = "dockertesting.managed"
+
+	// LabelHost is the host name of the process that created a resource.
+	LabelHost = "dockertesting.host"
+
+	// LabelPID is the ID of the process that created a resource.
+	LabelPID = "dockertesting.pid"
+
+	// LabelRunID identifies the run that created a resource. All resources
+	// of one Run, Runner or Warmup share it.
+	LabelRunID = "dockertesting.run-id"
+
+	// LabelPackage identifies the package under test of the run that created
+	// a resource, by a hash of its absolute path.
+	LabelPackage = "dockertesting.package"
+
+	// LabelCreated is the time the run that created a resource started, in
+	// RFC 3339 format.
+	LabelCreated = "dockertesting.created"
+
+	// LabelName is the name of the run that created a resource, see WithName.
+	// It is only set for named runs.
+	LabelName = "dockertesting.name"
+)
+
+// testcontainersLabelPrefix is the prefix of the labels owned by
+// testcontainers, such as the reaper session label.
+const testcontainersLabelPrefix = "org.testcontainers"
+
+// processLabels are the labels identifying the current process.
+var processLabels = sync.OnceValue(func() map[string]string {
+	// Non-fatal: without a host name, CleanupStale treats the resources as
+	// created on another host and leaves them alone
+	host, _ := os.Hostname()
+	return map[string]string{
+		LabelManaged: "true",
+		LabelHost:    host,
+		LabelPID:     strconv.Itoa(os.Getpid()),
+	}
+})
+
+// resourceLabels returns the labels of the resources created by this process.
+func resourceLabels() map[string]string {
+	return maps.Clone(processLabels())
+}
+
+// runLabels returns the labels that a run adds to the resources it creates
+// according to options: a new run ID, the package hash, the start time and,
+// if set, the reaper session.
+func runLabels(options *Options) map[string]string {
+	labels := map[string]string{
+		LabelRunID:   uuid.NewString(),
+		LabelPackage: packageHash(options.PackagePath),
+		LabelCreated: time.Now().UTC().Format(time.RFC3339),
+	}
+	if options.Name != "" {
+		labels[LabelName] = options.Name
+	}
+	if options.ReaperSessionID != "" {
+		labels[reaperSessionLabel] = options.ReaperSessionID
+	}
+	return labels
+}
+
+// packageHash returns a short hash identifying the package at packagePath by
+// its absolute path.
+func packageHash(packagePath string) string {
+	if abs, err := filepath.Abs(packagePath); err == nil {
+		packagePath = abs
+	}
+	sum := sha256.Sum256([]byte(packagePath))
+	return hex.EncodeToString(sum[:6])
+}
+
+// ownLabels returns labels without those owned by testcontainers, which it
+// rejects for built images and volumes created along with a container.
+func ownLabels(labels map[string]string) map[string]string {
+	own := make(map[string]string, len(labels))
+	for key, value := range labels {
+		if !strings.HasPrefix(key, testcontainersLabelPrefix) {
+			own[key] = value
+		}
+	}
+	return own
+}
+
+// withLabels adds labels to the container of genReq. Labels owned by
+// testcontainers, i.e. the reaper session, are set on the container
+// configuration instead of the request, as testcontainers overwrites them in
+// the request and rejects them when building an image from it.
+func withLabels(genReq *testcontainers.GenericContainerRequest, labels map[string]string) {
+	if genReq.Labels == nil {
+		genReq.Labels = make(map[string]string)
+	}
+	reserved := make(map[string]string)
+	for key, value := range labels {
+		if strings.HasPrefix(key, testcontainersLabelPrefix) {
+			reserved[key] = value
+		} else {
+			genReq.Labels[key] = value
+		}
+	}
+	if len(reserved) == 0 {
+		return
+	}
+
+	modifier := genReq.ConfigModifier
+	genReq.ConfigModifier = func(cfg *container.Config) {
+		if modifier != nil {
+			modifier(cfg)
+		}
+		if cfg.Labels == nil {
+			cfg.Labels = make(map[string]string)
+		}
+		maps.Copy(cfg.Labels, reserved)
+	}
+}
@@ -0,0 +1,106 @@
+package dockertesting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestRunLabels(t *testing.T) {
+	t.Parallel()
+
+	opts, err := NewOptions("/path/to/package")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	labels := runLabels(opts)
+	if labels[LabelRunID] == "" {
+		t.Errorf("expected %s to be set, got %v", LabelRunID, labels)
+	}
+	if labels[LabelPackage] != packageHash("/path/to/package") {
+		t.Errorf("expected %s=%s, got %v", LabelPackage, packageHash("/path/to/package"), labels)
+	}
+	if _, err := time.Parse(time.RFC3339, labels[LabelCreated]); err != nil {
+		t.Errorf("expected %s in RFC 3339 format, got %q", LabelCreated, labels[LabelCreated])
+	}
+	if _, ok := labels[LabelName]; ok {
+		t.Errorf("expected no %s by default, got %v", LabelName, labels)
+	}
+	if _, ok := labels[reaperSessionLabel]; ok {
+		t.Errorf("expected no %s by default, got %v", reaperSessionLabel, labels)
+	}
+	if other := runLabels(opts); other[LabelRunID] == labels[LabelRunID] {
+		t.Errorf("expected a new run ID per run, got %q twice", labels[LabelRunID])
+	}
+
+	opts, err = NewOptions("/path/to/package", WithReaperSessionID("job-42"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if labels := runLabels(opts); labels[reaperSessionLabel] != "job-42" {
+		t.Errorf("expected %s=job-42, got %v", reaperSessionLabel, labels)
+	}
+
+	opts, err = NewOptions("/path/to/package", WithName("payments-integration"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if labels := runLabels(opts); labels[LabelName] != "payments-integration" {
+		t.Errorf("expected %s=payments-integration, got %v", LabelName, labels)
+	}
+}
+
+func TestPackageHash(t *testing.T) {
+	t.Parallel()
+
+	hash := packageHash("/path/to/package")
+	if len(hash) != 12 {
+		t.Errorf("expected a 12 character hash, got %q", hash)
+	}
+	if packageHash("/path/to/other") == hash {
+		t.Errorf("expected different packages to have different hashes, got %q", hash)
+	}
+	if packageHash("/path/to/package/") != hash {
+		t.Errorf("expected equivalent paths to have the same hash, got %q and %q", packageHash("/path/to/package/"), hash)
+	}
+}
+
+func TestWithLabels(t *testing.T) {
+	t.Parallel()
+
+	genReq := testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{Labels: resourceLabels()},
+	}
+	withLabels(&genReq, map[string]string{
+		LabelRunID:         "run-1",
+		reaperSessionLabel: "job-42",
+	})
+
+	if genReq.Labels[LabelRunID] != "run-1" {
+		t.Errorf("expected %s=run-1 on the request, got %v", LabelRunID, genReq.Labels)
+	}
+	if genReq.Labels[LabelManaged] != "true" {
+		t.Errorf("expected %s to be kept, got %v", LabelManaged, genReq.Labels)
+	}
+	if _, ok := genReq.Labels[reaperSessionLabel]; ok {
+		t.Errorf("expected %s not to be on the request, got %v", reaperSessionLabel, genReq.Labels)
+	}
+
+	// testcontainers overwrites the session label of the request
+	cfg := &container.Config{Labels: map[string]string{reaperSessionLabel: "process"}}
+	genReq.ConfigModifier(cfg)
+	if cfg.Labels[reaperSessionLabel] != "job-42" {
+		t.Errorf("expected %s=job-42 on the container config, got %v", reaperSessionLabel, cfg.Labels)
+	}
+}
+
+func TestOwnLabels(t *testing.T) {
+	t.Parallel()
+
+	own := ownLabels(map[string]string{LabelRunID: "run-1", reaperSessionLabel: "job-42"})
+	if len(own) != 1 || own[LabelRunID] != "run-1" {
+		t.Errorf("expected only %s, got %v", LabelRunID, own)
+	}
+}
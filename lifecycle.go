@@ -0,0 +1,82 @@
+package dockertesting
+
+import "context"
+
+// HookEvent describes the state of a run when a hook is invoked. Fields that
+// are not known yet at the time of the hook are left empty.
+type HookEvent struct {
+	// Name is the name of the run, see WithName.
+	Name string
+
+	// PackagePath is the path of the package under test.
+	PackagePath string
+
+	// ImageTag is the image of the test container, either the image being
+	// built or the image reused from WithImageCache.
+	ImageTag string
+
+	// ContainerID is the ID of the test container, set from
+	// OnContainerStarted on.
+	ContainerID string
+
+	// Result is the result of the tests, set for OnTestsFinished and for
+	// OnCleanup after tests ran. It is nil if the tests could not be executed.
+	Result *Result
+
+	// Err is the error of the run for OnTestsFinished and the error removing
+	// the resources for OnCleanup.
+	Err error
+
+	// Kept reports whether OnCleanup left the resources running, see
+	// WithKeepResources and WithKeepOnFailure.
+	Kept bool
+}
+
+// Hooks are functions invoked at the milestones of a run, e.g. for sending
+// notifications, uploading artifacts or custom bookkeeping. Nil hooks are
+// skipped. Hooks are invoked synchronously, so a slow hook delays the run.
+type Hooks struct {
+	// OnBuildStart is invoked before the test image is built. It is not
+	// invoked if WithImageCache reuses an image.
+	OnBuildStart func(ctx context.Context, event HookEvent)
+
+	// OnContainerStarted is invoked once the test container is running,
+	// before the setup commands.
+	OnContainerStarted func(ctx context.Context, event HookEvent)
+
+	// OnTestsFinished is invoked after every go test invocation of Run and
+	// Runner.Test, with the Result or the error.
+	OnTestsFinished func(ctx context.Context, event HookEvent)
+
+	// OnCleanup is invoked after the resources of the run were removed or
+	// kept, with the Result of the last go test invocation.
+	OnCleanup func(ctx context.Context, event HookEvent)
+}
+
+// runHooks returns the hooks of a run, which fill in the name and package of
+// the run in the events.
+func runHooks(options *Options) Hooks {
+	wrap := func(hook func(context.Context, HookEvent)) func(context.Context, HookEvent) {
+		if hook == nil {
+			return nil
+		}
+		return func(ctx context.Context, event HookEvent) {
+			event.Name = options.Name
+			event.PackagePath = options.PackagePath
+			hook(ctx, event)
+		}
+	}
+	return Hooks{
+		OnBuildStart:       wrap(options.Hooks.OnBuildStart),
+		OnContainerStarted: wrap(options.Hooks.OnContainerStarted),
+		OnTestsFinished:    wrap(options.Hooks.OnTestsFinished),
+		OnCleanup:          wrap(options.Hooks.OnCleanup),
+	}
+}
+
+// invokeHook calls hook with event if it is non-nil.
+func invokeHook(ctx context.Context, hook func(context.Context, HookEvent), event HookEvent) {
+	if hook != nil {
+		hook(ctx, event)
+	}
+}
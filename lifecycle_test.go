@@ -0,0 +1,62 @@
+package dockertesting
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestRunHooks(t *testing.T) {
+	t.Parallel()
+	var events []HookEvent
+	opts, err := NewOptions("/path/to/package", WithName("payments"), WithHooks(Hooks{
+		OnTestsFinished: func(ctx context.Context, event HookEvent) {
+			events = append(events, event)
+		},
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	hooks := runHooks(opts)
+	if hooks.OnBuildStart != nil || hooks.OnContainerStarted != nil || hooks.OnCleanup != nil {
+		t.Error("expected unset hooks to stay nil")
+	}
+	invokeHook(context.Background(), hooks.OnBuildStart, HookEvent{})
+	invokeHook(context.Background(), hooks.OnTestsFinished, HookEvent{ContainerID: "abc", Err: errors.New("boom")})
+
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	event := events[0]
+	if event.Name != "payments" || event.PackagePath != "/path/to/package" {
+		t.Errorf("expected the run in the event, got name %q and package %q", event.Name, event.PackagePath)
+	}
+	if event.ContainerID != "abc" || event.Err == nil {
+		t.Errorf("expected the event fields to be kept, got %+v", event)
+	}
+}
+
+func TestImageBuild_Ref(t *testing.T) {
+	t.Parallel()
+	built := &imageBuild{fromDockerfile: testcontainers.FromDockerfile{Repo: "repo", Tag: "tag"}}
+	if got := built.ref(); got != "repo:tag" {
+		t.Errorf("expected ref %q, got %q", "repo:tag", got)
+	}
+
+	cached := &imageBuild{image: imageCacheRef("abc")}
+	if got := cached.ref(); got != ImageCacheRepository+":abc" {
+		t.Errorf("expected ref %q, got %q", ImageCacheRepository+":abc", got)
+	}
+}
+
+func TestRunner_HookEventWithoutContainer(t *testing.T) {
+	t.Parallel()
+	result := &Result{ExitCode: 1}
+	event := (&Runner{}).hookEvent(result, nil)
+	if event.Result != result || event.ContainerID != "" || event.ImageTag != "" {
+		t.Errorf("unexpected event %+v", event)
+	}
+}
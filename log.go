@@ -0,0 +1,69 @@
+package dockertesting
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// discardLogger is the logger of runs without WithLogger.
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// runLogger returns the logger of a run, with the package and the name of the
+// run as attributes, or discardLogger if none is configured.
+func runLogger(options *Options) *slog.Logger {
+	if options.Logger == nil {
+		return discardLogger
+	}
+	logger := options.Logger.With("package", options.PackagePath)
+	if options.Name != "" {
+		logger = logger.With("run", options.Name)
+	}
+	return logger
+}
+
+// orDiscard returns logger, or discardLogger if it is nil.
+func orDiscard(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return discardLogger
+	}
+	return logger
+}
+
+// newLayerCacheWriter returns a lineWriter that logs the build steps whose
+// layer was taken from the cache of the classic builder at debug level.
+func newLayerCacheWriter(logger *slog.Logger) *lineWriter {
+	var step string
+	return newLineWriter(OutputSourceBuild, func(line OutputLine) {
+		if match := buildStepPattern.FindStringSubmatch(line.Text); match != nil {
+			step = match[3]
+			return
+		}
+		if strings.TrimSpace(line.Text) == "---> Using cache" {
+			logger.Debug("layer cache hit", "step", step)
+		}
+	})
+}
+
+// logTests logs the outcome of a go test invocation that took duration.
+func logTests(logger *slog.Logger, result *Result, err error, duration time.Duration) {
+	if err != nil {
+		logger.Info("tests aborted", "duration", duration, "error", err)
+		return
+	}
+	logger.Info("tests finished", "exit_code", result.ExitCode, "duration", duration)
+	if len(result.Races) > 0 {
+		logger.Warn("data races detected", "count", len(result.Races))
+	}
+	for _, crash := range result.Crashes {
+		logger.Warn("test crashed", "package", crash.Package, "test", crash.Test, "kind", crash.Kind, "message", crash.Message)
+	}
+	if len(result.Skipped) > 0 {
+		tests := make([]string, len(result.Skipped))
+		for i, skipped := range result.Skipped {
+			tests[i] = skipped.Test
+		}
+		logger.Warn("tests skipped", "count", len(result.Skipped), "tests", tests)
+	}
+	logger.Debug("coverage collected", "bytes", len(result.Coverage), "percent", result.CoveragePercent)
+}
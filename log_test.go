@@ -0,0 +1,76 @@
+package dockertesting
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestLogger returns a logger writing text records of all levels to buf.
+func newTestLogger(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+func TestRunLogger(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if runLogger(opts) != discardLogger {
+		t.Error("expected the discard logger without WithLogger")
+	}
+
+	var buf bytes.Buffer
+	opts, err = NewOptions("/path/to/package", WithLogger(newTestLogger(&buf)), WithName("payments"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	runLogger(opts).Info("network created")
+	for _, want := range []string{"msg=\"network created\"", "package=/path/to/package", "run=payments"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected record to contain %q, got %q", want, buf.String())
+		}
+	}
+}
+
+func TestLayerCacheWriter(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	w := newLayerCacheWriter(newTestLogger(&buf))
+
+	fmt.Fprint(w, "Step 1/3 : FROM golang:1.25\n ---> 1234abcd\n")
+	fmt.Fprint(w, "Step 2/3 : COPY go.mod go.sum ./\n ---> Using cache\n ---> 5678ef01\n")
+	fmt.Fprint(w, "Step 3/3 : COPY . .\n ---> 9abc2345\n")
+
+	records := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(records) != 1 {
+		t.Fatalf("expected 1 cache hit, got %q", buf.String())
+	}
+	if !strings.Contains(records[0], `step="COPY go.mod go.sum ./"`) {
+		t.Errorf("expected the cached step in the record, got %q", records[0])
+	}
+}
+
+func TestLogTests(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	logger := newTestLogger(&buf)
+
+	logTests(logger, &Result{ExitCode: 1, Coverage: []byte("mode: set\n"), Skipped: []SkippedTest{{Test: "TestDocker"}}, Races: []RaceReport{{Test: "TestRace"}}, Crashes: []Crash{{Test: "TestPanic", Kind: CrashPanic, Message: "panic: boom"}}}, nil, 2*time.Second)
+	for _, want := range []string{`msg="tests finished" exit_code=1 duration=2s`, `msg="coverage collected" bytes=10`, `msg="tests skipped" count=1 tests=[TestDocker]`, `msg="data races detected" count=1`, `msg="test crashed" package="" test=TestPanic kind=panic message="panic: boom"`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected records to contain %q, got %q", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	logTests(logger, nil, errors.New("boom"), time.Second)
+	if !strings.Contains(buf.String(), `msg="tests aborted" duration=1s error=boom`) {
+		t.Errorf("expected an aborted record, got %q", buf.String())
+	}
+}
@@ -0,0 +1,171 @@
+package dockertesting
+
+import (
+	"errors"
+	"expvar"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Names of the metrics recorded through Metrics. Durations are in seconds and
+// sizes in bytes.
+const (
+	// MetricRuns counts the Run calls by outcome: "passed", "failed" for
+	// test failures, and "error" with the phase of the RunError otherwise.
+	MetricRuns = "dockertesting_runs_total"
+
+	// MetricBuildDuration is the duration of the image builds.
+	MetricBuildDuration = "dockertesting_build_duration_seconds"
+
+	// MetricTestDuration is the duration of the go test invocations of Run
+	// and Runner.Test.
+	MetricTestDuration = "dockertesting_test_duration_seconds"
+
+	// MetricContextSize is the size of the build context archives.
+	MetricContextSize = "dockertesting_context_size_bytes"
+
+	// MetricImageCache counts the lookups of WithImageCache by result, "hit"
+	// or "miss", from which the cache hit rate follows.
+	MetricImageCache = "dockertesting_image_cache_lookups_total"
+)
+
+// Metrics receives the statistics of runs, so that platform teams can track
+// the health of containerized tests fleet-wide, e.g. by forwarding them to
+// Prometheus or OpenTelemetry. See the Metric constants for the recorded
+// metrics. Named runs (see WithName) add a "name" label.
+//
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// Count adds delta to the counter name.
+	Count(name string, delta float64, labels map[string]string)
+
+	// Observe records value in the histogram name.
+	Observe(name string, value float64, labels map[string]string)
+}
+
+// labeledMetrics adds labels to all metrics recorded through it.
+type labeledMetrics struct {
+	metrics Metrics
+	labels  map[string]string
+}
+
+// runMetrics returns the Metrics of a run, with the labels identifying it, or
+// nil if none are configured.
+func runMetrics(options *Options) Metrics {
+	if options.Metrics == nil {
+		return nil
+	}
+	labels := make(map[string]string)
+	if options.Name != "" {
+		labels["name"] = options.Name
+	}
+	return &labeledMetrics{metrics: options.Metrics, labels: labels}
+}
+
+func (m *labeledMetrics) Count(name string, delta float64, labels map[string]string) {
+	m.metrics.Count(name, delta, m.with(labels))
+}
+
+func (m *labeledMetrics) Observe(name string, value float64, labels map[string]string) {
+	m.metrics.Observe(name, value, m.with(labels))
+}
+
+// with returns the labels of m merged with labels.
+func (m *labeledMetrics) with(labels map[string]string) map[string]string {
+	merged := maps.Clone(m.labels)
+	maps.Copy(merged, labels)
+	return merged
+}
+
+// countMetric adds 1 to the counter name if metrics is non-nil.
+func countMetric(metrics Metrics, name string, labels map[string]string) {
+	if metrics != nil {
+		metrics.Count(name, 1, labels)
+	}
+}
+
+// observeMetric records value in the histogram name if metrics is non-nil.
+func observeMetric(metrics Metrics, name string, value float64) {
+	if metrics != nil {
+		metrics.Observe(name, value, nil)
+	}
+}
+
+// recordRun counts the outcome of a Run call in MetricRuns.
+func recordRun(metrics Metrics, res *Result, err error) {
+	var runErr *RunError
+	switch {
+	case errors.As(err, &runErr):
+		countMetric(metrics, MetricRuns, map[string]string{"outcome": "error", "phase": string(runErr.Phase)})
+	case err != nil:
+		countMetric(metrics, MetricRuns, map[string]string{"outcome": "error"})
+	case res.ExitCode != 0:
+		countMetric(metrics, MetricRuns, map[string]string{"outcome": "failed"})
+	default:
+		countMetric(metrics, MetricRuns, map[string]string{"outcome": "passed"})
+	}
+}
+
+// ExpvarMetrics is a Metrics publishing the metrics as an expvar.Map, e.g. on
+// /debug/vars. Counters are published by name with their labels in Prometheus
+// notation, e.g. `dockertesting_runs_total{outcome="passed"}`, and histograms
+// as the _count and _sum of the observed values.
+type ExpvarMetrics struct {
+	vars *expvar.Map
+	mu   sync.Mutex
+}
+
+// NewExpvarMetrics returns an ExpvarMetrics publishing the metrics under
+// name. If an expvar.Map is already published under name, it is reused, so
+// several calls with the same name share the metrics.
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	vars, ok := expvar.Get(name).(*expvar.Map)
+	if !ok {
+		vars = expvar.NewMap(name)
+	}
+	return &ExpvarMetrics{vars: vars}
+}
+
+// Count adds delta to the counter name.
+func (m *ExpvarMetrics) Count(name string, delta float64, labels map[string]string) {
+	m.float(name + formatMetricLabels(labels)).Add(delta)
+}
+
+// Observe records value in the histogram name.
+func (m *ExpvarMetrics) Observe(name string, value float64, labels map[string]string) {
+	suffix := formatMetricLabels(labels)
+	m.float(name + "_count" + suffix).Add(1)
+	m.float(name + "_sum" + suffix).Add(value)
+}
+
+// Vars returns the published expvar.Map.
+func (m *ExpvarMetrics) Vars() *expvar.Map {
+	return m.vars
+}
+
+// float returns the expvar.Float of key, creating it if needed.
+func (m *ExpvarMetrics) float(key string) *expvar.Float {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if v, ok := m.vars.Get(key).(*expvar.Float); ok {
+		return v
+	}
+	v := new(expvar.Float)
+	m.vars.Set(key, v)
+	return v
+}
+
+// formatMetricLabels formats labels in Prometheus notation, sorted by name,
+// or returns an empty string if there are none.
+func formatMetricLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(labels))
+	for _, name := range slices.Sorted(maps.Keys(labels)) {
+		pairs = append(pairs, name+`="`+labels[name]+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
@@ -0,0 +1,108 @@
+package dockertesting
+
+import (
+	"errors"
+	"expvar"
+	"sync"
+	"testing"
+)
+
+// recordedMetrics is a Metrics recording the counters and histograms by name
+// and formatted labels.
+type recordedMetrics struct {
+	mu     sync.Mutex
+	counts map[string]float64
+	values map[string][]float64
+}
+
+func newRecordedMetrics() *recordedMetrics {
+	return &recordedMetrics{counts: make(map[string]float64), values: make(map[string][]float64)}
+}
+
+func (m *recordedMetrics) Count(name string, delta float64, labels map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts[name+formatMetricLabels(labels)] += delta
+}
+
+func (m *recordedMetrics) Observe(name string, value float64, labels map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := name + formatMetricLabels(labels)
+	m.values[key] = append(m.values[key], value)
+}
+
+func TestRunMetrics(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if runMetrics(opts) != nil {
+		t.Error("expected no metrics without WithMetrics")
+	}
+
+	recorded := newRecordedMetrics()
+	opts, err = NewOptions("/path/to/package", WithMetrics(recorded), WithName("payments"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	metrics := runMetrics(opts)
+	observeMetric(metrics, MetricTestDuration, 1.5)
+	countMetric(metrics, MetricImageCache, map[string]string{"result": "hit"})
+
+	if got := recorded.values[MetricTestDuration+`{name="payments"}`]; len(got) != 1 || got[0] != 1.5 {
+		t.Errorf("expected one named test duration of 1.5, got %v", recorded.values)
+	}
+	if got := recorded.counts[MetricImageCache+`{name="payments",result="hit"}`]; got != 1 {
+		t.Errorf("expected one named image cache hit, got %v", recorded.counts)
+	}
+}
+
+func TestRecordRun(t *testing.T) {
+	t.Parallel()
+	recorded := newRecordedMetrics()
+
+	recordRun(recorded, &Result{}, nil)
+	recordRun(recorded, &Result{ExitCode: 1}, nil)
+	recordRun(recorded, nil, &RunError{Phase: PhaseBuild, Err: errors.New("boom")})
+	recordRun(recorded, nil, errors.New("boom"))
+
+	for _, key := range []string{
+		MetricRuns + `{outcome="passed"}`,
+		MetricRuns + `{outcome="failed"}`,
+		MetricRuns + `{outcome="error",phase="build"}`,
+		MetricRuns + `{outcome="error"}`,
+	} {
+		if recorded.counts[key] != 1 {
+			t.Errorf("expected %s to be 1, got %v", key, recorded.counts)
+		}
+	}
+	// Without metrics nothing is recorded
+	recordRun(nil, &Result{}, nil)
+}
+
+func TestExpvarMetrics(t *testing.T) {
+	t.Parallel()
+	metrics := NewExpvarMetrics("dockertesting_test_metrics")
+	metrics.Count(MetricRuns, 1, map[string]string{"outcome": "passed"})
+	metrics.Count(MetricRuns, 2, map[string]string{"outcome": "passed"})
+	metrics.Observe(MetricBuildDuration, 3, nil)
+	metrics.Observe(MetricBuildDuration, 5, nil)
+
+	vars := metrics.Vars()
+	if got := vars.Get(MetricRuns + `{outcome="passed"}`).(*expvar.Float).Value(); got != 3 {
+		t.Errorf("expected counter 3, got %v", got)
+	}
+	if got := vars.Get(MetricBuildDuration + "_count").(*expvar.Float).Value(); got != 2 {
+		t.Errorf("expected histogram count 2, got %v", got)
+	}
+	if got := vars.Get(MetricBuildDuration + "_sum").(*expvar.Float).Value(); got != 8 {
+		t.Errorf("expected histogram sum 8, got %v", got)
+	}
+
+	// The published map is shared by name
+	if NewExpvarMetrics("dockertesting_test_metrics").Vars() != vars {
+		t.Error("expected the published map to be reused")
+	}
+}
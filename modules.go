@@ -0,0 +1,226 @@
+package dockertesting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ModuleResult is the outcome of one module of RunAllModules.
+type ModuleResult struct {
+	// Dir is the slash-separated directory of the module relative to the
+	// repository root, "." for the root itself.
+	Dir string
+
+	// Path is the module path from go.mod.
+	Path string
+
+	// Result is the result of Run for the module, nil if it failed before
+	// the tests ran.
+	Result *Result
+
+	// Err is the error of Run for the module.
+	Err error
+}
+
+// Passed reports whether the tests of the module ran and passed.
+func (m ModuleResult) Passed() bool {
+	return m.Err == nil && m.Result != nil && m.Result.ExitCode == 0
+}
+
+// ModulesResult aggregates the results of RunAllModules.
+type ModulesResult struct {
+	// Modules are the results of the modules, sorted by Dir.
+	Modules []ModuleResult
+
+	// Coverage is the merged coverage profile of all modules, see
+	// MergeCoverage.
+	Coverage []byte
+
+	// CoveragePercent is the percentage of statements covered in Coverage.
+	CoveragePercent float64
+}
+
+// Passed reports whether the tests of all modules ran and passed.
+func (r *ModulesResult) Passed() bool {
+	for _, m := range r.Modules {
+		if !m.Passed() {
+			return false
+		}
+	}
+	return true
+}
+
+// Failed returns the modules whose tests failed or did not run.
+func (r *ModulesResult) Failed() []ModuleResult {
+	var failed []ModuleResult
+	for _, m := range r.Modules {
+		if !m.Passed() {
+			failed = append(failed, m)
+		}
+	}
+	return failed
+}
+
+// DiscoverModules returns the slash-separated directories of the Go modules
+// under repoRoot relative to it, sorted, "." for a module at the root. Like
+// the go command, it skips vendor and testdata directories and directories
+// whose names start with "." or "_", as well as DefaultContextExcludes.
+func DiscoverModules(repoRoot string) ([]string, error) {
+	var dirs []string
+	err := fs.WalkDir(os.DirFS(repoRoot), ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			base := path.Base(name)
+			if name != "." && (base == "vendor" || base == "testdata" ||
+				strings.HasPrefix(base, ".") || strings.HasPrefix(base, "_") ||
+				isContextExcluded(name, DefaultContextExcludes)) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if path.Base(name) == "go.mod" {
+			dirs = append(dirs, path.Dir(name))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover modules in %s: %w", repoRoot, err)
+	}
+	slices.Sort(dirs)
+	return dirs, nil
+}
+
+// RunAllModules runs the tests of every Go module under repoRoot, see
+// DiscoverModules, each in its own container as Run does, and aggregates the
+// results and the coverage: the typical CI job of a multi-module repository
+// in one call. opts apply to every module; the runs are named after the
+// module directories, or the repository for a module at its root, prefixed
+// by WithName if set. Modules run one at a time
+// unless WithParallelModules allows more. WithCoverageOutput receives the
+// merged coverage, and WithArtifactsDir the artifacts of each module in a
+// subdirectory named after its directory, e.g. <dir>/api for the module api;
+// the module at the root writes to <dir> itself.
+//
+// The error joins the errors of the modules, each naming its module. The
+// result is returned along with it, so the modules that passed can still be
+// reported; check ModulesResult.Passed for failed tests as well.
+//
+// Example:
+//
+//	result, err := dockertesting.RunAllModules(ctx, ".",
+//	    dockertesting.WithParallelModules(4),
+//	)
+//	if err != nil || !result.Passed() {
+//	    for _, m := range result.Failed() {
+//	        log.Printf("%s failed", m.Dir)
+//	    }
+//	}
+func RunAllModules(ctx context.Context, repoRoot string, opts ...Option) (*ModulesResult, error) {
+	options, err := NewOptions(repoRoot, opts...)
+	if err != nil {
+		return nil, err
+	}
+	// Run validates the other options per module
+	if problem := parallelModulesProblem(options.ParallelModules); problem != "" {
+		return nil, &ValidationError{Problems: []string{problem}}
+	}
+	absRoot, err := filepath.Abs(repoRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path for repository: %w", err)
+	}
+	dirs, err := DiscoverModules(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("no go.mod found in %s", repoRoot)
+	}
+
+	parallel := max(options.ParallelModules, 1)
+	result := &ModulesResult{Modules: make([]ModuleResult, len(dirs))}
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, dir := range dirs {
+		moduleDir := filepath.Join(repoRoot, filepath.FromSlash(dir))
+		// Non-fatal: the module path is only informational
+		modulePath, _ := readModulePath(moduleDir)
+		result.Modules[i] = ModuleResult{Dir: dir, Path: modulePath}
+
+		name := dir
+		if dir == "." {
+			name = filepath.Base(absRoot)
+		}
+		if options.Name != "" {
+			name = path.Join(options.Name, dir)
+		}
+		moduleOpts := moduleOptions(opts, options, dir, name)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				result.Modules[i].Err = ctx.Err()
+				return
+			}
+			result.Modules[i].Result, result.Modules[i].Err = Run(ctx, moduleDir, moduleOpts...)
+		}()
+	}
+	wg.Wait()
+
+	var errs []error
+	var profiles [][]byte
+	for _, m := range result.Modules {
+		if m.Err != nil {
+			errs = append(errs, fmt.Errorf("module %s: %w", m.Dir, m.Err))
+		}
+		if m.Result != nil {
+			profiles = append(profiles, m.Result.Coverage)
+		}
+	}
+	// Non-fatal: modules with different coverage modes leave the merged
+	// coverage empty, their own coverage is still in the results
+	merged, err := MergeCoverage(profiles)
+	if err == nil {
+		result.Coverage = merged
+		result.CoveragePercent, _ = CoveragePercent(merged)
+	}
+	if options.CoverageOutput != "" {
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("failed to write coverage output: %w", err))
+		case merged != nil:
+			if err := writeFileAtomic(options.CoverageOutput, merged, 0644); err != nil {
+				errs = append(errs, fmt.Errorf("failed to write coverage output: %w", err))
+			}
+		}
+	}
+	return result, errors.Join(errs...)
+}
+
+// moduleOptions returns opts for the run of the module in dir, named name.
+// The modules do not write the coverage of WithCoverageOutput, which
+// RunAllModules writes merged, and write the artifacts of WithArtifactsDir to
+// a subdirectory named after dir, so they do not overwrite each other.
+func moduleOptions(opts []Option, options *Options, dir, name string) []Option {
+	moduleOpts := append(slices.Clip(opts), WithName(name))
+	if options.CoverageOutput != "" {
+		moduleOpts = append(moduleOpts, WithCoverageOutput(""))
+	}
+	if options.ArtifactsDir != "" {
+		moduleOpts = append(moduleOpts, WithArtifactsDir(filepath.Join(options.ArtifactsDir, filepath.FromSlash(dir))))
+	}
+	return moduleOpts
+}
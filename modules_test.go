@@ -0,0 +1,106 @@
+package dockertesting
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDiscoverModules(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":                      "module example.com/root",
+		"services/api/go.mod":         "module example.com/api",
+		"services/api/sub/go.mod":     "module example.com/api/sub",
+		"libs/util/go.mod":            "module example.com/util",
+		"libs/util/testdata/go.mod":   "module example.com/fixture",
+		"vendor/example.com/x/go.mod": "module example.com/x",
+		".git/go.mod":                 "module example.com/git",
+		"_old/go.mod":                 "module example.com/old",
+		"node_modules/pkg/go.mod":     "module example.com/pkg",
+		"docs/README.md":              "# docs",
+	})
+
+	dirs, err := DiscoverModules(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{".", "libs/util", "services/api", "services/api/sub"}
+	if !slices.Equal(dirs, expected) {
+		t.Errorf("expected modules %v, got %v", expected, dirs)
+	}
+}
+
+func TestModuleOptions_Outputs(t *testing.T) {
+	t.Parallel()
+	opts := []Option{WithCoverageOutput("reports/coverage.out"), WithArtifactsDir("build/artifacts")}
+	options, err := NewOptions("/repo", opts...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	artifactsDirs := make(map[string]bool)
+	for _, dir := range []string{".", "services/api"} {
+		moduleOpts, err := NewOptions("/repo/"+dir, moduleOptions(opts, options, dir, dir)...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if moduleOpts.CoverageOutput != "" {
+			t.Errorf("module %s: expected the merged coverage to be written instead, got %q", dir, moduleOpts.CoverageOutput)
+		}
+		artifactsDirs[moduleOpts.ArtifactsDir] = true
+	}
+	expected := map[string]bool{"build/artifacts": true, filepath.Join("build/artifacts", "services", "api"): true}
+	if !maps.Equal(artifactsDirs, expected) {
+		t.Errorf("expected artifacts directories %v, got %v", expected, artifactsDirs)
+	}
+	if options.CoverageOutput != "reports/coverage.out" || len(opts) != 2 {
+		t.Errorf("expected the options of the repository to be kept, got %q and %d options", options.CoverageOutput, len(opts))
+	}
+}
+
+func TestRunAllModules_NoModules(t *testing.T) {
+	t.Parallel()
+	_, err := RunAllModules(context.Background(), t.TempDir())
+	if err == nil {
+		t.Fatal("expected error for a tree without go.mod")
+	}
+}
+
+func TestRunAllModules_InvalidParallelism(t *testing.T) {
+	t.Parallel()
+	_, err := RunAllModules(context.Background(), t.TempDir(), WithParallelModules(-1))
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+}
+
+func TestModulesResult_Passed(t *testing.T) {
+	t.Parallel()
+	result := &ModulesResult{Modules: []ModuleResult{
+		{Dir: ".", Result: &Result{ExitCode: 0}},
+		{Dir: "api", Result: &Result{ExitCode: 1}},
+		{Dir: "lib", Err: errors.New("build failed")},
+	}}
+
+	if result.Passed() {
+		t.Error("expected the result not to pass")
+	}
+	var failed []string
+	for _, m := range result.Failed() {
+		failed = append(failed, m.Dir)
+	}
+	if !slices.Equal(failed, []string{"api", "lib"}) {
+		t.Errorf("expected failed modules [api lib], got %v", failed)
+	}
+
+	result.Modules = result.Modules[:1]
+	if !result.Passed() {
+		t.Error("expected the result to pass")
+	}
+}
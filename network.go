@@ -1,9 +1,15 @@
 package dockertesting
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"maps"
+	"net"
+	"net/netip"
 
+	dockernetwork "github.com/docker/docker/api/types/network"
+	"github.com/google/uuid"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/network"
 )
@@ -16,6 +22,18 @@ type DockerNetwork struct {
 
 	// network is the underlying testcontainers network.
 	network *testcontainers.DockerNetwork
+
+	// provider is the provider the network was created with, if any.
+	// Containers attached to the network are created with it as well.
+	provider *testcontainers.DockerProvider
+
+	// extraLabels are added to the labels of the sidecars started on the
+	// network.
+	extraLabels map[string]string
+
+	// remove removes a network not created by testcontainers, whose
+	// network cannot remove it. Nil removes network.
+	remove func(context.Context) error
 }
 
 // CreateNetwork creates a new Docker network using testcontainers-go.
@@ -25,14 +43,94 @@ type DockerNetwork struct {
 // The caller is responsible for cleaning up the network by calling
 // the cleanup function returned, or by calling network.Remove(ctx).
 func CreateNetwork(ctx context.Context) (*DockerNetwork, func(context.Context) error, error) {
-	net, err := network.New(ctx)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create docker network: %w", err)
+	return CreateNetworkWithProvider(ctx, nil)
+}
+
+// CreateNetworkWithProvider is like CreateNetwork, but creates the network
+// through the given provider instead of one configured from the environment.
+// Sidecars started on the returned network use the same provider.
+// A nil provider behaves like CreateNetwork.
+func CreateNetworkWithProvider(ctx context.Context, provider *testcontainers.DockerProvider) (*DockerNetwork, func(context.Context) error, error) {
+	return createNetwork(ctx, provider, nil, networkConfig{})
+}
+
+// networkConfig configures the network created for a run.
+type networkConfig struct {
+	// cidr is the subnet of the network, see WithNetworkCIDR. Empty lets
+	// Docker pick one.
+	cidr string
+
+	// driver is the network driver, see WithNetworkDriver. Empty selects
+	// "bridge".
+	driver string
+
+	// driverOptions are the options of driver.
+	driverOptions map[string]string
+}
+
+// ipam returns the IP address management of the network, or nil for the
+// defaults of Docker.
+func (c networkConfig) ipam() *dockernetwork.IPAM {
+	if c.cidr == "" {
+		return nil
+	}
+	return &dockernetwork.IPAM{
+		Driver: "default",
+		Config: []dockernetwork.IPAMConfig{{Subnet: c.cidr}},
+	}
+}
+
+// createNetwork is CreateNetworkWithProvider with extra labels for the
+// network and the sidecars started on it, configured by cfg.
+func createNetwork(ctx context.Context, provider *testcontainers.DockerProvider, extraLabels map[string]string, cfg networkConfig) (*DockerNetwork, func(context.Context) error, error) {
+	labels := resourceLabels()
+	maps.Copy(labels, extraLabels)
+	driver := cmp.Or(cfg.driver, "bridge")
+
+	if len(cfg.driverOptions) > 0 {
+		// testcontainers cannot pass driver options
+		dn, err := createNetworkWithClient(ctx, provider, labels, driver, cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		dn.extraLabels = extraLabels
+		return dn, dn.Remove, nil
+	}
+
+	var net *testcontainers.DockerNetwork
+	if provider == nil {
+		opts := []network.NetworkCustomizer{network.WithLabels(labels), network.WithDriver(driver)}
+		if ipam := cfg.ipam(); ipam != nil {
+			opts = append(opts, network.WithIPAM(ipam))
+		}
+		var err error
+		net, err = network.New(ctx, opts...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create docker network: %w", err)
+		}
+	} else {
+		networkLabels := testcontainers.GenericLabels()
+		maps.Copy(networkLabels, labels)
+
+		// Mirrors the request built by network.New
+		//nolint:staticcheck
+		n, err := provider.CreateNetwork(ctx, testcontainers.NetworkRequest{
+			Driver: driver,
+			Name:   uuid.NewString(),
+			Labels: networkLabels,
+			IPAM:   cfg.ipam(),
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create docker network: %w", err)
+		}
+		net = n.(*testcontainers.DockerNetwork)
 	}
 
 	dn := &DockerNetwork{
-		Name:    net.Name,
-		network: net,
+		Name:        net.Name,
+		network:     net,
+		provider:    provider,
+		extraLabels: extraLabels,
 	}
 
 	cleanup := func(ctx context.Context) error {
@@ -45,13 +143,52 @@ func CreateNetwork(ctx context.Context) (*DockerNetwork, func(context.Context) e
 	return dn, cleanup, nil
 }
 
+// createNetworkWithClient creates the network through the Docker API instead
+// of testcontainers, which drops driver options. The network carries the
+// labels of testcontainers, so the reaper removes it like the networks
+// created by testcontainers.
+func createNetworkWithClient(ctx context.Context, provider *testcontainers.DockerProvider, labels map[string]string, driver string, cfg networkConfig) (*DockerNetwork, error) {
+	networkLabels := testcontainers.GenericLabels()
+	maps.Copy(networkLabels, labels)
+
+	cli, closeClient, err := dockerClient(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	name := uuid.NewString()
+	response, err := cli.NetworkCreate(ctx, name, dockernetwork.CreateOptions{
+		Driver:  driver,
+		Options: cfg.driverOptions,
+		IPAM:    cfg.ipam(),
+		Labels:  networkLabels,
+	})
+	if err != nil {
+		closeClient()
+		return nil, fmt.Errorf("failed to create docker network: %w", err)
+	}
+
+	return &DockerNetwork{
+		Name:     name,
+		network:  &testcontainers.DockerNetwork{ID: response.ID, Driver: driver, Name: name},
+		provider: provider,
+		remove: func(ctx context.Context) error {
+			defer closeClient()
+			return cli.NetworkRemove(ctx, response.ID)
+		},
+	}, nil
+}
+
 // Remove removes the Docker network. This should be called when the
 // network is no longer needed to clean up resources.
 func (n *DockerNetwork) Remove(ctx context.Context) error {
-	if n.network == nil {
-		return nil
+	remove := n.remove
+	if remove == nil {
+		if n.network == nil {
+			return nil
+		}
+		remove = n.network.Remove
 	}
-	if err := n.network.Remove(ctx); err != nil {
+	if err := remove(ctx); err != nil {
 		return fmt.Errorf("failed to remove docker network: %w", err)
 	}
 	return nil
@@ -62,3 +199,59 @@ func (n *DockerNetwork) Remove(ctx context.Context) error {
 func (n *DockerNetwork) Network() *testcontainers.DockerNetwork {
 	return n.network
 }
+
+// staticIPModifier returns an endpoint settings modifier that assigns ip to
+// the container on the network named name, see WithStaticIP.
+func staticIPModifier(name, ip string) func(map[string]*dockernetwork.EndpointSettings) {
+	return func(settings map[string]*dockernetwork.EndpointSettings) {
+		endpoint, ok := settings[name]
+		if !ok {
+			endpoint = &dockernetwork.EndpointSettings{}
+			settings[name] = endpoint
+		}
+		endpoint.IPAMConfig = &dockernetwork.EndpointIPAMConfig{IPv4Address: ip}
+	}
+}
+
+// hostNetwork is an IPv4 network of an interface of this host.
+type hostNetwork struct {
+	// iface is the name of the interface, e.g. "tun0".
+	iface string
+
+	// prefix is the network of the interface address.
+	prefix netip.Prefix
+}
+
+// hostNetworks returns the IPv4 networks of the interfaces of this host,
+// without loopback. Interfaces whose addresses cannot be read are left out.
+func hostNetworks() []hostNetwork {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	var networks []hostNetwork
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		// Non-fatal: the check is best-effort
+		addrs, _ := iface.Addrs()
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			ip, ok := netip.AddrFromSlice(ipNet.IP)
+			if !ok || !ip.Unmap().Is4() {
+				continue
+			}
+			ones, size := ipNet.Mask.Size()
+			if size == 128 {
+				// IPv4 addresses in 16-byte form have 128-bit masks
+				ones -= 96
+			}
+			networks = append(networks, hostNetwork{iface: iface.Name, prefix: netip.PrefixFrom(ip.Unmap(), ones).Masked()})
+		}
+	}
+	return networks
+}
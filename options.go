@@ -2,7 +2,16 @@ package dockertesting
 
 import (
 	"errors"
+	"io"
+	"log/slog"
+	"maps"
+	"path"
+	"path/filepath"
 	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
 )
 
 // DefaultPattern is the default test pattern used when none is specified.
@@ -14,20 +23,94 @@ const DefaultSockPath = "/var/run/docker.sock"
 // DefaultTimeout is the default timeout for test execution (10 minutes).
 const DefaultTimeout = 10 * time.Minute
 
+// DefaultCleanupTimeout is the default timeout for removing the resources of
+// a run (30 seconds).
+const DefaultCleanupTimeout = 30 * time.Second
+
+// Verbosity controls how much output is produced during a run.
+type Verbosity int
+
+const (
+	// VerbosityQuiet suppresses forwarding of output to os.Stdout and silences
+	// container lifecycle logging. Output is still captured in the Result.
+	VerbosityQuiet Verbosity = iota
+
+	// VerbosityNormal forwards test output to os.Stdout in real-time.
+	VerbosityNormal
+
+	// VerbosityVerbose additionally passes -v to go test, forwards the docker
+	// build log to os.Stdout and logs container lifecycle events to os.Stderr.
+	VerbosityVerbose
+)
+
+// DefaultVerbosity is the default verbosity level.
+const DefaultVerbosity = VerbosityNormal
+
+// CoverMode is a coverage analysis mode passed to go test via -covermode.
+type CoverMode string
+
+const (
+	// CoverModeSet records whether each statement ran.
+	CoverModeSet CoverMode = "set"
+
+	// CoverModeCount records how many times each statement ran.
+	CoverModeCount CoverMode = "count"
+
+	// CoverModeAtomic is like CoverModeCount but safe for concurrent tests.
+	// It is required when the race detector is enabled.
+	CoverModeAtomic CoverMode = "atomic"
+)
+
 // Options holds the configuration for running tests in a Docker container.
 type Options struct {
 	// PackagePath is the path to the Go package to test (required).
 	PackagePath string
 
+	// Name identifies the run in the output, the resource labels, the Result
+	// and the reports. Empty leaves runs unnamed.
+	Name string
+
 	// Pattern is the test pattern to run (default: "./...").
 	Pattern string
 
+	// ParallelModules is the number of modules RunAllModules runs at a time
+	// (default: 1).
+	ParallelModules int
+
+	// PackageSubdir is the slash-separated directory of the module, relative
+	// to PackagePath, that go test runs in. Empty runs it in the module root.
+	PackageSubdir string
+
 	// Args are additional arguments to pass to go test.
 	Args []string
 
 	// Aliases are DNS aliases for the container.
 	Aliases []string
 
+	// Network is a caller-created network to attach the containers to instead
+	// of creating one for the run. It is not removed when the run ends.
+	Network *DockerNetwork
+
+	// NetworkCIDR is the IPv4 subnet of the network created for the run, e.g.
+	// "172.28.0.0/16". Empty lets Docker pick one.
+	NetworkCIDR string
+
+	// NetworkDriver is the driver of the network created for the run
+	// (default: "bridge").
+	NetworkDriver string
+
+	// NetworkDriverOptions are the options of NetworkDriver, e.g.
+	// NetworkOptionMTU.
+	NetworkDriverOptions map[string]string
+
+	// HostPorts are the ports of the host reachable from the test container
+	// at HostAlias.
+	HostPorts []int
+
+	// StaticIP is the IPv4 address of the test container on the network.
+	// Empty lets Docker assign one.
+	StaticIP string
+
 	// EnableVarSock enables mounting the Docker socket into the container.
 	EnableVarSock bool
 
@@ -37,17 +120,258 @@ type Options struct {
 	// Timeout is the maximum duration for the entire test execution (default: 10 minutes).
 	Timeout time.Duration
 
+	// CleanupTimeout is the maximum duration for removing the resources of a
+	// run (default: 30 seconds).
+	CleanupTimeout time.Duration
+
+	// StopTimeout is how long the processes in the test container get to exit
+	// after SIGTERM before it is killed. If 0, it is killed right away.
+	StopTimeout time.Duration
+
 	// DockerfilePath is the path to a custom Dockerfile to use for building the test container.
 	// If empty, the default embedded Dockerfile template is used.
 	// Supports both relative and absolute paths.
 	DockerfilePath string
+
+	// ImageFlavor is the variant of the golang base image of the Dockerfile
+	// template, e.g. ImageFlavorAlpine.
+	ImageFlavor ImageFlavor
+
+	// GoTools are the packages go installed into the image, each with a
+	// version, e.g. "golang.org/x/tools/cmd/stringer@latest".
+	GoTools []string
+
+	// DockerfileGenerator generates the Dockerfile instead of the embedded
+	// template, see WithDockerfileGenerator.
+	DockerfileGenerator DockerfileGenerator
+
+	// BuildTarget is the stage of a multi-stage Dockerfile the test container
+	// is built from. Empty builds the last stage.
+	BuildTarget string
+
+	// Template replaces the embedded Dockerfile template with a text/template
+	// source, see WithTemplate.
+	Template string
+
+	// TemplateData is the data the Dockerfile template is rendered with, see
+	// WithTemplateData.
+	TemplateData map[string]any
+
+	// GoVersion is the version of the golang base image, passed to the
+	// Dockerfile as the GO_VERSION build arg. If empty, the default of the
+	// Dockerfile is used.
+	GoVersion string
+
+	// Toolchain is the GOTOOLCHAIN of the commands run in the test container,
+	// e.g. "go1.23.4". Empty leaves the toolchain of the image.
+	Toolchain string
+
+	// GitCredentials authenticate git for fetching private modules during
+	// the build and the tests, see WithGitCredentials.
+	GitCredentials []GitCredentials
+
+	// TLSBundle is the directory of CA certificates trusted in the test
+	// image, see WithTLSBundle.
+	TLSBundle string
+
+	// HostProxyEnv copies the proxy variables of the host into the image
+	// build and the test container, see WithHostProxyEnv.
+	HostProxyEnv bool
+
+	// Root runs the tests of the embedded templates as root instead of
+	// TestUser, see WithRoot.
+	Root bool
+
+	// SocketProxy gives the test container access to the Docker daemon
+	// through a restricted proxy instead of the socket, see WithSocketProxy.
+	SocketProxy bool
+
+	// SocketProxyWrite are the sections of the Docker API the tests may
+	// write to through the proxy of WithSocketProxy; empty is read-only.
+	SocketProxyWrite []SocketProxySection
+
+	// SockGuard sets guard rails on what the tests do with the Docker socket,
+	// see WithSockGuard.
+	SockGuard *SockGuard
+
+	// SecretEnv are environment variables passed to the commands run in the
+	// test container only, see WithSecretEnv.
+	SecretEnv map[string]string
+
+	// ReadOnlySource makes the package source and the rest of the file system
+	// of the test container read-only, see WithReadOnlySource.
+	ReadOnlySource bool
+
+	// SetupCommands are commands executed inside the container, in order,
+	// after it has started and before go test runs.
+	SetupCommands [][]string
+
+	// TeardownCommands are commands executed inside the container, in order,
+	// after go test has finished and before the container is terminated.
+	TeardownCommands [][]string
+
+	// OutputCallback is invoked for every line of build and exec output.
+	OutputCallback func(OutputLine)
+
+	// stdout receives the forwarded output instead of os.Stdout if set, e.g.
+	// io.Discard when RunT logs the output through the test instead.
+	stdout io.Writer
+
+	// ProgressReporter receives coarse milestone events during the run.
+	ProgressReporter ProgressReporter
+
+	// Logger receives structured logs of the operations of the run. If nil,
+	// nothing is logged.
+	Logger *slog.Logger
+
+	// Metrics receives the statistics of the run. If nil, none are recorded.
+	Metrics Metrics
+
+	// Hooks are invoked at the milestones of the run.
+	Hooks Hooks
+
+	// FailFast passes -failfast to go test, stopping after the first test failure.
+	FailFast bool
+
+	// Short passes -short to go test, telling long-running tests to shorten their run time.
+	Short bool
+
+	// Verbosity controls go test -v and the amount of output produced by the library
+	// (default: VerbosityNormal).
+	Verbosity Verbosity
+
+	// CoverMode is the coverage mode passed to go test via -covermode.
+	// If empty, go test's default is used.
+	CoverMode CoverMode
+
+	// CoverageOutput is a path on the host where the coverage profile is written.
+	// If empty, the profile is only returned in the Result.
+	CoverageOutput string
+
+	// CoberturaReport enables converting the coverage profile to a Cobertura XML
+	// report returned in Result.Cobertura.
+	CoberturaReport bool
+
+	// CPUProfile enables collecting a CPU profile via go test -cpuprofile.
+	CPUProfile bool
+
+	// MemProfile enables collecting a memory profile via go test -memprofile.
+	MemProfile bool
+
+	// MemProfileRate is passed to go test via -memprofilerate when MemProfile is enabled.
+	// Zero keeps the runtime default.
+	MemProfileRate int
+
+	// BlockProfile enables collecting a goroutine blocking profile via go test -blockprofile.
+	BlockProfile bool
+
+	// MutexProfile enables collecting a mutex contention profile via go test -mutexprofile.
+	MutexProfile bool
+
+	// Artifacts are glob patterns of files inside the container to copy out after the tests.
+	Artifacts []string
+
+	// ArtifactsDir is a directory on the host where collected artifacts are written.
+	// If empty, artifacts are only returned in the Result.
+	ArtifactsDir string
+
+	// CompileOnly compiles the test binaries with go test -c instead of running
+	// the tests, and returns them in Result.TestBinaries.
+	CompileOnly bool
+
+	// Init runs an init process as PID 1 of the test container, which reaps
+	// zombie processes. Enabled by default.
+	Init bool
+
+	// KeepFailedBuild tags the last successful intermediate image when the
+	// image build fails, so the failing step can be debugged.
+	KeepFailedBuild bool
+
+	// Sidecars are dependency containers started on the test network before
+	// the tests execute.
+	Sidecars []SidecarSpec
+
+	// Seeds are scripts executed inside sidecars before the tests run.
+	Seeds []Seed
+
+	// Probes are services on the test network that must resolve and respond
+	// before the test container is built.
+	Probes []ProbeTarget
+
+	// KeepResources leaves the test container, the sidecars and the network
+	// running after Run returns.
+	KeepResources bool
+
+	// KeepOnFailure leaves the test container, the sidecars and the network
+	// running when the tests fail or the run errors, with a DiagnosticBundle
+	// in Result.Diagnostics.
+	KeepOnFailure bool
+
+	// SignalCleanup removes the resources of a run when the process receives
+	// SIGINT or SIGTERM.
+	SignalCleanup bool
+
+	// Reaper enables or disables the testcontainers reaper (Ryuk).
+	Reaper ReaperMode
+
+	// ReaperSessionID is the reaper session the containers and networks
+	// belong to. If empty, the session of the process is used.
+	ReaperSessionID string
+
+	// WaitFor decides when the test container is ready for running commands.
+	// If nil, Run waits until a command can be executed in the container.
+	WaitFor wait.Strategy
+
+	// BuildKit builds the test image with BuildKit cache mounts when the
+	// daemon supports it.
+	BuildKit bool
+
+	// LazyModDownload skips go mod download at image build time; modules are
+	// downloaded by go test into a shared module cache volume instead.
+	LazyModDownload bool
+
+	// Vendor controls whether go uses the vendor directory of the module.
+	Vendor VendorMode
+
+	// ImageCache reuses the test image of an earlier run with an identical
+	// build context instead of building it again.
+	ImageCache bool
+
+	// ContextExcludes are the patterns of paths left out of the build context.
+	// If nil, DefaultContextExcludes is used.
+	ContextExcludes []string
+
+	// MaxContextSize is the maximum size of the build context in bytes, or 0
+	// for no limit.
+	MaxContextSize int64
+
+	// BuildCacheRef is the registry image the build cache is imported from
+	// and exported to.
+	BuildCacheRef string
+
+	// BuildCacheDir is the directory the build cache is imported from and
+	// exported to.
+	BuildCacheDir string
+
+	// ContainerdCompat adapts the test container to Docker-compatible APIs in
+	// front of containerd, such as nerdctl's.
+	ContainerdCompat bool
+
+	// Provider creates the network and all containers of the run. If nil, a
+	// provider is configured from DockerClient or the environment.
+	Provider *testcontainers.DockerProvider
+
+	// DockerClient is used for all Docker API calls of the run when Provider
+	// is nil. If both are nil, the client is configured from the environment.
+	DockerClient client.APIClient
 }
 
 // Option is a functional option for configuring Options.
 type Option func(*Options)
 
 // WithPattern sets the test pattern to run. The pattern is passed to go test
-// and follows the same syntax (e.g., "./...", "./pkg/...", "TestFoo").
+// and follows the same syntax (e.g., "./...", "./pkg/..."); select tests
+// by name with -run in WithArgs.
 // If not set, defaults to "./..." to run all tests.
 //
 // Example:
@@ -59,6 +383,36 @@ func WithPattern(pattern string) Option {
 	}
 }
 
+// WithPackageSubdir runs go test in the directory dir of the module instead
+// of its root, so that the pattern, e.g. the default "./...", only covers that
+// subtree. The build context stays the module at the package path, and setup
+// and teardown commands still run in the module root. dir is relative to the
+// package path and must not leave it.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, "./mymodule", dockertesting.WithPackageSubdir("internal/service"))
+func WithPackageSubdir(dir string) Option {
+	return func(o *Options) {
+		o.PackageSubdir = path.Clean(filepath.ToSlash(dir))
+		if o.PackageSubdir == "." {
+			o.PackageSubdir = ""
+		}
+	}
+}
+
+// WithParallelModules lets RunAllModules run the tests of up to n modules at
+// a time, each with its own network and container. Other runs ignore it.
+//
+// Example:
+//
+//	dockertesting.RunAllModules(ctx, ".", dockertesting.WithParallelModules(4))
+func WithParallelModules(n int) Option {
+	return func(o *Options) {
+		o.ParallelModules = n
+	}
+}
+
 // WithArgs sets additional arguments to pass to go test. These are appended
 // after the pattern. Common examples include "-v" for verbose output,
 // "-race" for race detection, or "-count=1" to disable test caching.
@@ -74,6 +428,21 @@ func WithArgs(args ...string) Option {
 	}
 }
 
+// WithName names the run, e.g. "payments-integration", so that CI logs of
+// several runs stay readable: the lines forwarded to os.Stdout are prefixed
+// with "[name] ", the resources are labeled with LabelName, and the name is
+// included in the Result, in RunError messages and in the report of
+// WithKeepOnFailure.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithName("payments-integration"))
+func WithName(name string) Option {
+	return func(o *Options) {
+		o.Name = name
+	}
+}
+
 // WithAliases sets DNS aliases for the container within the Docker network.
 // Other containers on the same network can reach this container using these names.
 // This is useful for tests that need to connect to services via custom hostnames.
@@ -89,6 +458,113 @@ func WithAliases(aliases ...string) Option {
 	}
 }
 
+// WithNetwork attaches the test container and the sidecars of the run to a
+// network created by the caller, e.g. with CreateNetwork, instead of a network
+// created for the run. Several concurrent runs, e.g. for the packages of one
+// system, can then share the network and reach each other through their
+// aliases. The run neither creates nor removes the network; the caller
+// removes it once all runs on it have ended.
+//
+// Sidecars of runs sharing a network should use distinct aliases.
+//
+// Example:
+//
+//	network, cleanup, err := dockertesting.CreateNetwork(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer cleanup(ctx)
+//	go dockertesting.Run(ctx, "./api", dockertesting.WithNetwork(network), dockertesting.WithAliases("api.test"))
+//	dockertesting.Run(ctx, "./client", dockertesting.WithNetwork(network))
+func WithNetwork(network *DockerNetwork) Option {
+	return func(o *Options) {
+		o.Network = network
+	}
+}
+
+// WithNetworkCIDR sets the IPv4 subnet of the network created for the run,
+// e.g. so that it does not collide with the ranges of a corporate VPN, which
+// makes the hosts behind the VPN unreachable from the containers, or to
+// assign a static IP with WithStaticIP. Docker assigns the first address of
+// the subnet to the gateway. With a local daemon, Validate rejects subnets
+// overlapping a network of an interface of this host. It does not apply to a
+// network set with WithNetwork.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithNetworkCIDR("10.42.0.0/24"))
+func WithNetworkCIDR(cidr string) Option {
+	return func(o *Options) {
+		o.NetworkCIDR = cidr
+	}
+}
+
+// Options of the bridge network driver, see WithNetworkDriver.
+const (
+	// NetworkOptionMTU is the MTU of the network, e.g. "1400".
+	NetworkOptionMTU = "com.docker.network.driver.mtu"
+
+	// NetworkOptionICC enables or disables the communication between the
+	// containers of the network, "true" or "false".
+	NetworkOptionICC = "com.docker.network.bridge.enable_icc"
+)
+
+// WithNetworkDriver sets the driver of the network created for the run and
+// its options, for environments that need non-default settings, e.g. a lower
+// MTU behind VPNs whose smaller packets make TLS handshakes inside the network
+// hang. Multiple calls are cumulative; the last driver applies, and later
+// options replace earlier ones of the same key. It does not apply to a
+// network set with WithNetwork.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithNetworkDriver("bridge", map[string]string{
+//	    dockertesting.NetworkOptionMTU: "1400",
+//	}))
+func WithNetworkDriver(driver string, opts map[string]string) Option {
+	return func(o *Options) {
+		o.NetworkDriver = driver
+		if len(opts) > 0 && o.NetworkDriverOptions == nil {
+			o.NetworkDriverOptions = make(map[string]string, len(opts))
+		}
+		maps.Copy(o.NetworkDriverOptions, opts)
+	}
+}
+
+// WithHostPorts makes ports of the host reachable from the test container
+// at HostAlias, e.g. a locally running API under manual debugging. The ports
+// are forwarded through an SSH container of testcontainers, which also
+// answers to testcontainers.HostInternal. Multiple calls are cumulative.
+//
+// Example:
+//
+//	// The tests reach the API at http://host.dockertesting.internal:8080
+//	dockertesting.Run(ctx, path, dockertesting.WithHostPorts(8080))
+func WithHostPorts(ports ...int) Option {
+	return func(o *Options) {
+		o.HostPorts = append(o.HostPorts, ports...)
+	}
+}
+
+// WithStaticIP assigns a fixed IPv4 address to the test container on the
+// network, for tests that hardcode addresses, e.g. of legacy protocols or
+// license servers. Docker only assigns addresses in subnets chosen by the
+// user: the address must be in the subnet set with WithNetworkCIDR, or in
+// the subnet of a network set with WithNetwork. Runs on the same network
+// need distinct addresses.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path,
+//	    dockertesting.WithNetworkCIDR("172.28.0.0/16"),
+//	    dockertesting.WithStaticIP("172.28.0.10"),
+//	)
+func WithStaticIP(ip string) Option {
+	return func(o *Options) {
+		o.StaticIP = ip
+	}
+}
+
 // WithVarSock enables mounting the Docker socket into the container.
 // This is required when the tests inside the container use testcontainers-go
 // or otherwise need to interact with Docker.
@@ -99,6 +575,10 @@ func WithAliases(aliases ...string) Option {
 // When enabled, the TESTCONTAINERS_DOCKER_NETWORK environment variable is also
 // set in the container, allowing nested testcontainers to attach to the same network.
 //
+// If DOCKER_HOST points to a remote daemon (tcp://), its socket cannot be
+// mounted. Instead DOCKER_HOST, and with DOCKER_TLS_VERIFY the TLS client
+// certificates from DOCKER_CERT_PATH, are passed into the container.
+//
 // Example:
 //
 //	dockertesting.Run(ctx, path, dockertesting.WithVarSock())
@@ -108,9 +588,73 @@ func WithVarSock() Option {
 	}
 }
 
+// WithSocketProxy gives the test container access to the Docker daemon
+// through a restricted proxy, as a safer alternative to WithVarSock. A
+// SocketProxyImage sidecar mounts the socket and forwards the info endpoints
+// and, read-only, those listing and inspecting containers, images, networks
+// and volumes; builds, swarm, secrets, plugins and the system endpoints are
+// denied. The test container reaches it at SocketProxyAlias through
+// DOCKER_HOST.
+//
+// Write access must be granted per section with write, e.g.
+// SocketProxyTestcontainers for running containers from the tests. The proxy
+// allows writes per request method, so with write access only the listed
+// sections are forwarded, and it filters endpoints, not request bodies: tests
+// that may create containers can create privileged ones that mount host
+// paths. It limits mistakes rather than containing hostile code. The reaper
+// (Ryuk) the tests start mounts the raw socket of the daemon and bypasses the
+// proxy; disable it with WithReaper(false) if that is not acceptable.
+// WithSockPath selects the socket to proxy.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithSocketProxy(dockertesting.SocketProxyTestcontainers...))
+func WithSocketProxy(write ...SocketProxySection) Option {
+	return func(o *Options) {
+		o.SocketProxy = true
+		o.SocketProxyWrite = append(o.SocketProxyWrite, write...)
+	}
+}
+
+// WithSockGuard sets guard rails on what the tests do with the Docker
+// daemon of WithVarSock or WithSocketProxy. The events of the daemon are
+// watched while the test container runs: pulls from registries outside
+// guard.TrustedRegistries and, with guard.RequireLabel, containers, networks
+// and volumes created without LabelRunID are violations. The test container
+// gets the run ID in EnvRunID to label the resources with. Violations are
+// logged, and with SockGuardFail Run and Test fail with a *SockGuardError. With
+// guard.Cleanup, Close removes the containers the tests leaked.
+//
+// Events are attributed to the run by time, so resources created by other
+// clients of the daemon meanwhile, e.g. concurrent builds, count too.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path,
+//	    dockertesting.WithVarSock(),
+//	    dockertesting.WithSockGuard(dockertesting.SockGuard{
+//	        TrustedRegistries: []string{"docker.io/library", "registry.example.com"},
+//	        RequireLabel:      true,
+//	        Cleanup:           true,
+//	        Mode:              dockertesting.SockGuardFail,
+//	    }),
+//	)
+func WithSockGuard(guard SockGuard) Option {
+	return func(o *Options) {
+		o.SockGuard = &guard
+	}
+}
+
 // WithSockPath sets the path to the Docker socket on the host.
-// Only relevant when WithVarSock() is also used.
-// Defaults to "/var/run/docker.sock".
+// Only relevant when WithVarSock() or WithSocketProxy() is also used.
+//
+// By default the socket is detected: the TESTCONTAINERS_DOCKER_SOCKET_OVERRIDE
+// env var is honored, runtimes that run the daemon in a VM (Docker Desktop,
+// Colima, OrbStack, Rancher Desktop) use "/var/run/docker.sock" inside the VM,
+// on Linux a unix DOCKER_HOST or the rootless socket is used when
+// "/var/run/docker.sock" does not exist, and on Windows Docker Desktop's
+// WindowsSockPath is used. WithSockPath overrides the detection; named pipes
+// (npipe:////./pipe/docker_engine) are mounted as named pipes.
 //
 // Example:
 //
@@ -124,6 +668,25 @@ func WithSockPath(path string) Option {
 	}
 }
 
+// WithContainerdCompat adapts the test container to a Docker-compatible API in
+// front of containerd, such as the one finch-daemon serves for nerdctl. These
+// APIs ignore HostConfig mounts, so the Docker socket of WithVarSock is passed
+// as a bind instead, and mount types containerd does not support are dropped.
+// It is enabled automatically when DOCKER_HOST points to finch.sock or
+// nerdctl.sock; use it for such an API behind another address.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path,
+//	    dockertesting.WithVarSock(),
+//	    dockertesting.WithContainerdCompat(),
+//	)
+func WithContainerdCompat() Option {
+	return func(o *Options) {
+		o.ContainerdCompat = true
+	}
+}
+
 // WithTimeout sets the maximum duration for the entire test execution.
 // If the timeout is reached, the operation will be cancelled and a TimeoutError returned.
 // Defaults to 10 minutes.
@@ -137,6 +700,54 @@ func WithTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithCleanupTimeout sets the maximum duration for removing the test
+// container, the sidecars and the network after a run. Cleanup does not use
+// the context of the run, which may already be canceled or expired, e.g. after
+// a timeout, so the resources are removed in any case. A timeout of 0 waits
+// for as long as removing takes. Defaults to 30 seconds.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithCleanupTimeout(2 * time.Minute))
+func WithCleanupTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.CleanupTimeout = timeout
+	}
+}
+
+// WithInit runs the init process of the Docker daemon (tini, as with
+// docker run --init) as PID 1 of the test container. It reaps the zombie
+// processes left behind by tests that fork, e.g. spawning helper binaries,
+// and forwards signals to the processes of the container. Enabled by default;
+// disable it for daemons without an init binary.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithInit(false))
+func WithInit(enabled bool) Option {
+	return func(o *Options) {
+		o.Init = enabled
+	}
+}
+
+// WithStopTimeout makes removing the test container first send SIGTERM to the
+// commands running in it, such as go test and the test binaries, but not its
+// entrypoint, and wait up to timeout for them to exit before the container is
+// killed. This gives the
+// tests' own cleanup code, e.g. removing testcontainers they started or
+// closing database connections, a chance to run. The wait counts towards the
+// cleanup timeout, see WithCleanupTimeout. A timeout of 0, the default, kills
+// the container right away.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithStopTimeout(10 * time.Second))
+func WithStopTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.StopTimeout = timeout
+	}
+}
+
 // WithDockerfilePath sets the path to a custom Dockerfile to use for building
 // the test container. If not set, the default embedded Dockerfile template is used.
 // Supports both relative and absolute paths.
@@ -153,23 +764,1076 @@ func WithDockerfilePath(dockerfilePath string) Option {
 	}
 }
 
-// NewOptions creates a new Options with the given package path and functional options.
-// It returns an error if the package path is empty.
-func NewOptions(packagePath string, opts ...Option) (*Options, error) {
-	if packagePath == "" {
-		return nil, errors.New("package path is required")
+// WithImageFlavor selects the variant of the golang base image of the
+// embedded Dockerfile template, e.g. ImageFlavorAlpine for golang:<version>-alpine.
+// The C library matters for CGO and for tests depending on system libraries:
+// Alpine uses musl, the Debian variants glibc. The "Packages" of
+// WithTemplateData are installed with the package manager of the flavor, apk
+// or apt-get.
+//
+// Alpine images contain neither git nor a C compiler, so private modules,
+// see WithGitCredentials, need "git" and the race detector and CGO need "gcc"
+// and "musl-dev" in "Packages".
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path,
+//	    dockertesting.WithImageFlavor(dockertesting.ImageFlavorAlpine),
+//	    dockertesting.WithTemplateData(map[string]any{"Packages": []string{"gcc", "musl-dev"}}),
+//	)
+func WithImageFlavor(flavor ImageFlavor) Option {
+	return func(o *Options) {
+		o.ImageFlavor = flavor
 	}
+}
 
-	o := &Options{
-		PackagePath: packagePath,
-		Pattern:     DefaultPattern,
-		SockPath:    DefaultSockPath,
-		Timeout:     DefaultTimeout,
+// WithGoTools installs Go tools into the image of the embedded Dockerfile
+// template with go install, so that setup commands and go:generate
+// directives can use them. Each tool is a package path with a version, as
+// go install requires outside of the module. The tools are installed before
+// the package is copied, so their layers are cached across code changes.
+// Multiple calls to WithGoTools are cumulative.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path,
+//	    dockertesting.WithGoTools(
+//	        "golang.org/x/tools/cmd/stringer@latest",
+//	        "github.com/golang-migrate/migrate/v4/cmd/migrate@v4.18.1",
+//	    ),
+//	    dockertesting.WithSetupCommands([]string{"go", "generate", "./..."}),
+//	)
+func WithGoTools(tools ...string) Option {
+	return func(o *Options) {
+		o.GoTools = append(o.GoTools, tools...)
 	}
+}
 
-	for _, opt := range opts {
-		opt(o)
+// WithDockerfileGenerator generates the Dockerfile of the test image with
+// generator instead of rendering the embedded template, e.g. from Bazel or
+// internal build metadata. The build context, build args, image cache and
+// the rest of the pipeline are reused; the Dockerfile must keep the
+// container running, as the embedded template does with its ENTRYPOINT.
+// TemplateGenerator is the default, which generators can wrap.
+//
+// Example:
+//
+//	type generator struct{ base string }
+//
+//	func (g generator) Generate(options dockertesting.Options) ([]byte, error) {
+//	    return []byte("FROM " + g.base + "\nWORKDIR /app\nCOPY . .\n" +
+//	        `ENTRYPOINT ["sleep", "infinity"]` + "\n"), nil
+//	}
+//
+//	dockertesting.Run(ctx, path,
+//	    dockertesting.WithDockerfileGenerator(generator{base: "registry.example.com/go:1.24"}),
+//	)
+func WithDockerfileGenerator(generator DockerfileGenerator) Option {
+	return func(o *Options) {
+		o.DockerfileGenerator = generator
 	}
+}
 
-	return o, nil
+// WithBuildTarget builds the test container from the stage target of a
+// multi-stage Dockerfile instead of the last stage, so that one Dockerfile
+// serves both production builds and tests. The stage must keep the
+// container running and contain the Go toolchain and the module at its
+// working directory. A target that is not a stage fails before the build.
+//
+// Example:
+//
+//	// Dockerfile:
+//	//   FROM golang:1.24 AS test
+//	//   WORKDIR /app
+//	//   COPY . .
+//	//   ENTRYPOINT ["sleep", "infinity"]
+//	//
+//	//   FROM test AS build
+//	//   RUN go build -o /server .
+//	//
+//	//   FROM gcr.io/distroless/base
+//	//   COPY --from=build /server /server
+//	dockertesting.Run(ctx, path,
+//	    dockertesting.WithDockerfilePath("Dockerfile"),
+//	    dockertesting.WithBuildTarget("test"),
+//	)
+func WithBuildTarget(target string) Option {
+	return func(o *Options) {
+		o.BuildTarget = target
+	}
+}
+
+// WithTemplate replaces the embedded Dockerfile template with source, a
+// text/template rendered with the data of WithTemplateData, e.g. for a
+// different base image, without maintaining a Dockerfile next to the code.
+// Besides the keys of WithTemplateData, .BuildKit reports whether the image
+// is built with BuildKit, see WithBuildKit, .Flavor is the ImageFlavor,
+// .GoTools are the tools of WithGoTools and .User is TestUser, or empty with
+// WithRoot. Keys missing from the data fail
+// the build. The Dockerfile must keep the container running, as the embedded
+// template does with its ENTRYPOINT. WithDockerfilePath takes precedence.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithTemplate(`
+//	FROM golang:1.24-alpine
+//	RUN apk add --no-cache git gcc musl-dev
+//	WORKDIR /app
+//	COPY . .
+//	RUN go mod download
+//	ENTRYPOINT ["/bin/sh", "-c", "trap 'exit 0' TERM; while :; do sleep 0.1; done"]
+//	`))
+func WithTemplate(source string) Option {
+	return func(o *Options) {
+		o.Template = source
+	}
+}
+
+// WithTemplateData sets data the Dockerfile template is rendered with, so
+// that the embedded template can be extended without replacing it. The
+// embedded templates use these keys:
+//
+//   - "Packages" ([]string): system packages, installed with the package
+//     manager of WithImageFlavor
+//   - "Env" (map[string]string): ENV instructions, e.g. for build tags or CGO_ENABLED
+//   - "PreRun" ([]string): RUN instructions before the package is copied, which
+//     stay cached across code changes, e.g. installing system packages
+//   - "PostRun" ([]string): RUN instructions after the modules were downloaded,
+//     run as TestUser unless WithRoot is set
+//
+// Other keys are available to templates of WithTemplate. Multiple calls are
+// cumulative; later values replace earlier ones of the same key.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithTemplateData(map[string]any{
+//	    "Env":    map[string]string{"CGO_ENABLED": "1"},
+//	    "PreRun": []string{"apt-get update && apt-get install -y libsqlite3-dev"},
+//	}))
+func WithTemplateData(data map[string]any) Option {
+	return func(o *Options) {
+		if o.TemplateData == nil {
+			o.TemplateData = make(map[string]any)
+		}
+		maps.Copy(o.TemplateData, data)
+	}
+}
+
+// WithGoVersion sets the version of the golang base image of the test
+// container, e.g. "1.23" or "1.24.2-alpine". It is passed to the Dockerfile as
+// the GO_VERSION build arg, which custom Dockerfiles can declare as well.
+//
+// Without WithGoVersion, the embedded Dockerfile follows the toolchain and go
+// directives of go.mod if they are newer than its default. A version older
+// than the go directive fails before the build.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithGoVersion("1.23"))
+func WithGoVersion(version string) Option {
+	return func(o *Options) {
+		o.GoVersion = version
+	}
+}
+
+// WithToolchain selects the Go toolchain of the commands run in the test
+// container through GOTOOLCHAIN, e.g. "go1.23.4" or "1.23.4", without
+// rebuilding the image: the go command of the image downloads the toolchain
+// when it first runs. Together with WithImageCache, or with a Runner and
+// GOTOOLCHAIN in ExecConfig.Env, a version matrix reuses one built image.
+//
+// Downloading a toolchain requires access to the module proxy. Toolchains are
+// stored in the module cache, so with WithLazyModDownload they are downloaded
+// once into the shared volume; a custom Dockerfile can also pre-seed them by
+// running e.g. `GOTOOLCHAIN=go1.23.4 go version`. The toolchain must satisfy
+// the go directive of go.mod.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithToolchain("go1.23.4"))
+func WithToolchain(toolchain string) Option {
+	return func(o *Options) {
+		o.Toolchain = toolchainName(toolchain)
+	}
+}
+
+// WithGitCredentials configures git inside the test container for fetching
+// private modules from version control, during go mod download at build time
+// and go test at exec time, and adds their modules to GOPRIVATE so they
+// bypass the module proxy and checksum database. Multiple calls are
+// cumulative, e.g. for several hosts.
+//
+// The git configuration is never part of the image or the container
+// configuration. With WithBuildKit, go mod download mounts it as the BuildKit
+// secret "gitconfig", which custom Dockerfiles can mount too and point
+// GIT_CONFIG_GLOBAL to; without BuildKit, the modules are downloaded by go
+// test instead. At exec time it is passed like the secrets of WithSecretEnv,
+// and the tokens are redacted like them. Custom Dockerfiles also receive the
+// GOPRIVATE build arg.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithGitCredentials(dockertesting.GitCredentials{
+//	    Host:    "github.com",
+//	    Token:   os.Getenv("GITHUB_TOKEN"),
+//	    Modules: []string{"github.com/myorg/*"},
+//	}))
+func WithGitCredentials(creds ...GitCredentials) Option {
+	return func(o *Options) {
+		o.GitCredentials = append(o.GitCredentials, creds...)
+	}
+}
+
+// WithTLSBundle trusts the CA certificates in the directory certsDir in the
+// test image, so tests against internal TLS endpoints and builds behind
+// TLS-intercepting proxies verify successfully. The PEM files with the
+// extension .crt or .pem are added to the build context under TLSBundleDir,
+// which also works with remote daemons, and the embedded templates add them
+// to the system trust store with update-ca-certificates before anything is
+// downloaded. Custom Dockerfiles can copy them from TLSBundleDir.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithTLSBundle("/etc/corp/certs"))
+func WithTLSBundle(certsDir string) Option {
+	return func(o *Options) {
+		o.TLSBundle = certsDir
+	}
+}
+
+// WithSecretEnv sets the environment variable name to value for go test and
+// the setup and teardown commands, e.g. for API tokens of integration tests.
+// Unlike the build args and the environment of the container, the value is
+// passed at exec time only: it is never part of the build context, the
+// image, its history or the container configuration. The value is replaced
+// by Redacted in the streamed output, the Result, the output of
+// TestContainer.ExecCommand, goroutine dumps, artifacts and container logs,
+// so avoid short values that also occur elsewhere. Multiple calls are
+// cumulative.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithSecretEnv("API_TOKEN", os.Getenv("API_TOKEN")))
+func WithSecretEnv(name, value string) Option {
+	return func(o *Options) {
+		if o.SecretEnv == nil {
+			o.SecretEnv = make(map[string]string)
+		}
+		o.SecretEnv[name] = value
+	}
+}
+
+// WithReadOnlySource makes the package source read-only inside the test
+// container, so that tests writing to their own source tree, e.g. golden
+// files updated unconditionally, fail instead of changing the source seen by
+// the next Test call of a Runner or the image kept by WithImageCache. The
+// whole file system of the container is read-only, even for root, except
+// ReadOnlyTmpDir: GOCACHE, GOTMPDIR and TMPDIR point there, and GOFLAGS
+// includes -mod=readonly unless it selects a module mode already. The module
+// cache stays writable in a volume when go test downloads into it, i.e. for
+// WithToolchain and for WithGitCredentials without BuildKit. Setup commands
+// and tests that write elsewhere, e.g. install packages, fail too.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithReadOnlySource())
+func WithReadOnlySource() Option {
+	return func(o *Options) {
+		o.ReadOnlySource = true
+	}
+}
+
+// WithRoot runs the tests as root. By default the embedded Dockerfile
+// templates create the unprivileged TestUser with UID 1000, which owns the
+// package and the module cache and runs go test, the setup and teardown
+// commands and the PostRun instructions of WithTemplateData, as hardened CI
+// environments require, so tests that assume root fail locally too. Use it
+// for tests that need root, e.g. to bind privileged ports or install
+// packages at exec time. Custom Dockerfiles choose their user themselves.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithRoot())
+func WithRoot() Option {
+	return func(o *Options) {
+		o.Root = true
+	}
+}
+
+// WithHostProxyEnv copies the HTTP_PROXY, HTTPS_PROXY and NO_PROXY variables
+// of the host, in upper and lower case, into the image build and the test
+// container, so builds behind corporate proxies can go mod download. They
+// are passed to the build as the build args Docker predefines for proxies,
+// which Dockerfiles need not declare and which are left out of the image
+// history. In the test container, NO_PROXY is extended by the hosts of the
+// network, the aliases and subnets, so tests reach the sidecars directly.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithHostProxyEnv())
+func WithHostProxyEnv() Option {
+	return func(o *Options) {
+		o.HostProxyEnv = true
+	}
+}
+
+// WithSetupCommands sets commands to run inside the container after it has
+// been built and started, but before go test is executed. This is useful for
+// running migrations, seeding fixtures or generating code.
+//
+// Commands run in order from the container's working directory. If a command
+// cannot be executed or exits with a non-zero code, Run aborts and returns a
+// SetupError. Multiple calls to WithSetupCommands are cumulative.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithSetupCommands(
+//	    []string{"go", "generate", "./..."},
+//	    []string{"sh", "-c", "./scripts/seed.sh"},
+//	))
+func WithSetupCommands(commands ...[]string) Option {
+	return func(o *Options) {
+		o.SetupCommands = append(o.SetupCommands, commands...)
+	}
+}
+
+// WithTeardownCommands sets commands to run inside the container after the
+// tests have finished, regardless of whether they passed or failed, and before
+// the container is terminated. This is useful for dumping database state,
+// flushing logs or collecting diagnostics.
+//
+// Every command is attempted even if an earlier one fails. Teardown failures
+// are best-effort and do not affect the Result. Multiple calls to
+// WithTeardownCommands are cumulative.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithTeardownCommands(
+//	    []string{"sh", "-c", "cat /tmp/app.log"},
+//	))
+func WithTeardownCommands(commands ...[]string) Option {
+	return func(o *Options) {
+		o.TeardownCommands = append(o.TeardownCommands, commands...)
+	}
+}
+
+// WithOutputCallback sets a callback that is invoked for every line of output
+// produced during the run, both from the docker image build and from commands
+// executed in the container. Each OutputLine carries the text, the time it was
+// received and its source. This enables custom live UIs and log shipping.
+//
+// The callback is invoked sequentially and must not block for long, as it is
+// called while output is being read. Exec output is still forwarded to os.Stdout.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithOutputCallback(func(line dockertesting.OutputLine) {
+//	    log.Printf("[%s] %s", line.Source, line.Text)
+//	}))
+func WithOutputCallback(callback func(OutputLine)) Option {
+	return func(o *Options) {
+		o.OutputCallback = callback
+	}
+}
+
+// WithProgressReporter sets a ProgressReporter that is notified at coarse
+// milestones of the run: build context created, each image build step,
+// container started, tests running and coverage copied. This allows long runs
+// to render a progress indicator in CI dashboards instead of appearing hung.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithProgressReporter(
+//	    dockertesting.ProgressReporterFunc(func(e dockertesting.ProgressEvent) {
+//	        log.Printf("%s: %s", e.Stage, e.Message)
+//	    }),
+//	))
+func WithProgressReporter(reporter ProgressReporter) Option {
+	return func(o *Options) {
+		o.ProgressReporter = reporter
+	}
+}
+
+// WithLogger sets a logger for the operations of the run, so that operational
+// issues can be diagnosed from logs alone: milestones such as the image build,
+// the container start and the test execution with their durations are logged
+// at info level, details such as image and layer cache hits, coverage sizes
+// and best-effort steps that failed at debug level. The records carry the
+// package and the name of the run (see WithName) as attributes.
+//
+// The logger is independent of the Verbosity and of the testcontainers
+// logger. By default nothing is logged.
+//
+// Example:
+//
+//	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+//	dockertesting.Run(ctx, path, dockertesting.WithLogger(logger))
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+// WithMetrics records the statistics of the run in metrics: the build and
+// test durations, the build context size, the image cache hits and misses,
+// and the outcome of Run calls. Share one Metrics across runs to track the
+// health of containerized tests fleet-wide; NewExpvarMetrics publishes them
+// through expvar.
+//
+// Example:
+//
+//	metrics := dockertesting.NewExpvarMetrics("dockertesting")
+//	dockertesting.Run(ctx, path, dockertesting.WithMetrics(metrics))
+func WithMetrics(metrics Metrics) Option {
+	return func(o *Options) {
+		o.Metrics = metrics
+	}
+}
+
+// WithHooks invokes hooks at the milestones of the run: before the image
+// build, once the test container started, after the tests and after the
+// cleanup. The events carry the image, the container ID and the Result, so
+// notifications, artifact uploads or custom bookkeeping plug into Run
+// without reimplementing it. Calling WithHooks again replaces the hooks.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithHooks(dockertesting.Hooks{
+//	    OnTestsFinished: func(ctx context.Context, event dockertesting.HookEvent) {
+//	        if event.Result != nil && event.Result.ExitCode != 0 {
+//	            notify(event.Name + " failed")
+//	        }
+//	    },
+//	}))
+func WithHooks(hooks Hooks) Option {
+	return func(o *Options) {
+		o.Hooks = hooks
+	}
+}
+
+// WithFailFast passes -failfast to go test so that no new tests are started
+// after the first test failure.
+//
+// When combined with the -json flag (via WithArgs), Result.FailFastTest
+// reports the name of the test that triggered the early exit.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path,
+//	    dockertesting.WithFailFast(),
+//	    dockertesting.WithArgs("-json"),
+//	)
+func WithFailFast() Option {
+	return func(o *Options) {
+		o.FailFast = true
+	}
+}
+
+// WithShort passes -short to go test so that tests checking testing.Short()
+// can skip their long-running parts. This allows running the quick subset on
+// pull requests and the full suite nightly with the same code path.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithShort())
+func WithShort() Option {
+	return func(o *Options) {
+		o.Short = true
+	}
+}
+
+// WithVerbosity sets the verbosity level of the run. It controls both the
+// verbosity of go test and the amount of output produced by the library itself:
+//
+//   - VerbosityQuiet: nothing is forwarded to os.Stdout and container lifecycle
+//     logging is silenced; output is still captured in the Result.
+//   - VerbosityNormal (default): test output is forwarded to os.Stdout.
+//   - VerbosityVerbose: -v is passed to go test, the docker build log is
+//     forwarded to os.Stdout and container lifecycle events are logged.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithVerbosity(dockertesting.VerbosityVerbose))
+func WithVerbosity(level Verbosity) Option {
+	return func(o *Options) {
+		o.Verbosity = level
+	}
+}
+
+// WithCoverMode sets the coverage mode passed to go test via -covermode.
+// If not set, go test's default is used ("set", or "atomic" with -race).
+//
+// When the race detector is enabled via WithArgs("-race"), CoverModeAtomic is
+// selected automatically, as go test rejects any other mode in that case.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithCoverMode(dockertesting.CoverModeCount))
+func WithCoverMode(mode CoverMode) Option {
+	return func(o *Options) {
+		o.CoverMode = mode
+	}
+}
+
+// WithCoverageOutput sets a path on the host where Run writes the coverage
+// profile after the tests have finished. Parent directories are created as
+// needed and the file is replaced atomically. Nothing is written if no
+// coverage was generated. The profile is still returned in Result.Coverage.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithCoverageOutput("reports/coverage.out"))
+func WithCoverageOutput(path string) Option {
+	return func(o *Options) {
+		o.CoverageOutput = path
+	}
+}
+
+// WithCoberturaReport converts the coverage profile to a Cobertura XML report
+// and returns it in Result.Cobertura, so it can be uploaded to Codecov, GitLab
+// or other coverage dashboards from the same process that ran the tests.
+//
+// File names in the report are relative to the module root, using the module
+// path declared in the package's go.mod.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithCoberturaReport())
+func WithCoberturaReport() Option {
+	return func(o *Options) {
+		o.CoberturaReport = true
+	}
+}
+
+// WithCPUProfile passes -cpuprofile to go test and returns the resulting pprof
+// file in Result.CPUProfile, for analysis with `go tool pprof` on the host.
+//
+// As with go test itself, profiling requires the pattern to match a single
+// package, e.g. WithPattern(".").
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path,
+//	    dockertesting.WithPattern("."),
+//	    dockertesting.WithArgs("-bench=.", "-run=^$"),
+//	    dockertesting.WithCPUProfile(),
+//	)
+func WithCPUProfile() Option {
+	return func(o *Options) {
+		o.CPUProfile = true
+	}
+}
+
+// WithMemProfile passes -memprofile to go test and returns the resulting heap
+// profile in Result.MemProfile, for analysis with `go tool pprof` on the host.
+//
+// As with go test itself, profiling requires the pattern to match a single
+// package, e.g. WithPattern(".").
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path,
+//	    dockertesting.WithPattern("."),
+//	    dockertesting.WithMemProfile(),
+//	)
+func WithMemProfile() Option {
+	return func(o *Options) {
+		o.MemProfile = true
+	}
+}
+
+// WithMemProfileRate sets the memory profiling rate passed to go test via
+// -memprofilerate (see runtime.MemProfileRate). A rate of 1 records every
+// allocation. It implies WithMemProfile.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithMemProfileRate(1))
+func WithMemProfileRate(rate int) Option {
+	return func(o *Options) {
+		o.MemProfile = true
+		o.MemProfileRate = rate
+	}
+}
+
+// WithBlockProfile passes -blockprofile to go test and returns the resulting
+// goroutine blocking profile in Result.BlockProfile, enabling contention
+// analysis of concurrency-heavy packages. Requires a single package.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path,
+//	    dockertesting.WithPattern("."),
+//	    dockertesting.WithBlockProfile(),
+//	)
+func WithBlockProfile() Option {
+	return func(o *Options) {
+		o.BlockProfile = true
+	}
+}
+
+// WithMutexProfile passes -mutexprofile to go test and returns the resulting
+// mutex contention profile in Result.MutexProfile. Requires a single package.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path,
+//	    dockertesting.WithPattern("."),
+//	    dockertesting.WithMutexProfile(),
+//	)
+func WithMutexProfile() Option {
+	return func(o *Options) {
+		o.MutexProfile = true
+	}
+}
+
+// WithArtifacts sets glob patterns of files to copy out of the container after
+// the tests (and teardown commands) have finished. The files are returned in
+// Result.Artifacts keyed by their path inside the container.
+//
+// Patterns must be absolute paths. "*" and "?" match within a path segment and
+// "**" matches across segments. A pattern matching a directory collects all
+// files below it. Multiple calls to WithArtifacts are cumulative.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithArtifacts("/tmp/reports/**", "/app/logs/*.log"))
+func WithArtifacts(patterns ...string) Option {
+	return func(o *Options) {
+		o.Artifacts = append(o.Artifacts, patterns...)
+	}
+}
+
+// WithArtifactsDir sets a directory on the host where the artifacts collected
+// via WithArtifacts are written, mirroring their paths inside the container.
+// For example, /tmp/reports/junit.xml is written to <dir>/tmp/reports/junit.xml.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path,
+//	    dockertesting.WithArtifacts("/tmp/reports/**"),
+//	    dockertesting.WithArtifactsDir("build/artifacts"),
+//	)
+func WithArtifactsDir(dir string) Option {
+	return func(o *Options) {
+		o.ArtifactsDir = dir
+	}
+}
+
+// WithCompileOnly switches Run to compile-and-extract mode: instead of running
+// the tests, `go test -c` compiles a test binary for every package matching the
+// pattern and the binaries are copied out into Result.TestBinaries, keyed by
+// file name (e.g. "mypkg.test"). They can then be archived and run elsewhere
+// without the Go toolchain.
+//
+// Arguments from WithArgs are passed to the compiler, so build flags such as
+// "-race" or "-tags=integration" apply. No coverage is collected in this mode.
+//
+// Example:
+//
+//	result, err := dockertesting.Run(ctx, path, dockertesting.WithCompileOnly())
+//	for name, binary := range result.TestBinaries {
+//	    _ = os.WriteFile(name, binary, 0755)
+//	}
+func WithCompileOnly() Option {
+	return func(o *Options) {
+		o.CompileOnly = true
+	}
+}
+
+// NewOptions creates a new Options with the given package path and functional options.
+// The defaults are overridden by the DOCKERTESTING_* environment variables
+// (see EnvGoVersion, EnvTimeout, EnvSockPath and EnvQuiet) before opts are
+// applied. It returns an error if the package path is empty or an environment
+// variable is invalid.
+func NewOptions(packagePath string, opts ...Option) (*Options, error) {
+	if packagePath == "" {
+		return nil, errors.New("package path is required")
+	}
+
+	o := &Options{
+		PackagePath:    packagePath,
+		Pattern:        DefaultPattern,
+		SockPath:       DefaultSockPath,
+		Timeout:        DefaultTimeout,
+		CleanupTimeout: DefaultCleanupTimeout,
+		Verbosity:      DefaultVerbosity,
+		Init:           true,
+	}
+	if err := applyEnv(o); err != nil {
+		return nil, err
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o, nil
+}
+
+// WithBuildKit builds the test image with BuildKit when the daemon supports
+// it, using a template that downloads modules through cache mounts for the
+// module and build caches. The caches persist across image builds without
+// named volumes, so changing the code no longer downloads all modules again.
+// Daemons without BuildKit fall back to the classic builder. A Dockerfile set
+// with WithDockerfilePath is used as-is.
+//
+// BuildKit reports no per-step build log through the Docker API, so the
+// StageBuildStep progress events, BuildError.Log and WithKeepFailedBuild carry
+// less information.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithBuildKit())
+func WithBuildKit() Option {
+	return func(o *Options) {
+		o.BuildKit = true
+	}
+}
+
+// WithLazyModDownload skips `go mod download` when building the test image and
+// lets go test download missing modules when the tests run, into the
+// ModCacheVolume named volume mounted as module cache. The volume is shared by
+// all runs, so modules are downloaded once instead of on every image build.
+// This trades a self-contained image for much faster cold builds during
+// iterative development. Custom Dockerfiles can honor it through the
+// LAZY_MOD_DOWNLOAD build arg.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithLazyModDownload())
+func WithLazyModDownload() Option {
+	return func(o *Options) {
+		o.LazyModDownload = true
+	}
+}
+
+// WithVendor forces go to use the vendor directory of the module, passing
+// -mod=vendor through GOFLAGS and skipping go mod download when building the
+// test image, or to ignore it, passing -mod=mod. Without WithVendor, the
+// vendor directory is used if vendor/modules.txt lists every requirement of
+// go.mod at its version, so vendored modules build without network access.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithVendor(false))
+func WithVendor(enabled bool) Option {
+	return func(o *Options) {
+		if enabled {
+			o.Vendor = VendorEnabled
+		} else {
+			o.Vendor = VendorDisabled
+		}
+	}
+}
+
+// WithImageCache enables or disables reusing test images across runs. When
+// enabled, the image is tagged with a digest of the build context (the package
+// files and the Dockerfile) under ImageCacheRepository and kept after the run;
+// a later run with an identical context starts from that image instead of
+// building it again. File modification times do not affect the digest.
+//
+// Cached images are not removed automatically; use PruneImageCache. Base images
+// referenced by a floating tag in a custom Dockerfile are not re-pulled while
+// the context is unchanged.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithImageCache(true))
+func WithImageCache(enabled bool) Option {
+	return func(o *Options) {
+		o.ImageCache = enabled
+	}
+}
+
+// WithBuildCacheRegistry imports the build cache from the image ref before
+// building the test image and pushes the built image to ref afterwards, so
+// ephemeral CI runners start from the layers of the previous run instead of
+// an empty cache. The credentials for the registry are taken from the Docker
+// config, like for pulling images. A missing or unreachable cache only makes
+// the build slower and is noted in the build log.
+//
+// With WithBuildKit, the image carries BuildKit inline cache metadata. The
+// image is pushed on every build, so use a dedicated ref per package.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path,
+//	    dockertesting.WithBuildKit(),
+//	    dockertesting.WithBuildCacheRegistry("ghcr.io/acme/app-test-cache:main"),
+//	)
+func WithBuildCacheRegistry(ref string) Option {
+	return func(o *Options) {
+		o.BuildCacheRef = ref
+	}
+}
+
+// WithBuildCacheDir imports the build cache from dir before building the test
+// image and saves the built image to dir afterwards, for CI systems that
+// persist directories between runs rather than registries. The image is saved
+// as an archive named after the package path, so one directory can hold the
+// caches of several packages. A missing archive is expected on the first run
+// and skipped.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithBuildCacheDir(".cache/dockertesting"))
+func WithBuildCacheDir(dir string) Option {
+	return func(o *Options) {
+		o.BuildCacheDir = dir
+	}
+}
+
+// WithContextExcludes replaces the patterns of paths left out of the build
+// context, which default to DefaultContextExcludes. Patterns use path.Match
+// syntax; a pattern without a slash matches files and directories of that name
+// at any depth, a pattern with a slash matches the path relative to the
+// package directory. Excluded directories are skipped entirely.
+// Calling it without patterns sends the whole package directory.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithContextExcludes(
+//	    append(dockertesting.DefaultContextExcludes, "testdata/large")...,
+//	))
+func WithContextExcludes(patterns ...string) Option {
+	return func(o *Options) {
+		o.ContextExcludes = append([]string{}, patterns...)
+	}
+}
+
+// WithMaxContextSize limits the total size of the files in the build context
+// to maxBytes. A larger context fails before anything is sent to the daemon
+// with a *ContextSizeError listing the largest files, instead of silently
+// shipping gigabytes of fixtures or build output. A limit of 0 disables the
+// check, which is the default.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithMaxContextSize(100<<20))
+func WithMaxContextSize(maxBytes int64) Option {
+	return func(o *Options) {
+		o.MaxContextSize = maxBytes
+	}
+}
+
+// WithKeepFailedBuild keeps the last successfully built layer when the docker
+// build fails and tags it as `dockertesting-debug-<runid>`. The tag is reported
+// in BuildError.DebugImage and in the error message, so the state right before
+// the failing step can be inspected with `docker run -it <tag> sh`.
+//
+// This relies on the intermediate images of the classic docker builder. The
+// tagged image is not removed automatically.
+//
+// Example:
+//
+//	_, err := dockertesting.Run(ctx, path, dockertesting.WithKeepFailedBuild())
+//	var buildErr *dockertesting.BuildError
+//	if errors.As(err, &buildErr) && buildErr.DebugImage != "" {
+//	    fmt.Println("debug with: docker run -it", buildErr.DebugImage, "sh")
+//	}
+func WithKeepFailedBuild() Option {
+	return func(o *Options) {
+		o.KeepFailedBuild = true
+	}
+}
+
+// WithSidecar starts a dependency container (e.g. Postgres, Redis, nginx) on the
+// same network as the test container before the tests execute, and tears it
+// down afterwards. The tests reach the sidecar through its DNS aliases.
+// Sidecars are started in the order they are declared and terminated in
+// reverse order, after the test container.
+// Multiple calls to WithSidecar are cumulative.
+//
+// Example:
+//
+//	dockertesting.WithSidecar(dockertesting.SidecarSpec{
+//	    Image:   "postgres:17",
+//	    Aliases: []string{"postgres.test"},
+//	    Env:     map[string]string{"POSTGRES_PASSWORD": "secret"},
+//	    WaitFor: dockertesting.WaitForLog("database system is ready to accept connections"),
+//	})
+func WithSidecar(spec SidecarSpec) Option {
+	return func(o *Options) {
+		o.Sidecars = append(o.Sidecars, spec)
+	}
+}
+
+// WithWaitStrategy replaces the strategy that decides when the test container
+// is ready, which by default waits until a command can be executed in it.
+// This is useful with a custom Dockerfile whose entrypoint starts services
+// the tests depend on.
+//
+// Example:
+//
+//	dockertesting.WithWaitStrategy(dockertesting.WaitForAll(
+//	    dockertesting.WaitForExec("echo", "ready"),
+//	    dockertesting.WaitForLog("mock server listening"),
+//	))
+func WithWaitStrategy(strategy wait.Strategy) Option {
+	return func(o *Options) {
+		o.WaitFor = strategy
+	}
+}
+
+// WithSignalCleanup removes the test container, the sidecars and the network
+// when the process receives SIGINT or SIGTERM during a run, e.g. on Ctrl-C or
+// a CI job cancellation, instead of leaving them to the reaper. Operations in
+// progress are canceled, the resources are removed and the signal is then
+// delivered again, so the process still terminates as it would have. The
+// handler is only installed while resources exist.
+//
+// Example:
+//
+//	result, err := dockertesting.Run(ctx, path, dockertesting.WithSignalCleanup())
+func WithSignalCleanup() Option {
+	return func(o *Options) {
+		o.SignalCleanup = true
+	}
+}
+
+// WithReaper enables or disables the testcontainers reaper (Ryuk) for the
+// run, overriding TESTCONTAINERS_RYUK_DISABLED. Locked-down CI environments
+// that do not allow the privileged reaper container can disable it and rely on
+// the cleanup of dockertesting instead, see WithSignalCleanup and
+// CleanupStale. The test container inherits the setting, so testcontainers
+// started by the tests do not start a reaper either.
+//
+// testcontainers reads its configuration once per process, so all runs of a
+// process must use the same setting; a conflicting run fails with an error.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path,
+//	    dockertesting.WithReaper(false),
+//	    dockertesting.WithSignalCleanup(),
+//	)
+func WithReaper(enabled bool) Option {
+	return func(o *Options) {
+		if enabled {
+			o.Reaper = ReaperEnabled
+		} else {
+			o.Reaper = ReaperDisabled
+		}
+	}
+}
+
+// WithReaperSessionID labels the containers and networks of the run with the
+// reaper session id instead of the session of the process. The reaper removes
+// the resources of a session once all processes using it have disconnected,
+// so several processes of one CI job can share a session and its reaper.
+//
+// Example:
+//
+//	dockertesting.Run(ctx, path, dockertesting.WithReaperSessionID(os.Getenv("CI_JOB_ID")))
+func WithReaperSessionID(id string) Option {
+	return func(o *Options) {
+		o.ReaperSessionID = id
+	}
+}
+
+// WithKeepResources leaves the test container, the sidecars and the network
+// running after Run returns, so they can be inspected with `docker exec`.
+// Teardown commands still run. The testcontainers reaper (Ryuk) removes the
+// resources when the process exits unless it is disabled with
+// TESTCONTAINERS_RYUK_DISABLED=true, in which case they must be removed manually.
+//
+// Example:
+//
+//	result, err := dockertesting.Run(ctx, path, dockertesting.WithKeepResources())
+func WithKeepResources() Option {
+	return func(o *Options) {
+		o.KeepResources = true
+	}
+}
+
+// WithKeepOnFailure leaves the test container, the sidecars and the network
+// running when the tests fail or the run errors after the test container was
+// started; successful runs still remove them. A DiagnosticBundle with the
+// container inspect, its logs and environment, and the last 200 lines of
+// output is returned in Result.Diagnostics (or by Runner.Diagnostics), and the
+// commands to exec into the container and to remove the resources are printed
+// to stderr. As with WithKeepResources, the reaper removes the resources when
+// the process exits unless it is disabled with WithReaper(false).
+//
+// Example:
+//
+//	result, err := dockertesting.Run(ctx, path,
+//	    dockertesting.WithKeepOnFailure(),
+//	    dockertesting.WithReaper(false),
+//	)
+//	if result != nil && result.Diagnostics != nil {
+//	    fmt.Println(result.Diagnostics.Commands())
+//	}
+func WithKeepOnFailure() Option {
+	return func(o *Options) {
+		o.KeepOnFailure = true
+	}
+}
+
+// WithProbe verifies that the given aliases resolve on the test network and
+// their services respond, after the sidecars have started and before the test
+// container is built. A misconfigured alias then fails fast with a *ProbeError
+// instead of making the test suite fail cryptically. See Probe.
+// Multiple calls to WithProbe are cumulative.
+//
+// Example:
+//
+//	dockertesting.WithProbe(
+//	    dockertesting.ProbeTarget{Alias: "postgres.test", Port: 5432},
+//	    dockertesting.ProbeTarget{Alias: "nginx.test", Port: 80, HTTPPath: "/"},
+//	)
+func WithProbe(targets ...ProbeTarget) Option {
+	return func(o *Options) {
+		o.Probes = append(o.Probes, targets...)
+	}
+}
+
+// WithSeed copies scripts from the host into the named sidecar and executes
+// them in order after the sidecar is ready and before the tests start, e.g. to
+// create a schema and insert fixture rows. Scripts are run with the sidecar's
+// SeedCommand (psql for the Postgres preset), or with sh if it has none.
+// A failing script aborts the run with an error wrapping a *SetupError.
+// Multiple calls to WithSeed are cumulative.
+//
+// Example:
+//
+//	dockertesting.WithSidecar(sidecar.Postgres("17", "app")),
+//	dockertesting.WithSeed("postgres", "testdata/schema.sql", "testdata/rows.sql"),
+func WithSeed(sidecarName string, scripts ...string) Option {
+	return func(o *Options) {
+		o.Seeds = append(o.Seeds, Seed{Sidecar: sidecarName, Scripts: scripts})
+	}
+}
+
+// WithDockerProvider creates the network, the sidecars and the test container
+// through the given testcontainers provider instead of one configured from the
+// environment (DOCKER_HOST, ~/.testcontainers.properties, ...). The provider is
+// not closed by Run.
+//
+// Example:
+//
+//	provider, err := testcontainers.NewDockerProvider(testcontainers.WithLogger(logger))
+//	// ...
+//	dockertesting.Run(ctx, path, dockertesting.WithDockerProvider(provider))
+func WithDockerProvider(provider *testcontainers.DockerProvider) Option {
+	return func(o *Options) {
+		o.Provider = provider
+	}
+}
+
+// WithDockerClient sends all Docker API calls of the run through the given
+// client, e.g. one with custom TLS settings, a proxy or API middleware, or a
+// fake in unit tests. The client is not closed by Run.
+// It is ignored if WithDockerProvider is also set.
+//
+// Example:
+//
+//	cli, err := client.NewClientWithOpts(client.WithHost(host), client.WithHTTPClient(httpClient))
+//	// ...
+//	dockertesting.Run(ctx, path, dockertesting.WithDockerClient(cli))
+func WithDockerClient(cli client.APIClient) Option {
+	return func(o *Options) {
+		o.DockerClient = cli
+	}
 }
@@ -1,8 +1,17 @@
 package dockertesting
 
 import (
+	"context"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/testcontainers/testcontainers-go"
 )
 
 func TestNewOptions_RequiresPackagePath(t *testing.T) {
@@ -88,6 +97,18 @@ func TestWithArgs_Multiple(t *testing.T) {
 	}
 }
 
+func TestWithName(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithName("payments-integration"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.Name != "payments-integration" {
+		t.Errorf("expected Name %q, got %q", "payments-integration", opts.Name)
+	}
+}
+
 func TestWithAliases(t *testing.T) {
 	t.Parallel()
 	opts, err := NewOptions("/path/to/package", WithAliases("myapp.test", "api.test"))
@@ -118,6 +139,179 @@ func TestWithAliases_Multiple(t *testing.T) {
 	}
 }
 
+func TestWithNetwork(t *testing.T) {
+	t.Parallel()
+	network := &DockerNetwork{Name: "shared"}
+	opts, err := NewOptions("/path/to/package", WithNetwork(network))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.Network != network {
+		t.Errorf("expected Network %v, got %v", network, opts.Network)
+	}
+}
+
+func TestWithSecretEnv(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package",
+		WithSecretEnv("API_TOKEN", "s3cr3t"),
+		WithSecretEnv("DB_PASSWORD", "hunter2"),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{"API_TOKEN": "s3cr3t", "DB_PASSWORD": "hunter2"}
+	if !maps.Equal(opts.SecretEnv, expected) {
+		t.Errorf("expected SecretEnv %v, got %v", expected, opts.SecretEnv)
+	}
+}
+
+func TestWithSockGuard(t *testing.T) {
+	t.Parallel()
+	guard := SockGuard{TrustedRegistries: []string{"docker.io/library"}, RequireLabel: true, Mode: SockGuardFail}
+	opts, err := NewOptions("/path/to/package", WithVarSock(), WithSockGuard(guard))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.SockGuard == nil {
+		t.Fatal("expected a sock guard")
+	}
+	if !slices.Equal(opts.SockGuard.TrustedRegistries, guard.TrustedRegistries) || !opts.SockGuard.RequireLabel || opts.SockGuard.Mode != SockGuardFail {
+		t.Errorf("expected sock guard %+v, got %+v", guard, *opts.SockGuard)
+	}
+}
+
+func TestWithReadOnlySource(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithReadOnlySource())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !opts.ReadOnlySource {
+		t.Error("expected ReadOnlySource to be enabled")
+	}
+}
+
+func TestWithSocketProxy(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithSocketProxy())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !opts.SocketProxy {
+		t.Error("expected SocketProxy to be enabled")
+	}
+	if opts.EnableVarSock {
+		t.Error("expected the socket not to be mounted")
+	}
+	if len(opts.SocketProxyWrite) != 0 {
+		t.Errorf("expected the proxy to be read-only, got write access to %v", opts.SocketProxyWrite)
+	}
+
+	opts, err = NewOptions("/path/to/package", WithSocketProxy(SocketProxyContainers), WithSocketProxy(SocketProxyImages))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []SocketProxySection{SocketProxyContainers, SocketProxyImages}; !slices.Equal(opts.SocketProxyWrite, expected) {
+		t.Errorf("expected write access to %v, got %v", expected, opts.SocketProxyWrite)
+	}
+}
+
+func TestWithRoot(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Root {
+		t.Error("expected the tests to run as the non-root user by default")
+	}
+
+	opts, err = NewOptions("/path/to/package", WithRoot())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !opts.Root {
+		t.Error("expected Root to be enabled")
+	}
+}
+
+func TestWithHostProxyEnv(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithHostProxyEnv())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !opts.HostProxyEnv {
+		t.Error("expected HostProxyEnv to be enabled")
+	}
+}
+
+func TestWithTLSBundle(t *testing.T) {
+	t.Parallel()
+	certsDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(certsDir, "corp.crt"), []byte("certificate"), 0644); err != nil {
+		t.Fatalf("failed to write certificate: %v", err)
+	}
+	opts, err := NewOptions("/path/to/package", WithTLSBundle(certsDir))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.TLSBundle != certsDir {
+		t.Errorf("expected TLSBundle %q, got %q", certsDir, opts.TLSBundle)
+	}
+}
+
+func TestWithHostPorts(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithHostPorts(8080), WithHostPorts(5432, 6379))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !slices.Equal(opts.HostPorts, []int{8080, 5432, 6379}) {
+		t.Errorf("expected HostPorts [8080 5432 6379], got %v", opts.HostPorts)
+	}
+}
+
+func TestWithStaticIP(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithNetworkCIDR("172.28.0.0/16"), WithStaticIP("172.28.0.10"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.NetworkCIDR != "172.28.0.0/16" || opts.StaticIP != "172.28.0.10" {
+		t.Errorf("expected NetworkCIDR 172.28.0.0/16 and StaticIP 172.28.0.10, got %q and %q", opts.NetworkCIDR, opts.StaticIP)
+	}
+	if err := opts.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWithNetworkDriver(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package",
+		WithNetworkDriver("bridge", map[string]string{NetworkOptionMTU: "1500", NetworkOptionICC: "false"}),
+		WithNetworkDriver("bridge", map[string]string{NetworkOptionMTU: "1400"}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{NetworkOptionMTU: "1400", NetworkOptionICC: "false"}
+	if opts.NetworkDriver != "bridge" || !maps.Equal(opts.NetworkDriverOptions, expected) {
+		t.Errorf("expected bridge with %v, got %q with %v", expected, opts.NetworkDriver, opts.NetworkDriverOptions)
+	}
+}
+
 func TestWithVarSock(t *testing.T) {
 	t.Parallel()
 	opts, err := NewOptions("/path/to/package", WithVarSock())
@@ -225,3 +419,825 @@ func TestWithTimeout_ShortDuration(t *testing.T) {
 		t.Errorf("expected Timeout %v, got %v", shortTimeout, opts.Timeout)
 	}
 }
+
+func TestWithCleanupTimeout(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.CleanupTimeout != DefaultCleanupTimeout {
+		t.Errorf("expected CleanupTimeout %v, got %v", DefaultCleanupTimeout, opts.CleanupTimeout)
+	}
+
+	opts, err = NewOptions("/path/to/package", WithCleanupTimeout(2*time.Minute))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.CleanupTimeout != 2*time.Minute {
+		t.Errorf("expected CleanupTimeout %v, got %v", 2*time.Minute, opts.CleanupTimeout)
+	}
+}
+
+func TestWithInit(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !opts.Init {
+		t.Error("expected Init to be true by default")
+	}
+
+	opts, err = NewOptions("/path/to/package", WithInit(false))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Init {
+		t.Error("expected Init to be false")
+	}
+}
+
+func TestWithStopTimeout(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.StopTimeout != 0 {
+		t.Errorf("expected StopTimeout 0 by default, got %v", opts.StopTimeout)
+	}
+
+	opts, err = NewOptions("/path/to/package", WithStopTimeout(10*time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.StopTimeout != 10*time.Second {
+		t.Errorf("expected StopTimeout %v, got %v", 10*time.Second, opts.StopTimeout)
+	}
+}
+
+func TestWithSetupCommands(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package",
+		WithSetupCommands([]string{"go", "generate", "./..."}),
+		WithSetupCommands([]string{"sh", "-c", "echo seed"}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(opts.SetupCommands) != 2 {
+		t.Fatalf("expected 2 SetupCommands, got %d", len(opts.SetupCommands))
+	}
+	if opts.SetupCommands[0][0] != "go" {
+		t.Errorf("expected SetupCommands[0][0] 'go', got %q", opts.SetupCommands[0][0])
+	}
+	if opts.SetupCommands[1][2] != "echo seed" {
+		t.Errorf("expected SetupCommands[1][2] 'echo seed', got %q", opts.SetupCommands[1][2])
+	}
+}
+
+func TestWithTeardownCommands(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package",
+		WithTeardownCommands([]string{"cat", "/tmp/app.log"}),
+		WithTeardownCommands([]string{"pg_dump", "app"}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(opts.TeardownCommands) != 2 {
+		t.Fatalf("expected 2 TeardownCommands, got %d", len(opts.TeardownCommands))
+	}
+	if opts.TeardownCommands[1][0] != "pg_dump" {
+		t.Errorf("expected TeardownCommands[1][0] 'pg_dump', got %q", opts.TeardownCommands[1][0])
+	}
+}
+
+func TestWithOutputCallback(t *testing.T) {
+	t.Parallel()
+	var lines []OutputLine
+	opts, err := NewOptions("/path/to/package", WithOutputCallback(func(line OutputLine) {
+		lines = append(lines, line)
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.OutputCallback == nil {
+		t.Fatal("expected OutputCallback to be set")
+	}
+	opts.OutputCallback(OutputLine{Text: "hello"})
+	if len(lines) != 1 || lines[0].Text != "hello" {
+		t.Errorf("expected callback to receive line, got %v", lines)
+	}
+}
+
+func TestWithProgressReporter(t *testing.T) {
+	t.Parallel()
+	reporter := ProgressReporterFunc(func(ProgressEvent) {})
+	opts, err := NewOptions("/path/to/package", WithProgressReporter(reporter))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.ProgressReporter == nil {
+		t.Error("expected ProgressReporter to be set")
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	t.Parallel()
+	logger := slog.New(slog.DiscardHandler)
+	opts, err := NewOptions("/path/to/package", WithLogger(logger))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.Logger != logger {
+		t.Error("expected Logger to be set")
+	}
+}
+
+func TestWithMetrics(t *testing.T) {
+	t.Parallel()
+	metrics := NewExpvarMetrics("dockertesting_test_options")
+	opts, err := NewOptions("/path/to/package", WithMetrics(metrics))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.Metrics != metrics {
+		t.Error("expected Metrics to be set")
+	}
+}
+
+func TestWithHooks(t *testing.T) {
+	t.Parallel()
+	called := false
+	opts, err := NewOptions("/path/to/package", WithHooks(Hooks{
+		OnCleanup: func(ctx context.Context, event HookEvent) {
+			called = true
+		},
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.Hooks.OnCleanup == nil {
+		t.Fatal("expected OnCleanup to be set")
+	}
+	opts.Hooks.OnCleanup(context.Background(), HookEvent{})
+	if !called {
+		t.Error("expected OnCleanup to be the configured hook")
+	}
+	if opts.Hooks.OnBuildStart != nil {
+		t.Error("expected OnBuildStart to be unset")
+	}
+}
+
+func TestWithFailFast(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithFailFast())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !opts.FailFast {
+		t.Error("expected FailFast to be true")
+	}
+}
+
+func TestWithShort(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithShort())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !opts.Short {
+		t.Error("expected Short to be true")
+	}
+}
+
+func TestNewOptions_DefaultVerbosity(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.Verbosity != VerbosityNormal {
+		t.Errorf("expected Verbosity %v, got %v", VerbosityNormal, opts.Verbosity)
+	}
+}
+
+func TestWithVerbosity(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithVerbosity(VerbosityQuiet))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.Verbosity != VerbosityQuiet {
+		t.Errorf("expected Verbosity %v, got %v", VerbosityQuiet, opts.Verbosity)
+	}
+}
+
+func TestWithCoverMode(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithCoverMode(CoverModeCount))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.CoverMode != CoverModeCount {
+		t.Errorf("expected CoverMode %q, got %q", CoverModeCount, opts.CoverMode)
+	}
+}
+
+func TestWithCoverageOutput(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithCoverageOutput("reports/coverage.out"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.CoverageOutput != "reports/coverage.out" {
+		t.Errorf("expected CoverageOutput 'reports/coverage.out', got %q", opts.CoverageOutput)
+	}
+}
+
+func TestWithCoberturaReport(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithCoberturaReport())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !opts.CoberturaReport {
+		t.Error("expected CoberturaReport to be true")
+	}
+}
+
+func TestWithCPUProfile(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithCPUProfile())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !opts.CPUProfile {
+		t.Error("expected CPUProfile to be true")
+	}
+}
+
+func TestWithMemProfile(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithMemProfile())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !opts.MemProfile {
+		t.Error("expected MemProfile to be true")
+	}
+	if opts.MemProfileRate != 0 {
+		t.Errorf("expected MemProfileRate 0, got %d", opts.MemProfileRate)
+	}
+}
+
+func TestWithMemProfileRate(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithMemProfileRate(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !opts.MemProfile {
+		t.Error("expected WithMemProfileRate to enable MemProfile")
+	}
+	if opts.MemProfileRate != 1 {
+		t.Errorf("expected MemProfileRate 1, got %d", opts.MemProfileRate)
+	}
+}
+
+func TestWithBlockAndMutexProfile(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithBlockProfile(), WithMutexProfile())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !opts.BlockProfile {
+		t.Error("expected BlockProfile to be true")
+	}
+	if !opts.MutexProfile {
+		t.Error("expected MutexProfile to be true")
+	}
+}
+
+func TestWithArtifacts(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package",
+		WithArtifacts("/tmp/reports/**"),
+		WithArtifacts("/app/logs/*.log"),
+		WithArtifactsDir("build/artifacts"),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(opts.Artifacts) != 2 {
+		t.Fatalf("expected 2 Artifacts, got %d", len(opts.Artifacts))
+	}
+	if opts.ArtifactsDir != "build/artifacts" {
+		t.Errorf("expected ArtifactsDir 'build/artifacts', got %q", opts.ArtifactsDir)
+	}
+}
+
+func TestWithCompileOnly(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithCompileOnly())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !opts.CompileOnly {
+		t.Error("expected CompileOnly to be true")
+	}
+}
+
+func TestWithKeepFailedBuild(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithKeepFailedBuild())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !opts.KeepFailedBuild {
+		t.Error("expected KeepFailedBuild to be true")
+	}
+}
+
+func TestWithSidecar(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package",
+		WithSidecar(SidecarSpec{Image: "postgres:17", Aliases: []string{"postgres.test"}}),
+		WithSidecar(SidecarSpec{Image: "redis:7", Aliases: []string{"redis.test"}}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(opts.Sidecars) != 2 {
+		t.Fatalf("expected 2 Sidecars, got %d", len(opts.Sidecars))
+	}
+	if opts.Sidecars[0].Image != "postgres:17" {
+		t.Errorf("expected first sidecar image 'postgres:17', got %q", opts.Sidecars[0].Image)
+	}
+	if opts.Sidecars[1].Image != "redis:7" {
+		t.Errorf("expected second sidecar image 'redis:7', got %q", opts.Sidecars[1].Image)
+	}
+}
+
+func TestWithWaitStrategy(t *testing.T) {
+	t.Parallel()
+	strategy := WaitForLog("listening")
+	opts, err := NewOptions("/path/to/package", WithWaitStrategy(strategy))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.WaitFor != strategy {
+		t.Errorf("expected WaitFor to be the configured strategy, got %v", opts.WaitFor)
+	}
+}
+
+func TestWithSignalCleanup(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithSignalCleanup())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !opts.SignalCleanup {
+		t.Error("expected SignalCleanup to be true")
+	}
+}
+
+func TestWithReaper(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Reaper != ReaperDefault {
+		t.Errorf("expected Reaper %v, got %v", ReaperDefault, opts.Reaper)
+	}
+
+	opts, err = NewOptions("/path/to/package", WithReaper(false))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Reaper != ReaperDisabled {
+		t.Errorf("expected Reaper %v, got %v", ReaperDisabled, opts.Reaper)
+	}
+
+	opts, err = NewOptions("/path/to/package", WithReaper(true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Reaper != ReaperEnabled {
+		t.Errorf("expected Reaper %v, got %v", ReaperEnabled, opts.Reaper)
+	}
+}
+
+func TestWithReaperSessionID(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithReaperSessionID("job-42"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.ReaperSessionID != "job-42" {
+		t.Errorf("expected ReaperSessionID %q, got %q", "job-42", opts.ReaperSessionID)
+	}
+}
+
+func TestWithKeepResources(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithKeepResources())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !opts.KeepResources {
+		t.Error("expected KeepResources to be true")
+	}
+}
+
+func TestWithKeepOnFailure(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.KeepOnFailure {
+		t.Error("expected KeepOnFailure to be false by default")
+	}
+
+	opts, err = NewOptions("/path/to/package", WithKeepOnFailure())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !opts.KeepOnFailure {
+		t.Error("expected KeepOnFailure to be true")
+	}
+}
+
+func TestWithProbe(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package",
+		WithProbe(ProbeTarget{Alias: "postgres.test", Port: 5432}),
+		WithProbe(ProbeTarget{Alias: "nginx.test", Port: 80, HTTPPath: "/"}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(opts.Probes) != 2 {
+		t.Fatalf("expected 2 Probes, got %d", len(opts.Probes))
+	}
+	if opts.Probes[1].HTTPPath != "/" {
+		t.Errorf("expected second probe HTTPPath '/', got %q", opts.Probes[1].HTTPPath)
+	}
+}
+
+func TestWithSeed(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package",
+		WithSeed("postgres", "schema.sql", "rows.sql"),
+		WithSeed("redis", "seed.sh"),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(opts.Seeds) != 2 {
+		t.Fatalf("expected 2 Seeds, got %d", len(opts.Seeds))
+	}
+	if opts.Seeds[0].Sidecar != "postgres" || len(opts.Seeds[0].Scripts) != 2 {
+		t.Errorf("expected postgres seed with 2 scripts, got %+v", opts.Seeds[0])
+	}
+}
+
+func TestWithDockerProvider(t *testing.T) {
+	t.Parallel()
+	provider := &testcontainers.DockerProvider{}
+	opts, err := NewOptions("/path/to/package", WithDockerProvider(provider))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.Provider != provider {
+		t.Error("expected Provider to be the given provider")
+	}
+}
+
+func TestWithDockerClient(t *testing.T) {
+	t.Parallel()
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://docker.example.com:2376"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	opts, err := NewOptions("/path/to/package", WithDockerClient(cli))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.DockerClient != cli {
+		t.Error("expected DockerClient to be the given client")
+	}
+}
+
+func TestWithContainerdCompat(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithContainerdCompat())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !opts.ContainerdCompat {
+		t.Error("expected ContainerdCompat to be true")
+	}
+}
+
+func TestWithImageCache(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithImageCache(true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !opts.ImageCache {
+		t.Error("expected ImageCache to be true")
+	}
+
+	opts, err = NewOptions("/path/to/package", WithImageCache(true), WithImageCache(false))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.ImageCache {
+		t.Error("expected the last WithImageCache call to win")
+	}
+}
+
+func TestWithMaxContextSize(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithMaxContextSize(100<<20))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.MaxContextSize != 100<<20 {
+		t.Errorf("expected MaxContextSize %d, got %d", 100<<20, opts.MaxContextSize)
+	}
+}
+
+func TestWithBuildCacheRegistry(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithBuildCacheRegistry("ghcr.io/acme/cache:main"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.BuildCacheRef != "ghcr.io/acme/cache:main" {
+		t.Errorf("expected BuildCacheRef %q, got %q", "ghcr.io/acme/cache:main", opts.BuildCacheRef)
+	}
+}
+
+func TestWithBuildCacheDir(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithBuildCacheDir(".cache/dockertesting"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.BuildCacheDir != ".cache/dockertesting" {
+		t.Errorf("expected BuildCacheDir %q, got %q", ".cache/dockertesting", opts.BuildCacheDir)
+	}
+}
+
+func TestWithContextExcludes(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.ContextExcludes != nil {
+		t.Errorf("expected nil ContextExcludes by default, got %v", opts.ContextExcludes)
+	}
+
+	opts, err = NewOptions("/path/to/package", WithContextExcludes("testdata/large", "*.log"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(opts.ContextExcludes) != 2 || opts.ContextExcludes[0] != "testdata/large" || opts.ContextExcludes[1] != "*.log" {
+		t.Errorf("expected ContextExcludes [testdata/large *.log], got %v", opts.ContextExcludes)
+	}
+
+	opts, err = NewOptions("/path/to/package", WithContextExcludes())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.ContextExcludes == nil || len(opts.ContextExcludes) != 0 {
+		t.Errorf("expected empty non-nil ContextExcludes, got %#v", opts.ContextExcludes)
+	}
+}
+
+func TestWithBuildKit(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithBuildKit())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !opts.BuildKit {
+		t.Error("expected BuildKit to be true")
+	}
+}
+
+func TestWithGoVersion(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithGoVersion("1.23"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.GoVersion != "1.23" {
+		t.Errorf("expected GoVersion %q, got %q", "1.23", opts.GoVersion)
+	}
+}
+
+func TestWithBuildTarget(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithBuildTarget("test"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.BuildTarget != "test" {
+		t.Errorf("expected BuildTarget %q, got %q", "test", opts.BuildTarget)
+	}
+}
+
+func TestWithImageFlavor(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithImageFlavor(ImageFlavorAlpine))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.ImageFlavor != ImageFlavorAlpine {
+		t.Errorf("expected ImageFlavor %q, got %q", ImageFlavorAlpine, opts.ImageFlavor)
+	}
+}
+
+func TestWithGoTools(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package",
+		WithGoTools("golang.org/x/tools/cmd/stringer@latest"),
+		WithGoTools("github.com/golang-migrate/migrate/v4/cmd/migrate@v4.18.1"),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"golang.org/x/tools/cmd/stringer@latest", "github.com/golang-migrate/migrate/v4/cmd/migrate@v4.18.1"}
+	if !slices.Equal(opts.GoTools, expected) {
+		t.Errorf("expected GoTools %v, got %v", expected, opts.GoTools)
+	}
+}
+
+func TestWithDockerfileGenerator(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithDockerfileGenerator(TemplateGenerator{}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := opts.DockerfileGenerator.(TemplateGenerator); !ok {
+		t.Errorf("expected DockerfileGenerator TemplateGenerator, got %T", opts.DockerfileGenerator)
+	}
+}
+
+func TestWithTemplate(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithTemplate("FROM golang:{{.Version}}"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.Template != "FROM golang:{{.Version}}" {
+		t.Errorf("expected Template %q, got %q", "FROM golang:{{.Version}}", opts.Template)
+	}
+}
+
+func TestWithTemplateData(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package",
+		WithTemplateData(map[string]any{"PreRun": []string{"a"}, "Version": "1.23"}),
+		WithTemplateData(map[string]any{"Version": "1.24"}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(opts.TemplateData) != 2 || opts.TemplateData["Version"] != "1.24" {
+		t.Errorf("expected cumulative TemplateData with Version 1.24, got %v", opts.TemplateData)
+	}
+}
+
+func TestWithToolchain(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithToolchain("go1.23.4"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.Toolchain != "go1.23.4" {
+		t.Errorf("expected Toolchain %q, got %q", "go1.23.4", opts.Toolchain)
+	}
+}
+
+func TestWithGitCredentials(t *testing.T) {
+	t.Parallel()
+	github := GitCredentials{Host: "github.com", Token: "secret"}
+	gitlab := GitCredentials{Host: "gitlab.com", Helper: "store"}
+	opts, err := NewOptions("/path/to/package", WithGitCredentials(github), WithGitCredentials(gitlab))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(opts.GitCredentials) != 2 || opts.GitCredentials[0].Host != "github.com" || opts.GitCredentials[1].Host != "gitlab.com" {
+		t.Errorf("expected GitCredentials for github.com and gitlab.com, got %+v", opts.GitCredentials)
+	}
+}
+
+func TestWithPackageSubdir(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		"internal/service":    "internal/service",
+		"./internal/service/": "internal/service",
+		".":                   "",
+	}
+	for dir, expected := range tests {
+		opts, err := NewOptions("/path/to/package", WithPackageSubdir(dir))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if opts.PackageSubdir != expected {
+			t.Errorf("WithPackageSubdir(%q): expected PackageSubdir %q, got %q", dir, expected, opts.PackageSubdir)
+		}
+	}
+}
+
+func TestWithParallelModules(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithParallelModules(4))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.ParallelModules != 4 {
+		t.Errorf("expected ParallelModules 4, got %d", opts.ParallelModules)
+	}
+}
+
+func TestWithVendor(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		enabled bool
+		want    VendorMode
+	}{
+		{true, VendorEnabled},
+		{false, VendorDisabled},
+	} {
+		opts, err := NewOptions("/path/to/package", WithVendor(tt.enabled))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if opts.Vendor != tt.want {
+			t.Errorf("WithVendor(%t): expected Vendor %d, got %d", tt.enabled, tt.want, opts.Vendor)
+		}
+	}
+}
+
+func TestWithLazyModDownload(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithLazyModDownload())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !opts.LazyModDownload {
+		t.Error("expected LazyModDownload to be true")
+	}
+}
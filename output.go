@@ -0,0 +1,83 @@
+package dockertesting
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+)
+
+// OutputSource identifies which phase of a run produced an output line.
+type OutputSource string
+
+const (
+	// OutputSourceBuild marks lines from the docker image build.
+	OutputSourceBuild OutputSource = "build"
+
+	// OutputSourceExec marks lines from commands executed in the container,
+	// including go test and setup/teardown commands.
+	OutputSourceExec OutputSource = "exec"
+)
+
+// OutputLine is a single line of output produced during a run.
+type OutputLine struct {
+	// Text is the content of the line without the trailing newline.
+	Text string
+
+	// Time is when the line was received.
+	Time time.Time
+
+	// Source identifies whether the line came from the build or from exec.
+	Source OutputSource
+}
+
+// lineWriter is an io.Writer that splits written data into lines and invokes
+// a callback for each complete line. Incomplete trailing data is buffered until
+// more data arrives or Flush is called.
+type lineWriter struct {
+	source   OutputSource
+	callback func(OutputLine)
+	buf      []byte
+}
+
+// newLineWriter creates a lineWriter that reports lines from source to callback.
+func newLineWriter(source OutputSource, callback func(OutputLine)) *lineWriter {
+	return &lineWriter{source: source, callback: callback}
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush reports any buffered partial line.
+func (w *lineWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.emit(w.buf)
+		w.buf = nil
+	}
+}
+
+func (w *lineWriter) emit(line []byte) {
+	w.callback(OutputLine{
+		Text:   string(bytes.TrimSuffix(line, []byte("\r"))),
+		Time:   time.Now(),
+		Source: w.source,
+	})
+}
+
+// prefixLines returns a line callback writing the lines to w prefixed with
+// "[name] ", so that the output of concurrent runs can be told apart.
+func prefixLines(w io.Writer, name string) func(OutputLine) {
+	return func(line OutputLine) {
+		fmt.Fprintf(w, "[%s] %s\n", name, line.Text)
+	}
+}
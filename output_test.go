@@ -0,0 +1,95 @@
+package dockertesting
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestLineWriter_SplitsLines(t *testing.T) {
+	t.Parallel()
+	var lines []OutputLine
+	w := newLineWriter(OutputSourceExec, func(line OutputLine) {
+		lines = append(lines, line)
+	})
+
+	// Write data split across line boundaries
+	if _, err := w.Write([]byte("=== RUN   TestAdd\n--- PA")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := w.Write([]byte("SS: TestAdd\r\nok")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 complete lines, got %d: %v", len(lines), lines)
+	}
+	if lines[0].Text != "=== RUN   TestAdd" {
+		t.Errorf("expected first line '=== RUN   TestAdd', got %q", lines[0].Text)
+	}
+	if lines[1].Text != "--- PASS: TestAdd" {
+		t.Errorf("expected second line '--- PASS: TestAdd', got %q", lines[1].Text)
+	}
+
+	// The trailing partial line is only reported on Flush
+	w.Flush()
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines after flush, got %d", len(lines))
+	}
+	if lines[2].Text != "ok" {
+		t.Errorf("expected flushed line 'ok', got %q", lines[2].Text)
+	}
+
+	for _, line := range lines {
+		if line.Source != OutputSourceExec {
+			t.Errorf("expected source %q, got %q", OutputSourceExec, line.Source)
+		}
+		if line.Time.IsZero() {
+			t.Error("expected line time to be set")
+		}
+	}
+}
+
+func TestLineWriter_FlushEmpty(t *testing.T) {
+	t.Parallel()
+	called := false
+	w := newLineWriter(OutputSourceBuild, func(OutputLine) {
+		called = true
+	})
+
+	w.Flush()
+	if called {
+		t.Error("expected no callback when flushing an empty writer")
+	}
+}
+
+func TestRunOutputs_NamePrefix(t *testing.T) {
+	t.Parallel()
+	var stdout bytes.Buffer
+	var lines []OutputLine
+	opts, err := NewOptions("/path/to/package",
+		WithName("payments"),
+		WithVerbosity(VerbosityVerbose),
+		WithOutputCallback(func(line OutputLine) {
+			lines = append(lines, line)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	opts.stdout = &stdout
+
+	buildOutput, execOutput, flush := runOutputs(opts)
+	fmt.Fprint(buildOutput, "Step 1/4\n")
+	fmt.Fprint(execOutput, "=== RUN   TestAdd\nok")
+	flush()
+
+	want := "[payments] Step 1/4\n[payments] === RUN   TestAdd\n[payments] ok\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("expected output %q, got %q", want, got)
+	}
+	// The callback receives the lines without the prefix
+	if len(lines) != 3 || lines[1].Text != "=== RUN   TestAdd" {
+		t.Errorf("expected unprefixed lines, got %v", lines)
+	}
+}
@@ -0,0 +1,146 @@
+package dockertesting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	tclog "github.com/testcontainers/testcontainers-go/log"
+)
+
+// DefaultProbeImage is the image of the short-lived container that runs probes.
+const DefaultProbeImage = "busybox:1.37"
+
+// ProbeTarget is a service on the test network that a probe checks.
+type ProbeTarget struct {
+	// Alias is the DNS alias that must resolve on the network.
+	Alias string
+
+	// Port is the TCP port that must accept connections (optional).
+	// If 0, only DNS resolution is checked.
+	Port int
+
+	// HTTPPath, if set, requires a GET request to the path on Port to succeed
+	// instead of only checking that the port accepts connections.
+	HTTPPath string
+}
+
+func (t ProbeTarget) String() string {
+	if t.Port == 0 {
+		return t.Alias
+	}
+	return t.Alias + ":" + strconv.Itoa(t.Port) + t.HTTPPath
+}
+
+// ProbeError represents a failed probe of a service on the test network.
+type ProbeError struct {
+	// Target is the probed service.
+	Target ProbeTarget
+
+	// Reason describes which check failed.
+	Reason string
+
+	// Output contains the output of the probe command.
+	Output []byte
+
+	// Err is the underlying error if the probe could not be executed.
+	Err error
+}
+
+func (e *ProbeError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("probe of %s failed: %v", e.Target, e.Err)
+	}
+	return fmt.Sprintf("probe of %s failed: %s", e.Target, e.Reason)
+}
+
+func (e *ProbeError) Unwrap() error {
+	return e.Err
+}
+
+// probeScript checks the target given as $1 (alias), $2 (port) and $3 (HTTP path).
+// Its exit code tells which check failed, see probeFailureReason.
+const probeScript = `
+nslookup "$1" >/dev/null 2>&1 || exit 2
+[ "$2" = 0 ] && exit 0
+if [ -n "$3" ]; then
+	wget -q -O /dev/null -T 5 "http://$1:$2$3" || exit 4
+else
+	nc -z -w 5 "$1" "$2" || exit 3
+fi
+`
+
+// probeFailureReason maps an exit code of probeScript to a description.
+func probeFailureReason(target ProbeTarget, exitCode int) string {
+	switch exitCode {
+	case 2:
+		return fmt.Sprintf("alias %q does not resolve on the network", target.Alias)
+	case 3:
+		return fmt.Sprintf("alias %q resolves but port %d does not accept connections", target.Alias, target.Port)
+	case 4:
+		return fmt.Sprintf("alias %q resolves but GET %s on port %d failed", target.Alias, target.HTTPPath, target.Port)
+	default:
+		return fmt.Sprintf("probe exited with code %d", exitCode)
+	}
+}
+
+// Probe verifies from inside the network that each target's alias resolves and
+// its service responds, so alias misconfiguration is detected before the test
+// suite runs and fails cryptically. It starts a short-lived DefaultProbeImage
+// container on the network to run the checks and removes it afterwards.
+//
+// The first failing target is returned as a *ProbeError.
+//
+// Example:
+//
+//	err := dockertesting.Probe(ctx, network, nil,
+//	    dockertesting.ProbeTarget{Alias: "postgres.test", Port: 5432},
+//	    dockertesting.ProbeTarget{Alias: "api.test", Port: 8080, HTTPPath: "/healthz"},
+//	)
+func Probe(ctx context.Context, dn *DockerNetwork, logger tclog.Logger, targets ...ProbeTarget) error {
+	if len(targets) == 0 {
+		return nil
+	}
+
+	prober, err := StartSidecar(ctx, dn, SidecarSpec{
+		Name:  "probe",
+		Image: DefaultProbeImage,
+		Cmd:   []string{"sleep", "3600"},
+	}, logger)
+	if err != nil {
+		return fmt.Errorf("failed to start probe container: %w", err)
+	}
+	defer func() {
+		_ = prober.Terminate(context.WithoutCancel(ctx))
+	}()
+
+	for _, target := range targets {
+		if err := probeTarget(ctx, prober, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// probeTarget runs probeScript for target in the given container.
+func probeTarget(ctx context.Context, container commandExecutor, target ProbeTarget) error {
+	if target.Alias == "" {
+		return &ProbeError{Target: target, Err: errors.New("alias must not be empty")}
+	}
+
+	result, err := container.ExecCommand(ctx, []string{
+		"sh", "-c", probeScript, "sh", target.Alias, strconv.Itoa(target.Port), target.HTTPPath,
+	}, ExecOptions{})
+	if err != nil {
+		return &ProbeError{Target: target, Err: err}
+	}
+	if result.ExitCode != 0 {
+		return &ProbeError{
+			Target: target,
+			Reason: probeFailureReason(target, result.ExitCode),
+			Output: result.Stdout,
+		}
+	}
+	return nil
+}
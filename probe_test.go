@@ -0,0 +1,78 @@
+package dockertesting
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestProbeTarget_String(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		target   ProbeTarget
+		expected string
+	}{
+		{target: ProbeTarget{Alias: "postgres.test"}, expected: "postgres.test"},
+		{target: ProbeTarget{Alias: "postgres.test", Port: 5432}, expected: "postgres.test:5432"},
+		{target: ProbeTarget{Alias: "api.test", Port: 8080, HTTPPath: "/healthz"}, expected: "api.test:8080/healthz"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.target.String(); got != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, got)
+		}
+	}
+}
+
+func TestProbeFailureReason(t *testing.T) {
+	t.Parallel()
+	target := ProbeTarget{Alias: "api.test", Port: 8080, HTTPPath: "/healthz"}
+
+	tests := []struct {
+		exitCode int
+		contains string
+	}{
+		{exitCode: 2, contains: "does not resolve"},
+		{exitCode: 3, contains: "port 8080 does not accept connections"},
+		{exitCode: 4, contains: "GET /healthz on port 8080 failed"},
+		{exitCode: 1, contains: "exited with code 1"},
+	}
+
+	for _, tt := range tests {
+		if got := probeFailureReason(target, tt.exitCode); !strings.Contains(got, tt.contains) {
+			t.Errorf("exit code %d: expected reason containing %q, got %q", tt.exitCode, tt.contains, got)
+		}
+	}
+}
+
+func TestProbe_NoTargets(t *testing.T) {
+	t.Parallel()
+
+	if err := Probe(context.Background(), nil, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestProbeTarget_NilContainer(t *testing.T) {
+	t.Parallel()
+
+	err := probeTarget(context.Background(), &TestContainer{}, ProbeTarget{Alias: "db.test", Port: 5432})
+	var probeErr *ProbeError
+	if !errors.As(err, &probeErr) {
+		t.Fatalf("expected *ProbeError, got %T: %v", err, err)
+	}
+	if probeErr.Target.Alias != "db.test" {
+		t.Errorf("expected target alias 'db.test', got %q", probeErr.Target.Alias)
+	}
+}
+
+func TestProbeTarget_EmptyAlias(t *testing.T) {
+	t.Parallel()
+
+	err := probeTarget(context.Background(), &TestContainer{}, ProbeTarget{Port: 5432})
+	if err == nil || !strings.Contains(err.Error(), "alias must not be empty") {
+		t.Errorf("expected empty alias error, got %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package dockertesting
+
+import (
+	"context"
+	"strconv"
+)
+
+// DefaultCPUProfileFile is the path inside the container where the CPU profile is written.
+const DefaultCPUProfileFile = "/tmp/cpu.pprof"
+
+// DefaultMemProfileFile is the path inside the container where the memory profile is written.
+const DefaultMemProfileFile = "/tmp/mem.pprof"
+
+// DefaultBlockProfileFile is the path inside the container where the block profile is written.
+const DefaultBlockProfileFile = "/tmp/block.pprof"
+
+// DefaultMutexProfileFile is the path inside the container where the mutex profile is written.
+const DefaultMutexProfileFile = "/tmp/mutex.pprof"
+
+// profileFlags returns the go test flags for the profiles enabled in options.
+func profileFlags(options *Options) []string {
+	var flags []string
+	if options.CPUProfile {
+		flags = append(flags, "-cpuprofile="+DefaultCPUProfileFile)
+	}
+	if options.MemProfile {
+		flags = append(flags, "-memprofile="+DefaultMemProfileFile)
+		if options.MemProfileRate != 0 {
+			flags = append(flags, "-memprofilerate="+strconv.Itoa(options.MemProfileRate))
+		}
+	}
+	if options.BlockProfile {
+		flags = append(flags, "-blockprofile="+DefaultBlockProfileFile)
+	}
+	if options.MutexProfile {
+		flags = append(flags, "-mutexprofile="+DefaultMutexProfileFile)
+	}
+	return flags
+}
+
+// copyProfiles copies the profiles enabled in options out of the container into res.
+// Missing profiles are non-fatal, as they are not written if the tests failed to build.
+func copyProfiles(ctx context.Context, container *TestContainer, options *Options, res *Result) {
+	if options.CPUProfile {
+		res.CPUProfile, _ = container.CopyFileFromContainer(ctx, DefaultCPUProfileFile)
+	}
+	if options.MemProfile {
+		res.MemProfile, _ = container.CopyFileFromContainer(ctx, DefaultMemProfileFile)
+	}
+	if options.BlockProfile {
+		res.BlockProfile, _ = container.CopyFileFromContainer(ctx, DefaultBlockProfileFile)
+	}
+	if options.MutexProfile {
+		res.MutexProfile, _ = container.CopyFileFromContainer(ctx, DefaultMutexProfileFile)
+	}
+}
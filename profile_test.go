@@ -0,0 +1,73 @@
+package dockertesting
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestProfileFlags_None(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if flags := profileFlags(opts); len(flags) != 0 {
+		t.Errorf("expected no profile flags, got %v", flags)
+	}
+}
+
+func TestProfileFlags_CPU(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithCPUProfile())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	flags := profileFlags(opts)
+	if !slices.Contains(flags, "-cpuprofile="+DefaultCPUProfileFile) {
+		t.Errorf("expected -cpuprofile flag, got %v", flags)
+	}
+	if !slices.Contains(buildTestCommand(opts), "-cpuprofile="+DefaultCPUProfileFile) {
+		t.Error("expected go test command to contain -cpuprofile flag")
+	}
+}
+
+func TestProfileFlags_Mem(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithMemProfile())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	flags := profileFlags(opts)
+	if !slices.Contains(flags, "-memprofile="+DefaultMemProfileFile) {
+		t.Errorf("expected -memprofile flag, got %v", flags)
+	}
+	for _, flag := range flags {
+		if flag == "-memprofilerate=0" {
+			t.Error("expected no -memprofilerate flag without a rate")
+		}
+	}
+
+	WithMemProfileRate(512)(opts)
+	if !slices.Contains(profileFlags(opts), "-memprofilerate=512") {
+		t.Errorf("expected -memprofilerate=512, got %v", profileFlags(opts))
+	}
+}
+
+func TestProfileFlags_BlockAndMutex(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithBlockProfile(), WithMutexProfile())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	flags := profileFlags(opts)
+	if !slices.Contains(flags, "-blockprofile="+DefaultBlockProfileFile) {
+		t.Errorf("expected -blockprofile flag, got %v", flags)
+	}
+	if !slices.Contains(flags, "-mutexprofile="+DefaultMutexProfileFile) {
+		t.Errorf("expected -mutexprofile flag, got %v", flags)
+	}
+}
@@ -0,0 +1,100 @@
+package dockertesting
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// ProgressStage identifies a coarse milestone of a run.
+type ProgressStage string
+
+const (
+	// StageSidecarStarted is reported for every sidecar once it is ready.
+	StageSidecarStarted ProgressStage = "sidecar_started"
+
+	// StageTarCreated is reported once the build context archive has been created.
+	StageTarCreated ProgressStage = "tar_created"
+
+	// StageBuildStep is reported for every step of the docker image build.
+	StageBuildStep ProgressStage = "build_step"
+
+	// StageContainerStarted is reported once the test container is running.
+	StageContainerStarted ProgressStage = "container_started"
+
+	// StageTestsRunning is reported right before go test is executed.
+	StageTestsRunning ProgressStage = "tests_running"
+
+	// StageCoverageCopied is reported after the coverage file has been copied
+	// out of the container, by Run and Runner.Test. It is not reported if
+	// there is no coverage file, e.g. when the tests failed to compile.
+	StageCoverageCopied ProgressStage = "coverage_copied"
+)
+
+// ProgressEvent describes a milestone reached during a run.
+type ProgressEvent struct {
+	// Stage is the milestone that was reached.
+	Stage ProgressStage
+
+	// Message is a human-readable description of the milestone.
+	Message string
+
+	// Step is the current build step (only set for StageBuildStep).
+	Step int
+
+	// TotalSteps is the total number of build steps (only set for StageBuildStep).
+	TotalSteps int
+
+	// Time is when the milestone was reached.
+	Time time.Time
+}
+
+// ProgressReporter receives progress events during a run, allowing long runs
+// to render a progress indicator instead of appearing hung.
+type ProgressReporter interface {
+	Progress(event ProgressEvent)
+}
+
+// ProgressReporterFunc is an adapter to allow the use of ordinary functions
+// as a ProgressReporter.
+type ProgressReporterFunc func(event ProgressEvent)
+
+// Progress calls f(event).
+func (f ProgressReporterFunc) Progress(event ProgressEvent) {
+	f(event)
+}
+
+// buildStepPattern matches the step lines of the classic docker builder, e.g.
+// "Step 3/5 : RUN go mod download".
+var buildStepPattern = regexp.MustCompile(`^Step (\d+)/(\d+) : (.*)$`)
+
+// reportProgress sends an event to the reporter if it is non-nil.
+func reportProgress(reporter ProgressReporter, event ProgressEvent) {
+	if reporter == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	reporter.Progress(event)
+}
+
+// newBuildProgressWriter returns a lineWriter that reports a StageBuildStep
+// event for every build step line in the docker build log.
+func newBuildProgressWriter(reporter ProgressReporter) *lineWriter {
+	return newLineWriter(OutputSourceBuild, func(line OutputLine) {
+		match := buildStepPattern.FindStringSubmatch(line.Text)
+		if match == nil {
+			return
+		}
+		step, _ := strconv.Atoi(match[1])
+		total, _ := strconv.Atoi(match[2])
+		reportProgress(reporter, ProgressEvent{
+			Stage:      StageBuildStep,
+			Message:    match[3],
+			Step:       step,
+			TotalSteps: total,
+			Time:       line.Time,
+		})
+	})
+}
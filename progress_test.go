@@ -0,0 +1,56 @@
+package dockertesting
+
+import (
+	"testing"
+)
+
+func TestReportProgress_NilReporter(t *testing.T) {
+	t.Parallel()
+	// Must not panic
+	reportProgress(nil, ProgressEvent{Stage: StageTestsRunning})
+}
+
+func TestReportProgress_SetsTime(t *testing.T) {
+	t.Parallel()
+	var got ProgressEvent
+	reportProgress(ProgressReporterFunc(func(e ProgressEvent) {
+		got = e
+	}), ProgressEvent{Stage: StageContainerStarted})
+
+	if got.Stage != StageContainerStarted {
+		t.Errorf("expected stage %q, got %q", StageContainerStarted, got.Stage)
+	}
+	if got.Time.IsZero() {
+		t.Error("expected event time to be set")
+	}
+}
+
+func TestBuildProgressWriter_ReportsSteps(t *testing.T) {
+	t.Parallel()
+	var events []ProgressEvent
+	w := newBuildProgressWriter(ProgressReporterFunc(func(e ProgressEvent) {
+		events = append(events, e)
+	}))
+
+	log := "Step 1/3 : ARG GO_VERSION=1.25.6\n" +
+		" ---> Running in abc123\n" +
+		"Step 2/3 : FROM golang:${GO_VERSION}\n" +
+		"Step 3/3 : RUN go mod download\n"
+	if _, err := w.Write([]byte(log)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("expected 3 build step events, got %d", len(events))
+	}
+	last := events[2]
+	if last.Stage != StageBuildStep {
+		t.Errorf("expected stage %q, got %q", StageBuildStep, last.Stage)
+	}
+	if last.Step != 3 || last.TotalSteps != 3 {
+		t.Errorf("expected step 3/3, got %d/%d", last.Step, last.TotalSteps)
+	}
+	if last.Message != "RUN go mod download" {
+		t.Errorf("expected message 'RUN go mod download', got %q", last.Message)
+	}
+}
@@ -0,0 +1,110 @@
+package dockertesting
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/docker/docker/api/types/network"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// proxyEnvNames are the proxy variables copied by WithHostProxyEnv. Both
+// cases are copied, as tools differ in the case they read.
+var proxyEnvNames = []string{"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy"}
+
+// hostProxyEnv returns the proxy variables set in the environment of this
+// process, or nil if none is set.
+func hostProxyEnv() map[string]string {
+	var env map[string]string
+	for _, name := range proxyEnvNames {
+		if value := os.Getenv(name); value != "" {
+			if env == nil {
+				env = make(map[string]string)
+			}
+			env[name] = value
+		}
+	}
+	return env
+}
+
+// buildProxyEnv returns the proxy variables for the image build, or nil
+// without WithHostProxyEnv.
+func buildProxyEnv(options *Options) map[string]string {
+	if !options.HostProxyEnv {
+		return nil
+	}
+	return hostProxyEnv()
+}
+
+// withNoProxy returns the proxy variables env with hosts appended to NO_PROXY
+// and no_proxy, so that the test container reaches the containers of its
+// network directly instead of through the proxy. It returns nil if env
+// sets no proxy.
+func withNoProxy(env map[string]string, hosts []string) map[string]string {
+	if len(env) == 0 {
+		return nil
+	}
+	var entries []string
+	if noProxy := cmp.Or(env["NO_PROXY"], env["no_proxy"]); noProxy != "" {
+		entries = strings.Split(noProxy, ",")
+	}
+	for _, host := range hosts {
+		if !slices.Contains(entries, host) {
+			entries = append(entries, host)
+		}
+	}
+	extended := maps.Clone(env)
+	extended["NO_PROXY"] = strings.Join(entries, ",")
+	extended["no_proxy"] = extended["NO_PROXY"]
+	return extended
+}
+
+// networkNoProxy returns the hosts of the network of a run that must bypass
+// the proxy: the loopback, the aliases of the test container and the
+// sidecars, HostAlias for forwarded host ports and the subnets of the
+// network.
+func networkNoProxy(ctx context.Context, provider *testcontainers.DockerProvider, dn *DockerNetwork, options *Options) []string {
+	hosts := []string{"localhost", "127.0.0.1"}
+	hosts = append(hosts, options.Aliases...)
+	for _, sidecar := range options.Sidecars {
+		hosts = append(hosts, sidecar.Aliases...)
+	}
+	if len(options.HostPorts) > 0 {
+		hosts = append(hosts, HostAlias)
+	}
+	if options.SocketProxy {
+		hosts = append(hosts, SocketProxyAlias)
+	}
+	return append(hosts, networkSubnets(ctx, provider, dn)...)
+}
+
+// networkSubnets returns the subnets of dn, best-effort: nil if they cannot
+// be inspected.
+func networkSubnets(ctx context.Context, provider *testcontainers.DockerProvider, dn *DockerNetwork) []string {
+	var subnets []string
+	for _, config := range networkIPAM(ctx, provider, dn) {
+		if config.Subnet != "" {
+			subnets = append(subnets, config.Subnet)
+		}
+	}
+	return subnets
+}
+
+// networkIPAM returns the IPAM configuration of dn, best-effort: nil if it
+// cannot be inspected.
+func networkIPAM(ctx context.Context, provider *testcontainers.DockerProvider, dn *DockerNetwork) []network.IPAMConfig {
+	cli, closeClient, err := dockerClient(ctx, provider)
+	if err != nil {
+		return nil
+	}
+	defer closeClient()
+	inspect, err := cli.NetworkInspect(ctx, dn.Name, network.InspectOptions{})
+	if err != nil {
+		return nil
+	}
+	return inspect.IPAM.Config
+}
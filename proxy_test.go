@@ -0,0 +1,42 @@
+package dockertesting
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestHostProxyEnv(t *testing.T) {
+	for _, name := range proxyEnvNames {
+		t.Setenv(name, "")
+	}
+	if env := hostProxyEnv(); env != nil {
+		t.Errorf("expected no proxy variables, got %v", env)
+	}
+
+	t.Setenv("HTTPS_PROXY", "http://proxy.corp:3128")
+	t.Setenv("no_proxy", ".corp")
+	expected := map[string]string{"HTTPS_PROXY": "http://proxy.corp:3128", "no_proxy": ".corp"}
+	if env := hostProxyEnv(); !maps.Equal(env, expected) {
+		t.Errorf("expected %v, got %v", expected, env)
+	}
+}
+
+func TestWithNoProxy(t *testing.T) {
+	t.Parallel()
+	env := withNoProxy(
+		map[string]string{"HTTPS_PROXY": "http://proxy.corp:3128", "no_proxy": ".corp,localhost"},
+		[]string{"localhost", "db.test", "172.18.0.0/16"},
+	)
+	expected := map[string]string{
+		"HTTPS_PROXY": "http://proxy.corp:3128",
+		"NO_PROXY":    ".corp,localhost,db.test,172.18.0.0/16",
+		"no_proxy":    ".corp,localhost,db.test,172.18.0.0/16",
+	}
+	if !maps.Equal(env, expected) {
+		t.Errorf("expected %v, got %v", expected, env)
+	}
+
+	if env := withNoProxy(nil, []string{"db.test"}); env != nil {
+		t.Errorf("expected no variables without a proxy, got %v", env)
+	}
+}
@@ -0,0 +1,172 @@
+package dockertesting
+
+import (
+	"bufio"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// raceSeparator delimits the reports of the race detector.
+	raceSeparator = "=================="
+
+	// raceWarning is the first line of a data race report.
+	raceWarning = "WARNING: DATA RACE"
+)
+
+// stackLocationPattern matches the location lines of stack traces, e.g.
+// "      /app/race_test.go:12 +0x44".
+var stackLocationPattern = regexp.MustCompile(`^\s+(\S+):(\d+)(?: \+0x[0-9a-f]+)?$`)
+
+// StackFrame is a frame of a goroutine stack trace.
+type StackFrame struct {
+	// Function is the fully qualified function, e.g.
+	// "example.com/mod.TestRace.func1".
+	Function string `json:"function"`
+
+	// File is the path of the source file, relative to the module root for
+	// files of the tested module, otherwise as printed, e.g. for the
+	// standard library.
+	File string `json:"file,omitempty"`
+
+	// Line is the line in File.
+	Line int `json:"line,omitempty"`
+}
+
+// RaceAccess is a section of a data race report: one of the conflicting
+// memory accesses, or where the goroutine of an access was created.
+type RaceAccess struct {
+	// Description is the heading of the section, e.g.
+	// "Write at 0x00c000018128 by goroutine 8".
+	Description string `json:"description"`
+
+	// Stack is the stack of the goroutine, innermost frame first.
+	Stack []StackFrame `json:"stack,omitempty"`
+}
+
+// RaceReport is a data race found by the race detector, see WithArgs and
+// the -race flag.
+type RaceReport struct {
+	// Package is the import path of the package of the test. It is empty if
+	// the output does not name it.
+	Package string `json:"package,omitempty"`
+
+	// Test is the test running when the race was detected, best-effort.
+	Test string `json:"test,omitempty"`
+
+	// Accesses are the sections of the report in order.
+	Accesses []RaceAccess `json:"accesses"`
+
+	// Report is the text of the report.
+	Report string `json:"report"`
+}
+
+// ParseRaces returns the data races reported by the race detector in output,
+// the plain or -json output of go test with -race.
+//
+// Example:
+//
+//	for _, race := range dockertesting.ParseRaces(result.Stdout) {
+//	    for _, access := range race.Accesses {
+//	        fmt.Printf("%s at %s:%d\n", access.Description, access.Stack[0].File, access.Stack[0].Line)
+//	    }
+//	}
+func ParseRaces(output []byte) []RaceReport {
+	var races []RaceReport
+	for _, out := range packageOutputs(output) {
+		var (
+			test    string
+			report  []string
+			inRace  bool
+			pending int // races of plain output waiting for their package
+		)
+		scanner := bufio.NewScanner(strings.NewReader(out.text))
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			line := strings.TrimRight(scanner.Text(), "\r")
+			switch {
+			case inRace && line == raceSeparator:
+				race := parseRaceReport(report)
+				race.Package, race.Test = out.pkg, test
+				races = append(races, race)
+				pending++
+				report, inRace = nil, false
+			case inRace:
+				report = append(report, line)
+			case line == raceWarning:
+				inRace = true
+			case out.pkg == "" && packageResultPattern.MatchString(line):
+				pkg := packageResultPattern.FindStringSubmatch(line)[1]
+				for i := len(races) - pending; i < len(races); i++ {
+					races[i].Package = pkg
+				}
+				pending = 0
+			default:
+				if match := testStatePattern.FindStringSubmatch(line); match != nil {
+					test = match[2]
+				}
+			}
+		}
+	}
+	return races
+}
+
+// parseRaceReport parses the lines of a report after its warning.
+func parseRaceReport(lines []string) RaceReport {
+	race := RaceReport{Report: strings.TrimSpace(strings.Join(lines, "\n"))}
+	var section []string
+	flush := func() {
+		if len(section) > 0 {
+			race.Accesses = append(race.Accesses, RaceAccess{
+				Description: strings.TrimSuffix(section[0], ":"),
+				Stack:       parseStack(section[1:]),
+			})
+		}
+		section = nil
+	}
+	for _, line := range lines {
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+		case !strings.HasPrefix(line, " ") && strings.HasSuffix(line, ":"):
+			flush()
+			section = []string{line}
+		case len(section) > 0:
+			section = append(section, line)
+		}
+	}
+	flush()
+	return race
+}
+
+// parseStack parses the frames of a stack trace, pairs of a function line
+// and a location line.
+func parseStack(lines []string) []StackFrame {
+	var frames []StackFrame
+	for _, line := range lines {
+		if match := stackLocationPattern.FindStringSubmatch(line); match != nil {
+			if len(frames) > 0 {
+				frame := &frames[len(frames)-1]
+				frame.File = match[1]
+				if file, ok := moduleRelativePath(match[1]); ok {
+					frame.File = file
+				}
+				frame.Line, _ = strconv.Atoi(match[2])
+			}
+			continue
+		}
+		function := strings.TrimSpace(line)
+		// Goroutine stacks end with their creator, e.g.
+		// "created by example.com/mod.TestRace in goroutine 7"
+		if creator, ok := strings.CutPrefix(function, "created by "); ok {
+			function, _, _ = strings.Cut(creator, " in goroutine ")
+		}
+		// Cut the arguments, e.g. "example.com/mod.(*Counter).Inc(0xc000012345)"
+		if i := strings.LastIndex(function, "("); i > 0 && strings.HasSuffix(function, ")") {
+			function = function[:i]
+		}
+		frames = append(frames, StackFrame{Function: function})
+	}
+	return frames
+}
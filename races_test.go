@@ -0,0 +1,111 @@
+package dockertesting
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// raceOutput is the report of a data race as printed by the race detector.
+const raceOutput = `==================
+WARNING: DATA RACE
+Write at 0x00c000018128 by goroutine 8:
+  example.com/mod.(*Counter).Inc()
+      /app/counter.go:9 +0x44
+  example.com/mod.TestRace.func1()
+      /app/race_test.go:12 +0x2c
+
+Previous write at 0x00c000018128 by goroutine 7:
+  example.com/mod.(*Counter).Inc()
+      /app/counter.go:9 +0x44
+  testing.tRunner()
+      /usr/local/go/src/testing/testing.go:1792 +0x225
+
+Goroutine 8 (running) created at:
+  example.com/mod.TestRace()
+      /app/race_test.go:11 +0xd0
+==================
+`
+
+// expectedRaceAccesses are the accesses of raceOutput.
+var expectedRaceAccesses = []RaceAccess{
+	{
+		Description: "Write at 0x00c000018128 by goroutine 8",
+		Stack: []StackFrame{
+			{Function: "example.com/mod.(*Counter).Inc", File: "counter.go", Line: 9},
+			{Function: "example.com/mod.TestRace.func1", File: "race_test.go", Line: 12},
+		},
+	},
+	{
+		Description: "Previous write at 0x00c000018128 by goroutine 7",
+		Stack: []StackFrame{
+			{Function: "example.com/mod.(*Counter).Inc", File: "counter.go", Line: 9},
+			{Function: "testing.tRunner", File: "/usr/local/go/src/testing/testing.go", Line: 1792},
+		},
+	},
+	{
+		Description: "Goroutine 8 (running) created at",
+		Stack:       []StackFrame{{Function: "example.com/mod.TestRace", File: "race_test.go", Line: 11}},
+	},
+}
+
+func TestParseRaces_PlainOutput(t *testing.T) {
+	t.Parallel()
+	output := "=== RUN   TestRace\n" + raceOutput + `    testing.go:1490: race detected during execution of test
+--- FAIL: TestRace (0.00s)
+=== RUN   TestAdd
+    add_test.go:5: unrelated failure
+--- FAIL: TestAdd (0.00s)
+FAIL
+FAIL	example.com/mod	0.010s
+FAIL
+`
+
+	races := ParseRaces([]byte(output))
+	if len(races) != 1 {
+		t.Fatalf("expected 1 race, got %+v", races)
+	}
+	race := races[0]
+	if race.Package != "example.com/mod" || race.Test != "TestRace" {
+		t.Errorf("expected race in example.com/mod TestRace, got %q %q", race.Package, race.Test)
+	}
+	if !reflect.DeepEqual(race.Accesses, expectedRaceAccesses) {
+		t.Errorf("expected %+v, got %+v", expectedRaceAccesses, race.Accesses)
+	}
+	if !strings.HasPrefix(race.Report, "Write at") || !strings.HasSuffix(race.Report, "/app/race_test.go:11 +0xd0") {
+		t.Errorf("unexpected report %q", race.Report)
+	}
+}
+
+func TestParseRaces_JSONOutput(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	b.WriteString(`{"Action":"run","Package":"example.com/mod","Test":"TestRace"}` + "\n")
+	b.WriteString(`{"Action":"output","Package":"example.com/mod","Test":"TestRace","Output":"=== RUN   TestRace\n"}` + "\n")
+	for line := range strings.Lines(raceOutput) {
+		data, err := json.Marshal(TestEvent{Action: "output", Package: "example.com/mod", Test: "TestRace", Output: line})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		b.Write(data)
+		b.WriteString("\n")
+	}
+	b.WriteString(`{"Action":"fail","Package":"example.com/mod","Test":"TestRace"}` + "\n")
+	b.WriteString(`{"Action":"fail","Package":"example.com/mod"}` + "\n")
+
+	races := ParseRaces([]byte(b.String()))
+	if len(races) != 1 || races[0].Package != "example.com/mod" || races[0].Test != "TestRace" {
+		t.Fatalf("expected 1 race in example.com/mod TestRace, got %+v", races)
+	}
+	if !reflect.DeepEqual(races[0].Accesses, expectedRaceAccesses) {
+		t.Errorf("expected %+v, got %+v", expectedRaceAccesses, races[0].Accesses)
+	}
+}
+
+func TestParseRaces_NoRaces(t *testing.T) {
+	t.Parallel()
+	if races := ParseRaces([]byte("PASS\nok  \texample.com/mod\t0.001s\n")); races != nil {
+		t.Errorf("expected no races, got %+v", races)
+	}
+}
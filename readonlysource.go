@@ -0,0 +1,68 @@
+package dockertesting
+
+import (
+	"maps"
+	"strings"
+
+	"github.com/docker/docker/api/types/mount"
+)
+
+const (
+	// ReadOnlyTmpDir is the writable temporary directory of the test
+	// container of WithReadOnlySource. It holds the coverage profile, the
+	// test output and the other files dockertesting collects.
+	ReadOnlyTmpDir = "/tmp"
+
+	// ReadOnlyGoCache is the build cache of the go command in the test
+	// container of WithReadOnlySource.
+	ReadOnlyGoCache = ReadOnlyTmpDir + "/go-build"
+)
+
+// readOnlySourceEnv points the go command in the read-only test container at
+// the writable ReadOnlyTmpDir and keeps it from updating go.mod and go.sum,
+// unless env already selects a module mode, e.g. for the vendor directory.
+func readOnlySourceEnv(env map[string]string) {
+	env["GOCACHE"] = ReadOnlyGoCache
+	env["GOTMPDIR"] = ReadOnlyTmpDir
+	env["TMPDIR"] = ReadOnlyTmpDir
+	if !strings.Contains(env[goFlagsEnv], "-mod=") {
+		env[goFlagsEnv] = strings.TrimSpace(env[goFlagsEnv] + " -mod=readonly")
+	}
+}
+
+// readOnlySourceMounts returns the anonymous volumes that stay writable in
+// the read-only test container: ReadOnlyTmpDir and, if the certificates of a
+// remote daemon are copied into the container, remoteCertPath, and, if the go
+// command downloads modules or a toolchain at exec time, the module cache.
+// Volumes are used rather than tmpfs mounts, as files cannot be copied from
+// and to tmpfs mounts, and new volumes are populated with the modules of the
+// image. They are removed with the container.
+func readOnlySourceMounts(remoteCerts, modCache bool, labels map[string]string) []mount.Mount {
+	targets := []string{ReadOnlyTmpDir}
+	if remoteCerts {
+		targets = append(targets, remoteCertPath)
+	}
+	if modCache {
+		targets = append(targets, containerModCache)
+	}
+	volumeLabels := resourceLabels()
+	maps.Copy(volumeLabels, ownLabels(labels))
+	mounts := make([]mount.Mount, len(targets))
+	for i, target := range targets {
+		mounts[i] = mount.Mount{
+			Type:          mount.TypeVolume,
+			Target:        target,
+			VolumeOptions: &mount.VolumeOptions{Labels: volumeLabels},
+		}
+	}
+	return mounts
+}
+
+// readOnlyModCache reports whether the module cache of the read-only test
+// container must be writable: the private modules of WithGitCredentials are
+// downloaded at exec time, see modDownloadAtExec, or WithToolchain may
+// download a toolchain. With WithLazyModDownload, the module cache is a
+// writable volume already.
+func readOnlyModCache(lazy, modulesAtExec bool, env map[string]string) bool {
+	return !lazy && (modulesAtExec || env[toolchainEnv] != "")
+}
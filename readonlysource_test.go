@@ -0,0 +1,91 @@
+package dockertesting
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/docker/docker/api/types/mount"
+)
+
+func TestReadOnlySourceEnv(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		goFlags string
+		want    string
+	}{
+		{"unset", "", "-mod=readonly"},
+		{"other flags", "-count=1", "-count=1 -mod=readonly"},
+		{"vendor", "-mod=vendor", "-mod=vendor"},
+	}
+	for _, tt := range tests {
+		env := map[string]string{}
+		if tt.goFlags != "" {
+			env[goFlagsEnv] = tt.goFlags
+		}
+		readOnlySourceEnv(env)
+		expected := map[string]string{
+			"GOCACHE":  ReadOnlyGoCache,
+			"GOTMPDIR": ReadOnlyTmpDir,
+			"TMPDIR":   ReadOnlyTmpDir,
+			goFlagsEnv: tt.want,
+		}
+		if !maps.Equal(env, expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, expected, env)
+		}
+	}
+}
+
+func TestReadOnlySourceMounts(t *testing.T) {
+	t.Parallel()
+	mounts := readOnlySourceMounts(true, false, map[string]string{LabelRunID: "abc", testcontainersLabelPrefix + ".sessionId": "s"})
+	if len(mounts) != 2 || mounts[0].Target != ReadOnlyTmpDir || mounts[1].Target != remoteCertPath {
+		t.Fatalf("expected volumes at %s and %s, got %+v", ReadOnlyTmpDir, remoteCertPath, mounts)
+	}
+	for _, m := range mounts {
+		if m.Type != mount.TypeVolume || m.Source != "" {
+			t.Errorf("expected an anonymous volume, got %+v", m)
+		}
+		labels := m.VolumeOptions.Labels
+		if labels[LabelManaged] != "true" || labels[LabelRunID] != "abc" {
+			t.Errorf("expected the run labels, got %v", labels)
+		}
+		if _, ok := labels[testcontainersLabelPrefix+".sessionId"]; ok {
+			t.Errorf("expected no testcontainers labels, got %v", labels)
+		}
+	}
+	if mounts := readOnlySourceMounts(false, false, nil); len(mounts) != 1 {
+		t.Errorf("expected only the temporary directory without remote certificates, got %+v", mounts)
+	}
+	if mounts := readOnlySourceMounts(false, true, nil); len(mounts) != 2 || mounts[1].Target != containerModCache {
+		t.Errorf("expected a writable module cache, got %+v", mounts)
+	}
+}
+
+func TestReadOnlyModCache(t *testing.T) {
+	t.Parallel()
+	toolchain := map[string]string{toolchainEnv: "go1.25.6"}
+	gitCredentials := CreateContainerConfig{GitCredentials: []GitCredentials{{Host: "github.com", Token: "t"}}}
+
+	tests := []struct {
+		name          string
+		lazy          bool
+		modulesAtExec bool
+		env           map[string]string
+		expected      bool
+	}{
+		{name: "modules in the image", expected: false},
+		{name: "git credentials without buildkit", modulesAtExec: modDownloadAtExec(gitCredentials, false), expected: true},
+		{name: "git credentials with buildkit", modulesAtExec: modDownloadAtExec(gitCredentials, true), expected: false},
+		{name: "toolchain", env: toolchain, expected: true},
+		{name: "lazy mod download volume", lazy: true, modulesAtExec: true, env: toolchain, expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := readOnlyModCache(tt.lazy, tt.modulesAtExec, tt.env); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package dockertesting
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// ReaperMode controls the testcontainers reaper (Ryuk), which removes the
+// containers and networks of a session once its process disconnects.
+type ReaperMode int
+
+const (
+	// ReaperDefault leaves the reaper as configured for testcontainers, i.e.
+	// by TESTCONTAINERS_RYUK_DISABLED or ryuk.disabled in
+	// ~/.testcontainers.properties.
+	ReaperDefault ReaperMode = iota
+
+	// ReaperEnabled starts the reaper.
+	ReaperEnabled
+
+	// ReaperDisabled does not start the reaper. Resources are only removed
+	// by dockertesting itself, see WithSignalCleanup and CleanupStale.
+	ReaperDisabled
+)
+
+// ryukDisabledEnv is the environment variable testcontainers reads to
+// disable the reaper.
+const ryukDisabledEnv = "TESTCONTAINERS_RYUK_DISABLED"
+
+// reaperSessionLabel is the label the reaper selects the resources of a
+// session by. It mirrors the label of testcontainers' internal core package.
+const reaperSessionLabel = "org.testcontainers.sessionId"
+
+// reaperMu serializes configuring the reaper.
+var reaperMu sync.Mutex
+
+// configureReaper applies mode to the testcontainers configuration.
+// testcontainers reads its configuration once per process, so the mode must
+// be the same for all runs of the process, and an error is returned if the
+// configuration was already read with a different setting.
+func configureReaper(mode ReaperMode) error {
+	if mode == ReaperDefault {
+		return nil
+	}
+	disabled := mode == ReaperDisabled
+
+	reaperMu.Lock()
+	defer reaperMu.Unlock()
+
+	if err := os.Setenv(ryukDisabledEnv, strconv.FormatBool(disabled)); err != nil {
+		return fmt.Errorf("failed to configure reaper: %w", err)
+	}
+	if testcontainers.ReadConfig().RyukDisabled != disabled {
+		return fmt.Errorf("failed to configure reaper: testcontainers already read its configuration with %s=%t; set it before the first container is created",
+			ryukDisabledEnv, !disabled)
+	}
+	return nil
+}
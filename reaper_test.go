@@ -0,0 +1,61 @@
+package dockertesting
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestConfigureReaper(t *testing.T) {
+	// Not parallel: modifies the environment of the process
+	t.Setenv(ryukDisabledEnv, "")
+
+	if err := configureReaper(ReaperDefault); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// testcontainers reads its configuration only once per process
+	current, conflicting := ReaperEnabled, ReaperDisabled
+	if testcontainers.ReadConfig().RyukDisabled {
+		current, conflicting = ReaperDisabled, ReaperEnabled
+	}
+
+	if err := configureReaper(current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := configureReaper(conflicting)
+	if err == nil {
+		t.Fatal("expected error for a conflicting reaper mode")
+	}
+	if !strings.Contains(err.Error(), ryukDisabledEnv) {
+		t.Errorf("expected error to mention %s, got %q", ryukDisabledEnv, err.Error())
+	}
+}
+
+func TestTestContainerEnv(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected string
+	}{
+		{name: "default"},
+		{name: "enabled", opts: []Option{WithReaper(true)}, expected: "false"},
+		{name: "disabled", opts: []Option{WithReaper(false)}, expected: "true"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			opts, err := NewOptions("/path/to/package", tt.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			env := testContainerEnv(opts)
+			if env[ryukDisabledEnv] != tt.expected {
+				t.Errorf("expected %s=%q, got %q", ryukDisabledEnv, tt.expected, env[ryukDisabledEnv])
+			}
+		})
+	}
+}
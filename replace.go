@@ -0,0 +1,265 @@
+package dockertesting
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// ReplaceDir is the directory of the build context, relative to its root,
+// that holds the modules which replace directives of go.mod point to outside
+// of the package path, e.g. sibling modules in a multi-module repository.
+const ReplaceDir = ".dockertesting/replace"
+
+// localReplace is a directory outside of the build context that a replace
+// directive of go.mod points to.
+type localReplace struct {
+	// dir is the absolute path of the directory on the host.
+	dir string
+
+	// archivePath is the slash-separated path of the directory in the
+	// build context.
+	archivePath string
+}
+
+// moduleLayout places the modules that the go.mod and go.work of a build
+// context refer to by directory in the build context, and rewrites the
+// directives referring to them, in the copies in the archive only, so that
+// they resolve inside the container:
+//
+//   - relative paths to directories outside of the context point to their
+//     copies under ReplaceDir;
+//   - absolute paths, which only exist on the host, become relative paths;
+//   - the go.mod files of the copied modules are rewritten alike, as a
+//     workspace applies their replace directives too.
+type moduleLayout struct {
+	contextPath string
+
+	// replaces are the directories outside of the context to add.
+	replaces []localReplace
+
+	// uses are the module directories inside the context used by go.work.
+	uses []string
+
+	// archivePaths maps the absolute host directories of the modules to
+	// their paths in the build context.
+	archivePaths map[string]string
+
+	// files maps the paths of rewritten files in the build context to their
+	// new content.
+	files map[string][]byte
+}
+
+// newModuleLayout returns the layout of the modules of the build context at
+// contextPath, following the directives of go.work and go.mod transitively.
+func newModuleLayout(contextPath string) (*moduleLayout, error) {
+	contextPath, err := filepath.Abs(contextPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path for package: %w", err)
+	}
+	l := &moduleLayout{
+		contextPath:  contextPath,
+		archivePaths: map[string]string{contextPath: "."},
+		files:        make(map[string][]byte),
+	}
+	if err := l.rewriteWork(); err != nil {
+		return nil, err
+	}
+	if err := l.rewriteMod(contextPath, true); err != nil {
+		return nil, err
+	}
+	for _, dir := range l.uses {
+		if dir == contextPath {
+			continue
+		}
+		if err := l.rewriteMod(dir, true); err != nil {
+			return nil, err
+		}
+	}
+	// Replaced modules may refer to further modules. Outside of a workspace
+	// go ignores their replace directives, so missing directories are
+	// tolerated
+	for i := 0; i < len(l.replaces); i++ {
+		if err := l.rewriteMod(l.replaces[i].dir, false); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// place returns the path in the build context of the module directory dir,
+// which the directive of file refers to. Directories outside of the context
+// are added under ReplaceDir.
+func (l *moduleLayout) place(file, directive, dir string) (string, error) {
+	if archivePath, ok := l.archivePaths[dir]; ok {
+		return archivePath, nil
+	}
+	if rel, err := filepath.Rel(l.contextPath, dir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		archivePath := filepath.ToSlash(rel)
+		l.archivePaths[dir] = archivePath
+		return archivePath, nil
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("%s: %s points to %s, which is not a directory; the module must exist on the host to be copied into the container", file, directive, dir)
+	}
+	archivePath := path.Join(ReplaceDir, strconv.Itoa(len(l.replaces)), filepath.Base(dir))
+	l.archivePaths[dir] = archivePath
+	l.replaces = append(l.replaces, localReplace{dir: dir, archivePath: archivePath})
+	return archivePath, nil
+}
+
+// resolve returns the path that a directive of the file in the module
+// directory moduleDir should use for the directory dirPath, relative to the
+// module's place in the build context, and whether it differs from dirPath.
+func (l *moduleLayout) resolve(file, directive, moduleDir, dirPath string) (string, bool, error) {
+	dir := filepath.FromSlash(dirPath)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(moduleDir, dir)
+	}
+	target, err := l.place(file, directive, filepath.Clean(dir))
+	if err != nil {
+		return "", false, err
+	}
+	rel, err := filepath.Rel(filepath.FromSlash(l.archivePaths[moduleDir]), filepath.FromSlash(target))
+	if err != nil {
+		return "", false, fmt.Errorf("failed to locate %s relative to %s: %w", target, moduleDir, err)
+	}
+	rel = filepath.ToSlash(rel)
+	if !strings.HasPrefix(rel, "../") && rel != ".." {
+		rel = "./" + strings.TrimPrefix(rel, "./")
+	}
+	if rel == "./." {
+		rel = "."
+	}
+	return rel, path.Clean(rel) != path.Clean(dirPath), nil
+}
+
+// readModFile reads the go.mod or go.work at name, returning nil if it does
+// not exist.
+func readModFile(name string) ([]byte, error) {
+	data, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(name), err)
+	}
+	return data, nil
+}
+
+// rewriteMod rewrites the replace directives of the go.mod in moduleDir that
+// point to directories. Unless required, directives pointing to missing
+// directories are left alone.
+func (l *moduleLayout) rewriteMod(moduleDir string, required bool) error {
+	name := filepath.Join(moduleDir, "go.mod")
+	data, err := readModFile(name)
+	if data == nil || err != nil {
+		return err
+	}
+	file, err := modfile.Parse(name, data, nil)
+	if err != nil {
+		// Non-fatal: go reports the invalid go.mod with more context in the container
+		return nil
+	}
+
+	changed := false
+	for _, replace := range file.Replace {
+		if replace.New.Version != "" || !modfile.IsDirectoryPath(replace.New.Path) {
+			continue
+		}
+		directive := "replace " + replace.Old.Path + " => " + replace.New.Path
+		newPath, rewrite, err := l.resolve(name, directive, moduleDir, replace.New.Path)
+		if err != nil && !required {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !rewrite {
+			continue
+		}
+		if err := file.AddReplace(replace.Old.Path, replace.Old.Version, newPath, ""); err != nil {
+			return fmt.Errorf("failed to rewrite %s: %w", directive, err)
+		}
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	content, err := file.Format()
+	if err != nil {
+		return fmt.Errorf("failed to format %s: %w", name, err)
+	}
+	l.files[path.Join(l.archivePaths[moduleDir], "go.mod")] = content
+	return nil
+}
+
+// rewriteWork rewrites the use and replace directives of the go.work at the
+// root of the context.
+func (l *moduleLayout) rewriteWork() error {
+	name := filepath.Join(l.contextPath, "go.work")
+	data, err := readModFile(name)
+	if data == nil || err != nil {
+		return err
+	}
+	file, err := modfile.ParseWork(name, data, nil)
+	if err != nil {
+		// Non-fatal: go reports the invalid go.work with more context in the container
+		return nil
+	}
+
+	// Collect the rewritten uses first, as AddUse and DropUse modify file.Use
+	type rewrittenUse struct{ oldPath, newPath, modulePath string }
+	var uses []rewrittenUse
+	for _, use := range file.Use {
+		newPath, rewrite, err := l.resolve(name, "use "+use.Path, l.contextPath, use.Path)
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(path.Clean(newPath), ReplaceDir+"/") {
+			l.uses = append(l.uses, filepath.Join(l.contextPath, filepath.FromSlash(newPath)))
+		}
+		if rewrite {
+			uses = append(uses, rewrittenUse{use.Path, newPath, use.ModulePath})
+		}
+	}
+	changed := len(uses) > 0
+	for _, use := range uses {
+		if err := file.DropUse(use.oldPath); err != nil {
+			return fmt.Errorf("failed to rewrite use %s: %w", use.oldPath, err)
+		}
+		if err := file.AddUse(use.newPath, use.modulePath); err != nil {
+			return fmt.Errorf("failed to rewrite use %s: %w", use.oldPath, err)
+		}
+	}
+	for _, replace := range file.Replace {
+		if replace.New.Version != "" || !modfile.IsDirectoryPath(replace.New.Path) {
+			continue
+		}
+		directive := "replace " + replace.Old.Path + " => " + replace.New.Path
+		newPath, rewrite, err := l.resolve(name, directive, l.contextPath, replace.New.Path)
+		if err != nil {
+			return err
+		}
+		if !rewrite {
+			continue
+		}
+		if err := file.AddReplace(replace.Old.Path, replace.Old.Version, newPath, ""); err != nil {
+			return fmt.Errorf("failed to rewrite %s: %w", directive, err)
+		}
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	file.Cleanup()
+	l.files["go.work"] = modfile.Format(file.Syntax)
+	return nil
+}
@@ -0,0 +1,199 @@
+package dockertesting
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes files with their content under dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		full := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatalf("failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestCreateTarContext_LocalReplaces(t *testing.T) {
+	t.Parallel()
+	repo := t.TempDir()
+	goMod := `module example.com/app
+
+go 1.23
+
+require (
+	example.com/lib v0.0.0
+	example.com/inside v0.0.0
+)
+
+replace example.com/lib => ../lib
+
+replace example.com/inside => ./internal/inside
+`
+	writeFiles(t, repo, map[string]string{
+		"app/go.mod":                 goMod,
+		"app/main.go":                "package main",
+		"app/internal/inside/go.mod": "module example.com/inside",
+		"lib/go.mod":                 "module example.com/lib",
+		"lib/lib.go":                 "package lib",
+		"lib/.git/HEAD":              "ref: refs/heads/main",
+		"lib/Dockerfile":             "FROM scratch",
+	})
+
+	reader, err := CreateTarContext(filepath.Join(repo, "app"), "")
+	if err != nil {
+		t.Fatalf("CreateTarContext failed: %v", err)
+	}
+	files := readTarContents(t, reader)
+
+	for _, name := range []string{ReplaceDir + "/0/lib/go.mod", ReplaceDir + "/0/lib/lib.go", "main.go"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("expected %s in tar, got %v", name, getFileNames(files))
+		}
+	}
+	for _, name := range []string{ReplaceDir + "/0/lib/.git/HEAD", ReplaceDir + "/0/lib/Dockerfile"} {
+		if _, ok := files[name]; ok {
+			t.Errorf("expected %s to be excluded from tar", name)
+		}
+	}
+
+	archived := files["go.mod"]
+	if !strings.Contains(archived, "example.com/lib => ./"+ReplaceDir+"/0/lib") {
+		t.Errorf("expected the replace directive to be rewritten, got:\n%s", archived)
+	}
+	if !strings.Contains(archived, "example.com/inside => ./internal/inside") {
+		t.Errorf("expected the replace directive inside the context to be kept, got:\n%s", archived)
+	}
+
+	// The host files are left untouched
+	host, err := os.ReadFile(filepath.Join(repo, "app", "go.mod"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(host) != goMod {
+		t.Errorf("expected go.mod on the host to be unchanged, got:\n%s", host)
+	}
+}
+
+func TestCreateTarContext_MissingReplace(t *testing.T) {
+	t.Parallel()
+	repo := t.TempDir()
+	writeFiles(t, repo, map[string]string{
+		"app/go.mod": "module example.com/app\n\nreplace example.com/lib => ../lib\n",
+	})
+
+	_, err := CreateTarContext(filepath.Join(repo, "app"), "")
+	if err == nil {
+		t.Fatal("expected error for a missing replacement")
+	}
+	if !strings.Contains(err.Error(), "example.com/lib") || !strings.Contains(err.Error(), "../lib") {
+		t.Errorf("expected the replace directive in the error, got %v", err)
+	}
+}
+
+func TestCreateTarContext_AbsoluteReplace(t *testing.T) {
+	t.Parallel()
+	repo := t.TempDir()
+	writeFiles(t, repo, map[string]string{
+		"app/go.mod":                 "module example.com/app\n\nreplace example.com/inside => " + filepath.ToSlash(filepath.Join(repo, "app", "internal", "inside")) + "\n",
+		"app/internal/inside/go.mod": "module example.com/inside",
+	})
+
+	reader, err := CreateTarContext(filepath.Join(repo, "app"), "")
+	if err != nil {
+		t.Fatalf("CreateTarContext failed: %v", err)
+	}
+	files := readTarContents(t, reader)
+
+	if archived := files["go.mod"]; !strings.Contains(archived, "example.com/inside => ./internal/inside") {
+		t.Errorf("expected the absolute path to be made relative, got:\n%s", archived)
+	}
+}
+
+func TestCreateTarContext_TransitiveReplaces(t *testing.T) {
+	t.Parallel()
+	repo := t.TempDir()
+	writeFiles(t, repo, map[string]string{
+		"app/go.mod":  "module example.com/app\n\nreplace example.com/lib => ../lib\n",
+		"lib/go.mod":  "module example.com/lib\n\nreplace example.com/util => ../util\n\nreplace example.com/gone => ../gone\n",
+		"util/go.mod": "module example.com/util",
+	})
+
+	reader, err := CreateTarContext(filepath.Join(repo, "app"), "")
+	if err != nil {
+		t.Fatalf("CreateTarContext failed: %v", err)
+	}
+	files := readTarContents(t, reader)
+
+	if _, ok := files[ReplaceDir+"/1/util/go.mod"]; !ok {
+		t.Errorf("expected the module replaced by lib in tar, got %v", getFileNames(files))
+	}
+	archived := files[ReplaceDir+"/0/lib/go.mod"]
+	if !strings.Contains(archived, "example.com/util => ../../1/util") {
+		t.Errorf("expected the replace directive of lib to be rewritten, got:\n%s", archived)
+	}
+	// go ignores the replace directives of dependencies, so a missing one is kept
+	if !strings.Contains(archived, "example.com/gone => ../gone") {
+		t.Errorf("expected the missing replacement of lib to be kept, got:\n%s", archived)
+	}
+}
+
+func TestCreateTarContext_WorkspaceUses(t *testing.T) {
+	t.Parallel()
+	repo := t.TempDir()
+	goWork := "go 1.23\n\nuse (\n\t.\n\t./tools\n\t../lib\n)\n"
+	writeFiles(t, repo, map[string]string{
+		"app/go.work":      goWork,
+		"app/go.mod":       "module example.com/app",
+		"app/tools/go.mod": "module example.com/tools\n\nreplace example.com/lib => ../../lib\n",
+		"lib/go.mod":       "module example.com/lib",
+	})
+
+	reader, err := CreateTarContext(filepath.Join(repo, "app"), "")
+	if err != nil {
+		t.Fatalf("CreateTarContext failed: %v", err)
+	}
+	files := readTarContents(t, reader)
+
+	archived := files["go.work"]
+	if !strings.Contains(archived, "./"+ReplaceDir+"/0/lib") || strings.Contains(archived, "../lib") {
+		t.Errorf("expected the use directive to be rewritten, got:\n%s", archived)
+	}
+	if !strings.Contains(archived, "./tools") {
+		t.Errorf("expected the use directive inside the context to be kept, got:\n%s", archived)
+	}
+	if tools := files["tools/go.mod"]; !strings.Contains(tools, "example.com/lib => ../"+ReplaceDir+"/0/lib") {
+		t.Errorf("expected the replace directive of the used module to be rewritten, got:\n%s", tools)
+	}
+
+	host, err := os.ReadFile(filepath.Join(repo, "app", "go.work"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(host) != goWork {
+		t.Errorf("expected go.work on the host to be unchanged, got:\n%s", host)
+	}
+}
+
+func TestNewModuleLayout_None(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/app\n\nreplace example.com/lib => example.com/fork v1.0.0\n",
+	})
+
+	layout, err := newModuleLayout(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(layout.files) != 0 || len(layout.replaces) != 0 {
+		t.Errorf("expected no rewrite, got %d files and %d replaces", len(layout.files), len(layout.replaces))
+	}
+}
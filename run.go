@@ -5,15 +5,24 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"os"
+	"path"
+	"strings"
+	"time"
 
-	"github.com/testcontainers/testcontainers-go/exec"
+	tclog "github.com/testcontainers/testcontainers-go/log"
 )
 
 // TimeoutError represents an error that occurred due to a timeout.
 type TimeoutError struct {
 	Operation string
 	Err       error
+
+	// GoroutineDump contains the goroutine dump of the test binaries, captured
+	// by sending them SIGQUIT when the timeout fired during test execution.
+	// It is nil if the timeout fired during another operation or no dump was produced.
+	GoroutineDump []byte
 }
 
 func (e *TimeoutError) Error() string {
@@ -37,6 +46,9 @@ func wrapTimeoutError(ctx context.Context, err error, operation string) error {
 
 // Result holds the result of running tests in a Docker container.
 type Result struct {
+	// Name is the name of the run, see WithName.
+	Name string
+
 	// Stdout contains the combined stdout/stderr output from the test execution.
 	Stdout []byte
 
@@ -44,9 +56,97 @@ type Result struct {
 	// May be nil if coverage was not generated (e.g., tests failed early).
 	Coverage []byte
 
+	// Cobertura contains the coverage as a Cobertura XML report.
+	// Only set when WithCoberturaReport is used and coverage was generated.
+	Cobertura []byte
+
+	// CoveragePercent is the percentage of statements covered, computed from Coverage.
+	// It is 0 if no coverage was generated.
+	CoveragePercent float64
+
+	// ContainerID is the ID of the container the tests ran in.
+	ContainerID string
+
+	// NetworkName is the name of the network of the run. It is empty for
+	// RunInContainer.
+	NetworkName string
+
 	// ExitCode is the exit code from the test execution.
 	// 0 indicates success, non-zero indicates test failures.
 	ExitCode int
+
+	// Duration is how long go test ran.
+	Duration time.Duration
+
+	// StartupDuration is how long Run took until the tests started: creating
+	// the network and the sidecars, building the image, starting the
+	// container and the setup commands. It is 0 for Runner.Test.
+	StartupDuration time.Duration
+
+	// CPUProfile contains the pprof CPU profile.
+	// Only set when WithCPUProfile is used and the profile was written.
+	CPUProfile []byte
+
+	// MemProfile contains the pprof heap profile.
+	// Only set when WithMemProfile is used and the profile was written.
+	MemProfile []byte
+
+	// BlockProfile contains the pprof goroutine blocking profile.
+	// Only set when WithBlockProfile is used and the profile was written.
+	BlockProfile []byte
+
+	// MutexProfile contains the pprof mutex contention profile.
+	// Only set when WithMutexProfile is used and the profile was written.
+	MutexProfile []byte
+
+	// Artifacts contains the files collected via WithArtifacts,
+	// keyed by their path inside the container.
+	Artifacts map[string][]byte
+
+	// TestBinaries contains the compiled test binaries keyed by file name.
+	// Only set when WithCompileOnly is used.
+	TestBinaries map[string][]byte
+
+	// ContainerLogs contains the logs of the container's main process, e.g. from
+	// background processes started by setup commands or the entrypoint.
+	ContainerLogs []byte
+
+	// OOMKilled reports whether a process in the container was killed by the
+	// kernel out-of-memory killer during the run.
+	OOMKilled bool
+
+	// Failures are the failed tests with their messages and locations,
+	// parsed from Stdout, see ParseFailures. The -json flag names the
+	// packages of the failures more reliably than plain output.
+	Failures []TestFailure
+
+	// Skipped are the skipped tests with the messages they skipped with,
+	// parsed from Stdout, see ParseSkipped. Plain output only reports them
+	// with the -v flag.
+	Skipped []SkippedTest
+
+	// Races are the data races the race detector reported, see ParseRaces.
+	// They are reported regardless of why go test failed, as a race fails
+	// the test it was detected in, which may have failed for other reasons.
+	Races []RaceReport
+
+	// Crashes are the panics, fatal runtime errors and killed test binaries
+	// with the crashing test and goroutine stack, see ParseCrashes. A kill
+	// by the out-of-memory killer is reported with CrashOOMKilled.
+	Crashes []Crash
+
+	// Benchmarks contains the benchmark results in the input format of
+	// benchstat, see ExtractBenchmarks. Only set when benchmarks ran, e.g.
+	// with WithArgs("-bench=.").
+	Benchmarks []byte
+
+	// FailFastTest is the name of the test that triggered an early exit.
+	// Only set when WithFailFast is used together with the -json flag.
+	FailFastTest string
+
+	// Diagnostics describes the resources kept after failed tests.
+	// Only set when WithKeepOnFailure is used and the tests failed.
+	Diagnostics *DiagnosticBundle
 }
 
 // Run executes go test for the given package path inside a Docker container.
@@ -54,6 +154,10 @@ type Result struct {
 // It creates a Docker network, builds and starts a container with the package,
 // executes the tests with coverage profiling, and returns the results.
 //
+// Test failures are reported through a Result with a non-zero ExitCode, while a
+// failure to build the test image is returned as a *BuildError containing the
+// build log.
+//
 // The function ensures cleanup of all resources (network and container) regardless
 // of success or failure. Stdout/stderr are forwarded to os.Stdout/os.Stderr in real-time.
 //
@@ -71,9 +175,22 @@ type Result struct {
 func Run(ctx context.Context, packagePath string, opts ...Option) (*Result, error) {
 	// Parse options
 	options, err := NewOptions(packagePath, opts...)
+	if err == nil {
+		err = options.Validate()
+	}
 	if err != nil {
 		return nil, fmt.Errorf("invalid options: %w", err)
 	}
+	return run(ctx, options)
+}
+
+// run is Run with parsed options.
+func run(ctx context.Context, options *Options) (res *Result, err error) {
+	begin := time.Now()
+	metrics := runMetrics(options)
+	defer func() {
+		recordRun(metrics, res, err)
+	}()
 
 	// Apply timeout to context if configured
 	if options.Timeout > 0 {
@@ -82,93 +199,243 @@ func Run(ctx context.Context, packagePath string, opts ...Option) (*Result, erro
 		defer cancel()
 	}
 
-	// Create network
-	network, cleanupNetwork, err := CreateNetwork(ctx)
+	// Create the network, sidecars and test container
+	runner, err := newRunner(ctx, options)
 	if err != nil {
-		return nil, wrapTimeoutError(ctx, err, "create network")
+		return nil, err
 	}
+	container, execOutput := runner.container, runner.execOutput
+	startup := time.Since(begin)
+	runner.mu.Lock()
+	defer runner.mu.Unlock()
+
+	ctx, cancelOnSignal := runner.signals.bind(ctx)
+	defer cancelOnSignal()
 
-	// Ensure network cleanup always happens
+	// Ensure cleanup always happens, unless resources are kept.
+	// Non-fatal: cleanup is best-effort
 	defer func() {
-		if cleanupNetwork != nil {
-			_ = cleanupNetwork(ctx)
+		if err != nil {
+			runner.failed = true
+		}
+		runner.result = res
+		_ = runner.close(ctx)
+		if res != nil {
+			res.Diagnostics = runner.diagnostics
 		}
 	}()
 
-	// Create container
-	container, err := CreateContainer(ctx, CreateContainerConfig{
-		PackagePath:    options.PackagePath,
-		Network:        network,
-		Aliases:        options.Aliases,
-		EnableVarSock:  options.EnableVarSock,
-		SockPath:       options.SockPath,
-		NetworkName:    network.Name,
-		DockerfilePath: options.DockerfilePath,
-	})
+	// Execute tests with real-time output forwarding
+	reportProgress(options.ProgressReporter, ProgressEvent{Stage: StageTestsRunning, Message: "running go test"})
+	start := time.Now()
+	result, err := execTestWithStreaming(ctx, container, options, execOutput)
+	duration := time.Since(start)
+	observeMetric(metrics, MetricTestDuration, duration.Seconds())
+	// Invoked with the final result, after the copies below
+	defer func() {
+		invokeHook(ctx, runner.hooks.OnTestsFinished, runner.hookEvent(res, err))
+	}()
 	if err != nil {
-		return nil, wrapTimeoutError(ctx, err, "create container")
+		err = wrapTimeoutError(ctx, err, "execute tests")
+		// Capture what the tests were stuck on before the container is terminated
+		var timeoutErr *TimeoutError
+		if errors.As(err, &timeoutErr) {
+			timeoutErr.GoroutineDump = container.dumpGoroutines(ctx)
+		}
 	}
 
-	// Ensure container cleanup always happens
-	defer func() {
-		if container != nil {
-			_ = container.Terminate(ctx)
-		}
-	}()
+	// Violations of WithSockGuard fail the run like the tests would
+	if err == nil {
+		err = runner.guard.err()
+	}
+
+	if err != nil || result.ExitCode != 0 {
+		runner.failed = true
+	}
+
+	// Run teardown commands regardless of the test outcome.
+	// Non-fatal: teardown is best-effort collection of diagnostics
+	if err := runTeardownCommands(ctx, container, options.TeardownCommands, execOutput); err != nil {
+		runner.log.Debug("teardown commands failed", "error", err)
+	}
 
-	// Execute tests with real-time output forwarding
-	result, err := execTestWithStreaming(ctx, container, options)
 	if err != nil {
-		return nil, wrapTimeoutError(ctx, err, "execute tests")
+		logTests(runner.log, nil, err, duration)
+		return nil, runner.runError(PhaseExec, err)
 	}
 
 	// Copy coverage file from container
-	coverage, err := container.CopyCoverage(ctx)
-	if err != nil {
-		// Non-fatal: coverage may not exist if tests failed early
-		coverage = nil
+	coverage := copyCoverage(ctx, container, DefaultCoverageFile, options.ProgressReporter)
+
+	// Write coverage to the host if requested
+	if options.CoverageOutput != "" && coverage != nil {
+		if err := writeFileAtomic(options.CoverageOutput, coverage, 0644); err != nil {
+			return nil, runner.runError(PhaseCopy, fmt.Errorf("failed to write coverage output: %w", err))
+		}
 	}
 
-	return &Result{
-		Stdout:   result.Stdout,
-		Coverage: coverage,
-		ExitCode: result.ExitCode,
-	}, nil
+	// Non-fatal: a malformed profile leaves the percentage at 0
+	coveragePercent, _ := CoveragePercent(coverage)
+
+	// Non-fatal: without a module path, file names are kept as go test printed them
+	modulePath, _ := readModulePath(options.PackagePath)
+	outcomes := parseOutcomes(result.Stdout, modulePath)
+
+	res = &Result{
+		Name:            options.Name,
+		Stdout:          result.Stdout,
+		Coverage:        coverage,
+		CoveragePercent: coveragePercent,
+		ContainerID:     container.ID(),
+		NetworkName:     runner.network.Name,
+		ExitCode:        result.ExitCode,
+		Duration:        duration,
+		StartupDuration: startup,
+		Failures:        outcomes.failures,
+		Skipped:         outcomes.skipped,
+		Benchmarks:      ExtractBenchmarks(result.Stdout),
+		Races:           ParseRaces(result.Stdout),
+		Crashes:         ParseCrashes(result.Stdout),
+	}
+	logTests(runner.log, res, nil, duration)
+
+	// Copy profiles out of the container
+	copyProfiles(ctx, container, options, res)
+
+	// Collect container runtime logs and OOM status.
+	// Non-fatal: these are best-effort diagnostics
+	res.ContainerLogs, _ = container.Logs(ctx)
+	res.OOMKilled, _ = container.OOMKilled(ctx)
+	if res.OOMKilled {
+		res.Crashes = withOOMKilled(res.Crashes)
+	}
+
+	// Copy compiled test binaries out of the container
+	if options.CompileOnly {
+		res.TestBinaries, err = collectTestBinaries(ctx, container)
+		if err != nil {
+			return nil, runner.runError(PhaseCopy, wrapTimeoutError(ctx, err, "collect test binaries"))
+		}
+	}
+
+	// Copy artifacts out of the container
+	if len(options.Artifacts) > 0 {
+		res.Artifacts, err = collectArtifacts(ctx, container, options.Artifacts)
+		if err != nil {
+			return nil, runner.runError(PhaseCopy, wrapTimeoutError(ctx, err, "collect artifacts"))
+		}
+		if options.ArtifactsDir != "" {
+			if err := writeArtifacts(options.ArtifactsDir, res.Artifacts); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Convert coverage to Cobertura XML if requested
+	if options.CoberturaReport && coverage != nil {
+		res.Cobertura, err = ConvertToCobertura(coverage, modulePath)
+		if err != nil {
+			return nil, runner.runError(PhaseCopy, fmt.Errorf("failed to convert coverage to cobertura: %w", err))
+		}
+	}
+
+	// Report which test triggered the early exit when -failfast is combined with -json
+	if options.FailFast && result.ExitCode != 0 && hasFlag(options.Args, "-json") {
+		res.FailFastTest = firstFailedTest(parseTestEvents(result.Stdout))
+	}
+
+	return res, nil
 }
 
-// execTestWithStreaming executes tests and streams output to stdout in real-time.
-func execTestWithStreaming(ctx context.Context, container *TestContainer, options *Options) (*ExecResult, error) {
+// execTestWithStreaming executes tests and streams output to w in real-time.
+func execTestWithStreaming(ctx context.Context, container *TestContainer, options *Options, w io.Writer) (*ExecResult, error) {
 	if container.ctr == nil {
 		return nil, fmt.Errorf("container is nil")
 	}
 
-	// Build the go test command
+	// Log the output inside the container so it survives a timeout
+	cmd := withOutputLog(buildTestCommand(options), DefaultTestOutputFile)
+
+	// Execute the command in the container with multiplexed output
+	result, err := container.ExecCommand(ctx, cmd, ExecOptions{Output: w, WorkingDir: testWorkingDir(options)})
+	if err != nil {
+		return nil, wrapTimeoutError(ctx, err, "execute test command")
+	}
+
+	return result, nil
+}
+
+// buildTestCommand builds the go test command line from the options.
+func buildTestCommand(options *Options) []string {
+	if options.CompileOnly {
+		// -o with a trailing slash writes one binary per package into the directory
+		cmd := []string{"go", "test", "-c", "-o", DefaultTestBinaryDir + "/", options.Pattern}
+		return append(cmd, options.Args...)
+	}
+
 	cmd := []string{
 		"go", "test",
 		"-coverprofile=" + DefaultCoverageFile,
-		options.Pattern,
 	}
+	if mode := effectiveCoverMode(options); mode != "" {
+		cmd = append(cmd, "-covermode="+string(mode))
+	}
+	if options.FailFast {
+		cmd = append(cmd, "-failfast")
+	}
+	if options.Short {
+		cmd = append(cmd, "-short")
+	}
+	if options.Verbosity >= VerbosityVerbose && !hasFlag(options.Args, "-v") {
+		cmd = append(cmd, "-v")
+	}
+	cmd = append(cmd, profileFlags(options)...)
+	cmd = append(cmd, options.Pattern)
 	// Append additional arguments
 	cmd = append(cmd, options.Args...)
+	return cmd
+}
 
-	// Execute the command in the container with multiplexed output
-	exitCode, reader, err := container.ctr.Exec(ctx, cmd, exec.Multiplexed())
-	if err != nil {
-		return nil, wrapTimeoutError(ctx, err, "execute test command")
+// testWorkingDir returns the directory go test runs in, or an empty string
+// for the working directory of the container, see WithPackageSubdir.
+func testWorkingDir(options *Options) string {
+	if options.PackageSubdir == "" {
+		return ""
 	}
+	return path.Join(containerWorkDir, options.PackageSubdir)
+}
 
-	// Stream output to os.Stdout while also capturing it
-	var output []byte
-	if reader != nil {
-		// Create a TeeReader to write to stdout while also capturing the output
-		output, err = io.ReadAll(io.TeeReader(reader, os.Stdout))
-		if err != nil {
-			return nil, wrapTimeoutError(ctx, err, "read test output")
+// effectiveCoverMode returns the coverage mode to pass to go test.
+// The race detector requires atomic mode, so it takes precedence over the configured mode.
+func effectiveCoverMode(options *Options) CoverMode {
+	if hasFlag(options.Args, "-race") {
+		return CoverModeAtomic
+	}
+	return options.CoverMode
+}
+
+// hasFlag reports whether args contain the boolean flag name (e.g. "-json"),
+// accepting both the single and double dash forms and an explicit "=true" value.
+func hasFlag(args []string, name string) bool {
+	name = strings.TrimLeft(name, "-")
+	for _, arg := range args {
+		arg = strings.TrimLeft(arg, "-")
+		if arg == name || arg == name+"=true" {
+			return true
 		}
 	}
+	return false
+}
 
-	return &ExecResult{
-		Stdout:   output,
-		ExitCode: exitCode,
-	}, nil
+// containerLogger returns the testcontainers logger matching the verbosity level.
+// A nil logger makes testcontainers use its default logger.
+func containerLogger(level Verbosity) tclog.Logger {
+	switch {
+	case level <= VerbosityQuiet:
+		return log.New(io.Discard, "", 0)
+	case level >= VerbosityVerbose:
+		return log.New(os.Stderr, "", log.LstdFlags)
+	default:
+		return nil
+	}
 }
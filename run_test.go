@@ -3,6 +3,8 @@ package dockertesting
 import (
 	"context"
 	"errors"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -82,3 +84,200 @@ func TestWrapTimeoutError_DeadlineExceeded(t *testing.T) {
 		t.Error("expected TimeoutError to unwrap to inner error")
 	}
 }
+
+func TestBuildError_Error(t *testing.T) {
+	t.Parallel()
+	err := &BuildError{
+		Log: []byte("Step 4/5 : RUN go mod download\n"),
+		Err: errors.New("The command '/bin/sh -c go mod download' returned a non-zero code: 1"),
+	}
+
+	expected := "failed to build image: The command '/bin/sh -c go mod download' returned a non-zero code: 1"
+	if err.Error() != expected {
+		t.Errorf("expected error message %q, got %q", expected, err.Error())
+	}
+}
+
+func TestBuildError_Unwrap(t *testing.T) {
+	t.Parallel()
+	innerErr := errors.New("build failed")
+	err := &BuildError{Err: innerErr}
+
+	if !errors.Is(err, innerErr) {
+		t.Error("expected BuildError to unwrap to inner error")
+	}
+}
+
+func TestBuildTestCommand_Default(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithArgs("-v"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := strings.Join(buildTestCommand(opts), " ")
+	expected := "go test -coverprofile=" + DefaultCoverageFile + " ./... -v"
+	if got != expected {
+		t.Errorf("expected command %q, got %q", expected, got)
+	}
+}
+
+func TestTestWorkingDir(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dir := testWorkingDir(opts); dir != "" {
+		t.Errorf("expected the working directory of the container, got %q", dir)
+	}
+
+	opts, err = NewOptions("/path/to/package", WithPackageSubdir("internal/service"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dir := testWorkingDir(opts); dir != "/app/internal/service" {
+		t.Errorf("expected working directory %q, got %q", "/app/internal/service", dir)
+	}
+}
+
+func TestBuildTestCommand_FailFast(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithFailFast())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cmd := buildTestCommand(opts)
+	if !slices.Contains(cmd, "-failfast") {
+		t.Errorf("expected command to contain -failfast, got %v", cmd)
+	}
+}
+
+func TestHasFlag(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		args     []string
+		expected bool
+	}{
+		{[]string{"-v", "-json"}, true},
+		{[]string{"--json"}, true},
+		{[]string{"-json=true"}, true},
+		{[]string{"-json=false"}, false},
+		{[]string{"-v"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := hasFlag(tt.args, "-json"); got != tt.expected {
+			t.Errorf("hasFlag(%v, -json) = %v, want %v", tt.args, got, tt.expected)
+		}
+	}
+}
+
+func TestBuildTestCommand_Short(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithShort())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cmd := buildTestCommand(opts)
+	if !slices.Contains(cmd, "-short") {
+		t.Errorf("expected command to contain -short, got %v", cmd)
+	}
+}
+
+func TestBuildTestCommand_Verbose(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithVerbosity(VerbosityVerbose))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cmd := buildTestCommand(opts)
+	if !slices.Contains(cmd, "-v") {
+		t.Errorf("expected command to contain -v, got %v", cmd)
+	}
+
+	// -v must not be duplicated when already passed via WithArgs
+	WithArgs("-v")(opts)
+	cmd = buildTestCommand(opts)
+	count := 0
+	for _, arg := range cmd {
+		if arg == "-v" {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("expected -v exactly once, got %v", cmd)
+	}
+}
+
+func TestContainerLogger(t *testing.T) {
+	t.Parallel()
+	if containerLogger(VerbosityNormal) != nil {
+		t.Error("expected default logger for normal verbosity")
+	}
+	if containerLogger(VerbosityQuiet) == nil {
+		t.Error("expected discarding logger for quiet verbosity")
+	}
+	if containerLogger(VerbosityVerbose) == nil {
+		t.Error("expected stderr logger for verbose verbosity")
+	}
+}
+
+func TestBuildTestCommand_CoverMode(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected string
+	}{
+		{"default", nil, ""},
+		{"explicit", []Option{WithCoverMode(CoverModeCount)}, "-covermode=count"},
+		{"race selects atomic", []Option{WithArgs("-race")}, "-covermode=atomic"},
+		{"race overrides set", []Option{WithCoverMode(CoverModeSet), WithArgs("-race")}, "-covermode=atomic"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			opts, err := NewOptions("/path/to/package", tt.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			cmd := buildTestCommand(opts)
+			var got string
+			for _, arg := range cmd {
+				if strings.HasPrefix(arg, "-covermode=") {
+					got = arg
+				}
+			}
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q in %v", tt.expected, got, cmd)
+			}
+		})
+	}
+}
+
+func TestBuildTestCommand_CompileOnly(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithCompileOnly(), WithArgs("-race"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := strings.Join(buildTestCommand(opts), " ")
+	expected := "go test -c -o " + DefaultTestBinaryDir + "/ ./... -race"
+	if got != expected {
+		t.Errorf("expected command %q, got %q", expected, got)
+	}
+}
+
+func TestWarmup_InvalidOptions(t *testing.T) {
+	t.Parallel()
+
+	if err := Warmup(context.Background(), ""); err == nil {
+		t.Error("expected error for empty package path, got nil")
+	}
+}
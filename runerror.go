@@ -0,0 +1,108 @@
+package dockertesting
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// Phase is the phase of a run in which an error occurred, see RunError.
+type Phase string
+
+const (
+	// PhaseNetwork is the creation of the test network.
+	PhaseNetwork Phase = "network"
+	// PhaseSidecars is starting and seeding the sidecars.
+	PhaseSidecars Phase = "sidecars"
+	// PhaseProbe is probing the services on the test network.
+	PhaseProbe Phase = "probe"
+	// PhaseBuild is building the test image and starting the test container.
+	PhaseBuild Phase = "build"
+	// PhaseSetup is running the setup commands.
+	PhaseSetup Phase = "setup"
+	// PhaseExec is running go test.
+	PhaseExec Phase = "exec"
+	// PhaseCopy is copying coverage, profiles, binaries and artifacts out of
+	// the test container.
+	PhaseCopy Phase = "copy"
+)
+
+// RunError is returned by Run and Runner for failures after the options were
+// validated. It attaches the phase and the resources involved to the error, so
+// CI logs and callers can tell which container or network to inspect without
+// parsing the message. The underlying error, e.g. a *BuildError or a
+// *TimeoutError, remains available through errors.As.
+//
+// Example:
+//
+//	var runErr *dockertesting.RunError
+//	if errors.As(err, &runErr) {
+//	    log.Printf("%s failed, inspect container %s", runErr.Phase, runErr.ContainerID)
+//	}
+type RunError struct {
+	// Phase is the phase of the run that failed.
+	Phase Phase
+
+	// Name is the name of the run, see WithName.
+	Name string
+
+	// ContainerID is the ID of the test container, or empty if it was not
+	// created yet.
+	ContainerID string
+
+	// NetworkName is the name of the test network, or empty if it was not
+	// created yet.
+	NetworkName string
+
+	// Image is the image of the test container, or empty if it was not
+	// created yet.
+	Image string
+
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *RunError) Error() string {
+	var fields []string
+	if e.Name != "" {
+		fields = append(fields, "run "+e.Name)
+	}
+	fields = append(fields, "phase "+string(e.Phase))
+	if e.ContainerID != "" {
+		fields = append(fields, "container "+e.ContainerID)
+	}
+	if e.NetworkName != "" {
+		fields = append(fields, "network "+e.NetworkName)
+	}
+	if e.Image != "" {
+		fields = append(fields, "image "+e.Image)
+	}
+	return fmt.Sprintf("%v (%s)", e.Err, strings.Join(fields, ", "))
+}
+
+func (e *RunError) Unwrap() error {
+	return e.Err
+}
+
+// runError wraps err in a *RunError with the resources of r. It returns nil
+// if err is nil.
+func (r *Runner) runError(phase Phase, err error) error {
+	if err == nil {
+		return nil
+	}
+	runErr := &RunError{Phase: phase, Err: err}
+	if r.options != nil {
+		runErr.Name = r.options.Name
+	}
+	if r.network != nil {
+		runErr.NetworkName = r.network.Name
+	}
+	if r.container != nil && r.container.ctr != nil {
+		runErr.ContainerID = r.container.ctr.GetContainerID()
+		if ctr, ok := r.container.ctr.(*testcontainers.DockerContainer); ok {
+			runErr.Image = ctr.Image
+		}
+	}
+	return runErr
+}
@@ -0,0 +1,77 @@
+package dockertesting
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRunError_Error(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		err      *RunError
+		expected string
+	}{
+		{
+			name:     "phase only",
+			err:      &RunError{Phase: PhaseNetwork, Err: errors.New("failed to create network: boom")},
+			expected: "failed to create network: boom (phase network)",
+		},
+		{
+			name: "all resources",
+			err: &RunError{
+				Phase:       PhaseExec,
+				ContainerID: "abc123",
+				NetworkName: "testnet",
+				Image:       "dockertesting:latest",
+				Err:         errors.New("failed to execute tests: boom"),
+			},
+			expected: "failed to execute tests: boom (phase exec, container abc123, network testnet, image dockertesting:latest)",
+		},
+		{
+			name:     "named run",
+			err:      &RunError{Phase: PhaseBuild, Name: "payments-integration", Err: errors.New("boom")},
+			expected: "boom (run payments-integration, phase build)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.err.Error(); got != tt.expected {
+				t.Errorf("expected error message %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRunner_RunError(t *testing.T) {
+	t.Parallel()
+	r := &Runner{network: &DockerNetwork{Name: "testnet"}}
+
+	if err := r.runError(PhaseBuild, nil); err != nil {
+		t.Errorf("expected nil error, got %v", err)
+	}
+
+	buildErr := &BuildError{Err: errors.New("boom")}
+	err := r.runError(PhaseBuild, buildErr)
+
+	var runErr *RunError
+	if !errors.As(err, &runErr) {
+		t.Fatalf("expected *RunError, got %T: %v", err, err)
+	}
+	if runErr.Phase != PhaseBuild {
+		t.Errorf("expected phase %q, got %q", PhaseBuild, runErr.Phase)
+	}
+	if runErr.NetworkName != "testnet" {
+		t.Errorf("expected network name %q, got %q", "testnet", runErr.NetworkName)
+	}
+	if runErr.ContainerID != "" {
+		t.Errorf("expected no container ID before the container exists, got %q", runErr.ContainerID)
+	}
+
+	var unwrapped *BuildError
+	if !errors.As(err, &unwrapped) || unwrapped != buildErr {
+		t.Errorf("expected the *BuildError to remain reachable, got %v", err)
+	}
+}
@@ -0,0 +1,653 @@
+package dockertesting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Runner keeps a test container, its sidecars and its network alive across
+// several go test invocations, so that the image is built only once.
+// Test calls are serialized; use one Runner per concurrent test run.
+type Runner struct {
+	options        *Options
+	network        *DockerNetwork
+	cleanupNetwork func(context.Context) error
+	sidecars       []*Sidecar
+	container      *TestContainer
+
+	// execOutput receives the output of commands run in the containers.
+	execOutput io.Writer
+
+	// flushOutput flushes the line writers of the OutputCallback.
+	flushOutput func()
+
+	// log receives the structured logs of the run, see WithLogger.
+	log *slog.Logger
+
+	// metrics receives the statistics of the run, see WithMetrics.
+	metrics Metrics
+
+	// hooks are invoked at the milestones of the run, see WithHooks.
+	hooks Hooks
+
+	// result is the result of the last test run, passed to OnCleanup.
+	result *Result
+
+	// signals removes the resources when the process is interrupted, if
+	// WithSignalCleanup is set.
+	signals *signalWatcher
+
+	// failed is set when the runner could not be created or a test run
+	// failed, see WithKeepOnFailure.
+	failed bool
+
+	// outputTail keeps the last lines of the command output for the
+	// diagnostics of WithKeepOnFailure.
+	outputTail  *outputTail
+	tailWriter  *lineWriter
+	diagnostics *DiagnosticBundle
+
+	// events receives the events of go test -json, see Events.
+	events chan TestEvent
+
+	// guard watches what the tests do with the Docker socket, see
+	// WithSockGuard.
+	guard *sockGuard
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewRunner prepares everything Run does before executing the tests: it
+// creates the network, starts and seeds the sidecars, builds and starts the
+// test container and runs the setup commands. The container stays alive until
+// Close is called, and Test executes go test in it as often as needed, e.g.
+// with different -run filters or environment variables.
+//
+// Options.Timeout does not limit NewRunner itself; it is the default timeout of
+// every Test call.
+//
+// Example:
+//
+//	runner, err := dockertesting.NewRunner(ctx, "./mypackage", dockertesting.WithVarSock())
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer runner.Close(ctx)
+//
+//	for _, filter := range []string{"TestA", "TestB"} {
+//	    result, err := runner.Test(ctx, dockertesting.ExecConfig{Args: []string{"-run", filter}})
+//	    // ...
+//	}
+func NewRunner(ctx context.Context, packagePath string, opts ...Option) (*Runner, error) {
+	options, err := NewOptions(packagePath, opts...)
+	if err == nil {
+		err = options.Validate()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+	return newRunner(ctx, options)
+}
+
+// newRunner creates the resources of a run up to and including the setup
+// commands. On error, everything created so far is cleaned up.
+func newRunner(ctx context.Context, options *Options) (_ *Runner, err error) {
+	r := &Runner{
+		options: options,
+		log:     runLogger(options),
+		metrics: runMetrics(options),
+		hooks:   runHooks(options),
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if options.SignalCleanup {
+		r.signals = watchSignals(r.interrupt)
+		var cancel context.CancelFunc
+		ctx, cancel = r.signals.bind(ctx)
+		defer cancel()
+	}
+
+	// phase is the phase the errors below are attributed to
+	var phase Phase
+	defer func() {
+		if err != nil {
+			if phase != "" {
+				err = r.runError(phase, err)
+			}
+			r.failed = true
+			r.close(ctx)
+		}
+	}()
+
+	if err := configureReaper(options.Reaper); err != nil {
+		return nil, err
+	}
+
+	// Use the injected provider or client, if any, for all containers
+	provider, err := runProvider(options, containerLogger(options.Verbosity))
+	if err != nil {
+		return nil, err
+	}
+
+	// Create network, unless the caller shares one across runs
+	phase = PhaseNetwork
+	labels := runLabels(options)
+	if options.Network != nil {
+		r.network = options.Network
+		r.log.Debug("using shared network", "network", r.network.Name)
+	} else {
+		r.network, r.cleanupNetwork, err = createNetwork(ctx, provider, labels, networkConfig{
+			cidr:          options.NetworkCIDR,
+			driver:        options.NetworkDriver,
+			driverOptions: options.NetworkDriverOptions,
+		})
+		if err != nil {
+			return nil, wrapTimeoutError(ctx, err, "create network")
+		}
+		r.log.Info("network created", "network", r.network.Name, "run_id", labels[LabelRunID])
+	}
+
+	// Route output according to the verbosity and the per-line callback
+	var buildOutput io.Writer
+	buildOutput, r.execOutput, r.flushOutput = runOutputs(options)
+	if options.KeepOnFailure {
+		r.outputTail = &outputTail{max: diagnosticOutputLines}
+		r.tailWriter = newLineWriter(OutputSourceExec, r.outputTail.add)
+		r.execOutput = io.MultiWriter(r.execOutput, r.tailWriter)
+	}
+
+	// Start sidecars before the test container so they are reachable when tests run
+	phase = PhaseSidecars
+	r.sidecars, err = startSidecars(ctx, r.network, options.Sidecars, containerLogger(options.Verbosity))
+	if err != nil {
+		return nil, wrapTimeoutError(ctx, err, "start sidecars")
+	}
+	for _, sidecar := range r.sidecars {
+		reportProgress(options.ProgressReporter, ProgressEvent{
+			Stage:   StageSidecarStarted,
+			Message: fmt.Sprintf("sidecar %s started", sidecar.Spec.name()),
+		})
+		r.log.Info("sidecar started", "sidecar", sidecar.Spec.name(), "container", sidecar.ctr.GetContainerID())
+	}
+
+	// Tests reach the daemon through the proxy instead of the socket
+	var proxyEnv map[string]string
+	if options.SocketProxy {
+		sockPath := resolveSockPath(options.SockPath, dockerHostConfigFor(provider))
+		proxy, err := startSocketProxy(ctx, r.network, sockPath, options.SocketProxyWrite, containerLogger(options.Verbosity))
+		if err != nil {
+			return nil, wrapTimeoutError(ctx, err, "start docker socket proxy")
+		}
+		r.sidecars = append(r.sidecars, proxy)
+		r.log.Info("docker socket proxy started", "container", proxy.ctr.GetContainerID())
+		proxyEnv = socketProxyEnv(sockPath, networkGateway(ctx, provider, r.network))
+	}
+
+	// Seed sidecars now that they are ready
+	if err := seedSidecars(ctx, r.sidecars, options.Seeds, r.execOutput); err != nil {
+		return nil, err
+	}
+
+	// Probe services before spending time on building the test image
+	phase = PhaseProbe
+	if err := Probe(ctx, r.network, containerLogger(options.Verbosity), options.Probes...); err != nil {
+		var probeErr *ProbeError
+		if errors.As(err, &probeErr) && ctx.Err() == nil {
+			return nil, probeErr
+		}
+		return nil, wrapTimeoutError(ctx, err, "probe services")
+	}
+	if len(options.Probes) > 0 {
+		r.log.Debug("services probed", "probes", len(options.Probes))
+	}
+
+	// Tests reach the network directly, anything else through the proxy
+	env := testContainerEnv(options)
+	maps.Copy(env, proxyEnv)
+	if options.SockGuard != nil {
+		env[EnvRunID] = labels[LabelRunID]
+	}
+	if options.HostProxyEnv {
+		maps.Copy(env, withNoProxy(hostProxyEnv(), networkNoProxy(ctx, provider, r.network, options)))
+	}
+
+	// Create container
+	phase = PhaseBuild
+	r.container, err = CreateContainer(ctx, CreateContainerConfig{
+		PackagePath:         options.PackagePath,
+		Network:             r.network,
+		Aliases:             options.Aliases,
+		StaticIP:            options.StaticIP,
+		HostPorts:           options.HostPorts,
+		EnableVarSock:       options.EnableVarSock,
+		SockPath:            options.SockPath,
+		DockerHost:          dockerHostConfigFor(provider),
+		NetworkName:         r.network.Name,
+		Env:                 env,
+		DockerfilePath:      options.DockerfilePath,
+		BuildOutput:         buildOutput,
+		Progress:            options.ProgressReporter,
+		Logger:              containerLogger(options.Verbosity),
+		Log:                 r.log,
+		Metrics:             r.metrics,
+		Hooks:               r.hooks,
+		KeepFailedBuild:     options.KeepFailedBuild,
+		WaitFor:             options.WaitFor,
+		BuildKit:            options.BuildKit,
+		LazyModDownload:     options.LazyModDownload,
+		Vendored:            vendored(options),
+		GoVersion:           options.GoVersion,
+		ImageFlavor:         options.ImageFlavor,
+		GoTools:             options.GoTools,
+		DockerfileGenerator: options.DockerfileGenerator,
+		BuildTarget:         options.BuildTarget,
+		Template:            options.Template,
+		TemplateData:        options.TemplateData,
+		GitCredentials:      options.GitCredentials,
+		ProxyEnv:            buildProxyEnv(options),
+		TLSBundle:           options.TLSBundle,
+		Root:                options.Root,
+		SecretEnv:           testContainerSecretEnv(options),
+		ReadOnlySource:      options.ReadOnlySource,
+		ImageCache:          options.ImageCache,
+		ContextExcludes:     options.ContextExcludes,
+		MaxContextSize:      options.MaxContextSize,
+		Labels:              labels,
+		BuildCacheRef:       options.BuildCacheRef,
+		BuildCacheDir:       options.BuildCacheDir,
+		ContainerdCompat:    options.ContainerdCompat,
+		Init:                options.Init,
+		StopTimeout:         options.StopTimeout,
+		Provider:            provider,
+	})
+	if err != nil {
+		// Surface build failures as-is so callers can tell them apart from test failures
+		var buildErr *BuildError
+		if errors.As(err, &buildErr) && ctx.Err() == nil {
+			return nil, buildErr
+		}
+		return nil, wrapTimeoutError(ctx, err, "create container")
+	}
+
+	// Run setup commands before the tests
+	phase = PhaseSetup
+	if options.SockGuard != nil {
+		cli, closeCli, err := dockerClient(ctx, provider)
+		if err != nil {
+			return nil, err
+		}
+		r.guard = startSockGuard(ctx, cli, closeCli, *options.SockGuard, labels[LabelRunID], orDiscard(r.log))
+	}
+	setupStart := time.Now()
+	if err := runSetupCommands(ctx, r.container, options.SetupCommands, r.execOutput); err != nil {
+		var setupErr *SetupError
+		if errors.As(err, &setupErr) {
+			// Non-fatal: logs are best-effort diagnostics
+			setupErr.ContainerLogs, _ = r.container.Logs(ctx)
+		}
+		return nil, err
+	}
+	if len(options.SetupCommands) > 0 {
+		r.log.Info("setup commands finished", "commands", len(options.SetupCommands), "duration", time.Since(setupStart))
+	}
+
+	return r, nil
+}
+
+// runOutputs returns the writers for the build log and the command output of a
+// run, routed according to the verbosity and the OutputCallback, and a function
+// that flushes buffered partial lines. The build output is nil if
+// the build log is not shown.
+func runOutputs(options *Options) (buildOutput, execOutput io.Writer, flush func()) {
+	stdout := options.stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	var flushes []func()
+	buildStdout, execStdout := stdout, stdout
+	if options.Name != "" {
+		// Prefix the lines with the name of the run, see WithName
+		buildLines := newLineWriter(OutputSourceBuild, prefixLines(stdout, options.Name))
+		execLines := newLineWriter(OutputSourceExec, prefixLines(stdout, options.Name))
+		buildStdout, execStdout = buildLines, execLines
+		flushes = append(flushes, execLines.Flush, buildLines.Flush)
+	}
+
+	var buildOutputs, execOutputs []io.Writer
+	if options.Verbosity >= VerbosityNormal {
+		execOutputs = append(execOutputs, execStdout)
+	}
+	if options.Verbosity >= VerbosityVerbose {
+		buildOutputs = append(buildOutputs, buildStdout)
+	}
+	if options.OutputCallback != nil {
+		buildLines := newLineWriter(OutputSourceBuild, options.OutputCallback)
+		buildOutputs = append(buildOutputs, buildLines)
+
+		execLines := newLineWriter(OutputSourceExec, options.OutputCallback)
+		execOutputs = append(execOutputs, execLines)
+
+		flushes = append(flushes, execLines.Flush, buildLines.Flush)
+	}
+	flush = func() {
+		for _, f := range flushes {
+			f()
+		}
+	}
+	if len(buildOutputs) > 0 {
+		buildOutput = io.MultiWriter(buildOutputs...)
+	}
+	return buildOutput, io.MultiWriter(execOutputs...), flush
+}
+
+// testContainerEnv returns the environment of the test container: the
+// connection settings of the sidecars, the reaper setting, so that
+// testcontainers started by the tests follow it, the toolchain, the vendor
+// mode and the GOPRIVATE patterns of the git credentials.
+func testContainerEnv(options *Options) map[string]string {
+	env := sidecarEnv(options.Sidecars)
+	switch options.Reaper {
+	case ReaperEnabled:
+		env[ryukDisabledEnv] = "false"
+	case ReaperDisabled:
+		env[ryukDisabledEnv] = "true"
+	}
+	if options.Toolchain != "" {
+		env[toolchainEnv] = options.Toolchain
+	}
+	if flags := vendorGoFlags(options); flags != "" {
+		env[goFlagsEnv] = flags
+	}
+	if len(options.GitCredentials) > 0 {
+		env[goPrivateEnv] = goPrivate(options.GitCredentials)
+	}
+	return env
+}
+
+// testContainerSecretEnv returns the variables passed to the commands run in
+// the test container only: the git configuration of the git credentials and
+// the secrets of WithSecretEnv, which take precedence.
+func testContainerSecretEnv(options *Options) map[string]string {
+	if len(options.GitCredentials) == 0 {
+		return options.SecretEnv
+	}
+	env := gitConfigEnv(options.GitCredentials)
+	maps.Copy(env, options.SecretEnv)
+	return env
+}
+
+// Container returns the test container, e.g. for running additional commands
+// between Test calls.
+func (r *Runner) Container() *TestContainer {
+	return r.container
+}
+
+// Test executes go test in the test container and returns its result with the
+// coverage profile. Unset fields of cfg default to the Runner's options:
+// Pattern to WithPattern, Timeout to WithTimeout, WorkingDir to
+// WithPackageSubdir and Output to the output of the run. cfg.Args are appended to the arguments of WithArgs.
+// The go test flags of the options apply as in Run, e.g. WithShort,
+// WithFailFast, WithCoverMode, the profiles and WithCompileOnly.
+func (r *Runner) Test(ctx context.Context, cfg ExecConfig) (*Result, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil, errors.New("runner is closed")
+	}
+
+	if cfg.Pattern == "" {
+		cfg.Pattern = r.options.Pattern
+	}
+	cfg.Args = append(append([]string(nil), r.options.Args...), cfg.Args...)
+	if cfg.Timeout == 0 {
+		cfg.Timeout = r.options.Timeout
+	}
+	if cfg.WorkingDir == "" {
+		cfg.WorkingDir = testWorkingDir(r.options)
+	}
+	if cfg.Output == nil {
+		cfg.Output = r.execOutput
+	} else if r.tailWriter != nil {
+		cfg.Output = io.MultiWriter(cfg.Output, r.tailWriter)
+	}
+
+	ctx, cancel := r.signals.bind(ctx)
+	defer cancel()
+
+	var eventWriter *lineWriter
+	if r.events != nil {
+		eventWriter = newLineWriter(OutputSourceExec, func(line OutputLine) {
+			if event, ok := parseTestEvent([]byte(line.Text)); ok {
+				select {
+				case r.events <- event:
+				case <-ctx.Done():
+				}
+			}
+		})
+		cfg.Output = io.MultiWriter(cfg.Output, eventWriter)
+	}
+
+	reportProgress(r.options.ProgressReporter, ProgressEvent{Stage: StageTestsRunning, Message: "running go test"})
+	start := time.Now()
+	// Non-fatal: without a module path, the files of failures stay relative
+	// to their package
+	modulePath, _ := readModulePath(r.options.PackagePath)
+	result, err := runTests(ctx, r.container, cfg, r.testCommand(cfg), modulePath, r.options.ProgressReporter)
+	if eventWriter != nil {
+		eventWriter.Flush()
+	}
+	if err == nil {
+		err = r.collectOutputs(ctx, result)
+	}
+	if err != nil || result.ExitCode != 0 {
+		r.failed = true
+	}
+	logTests(orDiscard(r.log), result, err, time.Since(start))
+	observeMetric(r.metrics, MetricTestDuration, time.Since(start).Seconds())
+	if err == nil {
+		if err = r.guard.err(); err != nil {
+			r.failed = true
+		}
+	}
+	if result != nil {
+		result.Name = r.options.Name
+		result.NetworkName = r.network.Name
+	}
+	r.result = result
+	err = r.runError(PhaseExec, err)
+	invokeHook(ctx, r.hooks.OnTestsFinished, r.hookEvent(result, err))
+	return result, err
+}
+
+// testCommand returns the go test command of Test for cfg, built from the
+// Runner's options like the command of Run.
+func (r *Runner) testCommand(cfg ExecConfig) []string {
+	options := *r.options
+	options.Pattern, options.Args = cfg.Pattern, cfg.Args
+	cmd := buildTestCommand(&options)
+	if cfg.CoverageFile != "" {
+		if i := slices.Index(cmd, "-coverprofile="+DefaultCoverageFile); i >= 0 {
+			cmd[i] = "-coverprofile=" + cfg.CoverageFile
+		}
+	}
+	return cmd
+}
+
+// collectOutputs copies the profiles and, in compile-only mode, the test
+// binaries of a Test call out of the container into result, as Run does.
+func (r *Runner) collectOutputs(ctx context.Context, result *Result) error {
+	copyProfiles(ctx, r.container, r.options, result)
+	if r.options.CompileOnly {
+		binaries, err := collectTestBinaries(ctx, r.container)
+		if err != nil {
+			return wrapTimeoutError(ctx, err, "collect test binaries")
+		}
+		result.TestBinaries = binaries
+	}
+	return nil
+}
+
+// Events returns a channel receiving the events of go test while Test runs,
+// e.g. for live dashboards or to cancel the run on the first failure of a
+// critical test. go test reports events with the -json flag only, see
+// WithArgs and ExecConfig.Args. Call Events before Test; the channel is
+// closed by Close.
+//
+// The channel is buffered, but the output of go test blocks while it is
+// full, so it must be drained until it is closed or the context of Test is
+// canceled.
+//
+// Example:
+//
+//	ctx, cancel := context.WithCancel(ctx)
+//	defer cancel()
+//	events := runner.Events()
+//	go func() {
+//	    for event := range events {
+//	        if event.Action == "fail" && event.Test == "TestCritical" {
+//	            cancel()
+//	        }
+//	    }
+//	}()
+//	result, err := runner.Test(ctx, dockertesting.ExecConfig{Args: []string{"-json"}})
+func (r *Runner) Events() <-chan TestEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.events == nil {
+		r.events = make(chan TestEvent, eventBufferSize)
+		if r.closed {
+			close(r.events)
+		}
+	}
+	return r.events
+}
+
+// Diagnostics returns the diagnostics of the resources kept by
+// WithKeepOnFailure after Close, or nil if they were removed.
+func (r *Runner) Diagnostics() *DiagnosticBundle {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.diagnostics
+}
+
+// Close runs the teardown commands and removes the test container, the
+// sidecars and the network, unless WithKeepResources is set.
+// Calling Close more than once is a no-op.
+func (r *Runner) Close(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+
+	ctx, cancel := r.signals.bind(ctx)
+	defer cancel()
+
+	// Non-fatal: teardown is best-effort collection of diagnostics
+	_ = runTeardownCommands(ctx, r.container, r.options.TeardownCommands, r.execOutput)
+	return r.close(ctx)
+}
+
+// interrupt removes the resources of the runner after the process received
+// sig, then lets the signal take its course. Operations in progress have been
+// canceled and give up the runner shortly.
+func (r *Runner) interrupt(sig os.Signal) {
+	r.mu.Lock()
+	if !r.closed {
+		// Non-fatal: the process is terminating anyway
+		_ = r.close(context.Background())
+	}
+	r.mu.Unlock()
+	r.signals.reraise(sig)
+}
+
+// close removes the resources of the runner in reverse order of creation.
+// The caller must hold r.mu.
+func (r *Runner) close(ctx context.Context) error {
+	r.closed = true
+	if r.events != nil {
+		close(r.events)
+	}
+
+	r.signals.stop()
+
+	// ctx may be canceled or expired, which is often why the run ends, but
+	// the resources must go regardless
+	ctx = context.WithoutCancel(ctx)
+	if r.options.CleanupTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.options.CleanupTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	keep := r.options.KeepResources
+	if r.options.KeepOnFailure && r.failed && r.container != nil {
+		keep = true
+		r.tailWriter.Flush()
+		r.diagnostics = collectDiagnostics(ctx, r.container, r.network, r.sidecars, r.outputTail.snapshot())
+		r.diagnostics.Name = r.options.Name
+		r.diagnostics.SharedNetwork = r.options.Network != nil
+		r.diagnostics.writeReport(os.Stderr)
+	}
+
+	var errs []error
+	if r.container != nil && !keep {
+		errs = append(errs, r.container.Terminate(ctx))
+	}
+	// Containers leaked by the tests would keep the network in use
+	if r.guard != nil {
+		if r.guard.cfg.Cleanup && !keep {
+			var networkName string
+			if r.options.Network == nil {
+				networkName = r.network.Name
+			}
+			errs = append(errs, r.guard.cleanup(ctx, networkName))
+		}
+		r.guard.close()
+	}
+	// Sidecars are torn down after the test container
+	errs = append(errs, terminateSidecars(ctx, r.sidecars, r.execOutput, keep))
+	if r.flushOutput != nil {
+		r.flushOutput()
+	}
+	if r.cleanupNetwork != nil && !keep {
+		errs = append(errs, r.cleanupNetwork(ctx))
+	}
+	err := errors.Join(errs...)
+	event := r.hookEvent(r.result, err)
+	event.Kept = keep
+	invokeHook(ctx, r.hooks.OnCleanup, event)
+	log := orDiscard(r.log)
+	switch {
+	case keep:
+		log.Info("resources kept", "failed", r.failed)
+	case err != nil:
+		log.Info("resources not removed", "duration", time.Since(start), "error", err)
+	default:
+		log.Info("resources removed", "duration", time.Since(start))
+	}
+	return err
+}
+
+// hookEvent returns the event of a hook after the test container started.
+func (r *Runner) hookEvent(result *Result, err error) HookEvent {
+	event := HookEvent{Result: result, Err: err}
+	if r.container != nil {
+		event.ImageTag = r.container.image
+		event.ContainerID = r.container.ID()
+	}
+	return event
+}
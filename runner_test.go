@@ -0,0 +1,123 @@
+package dockertesting
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRunner_TestAfterClose(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := &Runner{options: opts}
+
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// A second Close is a no-op
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatalf("unexpected error on second Close: %v", err)
+	}
+
+	if _, err := r.Test(context.Background(), ExecConfig{}); err == nil {
+		t.Error("expected error when testing with a closed runner, got nil")
+	}
+}
+
+func TestRunner_EventsClosedByClose(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := &Runner{options: opts}
+
+	events := r.Events()
+	if r.Events() != events {
+		t.Error("expected Events to return the same channel")
+	}
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := <-events; ok {
+		t.Error("expected the channel to be closed by Close")
+	}
+
+	// A runner closed before Events returns a closed channel
+	r = &Runner{options: opts}
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := <-r.Events(); ok {
+		t.Error("expected a closed channel after Close")
+	}
+}
+
+func TestRunner_CloseUsesIndependentContext(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithCleanupTimeout(time.Minute))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var cleanupErr error
+	var deadline time.Time
+	r := &Runner{
+		options: opts,
+		cleanupNetwork: func(ctx context.Context) error {
+			cleanupErr = ctx.Err()
+			deadline, _ = ctx.Deadline()
+			return nil
+		},
+	}
+
+	// The run context has expired, e.g. after a timeout
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := r.Close(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cleanupErr != nil {
+		t.Errorf("expected cleanup context not to be canceled, got %v", cleanupErr)
+	}
+	if remaining := time.Until(deadline); remaining <= 0 || remaining > time.Minute {
+		t.Errorf("expected cleanup deadline within the cleanup timeout, got %v", remaining)
+	}
+}
+
+func TestRunner_TestCommand(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package",
+		WithShort(),
+		WithFailFast(),
+		WithVerbosity(VerbosityVerbose),
+		WithCPUProfile(),
+		WithArgs("-race"),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := &Runner{options: opts}
+
+	cmd := r.testCommand(ExecConfig{Pattern: "./pkg/...", Args: []string{"-race", "-count=1"}})
+	for _, flag := range []string{"-short", "-failfast", "-v", "-covermode=atomic", "-cpuprofile=" + DefaultCPUProfileFile, "./pkg/...", "-count=1"} {
+		if !slices.Contains(cmd, flag) {
+			t.Errorf("expected %s in the command, got %v", flag, cmd)
+		}
+	}
+
+	cmd = r.testCommand(ExecConfig{Pattern: "./...", CoverageFile: "/tmp/custom.out"})
+	if !slices.Contains(cmd, "-coverprofile=/tmp/custom.out") {
+		t.Errorf("expected the coverage file of the config, got %v", cmd)
+	}
+
+	r.options.CompileOnly = true
+	if cmd := r.testCommand(ExecConfig{Pattern: "./..."}); !slices.Contains(cmd, "-c") {
+		t.Errorf("expected a compile-only command, got %v", cmd)
+	}
+}
@@ -0,0 +1,151 @@
+package dockertesting
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// runTBuildLogLines is the number of build log lines RunT includes in the
+// failure message of a failed image build.
+const runTBuildLogLines = 20
+
+// RunT runs the tests of the package at packagePath like Run, as part of the
+// test t, and returns the result if they passed:
+//
+//   - The run is canceled when t ends, and times out ahead of the deadline of
+//     go test -timeout, leaving the cleanup timeout to remove the resources.
+//     The derived context is released with t.Cleanup.
+//   - The test output is logged through t.Log instead of being forwarded to
+//     os.Stdout, so it is reported with t. The build output is logged as well
+//     with VerbosityVerbose; VerbosityQuiet logs nothing.
+//   - If the run fails, or the tests fail, t.Fatalf is called with a summary:
+//     the error and the tail of the build log, or the failed tests.
+//
+// Example:
+//
+//	func TestIntegration(t *testing.T) {
+//	    result := dockertesting.RunT(t, "./mypackage", dockertesting.WithVarSock())
+//	    t.Logf("coverage: %.1f%%", result.CoveragePercent)
+//	}
+func RunT(t *testing.T, packagePath string, opts ...Option) *Result {
+	t.Helper()
+
+	options, err := NewOptions(packagePath, opts...)
+	if err == nil {
+		err = options.Validate()
+	}
+	if err != nil {
+		t.Fatalf("dockertesting: invalid options: %v", err)
+	}
+
+	// Time out before go test aborts the test binary, so resources are removed
+	ctx := t.Context()
+	if deadline, ok := t.Deadline(); ok {
+		margin := options.CleanupTimeout
+		if margin <= 0 {
+			margin = DefaultCleanupTimeout
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline.Add(-margin))
+		t.Cleanup(cancel)
+	}
+
+	// Log the output through the test instead of forwarding it to os.Stdout
+	callback := options.OutputCallback
+	verbosity := options.Verbosity
+	options.stdout = io.Discard
+	options.OutputCallback = func(line OutputLine) {
+		if line.Source == OutputSourceExec && verbosity >= VerbosityNormal ||
+			line.Source == OutputSourceBuild && verbosity >= VerbosityVerbose {
+			t.Log(line.Text)
+		}
+		if callback != nil {
+			callback(line)
+		}
+	}
+
+	result, err := run(ctx, options)
+	if err != nil {
+		t.Fatalf("dockertesting: %s", runErrorSummary(err))
+	}
+	if result.ExitCode != 0 {
+		t.Fatalf("dockertesting: %s", testFailureSummary(options.PackagePath, result))
+	}
+	return result
+}
+
+// runErrorSummary describes err for the failure message of RunT, with the
+// tail of the build log of a *BuildError and the goroutine dump of a
+// *TimeoutError.
+func runErrorSummary(err error) string {
+	var b strings.Builder
+	b.WriteString(err.Error())
+
+	var buildErr *BuildError
+	if errors.As(err, &buildErr) && len(buildErr.Log) > 0 {
+		fmt.Fprintf(&b, "\nbuild log:\n%s", lastLines(buildErr.Log, runTBuildLogLines))
+	}
+	var timeoutErr *TimeoutError
+	if errors.As(err, &timeoutErr) && len(timeoutErr.GoroutineDump) > 0 {
+		fmt.Fprintf(&b, "\ngoroutine dump:\n%s", timeoutErr.GoroutineDump)
+	}
+	return b.String()
+}
+
+// testFailureSummary describes the failed tests of result for the failure
+// message of RunT, from its Failures, so that -json output is covered too.
+func testFailureSummary(packagePath string, result *Result) string {
+	var b strings.Builder
+	if result.Name != "" {
+		fmt.Fprintf(&b, "run %s: ", result.Name)
+	}
+	fmt.Fprintf(&b, "tests in %s failed with exit code %d", packagePath, result.ExitCode)
+	if len(result.Failures) > 0 {
+		b.WriteString("\nfailed tests:")
+	}
+	for _, failure := range result.Failures {
+		b.WriteString("\n  ")
+		if failure.Package != "" {
+			b.WriteString(failure.Package + " ")
+		}
+		b.WriteString(failure.Test)
+		if failure.File != "" {
+			fmt.Fprintf(&b, " (%s:%d)", failure.File, failure.Line)
+		}
+		if message, _, _ := strings.Cut(failure.Message, "\n"); message != "" {
+			fmt.Fprintf(&b, ": %s", message)
+		}
+	}
+	for _, crash := range result.Crashes {
+		message, _, _ := strings.Cut(crash.Message, "\n")
+		fmt.Fprintf(&b, "\n%s crashed: %s", cmp.Or(crash.Test, crash.Package, "go test"), message)
+		if frame, ok := crash.Location(); ok {
+			fmt.Fprintf(&b, " (%s:%d)", frame.File, frame.Line)
+		}
+	}
+	if result.OOMKilled {
+		b.WriteString("\na process in the container was killed by the out-of-memory killer")
+	}
+	if result.Diagnostics != nil {
+		b.WriteString("\nthe resources were kept, inspect them with:")
+		for _, command := range result.Diagnostics.Commands() {
+			fmt.Fprintf(&b, "\n  %s", command)
+		}
+	}
+	return b.String()
+}
+
+// lastLines returns the last n lines of output.
+func lastLines(output []byte, n int) []byte {
+	lines := bytes.SplitAfter(bytes.TrimRight(output, "\n"), []byte("\n"))
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return bytes.Join(lines, nil)
+}
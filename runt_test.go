@@ -0,0 +1,62 @@
+package dockertesting
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLastLines(t *testing.T) {
+	t.Parallel()
+	output := []byte("one\ntwo\nthree\n")
+
+	if got := string(lastLines(output, 2)); got != "two\nthree" {
+		t.Errorf("expected last lines %q, got %q", "two\nthree", got)
+	}
+	if got := string(lastLines(output, 5)); got != "one\ntwo\nthree" {
+		t.Errorf("expected all lines %q, got %q", "one\ntwo\nthree", got)
+	}
+}
+
+func TestRunErrorSummary_BuildError(t *testing.T) {
+	t.Parallel()
+	err := &RunError{Phase: PhaseBuild, Err: &BuildError{
+		Log: []byte("Step 1/2 : FROM golang\nStep 2/2 : RUN go mod download\nmissing go.sum entry\n"),
+		Err: errors.New("The command '/bin/sh -c go mod download' returned a non-zero code: 1"),
+	}}
+
+	summary := runErrorSummary(err)
+	for _, want := range []string{"phase build", "build log:", "missing go.sum entry"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("expected summary to contain %q, got %q", want, summary)
+		}
+	}
+}
+
+func TestTestFailureSummary(t *testing.T) {
+	t.Parallel()
+	result := &Result{
+		ExitCode: 1,
+		Stdout:   []byte(`{"Action":"fail","Package":"example.com/mod","Test":"TestA"}` + "\n"),
+		Failures: []TestFailure{
+			{Package: "example.com/mod", Test: "TestA", File: "a_test.go", Line: 10, Message: "a_test.go:10: boom\nmore"},
+			{Test: "TestC"},
+		},
+		Diagnostics: &DiagnosticBundle{ContainerID: "abc123"},
+		Crashes: []Crash{{
+			Test:    "TestB",
+			Message: "panic: boom [recovered]\n\tpanic: boom",
+			Stack:   []StackFrame{{Function: "panic"}, {Function: "example.com/mod.TestB", File: "b_test.go", Line: 4}},
+		}},
+	}
+
+	summary := testFailureSummary("/path/to/package", result)
+	if strings.HasPrefix(summary, "run ") {
+		t.Errorf("expected no run name for an unnamed run, got %q", summary)
+	}
+	for _, want := range []string{"/path/to/package", "exit code 1", "failed tests:\n  example.com/mod TestA (a_test.go:10): a_test.go:10: boom\n  TestC\n", "TestB crashed: panic: boom [recovered] (b_test.go:4)", "docker exec -it abc123 sh"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("expected summary to contain %q, got %q", want, summary)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package dockertesting
+
+import (
+	"cmp"
+	"context"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Redacted replaces the values of WithSecretEnv in the output of the commands
+// run in the test container, in their results and in the goroutine dumps,
+// artifacts and logs collected from the container.
+const Redacted = "[REDACTED]"
+
+// newRedactor returns a replacer of the non-empty values of secrets and of
+// extra, e.g. the tokens of WithGitCredentials, with Redacted, or nil if there
+// are none. Longer values are replaced first, so that a value containing
+// another is redacted as a whole.
+func newRedactor(secrets map[string]string, extra ...string) *strings.Replacer {
+	values := append(slices.Collect(maps.Values(secrets)), extra...)
+	values = slices.DeleteFunc(values, func(value string) bool { return value == "" })
+	if len(values) == 0 {
+		return nil
+	}
+	slices.SortFunc(values, func(a, b string) int {
+		return cmp.Or(cmp.Compare(len(b), len(a)), strings.Compare(a, b))
+	})
+	pairs := make([]string, 0, 2*len(values))
+	for _, value := range values {
+		pairs = append(pairs, value, Redacted)
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// redactWriter is an io.Writer that redacts the secrets of a replacer from the
+// data written to it line by line, so that values split across writes are
+// redacted too. Incomplete trailing data is buffered until more data arrives
+// or Flush is called.
+type redactWriter struct {
+	w        io.Writer
+	replacer *strings.Replacer
+	buf      []byte
+}
+
+func (w *redactWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	i := strings.LastIndexByte(string(w.buf), '\n')
+	if i < 0 {
+		return len(p), nil
+	}
+	lines := w.replacer.Replace(string(w.buf[:i+1]))
+	w.buf = append([]byte(nil), w.buf[i+1:]...)
+	if _, err := io.WriteString(w.w, lines); err != nil {
+		return len(p), err
+	}
+	return len(p), nil
+}
+
+// Flush writes any buffered partial line.
+func (w *redactWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	rest := w.replacer.Replace(string(w.buf))
+	w.buf = nil
+	_, err := io.WriteString(w.w, rest)
+	return err
+}
+
+// redact returns data with the secrets of WithSecretEnv replaced by Redacted.
+func (c *TestContainer) redact(data []byte) []byte {
+	if c.redactor == nil || data == nil {
+		return data
+	}
+	return []byte(c.redactor.Replace(string(data)))
+}
+
+// execSecretCommand runs cmd like execCommand with the secrets of WithSecretEnv
+// in its environment, redacting them from its output and result. Variables of
+// opts.Env come last and take precedence over the secrets.
+func (c *TestContainer) execSecretCommand(ctx context.Context, cmd []string, opts ExecOptions) (*ExecResult, error) {
+	env := make([]string, 0, len(c.secretEnv)+len(opts.Env))
+	for _, name := range slices.Sorted(maps.Keys(c.secretEnv)) {
+		env = append(env, name+"="+c.secretEnv[name])
+	}
+	opts.Env = append(env, opts.Env...)
+
+	var redacted *redactWriter
+	if opts.Output != nil && c.redactor != nil {
+		redacted = &redactWriter{w: opts.Output, replacer: c.redactor}
+		opts.Output = redacted
+	}
+	result, err := execCommand(ctx, c.ctr, cmd, opts)
+	if redacted != nil {
+		// Non-fatal: the output is a copy of the result
+		_ = redacted.Flush()
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.Stdout = c.redact(result.Stdout)
+	return result, nil
+}
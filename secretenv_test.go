@@ -0,0 +1,60 @@
+package dockertesting
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNewRedactor(t *testing.T) {
+	t.Parallel()
+
+	if newRedactor(nil) != nil || newRedactor(map[string]string{"EMPTY": ""}) != nil {
+		t.Error("expected no redactor without non-empty secrets")
+	}
+
+	redactor := newRedactor(map[string]string{"SHORT": "s3cr3t", "LONG": "s3cr3t-and-more", "EMPTY": ""})
+	got := redactor.Replace("token=s3cr3t-and-more, key=s3cr3t, empty=")
+	if want := "token=" + Redacted + ", key=" + Redacted + ", empty="; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestRedactWriter(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	w := &redactWriter{w: &out, replacer: newRedactor(map[string]string{"API_TOKEN": "s3cr3t"})}
+	for _, chunk := range []string{"using s3", "cr3t\nsecond line with s3c", "r3t"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if strings.Contains(out.String(), "second") {
+		t.Errorf("expected the partial line to be buffered, got %q", out.String())
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "using " + Redacted + "\nsecond line with " + Redacted; out.String() != want {
+		t.Errorf("expected %q, got %q", want, out.String())
+	}
+}
+
+func TestTestContainer_Redact(t *testing.T) {
+	t.Parallel()
+
+	plain := &TestContainer{}
+	if got := plain.redact([]byte("key=s3cr3t")); string(got) != "key=s3cr3t" {
+		t.Errorf("expected the data unchanged without secrets, got %q", got)
+	}
+
+	container := &TestContainer{redactor: newRedactor(map[string]string{"API_TOKEN": "s3cr3t"})}
+	dump := extractGoroutineDump(container.redact([]byte("printed s3cr3t\nSIGQUIT: quit\ngoroutine 1 [running]:\nmain.token(\"s3cr3t\")\n")))
+	if strings.Contains(string(dump), "s3cr3t") || !strings.Contains(string(dump), Redacted) {
+		t.Errorf("expected the secret to be redacted from the dump, got %q", dump)
+	}
+	if container.redact(nil) != nil {
+		t.Error("expected nil data to stay nil")
+	}
+}
@@ -0,0 +1,73 @@
+package dockertesting
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+)
+
+// DefaultSeedDir is the directory inside a sidecar where seed scripts are copied.
+const DefaultSeedDir = "/tmp/dockertesting-seed"
+
+// Seed declares scripts that are executed inside a sidecar before the tests run.
+type Seed struct {
+	// Sidecar is the name of the sidecar to seed.
+	Sidecar string
+
+	// Scripts are the paths of the scripts on the host, executed in order.
+	Scripts []string
+}
+
+// CopyFileToContainer copies a file from the host into the sidecar.
+func (s *Sidecar) CopyFileToContainer(ctx context.Context, hostFilePath, containerFilePath string) error {
+	if s.ctr == nil {
+		return fmt.Errorf("container is nil")
+	}
+	if err := s.ctr.CopyFileToContainer(ctx, hostFilePath, containerFilePath, 0644); err != nil {
+		return fmt.Errorf("failed to copy %s to sidecar %q: %w", hostFilePath, s.Spec.name(), err)
+	}
+	return nil
+}
+
+// seedCommand returns the command that executes the seed script at
+// containerPath: the sidecar's SeedCommand followed by the path, or sh.
+func seedCommand(spec SidecarSpec, containerPath string) []string {
+	if len(spec.SeedCommand) == 0 {
+		return []string{"sh", containerPath}
+	}
+	cmd := append([]string{}, spec.SeedCommand...)
+	return append(cmd, containerPath)
+}
+
+// seedSidecars copies the seed scripts into their sidecars and executes them
+// in order, streaming their output to w. It stops at the first failure; a
+// script exiting with a non-zero code is reported as a *SetupError.
+func seedSidecars(ctx context.Context, sidecars []*Sidecar, seeds []Seed, w io.Writer) error {
+	byName := make(map[string]*Sidecar, len(sidecars))
+	for _, sidecar := range sidecars {
+		byName[sidecar.Spec.name()] = sidecar
+	}
+
+	for i, seed := range seeds {
+		sidecar, ok := byName[seed.Sidecar]
+		if !ok {
+			return fmt.Errorf("cannot seed unknown sidecar %q", seed.Sidecar)
+		}
+
+		for j, script := range seed.Scripts {
+			// Prefix with the position to keep names unique and ordered
+			containerPath := path.Join(DefaultSeedDir, fmt.Sprintf("%02d-%02d-%s", i, j, filepath.Base(script)))
+			if err := sidecar.CopyFileToContainer(ctx, script, containerPath); err != nil {
+				return err
+			}
+
+			cmd := seedCommand(sidecar.Spec, containerPath)
+			if err := runSetupCommands(ctx, sidecar, [][]string{cmd}, w); err != nil {
+				return fmt.Errorf("failed to seed sidecar %q with %s: %w", seed.Sidecar, script, err)
+			}
+		}
+	}
+	return nil
+}
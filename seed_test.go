@@ -0,0 +1,79 @@
+package dockertesting
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSeedCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		spec     SidecarSpec
+		expected []string
+	}{
+		{
+			name:     "default sh",
+			spec:     SidecarSpec{Image: "redis:7"},
+			expected: []string{"sh", "/tmp/seed.sh"},
+		},
+		{
+			name:     "custom command",
+			spec:     SidecarSpec{Image: "postgres:17", SeedCommand: []string{"psql", "-f"}},
+			expected: []string{"psql", "-f", "/tmp/seed.sh"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := seedCommand(tt.spec, "/tmp/seed.sh")
+			if strings.Join(got, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSeedCommand_DoesNotModifySpec(t *testing.T) {
+	t.Parallel()
+	spec := SidecarSpec{Image: "postgres:17", SeedCommand: make([]string, 2, 10)}
+	spec.SeedCommand[0], spec.SeedCommand[1] = "psql", "-f"
+
+	_ = seedCommand(spec, "/tmp/a.sql")
+	got := seedCommand(spec, "/tmp/b.sql")
+	if got[2] != "/tmp/b.sql" {
+		t.Errorf("expected script path '/tmp/b.sql', got %q", got[2])
+	}
+}
+
+func TestSeedSidecars_UnknownSidecar(t *testing.T) {
+	t.Parallel()
+	sidecars := []*Sidecar{{Spec: SidecarSpec{Name: "postgres", Image: "postgres:17"}}}
+
+	err := seedSidecars(context.Background(), sidecars, []Seed{{Sidecar: "mysql", Scripts: []string{"schema.sql"}}}, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), `unknown sidecar "mysql"`) {
+		t.Errorf("expected unknown sidecar error, got %v", err)
+	}
+}
+
+func TestSeedSidecars_NilContainer(t *testing.T) {
+	t.Parallel()
+	sidecars := []*Sidecar{{Spec: SidecarSpec{Name: "postgres", Image: "postgres:17"}}}
+
+	err := seedSidecars(context.Background(), sidecars, []Seed{{Sidecar: "postgres", Scripts: []string{"schema.sql"}}}, io.Discard)
+	if err == nil {
+		t.Fatal("expected error for nil container")
+	}
+}
+
+func TestSeedSidecars_NoSeeds(t *testing.T) {
+	t.Parallel()
+
+	if err := seedSidecars(context.Background(), nil, nil, io.Discard); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,299 @@
+package dockertesting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"strconv"
+	"strings"
+
+	"github.com/testcontainers/testcontainers-go"
+	tclog "github.com/testcontainers/testcontainers-go/log"
+	"github.com/testcontainers/testcontainers-go/network"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// SidecarSpec declares a dependency container, e.g. a database or cache, that is
+// started on the test network before the tests execute and torn down afterwards.
+type SidecarSpec struct {
+	// Name identifies the sidecar in errors and logs, and is the prefix of the
+	// connection env vars set in the test container (e.g. "postgres" sets
+	// POSTGRES_HOST). Defaults to the first alias, or the image if there are
+	// no aliases.
+	Name string
+
+	// Image is the Docker image to run, e.g. "postgres:17".
+	Image string
+
+	// Aliases are DNS aliases under which the test container can reach the sidecar.
+	Aliases []string
+
+	// Env sets environment variables in the sidecar.
+	Env map[string]string
+
+	// Cmd overrides the command of the image (optional).
+	Cmd []string
+
+	// Port is the main port the service listens on, exported to the test
+	// container as <NAME>_PORT (optional).
+	Port int
+
+	// ConnectionEnv are additional env vars set in the test container to
+	// describe how to connect to the sidecar, e.g. credentials (optional).
+	ConnectionEnv map[string]string
+
+	// ExposedPorts are ports (e.g. "5432/tcp") to publish on the host, which is
+	// required for port and HTTP wait strategies. If empty, the ports exposed
+	// by the image are used.
+	ExposedPorts []string
+
+	// DependsOn lists the names of sidecars that must be started before this
+	// one. This sidecar is terminated before the sidecars it depends on.
+	DependsOn []string
+
+	// SeedCommand is the command that executes seed scripts added with
+	// WithSeed; the path of the script inside the sidecar is appended to it,
+	// e.g. []string{"psql", "-f"}. If empty, scripts are executed with sh.
+	SeedCommand []string
+
+	// TeardownCommands are executed inside the sidecar after the test container
+	// has been terminated and right before the sidecar is terminated, e.g. to
+	// dump state. Failures are best-effort and do not affect the result.
+	TeardownCommands [][]string
+
+	// WaitFor is the strategy that decides when the sidecar is ready (optional),
+	// e.g. WaitForPort, WaitForLog, WaitForHTTP or WaitForHealthcheck.
+	// If nil, the sidecar is considered ready as soon as it is running.
+	WaitFor wait.Strategy
+}
+
+// name returns the name of the sidecar, falling back to its first alias or image.
+func (s SidecarSpec) name() string {
+	switch {
+	case s.Name != "":
+		return s.Name
+	case len(s.Aliases) > 0:
+		return s.Aliases[0]
+	default:
+		return s.Image
+	}
+}
+
+// connectionEnv returns the env vars that tell the test container how to reach
+// the sidecar: <NAME>_HOST with the first alias, <NAME>_PORT with the port, and
+// the spec's ConnectionEnv.
+func (s SidecarSpec) connectionEnv() map[string]string {
+	env := make(map[string]string)
+	prefix := envVarPrefix(s.name())
+	if len(s.Aliases) > 0 {
+		env[prefix+"_HOST"] = s.Aliases[0]
+	}
+	if s.Port != 0 {
+		env[prefix+"_PORT"] = strconv.Itoa(s.Port)
+	}
+	maps.Copy(env, s.ConnectionEnv)
+	return env
+}
+
+// envVarPrefix converts a sidecar name to an env var prefix by upper-casing it
+// and replacing all characters other than letters and digits with underscores.
+func envVarPrefix(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// sidecarEnv merges the connection env vars of all sidecars.
+// Later sidecars take precedence on conflicts.
+func sidecarEnv(specs []SidecarSpec) map[string]string {
+	env := make(map[string]string)
+	for _, spec := range specs {
+		maps.Copy(env, spec.connectionEnv())
+	}
+	return env
+}
+
+// Sidecar is a running dependency container started from a SidecarSpec.
+type Sidecar struct {
+	// Spec is the specification the sidecar was started from.
+	Spec SidecarSpec
+
+	// ctr is the underlying testcontainers container.
+	ctr testcontainers.Container
+}
+
+// StartSidecar starts a sidecar container on the given network and waits until
+// it is ready according to spec.WaitFor. The sidecar is created with the
+// provider of the network, see CreateNetworkWithProvider.
+//
+// The caller is responsible for terminating the sidecar by calling Terminate().
+func StartSidecar(ctx context.Context, dn *DockerNetwork, spec SidecarSpec, logger tclog.Logger) (*Sidecar, error) {
+	if spec.Image == "" {
+		return nil, fmt.Errorf("sidecar %q: image must not be empty", spec.name())
+	}
+
+	genReq := testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        spec.Image,
+			Env:          spec.Env,
+			Cmd:          spec.Cmd,
+			ExposedPorts: spec.ExposedPorts,
+			WaitingFor:   spec.WaitFor,
+			Labels:       resourceLabels(),
+		},
+		Started: true,
+		Logger:  logger,
+	}
+
+	if dn != nil {
+		withLabels(&genReq, dn.extraLabels)
+	}
+
+	if dn != nil && dn.Network() != nil {
+		networkOpt := network.WithNetwork(spec.Aliases, dn.Network())
+		if err := networkOpt.Customize(&genReq); err != nil {
+			return nil, fmt.Errorf("sidecar %q: failed to apply network option: %w", spec.name(), err)
+		}
+	}
+
+	var provider *testcontainers.DockerProvider
+	if dn != nil {
+		provider = dn.provider
+	}
+
+	ctr, err := startContainer(ctx, provider, genReq)
+	if err != nil {
+		// startContainer may return a container that failed to become ready
+		if ctr != nil {
+			_ = ctr.Terminate(context.WithoutCancel(ctx))
+		}
+		return nil, fmt.Errorf("sidecar %q: failed to start: %w", spec.name(), err)
+	}
+
+	return &Sidecar{Spec: spec, ctr: ctr}, nil
+}
+
+// Terminate stops and removes the sidecar container.
+func (s *Sidecar) Terminate(ctx context.Context) error {
+	if s.ctr == nil {
+		return nil
+	}
+	if err := s.ctr.Terminate(ctx); err != nil {
+		return fmt.Errorf("sidecar %q: failed to terminate: %w", s.Spec.name(), err)
+	}
+	return nil
+}
+
+// Container returns the underlying testcontainers.Container.
+func (s *Sidecar) Container() testcontainers.Container {
+	return s.ctr
+}
+
+// ExecCommand executes an arbitrary command inside the sidecar and returns its
+// combined output and exit code, like TestContainer.ExecCommand.
+func (s *Sidecar) ExecCommand(ctx context.Context, cmd []string, opts ExecOptions) (*ExecResult, error) {
+	return execCommand(ctx, s.ctr, cmd, opts)
+}
+
+// orderSidecars returns the specs in start order: every sidecar comes after
+// the sidecars it depends on, otherwise the declaration order is kept.
+// It returns an error for duplicate names, unknown dependencies and cycles.
+func orderSidecars(specs []SidecarSpec) ([]SidecarSpec, error) {
+	byName := make(map[string]int, len(specs))
+	for i, spec := range specs {
+		name := spec.name()
+		if _, ok := byName[name]; ok {
+			return nil, fmt.Errorf("duplicate sidecar name %q", name)
+		}
+		byName[name] = i
+	}
+	for _, spec := range specs {
+		for _, dep := range spec.DependsOn {
+			if _, ok := byName[dep]; !ok {
+				return nil, fmt.Errorf("sidecar %q depends on unknown sidecar %q", spec.name(), dep)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(specs))
+	ordered := make([]SidecarSpec, 0, len(specs))
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("sidecar %q has a dependency cycle", specs[i].name())
+		}
+		state[i] = visiting
+		for _, dep := range specs[i].DependsOn {
+			if err := visit(byName[dep]); err != nil {
+				return err
+			}
+		}
+		state[i] = visited
+		ordered = append(ordered, specs[i])
+		return nil
+	}
+	for i := range specs {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// startSidecars starts the sidecars in dependency order, see orderSidecars.
+// If one fails to start, the sidecars started so far are terminated and the
+// error is returned.
+func startSidecars(ctx context.Context, dn *DockerNetwork, specs []SidecarSpec, logger tclog.Logger) ([]*Sidecar, error) {
+	ordered, err := orderSidecars(specs)
+	if err != nil {
+		return nil, err
+	}
+
+	sidecars := make([]*Sidecar, 0, len(ordered))
+	for _, spec := range ordered {
+		sidecar, err := StartSidecar(ctx, dn, spec, logger)
+		if err != nil {
+			_ = terminateSidecars(context.WithoutCancel(ctx), sidecars, io.Discard, false)
+			return nil, err
+		}
+		sidecars = append(sidecars, sidecar)
+	}
+	return sidecars, nil
+}
+
+// terminateSidecars tears the sidecars down in reverse start order: each
+// sidecar runs its teardown commands, streaming their output to w, and is then
+// terminated unless keep is set. All sidecars are attempted even if some fail.
+func terminateSidecars(ctx context.Context, sidecars []*Sidecar, w io.Writer, keep bool) error {
+	var errs []error
+	for i := len(sidecars) - 1; i >= 0; i-- {
+		sidecar := sidecars[i]
+		if err := runTeardownCommands(ctx, sidecar, sidecar.Spec.TeardownCommands, w); err != nil {
+			errs = append(errs, fmt.Errorf("sidecar %q: %w", sidecar.Spec.name(), err))
+		}
+		if keep {
+			continue
+		}
+		if err := sidecar.Terminate(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,139 @@
+/*
+Package sidecar provides preset SidecarSpecs for common services, for use with
+dockertesting.WithSidecar.
+
+Each preset configures the image, environment, a readiness check and a default
+DNS alias, so the tests can reach the service on the shared network without
+any further setup:
+
+	result, err := dockertesting.Run(ctx, "./mypackage",
+	    dockertesting.WithSidecar(sidecar.Postgres("17", "app")),
+	    dockertesting.WithSidecar(sidecar.Redis()),
+	)
+
+The returned specs are plain values and can be adjusted before use, e.g. to
+change the alias or pin a different image.
+*/
+package sidecar
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/djosh34/dockertesting"
+)
+
+// Default aliases under which the presets are reachable on the test network.
+const (
+	PostgresAlias = "postgres.test"
+	RedisAlias    = "redis.test"
+	KafkaAlias    = "kafka.test"
+)
+
+// Default ports the preset services listen on.
+const (
+	PostgresPort = 5432
+	RedisPort    = 6379
+	KafkaPort    = 9092
+)
+
+// Default credentials of the Postgres preset.
+const (
+	PostgresUser     = "postgres"
+	PostgresPassword = "postgres"
+)
+
+// Default images of the presets without a version parameter.
+const (
+	DefaultRedisImage = "redis:7-alpine"
+	DefaultKafkaImage = "apache/kafka:3.9.0"
+)
+
+// Postgres returns a SidecarSpec for a PostgreSQL server of the given version
+// (e.g. "17") with the database dbName, reachable as PostgresAlias.
+// The user and password are PostgresUser and PostgresPassword.
+//
+// The test container receives POSTGRES_HOST, POSTGRES_PORT, POSTGRES_DB,
+// POSTGRES_USER, POSTGRES_PASSWORD and DATABASE_URL. Seed scripts added with
+// WithSeed("postgres", ...) are executed with psql.
+//
+// Example:
+//
+//	dockertesting.WithSidecar(sidecar.Postgres("17", "app")),
+//	dockertesting.WithSeed("postgres", "testdata/schema.sql", "testdata/rows.sql"),
+func Postgres(version, dbName string) dockertesting.SidecarSpec {
+	return dockertesting.SidecarSpec{
+		Name:    "postgres",
+		Image:   "postgres:" + version,
+		Aliases: []string{PostgresAlias},
+		Port:    PostgresPort,
+		Env: map[string]string{
+			"POSTGRES_DB":       dbName,
+			"POSTGRES_USER":     PostgresUser,
+			"POSTGRES_PASSWORD": PostgresPassword,
+		},
+		ConnectionEnv: map[string]string{
+			"POSTGRES_DB":       dbName,
+			"POSTGRES_USER":     PostgresUser,
+			"POSTGRES_PASSWORD": PostgresPassword,
+			"DATABASE_URL": fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=disable",
+				PostgresUser, PostgresPassword, PostgresAlias, PostgresPort, dbName),
+		},
+		SeedCommand: []string{"psql", "-v", "ON_ERROR_STOP=1", "-U", PostgresUser, "-d", dbName, "-f"},
+		// Check over TCP, as the temporary server used during initialization
+		// only listens on the unix socket
+		WaitFor: dockertesting.WaitForExec("pg_isready", "-h", "127.0.0.1", "-U", PostgresUser, "-d", dbName),
+	}
+}
+
+// Redis returns a SidecarSpec for a Redis server using DefaultRedisImage,
+// reachable as RedisAlias. The test container receives REDIS_HOST and REDIS_PORT.
+//
+// Example:
+//
+//	dockertesting.WithSidecar(sidecar.Redis())
+func Redis() dockertesting.SidecarSpec {
+	return dockertesting.SidecarSpec{
+		Name:    "redis",
+		Image:   DefaultRedisImage,
+		Aliases: []string{RedisAlias},
+		Port:    RedisPort,
+		WaitFor: dockertesting.WaitForLog("Ready to accept connections"),
+	}
+}
+
+// Kafka returns a SidecarSpec for a single-node Kafka broker in KRaft mode
+// using DefaultKafkaImage, reachable as KafkaAlias. The broker advertises
+// itself under the alias, so clients in the test container can connect to
+// "kafka.test:9092". The test container receives KAFKA_HOST, KAFKA_PORT and
+// KAFKA_BROKERS.
+//
+// Example:
+//
+//	dockertesting.WithSidecar(sidecar.Kafka())
+func Kafka() dockertesting.SidecarSpec {
+	port := strconv.Itoa(KafkaPort)
+	return dockertesting.SidecarSpec{
+		Name:    "kafka",
+		Image:   DefaultKafkaImage,
+		Aliases: []string{KafkaAlias},
+		Port:    KafkaPort,
+		ConnectionEnv: map[string]string{
+			"KAFKA_BROKERS": KafkaAlias + ":" + port,
+		},
+		Env: map[string]string{
+			"KAFKA_NODE_ID":                                  "1",
+			"KAFKA_PROCESS_ROLES":                            "broker,controller",
+			"KAFKA_LISTENERS":                                "PLAINTEXT://:" + port + ",CONTROLLER://:9093",
+			"KAFKA_ADVERTISED_LISTENERS":                     "PLAINTEXT://" + KafkaAlias + ":" + port,
+			"KAFKA_CONTROLLER_LISTENER_NAMES":                "CONTROLLER",
+			"KAFKA_LISTENER_SECURITY_PROTOCOL_MAP":           "CONTROLLER:PLAINTEXT,PLAINTEXT:PLAINTEXT",
+			"KAFKA_CONTROLLER_QUORUM_VOTERS":                 "1@localhost:9093",
+			"KAFKA_OFFSETS_TOPIC_REPLICATION_FACTOR":         "1",
+			"KAFKA_TRANSACTION_STATE_LOG_REPLICATION_FACTOR": "1",
+			"KAFKA_TRANSACTION_STATE_LOG_MIN_ISR":            "1",
+			"KAFKA_GROUP_INITIAL_REBALANCE_DELAY_MS":         "0",
+		},
+		WaitFor: dockertesting.WaitForLog("Kafka Server started"),
+	}
+}
@@ -0,0 +1,214 @@
+package dockertesting
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSidecarSpec_Name(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		spec     SidecarSpec
+		expected string
+	}{
+		{
+			name:     "explicit name",
+			spec:     SidecarSpec{Name: "db", Image: "postgres:17", Aliases: []string{"postgres.test"}},
+			expected: "db",
+		},
+		{
+			name:     "first alias",
+			spec:     SidecarSpec{Image: "postgres:17", Aliases: []string{"postgres.test", "db.test"}},
+			expected: "postgres.test",
+		},
+		{
+			name:     "image",
+			spec:     SidecarSpec{Image: "postgres:17"},
+			expected: "postgres:17",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.spec.name(); got != tt.expected {
+				t.Errorf("expected name %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestStartSidecar_EmptyImage(t *testing.T) {
+	t.Parallel()
+
+	_, err := StartSidecar(context.Background(), nil, SidecarSpec{Name: "db"}, nil)
+	if err == nil {
+		t.Fatal("expected error for empty image")
+	}
+	if !strings.Contains(err.Error(), "image must not be empty") {
+		t.Errorf("expected empty image error, got %v", err)
+	}
+}
+
+func TestTerminateSidecars_NilContainers(t *testing.T) {
+	t.Parallel()
+
+	sidecars := []*Sidecar{{Spec: SidecarSpec{Image: "redis:7"}}, {Spec: SidecarSpec{Image: "postgres:17"}}}
+	if err := terminateSidecars(context.Background(), sidecars, io.Discard, false); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestEnvVarPrefix(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "postgres", expected: "POSTGRES"},
+		{name: "postgres.test", expected: "POSTGRES_TEST"},
+		{name: "my-cache2", expected: "MY_CACHE2"},
+		{name: "redis:7", expected: "REDIS_7"},
+	}
+
+	for _, tt := range tests {
+		if got := envVarPrefix(tt.name); got != tt.expected {
+			t.Errorf("envVarPrefix(%q): expected %q, got %q", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestSidecarEnv(t *testing.T) {
+	t.Parallel()
+
+	env := sidecarEnv([]SidecarSpec{
+		{
+			Name:          "postgres",
+			Image:         "postgres:17",
+			Aliases:       []string{"postgres.test", "db.test"},
+			Port:          5432,
+			ConnectionEnv: map[string]string{"POSTGRES_USER": "postgres"},
+		},
+		{
+			Image: "nginx:alpine",
+		},
+		{
+			Image:   "redis:7",
+			Aliases: []string{"cache"},
+		},
+	})
+
+	expected := map[string]string{
+		"POSTGRES_HOST": "postgres.test",
+		"POSTGRES_PORT": "5432",
+		"POSTGRES_USER": "postgres",
+		"CACHE_HOST":    "cache",
+	}
+	if len(env) != len(expected) {
+		t.Errorf("expected %d env vars, got %d: %v", len(expected), len(env), env)
+	}
+	for key, value := range expected {
+		if env[key] != value {
+			t.Errorf("expected %s=%q, got %q", key, value, env[key])
+		}
+	}
+}
+
+func TestTerminateSidecars_TeardownCommandsOnNilContainer(t *testing.T) {
+	t.Parallel()
+
+	sidecars := []*Sidecar{{Spec: SidecarSpec{
+		Name:             "kafka",
+		Image:            "apache/kafka:3.9.0",
+		TeardownCommands: [][]string{{"kafka-topics.sh", "--list"}},
+	}}}
+	err := terminateSidecars(context.Background(), sidecars, io.Discard, true)
+	if err == nil {
+		t.Fatal("expected error for teardown command on nil container")
+	}
+	if !strings.Contains(err.Error(), `sidecar "kafka"`) {
+		t.Errorf("expected error to name the sidecar, got %v", err)
+	}
+}
+
+func TestOrderSidecars(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		specs    []SidecarSpec
+		expected []string
+		errMsg   string
+	}{
+		{
+			name: "declaration order without dependencies",
+			specs: []SidecarSpec{
+				{Name: "postgres", Image: "postgres:17"},
+				{Name: "redis", Image: "redis:7"},
+			},
+			expected: []string{"postgres", "redis"},
+		},
+		{
+			name: "dependencies first",
+			specs: []SidecarSpec{
+				{Name: "app", Image: "app", DependsOn: []string{"kafka", "postgres"}},
+				{Name: "kafka", Image: "kafka", DependsOn: []string{"zookeeper"}},
+				{Name: "postgres", Image: "postgres:17"},
+				{Name: "zookeeper", Image: "zookeeper"},
+			},
+			expected: []string{"zookeeper", "kafka", "postgres", "app"},
+		},
+		{
+			name: "unknown dependency",
+			specs: []SidecarSpec{
+				{Name: "app", Image: "app", DependsOn: []string{"db"}},
+			},
+			errMsg: `depends on unknown sidecar "db"`,
+		},
+		{
+			name: "cycle",
+			specs: []SidecarSpec{
+				{Name: "a", Image: "a", DependsOn: []string{"b"}},
+				{Name: "b", Image: "b", DependsOn: []string{"a"}},
+			},
+			errMsg: "dependency cycle",
+		},
+		{
+			name: "duplicate name",
+			specs: []SidecarSpec{
+				{Image: "redis:7", Aliases: []string{"cache"}},
+				{Image: "memcached", Aliases: []string{"cache"}},
+			},
+			errMsg: `duplicate sidecar name "cache"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ordered, err := orderSidecars(tt.specs)
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Fatalf("expected error containing %q, got %v", tt.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			names := make([]string, len(ordered))
+			for i, spec := range ordered {
+				names[i] = spec.name()
+			}
+			if strings.Join(names, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("expected order %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package dockertesting
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// signalWatcher cancels the operations of a run when the process receives
+// SIGINT or SIGTERM, so that its resources can be removed before the process
+// exits, see WithSignalCleanup.
+type signalWatcher struct {
+	ch       chan os.Signal
+	done     chan struct{}
+	stopOnce sync.Once
+
+	// ctx is canceled once a signal was received.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// watchSignals starts watching for SIGINT and SIGTERM. On the first signal,
+// the contexts bound to the watcher are canceled and onSignal is called in its
+// own goroutine.
+func watchSignals(onSignal func(os.Signal)) *signalWatcher {
+	w := &signalWatcher{
+		ch:   make(chan os.Signal, 1),
+		done: make(chan struct{}),
+	}
+	w.ctx, w.cancel = context.WithCancel(context.Background())
+	signal.Notify(w.ch, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case sig := <-w.ch:
+			w.cancel()
+			onSignal(sig)
+		case <-w.done:
+		}
+	}()
+	return w
+}
+
+// bind returns a copy of ctx that is also canceled when a signal is received.
+// A nil watcher returns ctx unchanged.
+func (w *signalWatcher) bind(ctx context.Context) (context.Context, context.CancelFunc) {
+	if w == nil {
+		return ctx, func() {}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(w.ctx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// stop stops watching for signals, restoring their previous handling. A
+// signal that was already received is still handled by onSignal.
+func (w *signalWatcher) stop() {
+	if w == nil {
+		return
+	}
+	w.stopOnce.Do(func() {
+		signal.Stop(w.ch)
+		close(w.done)
+	})
+}
+
+// reraise stops w and sends sig to the process again, so that it terminates
+// as it would have without the watcher, or is handled by other handlers of
+// the program. It exits with status 1 if the signal cannot be sent.
+func (w *signalWatcher) reraise(sig os.Signal) {
+	w.stop()
+	p, err := os.FindProcess(os.Getpid())
+	if err == nil {
+		err = p.Signal(sig)
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+}
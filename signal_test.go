@@ -0,0 +1,77 @@
+package dockertesting
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSignalWatcher_CancelsBoundContexts(t *testing.T) {
+	// Not parallel: signals are delivered to the whole process
+	if runtime.GOOS == "windows" {
+		t.Skip("sending signals to the own process is not supported on windows")
+	}
+
+	received := make(chan os.Signal, 1)
+	w := watchSignals(func(sig os.Signal) {
+		received <- sig
+	})
+	defer w.stop()
+
+	ctx, cancel := w.bind(context.Background())
+	defer cancel()
+
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := p.Signal(syscall.SIGTERM); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case sig := <-received:
+		if sig != syscall.SIGTERM {
+			t.Errorf("expected SIGTERM, got %v", sig)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the signal")
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Error("expected the bound context to be canceled")
+	}
+}
+
+func TestSignalWatcher_Stop(t *testing.T) {
+	t.Parallel()
+
+	w := watchSignals(func(os.Signal) {
+		t.Error("unexpected signal")
+	})
+	ctx, cancel := w.bind(context.Background())
+	defer cancel()
+
+	w.stop()
+	w.stop()
+	if ctx.Err() != nil {
+		t.Error("expected the bound context not to be canceled")
+	}
+}
+
+func TestSignalWatcher_Nil(t *testing.T) {
+	t.Parallel()
+
+	var w *signalWatcher
+	ctx := context.Background()
+	bound, cancel := w.bind(ctx)
+	defer cancel()
+	if bound != ctx {
+		t.Error("expected a nil watcher to return the context unchanged")
+	}
+	w.stop()
+}
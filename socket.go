@@ -0,0 +1,146 @@
+package dockertesting
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/docker/docker/api/types/mount"
+)
+
+// WindowsSockPath is the socket to mount into Linux containers on Docker
+// Desktop for Windows. The leading double slash keeps it from being
+// interpreted as a Windows path.
+const WindowsSockPath = "//var/run/docker.sock"
+
+// vmRuntimeSocketDirs are the directories, relative to the home directory,
+// where Docker runtimes that run the daemon inside a VM expose their socket:
+// Docker Desktop, Colima, OrbStack, Rancher Desktop and Lima. The daemon of
+// these runtimes sees its own socket at DefaultSockPath inside the VM.
+var vmRuntimeSocketDirs = []string{
+	".docker/run",
+	".docker/desktop",
+	".colima",
+	".orbstack",
+	".rd",
+	".lima",
+}
+
+// resolveSockPath returns the path of the Docker socket to mount into the test
+// container, as seen by the daemon. An explicitly configured path (anything
+// other than "" or DefaultSockPath) is used as-is, followed by the
+// TESTCONTAINERS_DOCKER_SOCKET_OVERRIDE env var, before falling back to
+// detection, see detectSockPath.
+func resolveSockPath(configured string, dockerHost DockerHostConfig) string {
+	if configured != "" && configured != DefaultSockPath {
+		return configured
+	}
+	if override := os.Getenv("TESTCONTAINERS_DOCKER_SOCKET_OVERRIDE"); override != "" {
+		return override
+	}
+	home, _ := os.UserHomeDir()
+	return detectSockPath(runtime.GOOS, dockerHost.Host, home, os.Getenv("XDG_RUNTIME_DIR"), fileExists)
+}
+
+// detectSockPath detects the Docker socket path to mount.
+//
+// Bind mount sources are resolved by the daemon, so for runtimes that run the
+// daemon inside a VM (all macOS runtimes, Docker Desktop on Linux) the socket
+// must be mounted from DefaultSockPath, even if the host talks to it through
+// e.g. ~/.colima/default/docker.sock. For a daemon running on the host itself,
+// the socket from DOCKER_HOST, DefaultSockPath or the rootless socket in
+// runtimeDir is used, in that order.
+func detectSockPath(goos, dockerHost, home, runtimeDir string, exists func(string) bool) string {
+	if hostSock, ok := strings.CutPrefix(dockerHost, "unix://"); ok && hostSock != "" {
+		if isVMRuntimeSocket(hostSock, home) {
+			return DefaultSockPath
+		}
+		return hostSock
+	}
+
+	switch goos {
+	case "windows":
+		return WindowsSockPath
+	case "darwin":
+		return DefaultSockPath
+	}
+
+	if exists(DefaultSockPath) {
+		return DefaultSockPath
+	}
+	if runtimeDir != "" {
+		if rootless := filepath.Join(runtimeDir, "docker.sock"); exists(rootless) {
+			return rootless
+		}
+	}
+	return DefaultSockPath
+}
+
+// sockMount returns the mount that exposes the Docker socket at sockPath inside
+// the container. Named pipes (npipe:// or \\.\pipe\...) are mounted as named
+// pipes at the same path for Windows containers; unix sockets, with or
+// without the unix:// scheme, are bind-mounted to /var/run/docker.sock.
+func sockMount(sockPath string) mount.Mount {
+	if pipe, ok := namedPipePath(sockPath); ok {
+		return mount.Mount{
+			Type:   mount.TypeNamedPipe,
+			Source: pipe,
+			Target: pipe,
+		}
+	}
+	return mount.Mount{
+		Type:   mount.TypeBind,
+		Source: strings.TrimPrefix(sockPath, "unix://"),
+		Target: DefaultSockPath,
+	}
+}
+
+// sockGroup returns the group owning the Docker socket at sockPath, which the
+// non-root TestUser joins to reach the daemon. Only sockets of a daemon running
+// on this Linux host can be inspected: named pipes and the sockets of runtimes
+// that run the daemon inside a VM, see detectSockPath, report false.
+func sockGroup(goos, sockPath string, dockerHost DockerHostConfig, home string) (int, bool) {
+	if goos != "linux" {
+		return 0, false
+	}
+	if hostSock, ok := strings.CutPrefix(dockerHost.Host, "unix://"); ok && isVMRuntimeSocket(hostSock, home) {
+		return 0, false
+	}
+	if _, ok := namedPipePath(sockPath); ok {
+		return 0, false
+	}
+	return fileGroup(strings.TrimPrefix(sockPath, "unix://"))
+}
+
+// namedPipePath converts a named pipe given as npipe:////./pipe/name or
+// \\.\pipe\name to the Windows form \\.\pipe\name.
+func namedPipePath(sockPath string) (string, bool) {
+	if rest, ok := strings.CutPrefix(sockPath, "npipe://"); ok {
+		return strings.ReplaceAll(rest, "/", `\`), true
+	}
+	if strings.HasPrefix(sockPath, `\\.\pipe\`) {
+		return sockPath, true
+	}
+	return "", false
+}
+
+// isVMRuntimeSocket reports whether sock belongs to a runtime that runs the
+// daemon inside a VM, see vmRuntimeSocketDirs.
+func isVMRuntimeSocket(sock, home string) bool {
+	if home == "" {
+		return false
+	}
+	for _, dir := range vmRuntimeSocketDirs {
+		if strings.HasPrefix(sock, filepath.Join(home, dir)+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// fileExists reports whether a file exists at path.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
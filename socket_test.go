@@ -0,0 +1,180 @@
+package dockertesting
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/docker/docker/api/types/mount"
+)
+
+func TestDetectSockPath(t *testing.T) {
+	t.Parallel()
+
+	const home = "/Users/dev"
+	tests := []struct {
+		name       string
+		goos       string
+		dockerHost string
+		runtimeDir string
+		existing   []string
+		expected   string
+	}{
+		{
+			name:       "windows named pipe",
+			goos:       "windows",
+			dockerHost: "npipe:////./pipe/docker_engine",
+			expected:   WindowsSockPath,
+		},
+		{
+			name:     "darwin default",
+			goos:     "darwin",
+			expected: DefaultSockPath,
+		},
+		{
+			name:       "colima socket mounts from the VM",
+			goos:       "darwin",
+			dockerHost: "unix:///Users/dev/.colima/default/docker.sock",
+			expected:   DefaultSockPath,
+		},
+		{
+			name:       "orbstack socket mounts from the VM",
+			goos:       "darwin",
+			dockerHost: "unix:///Users/dev/.orbstack/run/docker.sock",
+			expected:   DefaultSockPath,
+		},
+		{
+			name:       "linux docker desktop mounts from the VM",
+			goos:       "linux",
+			dockerHost: "unix:///Users/dev/.docker/desktop/docker.sock",
+			expected:   DefaultSockPath,
+		},
+		{
+			name:       "linux unix docker host",
+			goos:       "linux",
+			dockerHost: "unix:///run/user/1000/docker.sock",
+			expected:   "/run/user/1000/docker.sock",
+		},
+		{
+			name:     "linux default socket",
+			goos:     "linux",
+			existing: []string{DefaultSockPath, "/run/user/1000/docker.sock"},
+			expected: DefaultSockPath,
+		},
+		{
+			name:       "linux rootless",
+			goos:       "linux",
+			runtimeDir: "/run/user/1000",
+			existing:   []string{"/run/user/1000/docker.sock"},
+			expected:   "/run/user/1000/docker.sock",
+		},
+		{
+			name:       "linux nothing found",
+			goos:       "linux",
+			runtimeDir: "/run/user/1000",
+			expected:   DefaultSockPath,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			exists := func(path string) bool {
+				for _, e := range tt.existing {
+					if e == path {
+						return true
+					}
+				}
+				return false
+			}
+			if got := detectSockPath(tt.goos, tt.dockerHost, home, tt.runtimeDir, exists); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestResolveSockPath_Explicit(t *testing.T) {
+	t.Parallel()
+
+	if got := resolveSockPath("/custom/docker.sock", DockerHostConfig{}); got != "/custom/docker.sock" {
+		t.Errorf("expected explicit path '/custom/docker.sock', got %q", got)
+	}
+}
+
+func TestSockMount(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		sockPath string
+		expected mount.Mount
+	}{
+		{
+			name:     "unix socket",
+			sockPath: "/var/run/docker.sock",
+			expected: mount.Mount{Type: mount.TypeBind, Source: "/var/run/docker.sock", Target: "/var/run/docker.sock"},
+		},
+		{
+			name:     "unix scheme",
+			sockPath: "unix:///run/user/1000/docker.sock",
+			expected: mount.Mount{Type: mount.TypeBind, Source: "/run/user/1000/docker.sock", Target: "/var/run/docker.sock"},
+		},
+		{
+			name:     "docker desktop for windows",
+			sockPath: WindowsSockPath,
+			expected: mount.Mount{Type: mount.TypeBind, Source: "//var/run/docker.sock", Target: "/var/run/docker.sock"},
+		},
+		{
+			name:     "npipe scheme",
+			sockPath: "npipe:////./pipe/docker_engine",
+			expected: mount.Mount{Type: mount.TypeNamedPipe, Source: `\\.\pipe\docker_engine`, Target: `\\.\pipe\docker_engine`},
+		},
+		{
+			name:     "windows pipe path",
+			sockPath: `\\.\pipe\docker_engine`,
+			expected: mount.Mount{Type: mount.TypeNamedPipe, Source: `\\.\pipe\docker_engine`, Target: `\\.\pipe\docker_engine`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := sockMount(tt.sockPath)
+			if got.Type != tt.expected.Type || got.Source != tt.expected.Source || got.Target != tt.expected.Target {
+				t.Errorf("expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSockGroup(t *testing.T) {
+	t.Parallel()
+
+	sock := filepath.Join(t.TempDir(), "docker.sock")
+	if err := os.WriteFile(sock, nil, 0660); err != nil {
+		t.Fatalf("failed to write socket: %v", err)
+	}
+	if runtime.GOOS == "linux" {
+		gid, ok := sockGroup("linux", "unix://"+sock, DockerHostConfig{}, "/home/user")
+		if !ok || gid != os.Getegid() {
+			t.Errorf("expected group %d, got %d (%v)", os.Getegid(), gid, ok)
+		}
+	}
+
+	for name, tt := range map[string]struct {
+		goos       string
+		sockPath   string
+		dockerHost string
+	}{
+		"macos":        {goos: "darwin", sockPath: sock},
+		"named pipe":   {goos: "linux", sockPath: `\\.\pipe\docker_engine`},
+		"vm runtime":   {goos: "linux", sockPath: DefaultSockPath, dockerHost: "unix:///home/user/.docker/desktop/docker.sock"},
+		"missing file": {goos: "linux", sockPath: "/nonexistent/docker.sock"},
+	} {
+		if _, ok := sockGroup(tt.goos, tt.sockPath, DockerHostConfig{Host: tt.dockerHost}, "/home/user"); ok {
+			t.Errorf("%s: expected the group to be unknown", name)
+		}
+	}
+}
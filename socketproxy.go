@@ -0,0 +1,171 @@
+package dockertesting
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/testcontainers/testcontainers-go"
+	tclog "github.com/testcontainers/testcontainers-go/log"
+	"github.com/testcontainers/testcontainers-go/network"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const (
+	// SocketProxyImage is the image of the Docker socket proxy started by
+	// WithSocketProxy.
+	SocketProxyImage = "tecnativa/docker-socket-proxy:0.3.0"
+
+	// SocketProxyAlias is the DNS alias under which the test container
+	// reaches the Docker socket proxy of WithSocketProxy.
+	SocketProxyAlias = "docker.dockertesting.internal"
+
+	// SocketProxyPort is the port the Docker socket proxy listens on.
+	SocketProxyPort = 2375
+)
+
+// SocketProxySection is a section of the Docker API the proxy of
+// WithSocketProxy can grant write access to.
+type SocketProxySection string
+
+// Sections of the Docker API of the proxy of WithSocketProxy.
+const (
+	// SocketProxyContainers covers creating, starting, stopping and
+	// removing containers and creating exec sessions in them.
+	SocketProxyContainers SocketProxySection = "CONTAINERS"
+
+	// SocketProxyImages covers pulling and removing images.
+	SocketProxyImages SocketProxySection = "IMAGES"
+
+	// SocketProxyNetworks covers creating, connecting and removing networks.
+	SocketProxyNetworks SocketProxySection = "NETWORKS"
+
+	// SocketProxyVolumes covers creating and removing volumes.
+	SocketProxyVolumes SocketProxySection = "VOLUMES"
+
+	// SocketProxyExec covers starting and resizing exec sessions.
+	SocketProxyExec SocketProxySection = "EXEC"
+)
+
+// SocketProxyTestcontainers are the sections testcontainers needs write
+// access to for running containers from the tests, see WithSocketProxy.
+var SocketProxyTestcontainers = []SocketProxySection{
+	SocketProxyContainers,
+	SocketProxyImages,
+	SocketProxyNetworks,
+	SocketProxyVolumes,
+	SocketProxyExec,
+}
+
+// socketProxyReadSections are the sections the proxy forwards read-only when
+// no write access is granted.
+var socketProxyReadSections = []SocketProxySection{
+	SocketProxyContainers,
+	SocketProxyImages,
+	SocketProxyNetworks,
+	SocketProxyVolumes,
+}
+
+// socketProxyEndpoints returns the environment of the proxy that enables the
+// sections of the Docker API it forwards. Without write sections, the
+// containers, images, networks and volumes can be listed and inspected only.
+// The proxy allows writes per request method, not per section, so with write
+// sections only those are forwarded. The info and version endpoints are
+// always forwarded; everything else, e.g. swarm, secrets, plugins, builds and
+// the system endpoints, is denied.
+func socketProxyEndpoints(write []SocketProxySection) map[string]string {
+	env := map[string]string{"INFO": "1"}
+	sections := socketProxyReadSections
+	if len(write) > 0 {
+		env["POST"] = "1"
+		sections = write
+	}
+	for _, section := range sections {
+		env[string(section)] = "1"
+	}
+	return env
+}
+
+// socketProxyProblem describes why section cannot be granted write access by
+// WithSocketProxy, or returns an empty string.
+func socketProxyProblem(section SocketProxySection) string {
+	if !slices.Contains(SocketProxyTestcontainers, section) {
+		return fmt.Sprintf("WithSocketProxy section %q is unknown; use one of %v", section, SocketProxyTestcontainers)
+	}
+	return ""
+}
+
+// startSocketProxy starts the Docker socket proxy of WithSocketProxy on dn,
+// with the Docker socket at sockPath mounted and write access to the
+// sections write. It is returned as a Sidecar, so
+// it is torn down and diagnosed with the other sidecars.
+func startSocketProxy(ctx context.Context, dn *DockerNetwork, sockPath string, write []SocketProxySection, logger tclog.Logger) (*Sidecar, error) {
+	spec := SidecarSpec{
+		Name:    "docker-socket-proxy",
+		Image:   SocketProxyImage,
+		Aliases: []string{SocketProxyAlias},
+		Env:     socketProxyEndpoints(write),
+		// The port is not published, so readiness is checked from inside
+		WaitFor: wait.ForExec([]string{"wget", "-q", "-O", "/dev/null", "http://127.0.0.1:" + strconv.Itoa(SocketProxyPort) + "/_ping"}),
+	}
+	genReq := testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:      spec.Image,
+			Env:        spec.Env,
+			WaitingFor: spec.WaitFor,
+			Labels:     resourceLabels(),
+			HostConfigModifier: func(hc *container.HostConfig) {
+				hc.Mounts = append(hc.Mounts, sockMount(sockPath))
+			},
+		},
+		Started: true,
+		Logger:  logger,
+	}
+	withLabels(&genReq, dn.extraLabels)
+	if dn.Network() != nil {
+		if err := network.WithNetwork(spec.Aliases, dn.Network()).Customize(&genReq); err != nil {
+			return nil, fmt.Errorf("docker socket proxy: failed to apply network option: %w", err)
+		}
+	}
+
+	ctr, err := startContainer(ctx, dn.provider, genReq)
+	if err != nil {
+		// startContainer may return a container that failed to become ready
+		if ctr != nil {
+			_ = ctr.Terminate(context.WithoutCancel(ctx))
+		}
+		return nil, fmt.Errorf("docker socket proxy: failed to start: %w", err)
+	}
+	return &Sidecar{Spec: spec, ctr: ctr}, nil
+}
+
+// socketProxyEnv returns the environment that points testcontainers in the
+// test container at the Docker socket proxy. The daemon publishes the ports
+// of the containers the tests start on its host, reached through gateway, the
+// gateway of the network; an empty gateway leaves the host to testcontainers.
+// The reaper started by the tests mounts sockPath, the socket as seen by the
+// daemon.
+func socketProxyEnv(sockPath, gateway string) map[string]string {
+	env := map[string]string{
+		"DOCKER_HOST":                           "tcp://" + net.JoinHostPort(SocketProxyAlias, strconv.Itoa(SocketProxyPort)),
+		"TESTCONTAINERS_DOCKER_SOCKET_OVERRIDE": sockMount(sockPath).Source,
+	}
+	if gateway != "" {
+		env["TESTCONTAINERS_HOST_OVERRIDE"] = gateway
+	}
+	return env
+}
+
+// networkGateway returns the gateway of dn, best-effort: empty if it cannot
+// be inspected.
+func networkGateway(ctx context.Context, provider *testcontainers.DockerProvider, dn *DockerNetwork) string {
+	for _, config := range networkIPAM(ctx, provider, dn) {
+		if config.Gateway != "" {
+			return config.Gateway
+		}
+	}
+	return ""
+}
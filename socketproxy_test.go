@@ -0,0 +1,68 @@
+package dockertesting
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestSocketProxyEnv(t *testing.T) {
+	t.Parallel()
+
+	expected := map[string]string{
+		"DOCKER_HOST":                           "tcp://docker.dockertesting.internal:2375",
+		"TESTCONTAINERS_DOCKER_SOCKET_OVERRIDE": "/run/user/1000/docker.sock",
+		"TESTCONTAINERS_HOST_OVERRIDE":          "172.28.0.1",
+	}
+	if env := socketProxyEnv("unix:///run/user/1000/docker.sock", "172.28.0.1"); !maps.Equal(env, expected) {
+		t.Errorf("expected %v, got %v", expected, env)
+	}
+
+	if env := socketProxyEnv(DefaultSockPath, ""); env["TESTCONTAINERS_HOST_OVERRIDE"] != "" {
+		t.Errorf("expected no host override without a gateway, got %v", env)
+	}
+}
+
+func TestSocketProxyEndpoints(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		write    []SocketProxySection
+		expected map[string]string
+	}{
+		{
+			name:     "read-only by default",
+			expected: map[string]string{"INFO": "1", "CONTAINERS": "1", "IMAGES": "1", "NETWORKS": "1", "VOLUMES": "1"},
+		},
+		{
+			name:     "write to listed sections only",
+			write:    []SocketProxySection{SocketProxyVolumes},
+			expected: map[string]string{"INFO": "1", "POST": "1", "VOLUMES": "1"},
+		},
+		{
+			name:     "testcontainers",
+			write:    SocketProxyTestcontainers,
+			expected: map[string]string{"INFO": "1", "POST": "1", "CONTAINERS": "1", "IMAGES": "1", "NETWORKS": "1", "VOLUMES": "1", "EXEC": "1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if env := socketProxyEndpoints(tt.write); !maps.Equal(env, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, env)
+			}
+		})
+	}
+}
+
+func TestSocketProxyProblem(t *testing.T) {
+	t.Parallel()
+
+	if problem := socketProxyProblem(SocketProxyContainers); problem != "" {
+		t.Errorf("expected no problem, got %q", problem)
+	}
+	if problem := socketProxyProblem("BUILD"); !strings.Contains(problem, `"BUILD" is unknown`) {
+		t.Errorf("expected unknown section problem, got %q", problem)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package dockertesting
+
+// fileGroup returns the ID of the group owning the file at path. Groups are
+// not available on this platform.
+func fileGroup(string) (int, bool) {
+	return 0, false
+}
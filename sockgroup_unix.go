@@ -0,0 +1,21 @@
+//go:build unix
+
+package dockertesting
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileGroup returns the ID of the group owning the file at path.
+func fileGroup(path string) (int, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return int(stat.Gid), true
+}
@@ -0,0 +1,255 @@
+package dockertesting
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+)
+
+// EnvRunID is set in the test container to the LabelRunID of the run when
+// WithSockGuard is used, so that the tests can label the resources they
+// create through the Docker socket.
+const EnvRunID = "DOCKERTESTING_RUN_ID"
+
+// SockGuardMode selects how violations of a SockGuard are reported.
+type SockGuardMode int
+
+const (
+	// SockGuardWarn logs violations as warnings.
+	SockGuardWarn SockGuardMode = iota
+
+	// SockGuardFail logs violations and fails Run or Test with a
+	// *SockGuardError.
+	SockGuardFail
+)
+
+// SockGuard configures the guard rails of WithSockGuard on what the tests do
+// with the Docker socket of WithVarSock or WithSocketProxy.
+type SockGuard struct {
+	// TrustedRegistries are the registries, or repository prefixes, the
+	// tests may pull images from, e.g. "docker.io/library" or
+	// "registry.example.com". Names are normalized as by docker pull, so
+	// "postgres" is "docker.io/library/postgres". Empty allows all pulls.
+	TrustedRegistries []string
+
+	// RequireLabel requires the containers, networks and volumes created by
+	// the tests to carry LabelRunID, e.g. with the value of EnvRunID.
+	RequireLabel bool
+
+	// Cleanup removes the containers the tests leaked: those labelled with
+	// the run's LabelRunID and, unless the network is shared with
+	// WithNetwork, those still attached to the run's network.
+	Cleanup bool
+
+	// Mode selects how violations are reported (default: SockGuardWarn).
+	Mode SockGuardMode
+}
+
+// SockGuardError is returned by Run and Test when the tests violated the
+// guard rails of WithSockGuard in SockGuardFail mode.
+type SockGuardError struct {
+	// Violations describe what the tests did, in order.
+	Violations []string
+}
+
+func (e *SockGuardError) Error() string {
+	return fmt.Sprintf("docker socket guard: %d violation(s): %s", len(e.Violations), strings.Join(e.Violations, "; "))
+}
+
+// Labels of the reaper of testcontainers, which the tests start without
+// control over its labels.
+const (
+	reaperLabel = testcontainersLabelPrefix + ".reaper"
+	ryukLabel   = testcontainersLabelPrefix + ".ryuk"
+)
+
+// sockGuard watches the events of the daemon for the violations of a
+// SockGuard. Events are attributed to the run by time: resources created by
+// other clients of the daemon while the run is watched count too.
+type sockGuard struct {
+	cfg   SockGuard
+	runID string
+	cli   client.APIClient
+	log   *slog.Logger
+
+	// closeClient releases cli once the guard is done with it.
+	closeClient func()
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu         sync.Mutex
+	violations []string
+	// reported is the number of violations already returned by err.
+	reported int
+}
+
+// startSockGuard starts watching the events of the daemon of cli for the
+// violations of cfg, until close is called.
+func startSockGuard(ctx context.Context, cli client.APIClient, closeClient func(), cfg SockGuard, runID string, log *slog.Logger) *sockGuard {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	g := &sockGuard{
+		cfg:         cfg,
+		runID:       runID,
+		cli:         cli,
+		log:         log,
+		closeClient: closeClient,
+		cancel:      cancel,
+		done:        make(chan struct{}),
+	}
+	msgs, errs := cli.Events(ctx, events.ListOptions{
+		Since: strconv.FormatInt(time.Now().Unix(), 10),
+		Filters: filters.NewArgs(
+			filters.Arg("type", string(events.ContainerEventType)),
+			filters.Arg("type", string(events.NetworkEventType)),
+			filters.Arg("type", string(events.VolumeEventType)),
+			filters.Arg("type", string(events.ImageEventType)),
+			filters.Arg("event", string(events.ActionCreate)),
+			filters.Arg("event", string(events.ActionPull)),
+		),
+	})
+	go func() {
+		defer close(g.done)
+		for {
+			select {
+			case msg := <-msgs:
+				if violation := g.check(ctx, msg); violation != "" {
+					g.mu.Lock()
+					g.violations = append(g.violations, violation)
+					g.mu.Unlock()
+					log.Warn("docker socket guard violation", "violation", violation)
+				}
+			case err := <-errs:
+				if ctx.Err() == nil {
+					log.Warn("docker socket guard stopped watching", "error", err)
+				}
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return g
+}
+
+// check returns the violation of msg, or an empty string.
+func (g *sockGuard) check(ctx context.Context, msg events.Message) string {
+	switch {
+	case msg.Type == events.ImageEventType && msg.Action == events.ActionPull:
+		if len(g.cfg.TrustedRegistries) > 0 && !trustedImage(msg.Actor.ID, g.cfg.TrustedRegistries) {
+			return fmt.Sprintf("image %s pulled from an untrusted registry", msg.Actor.ID)
+		}
+	case g.cfg.RequireLabel && msg.Action == events.ActionCreate:
+		labels := msg.Actor.Attributes
+		name := msg.Actor.Attributes["name"]
+		switch msg.Type {
+		case events.NetworkEventType:
+			// Non-fatal: a network removed in the meantime has no labels
+			nw, _ := g.cli.NetworkInspect(ctx, msg.Actor.ID, network.InspectOptions{})
+			labels = nw.Labels
+		case events.VolumeEventType:
+			// Non-fatal: a volume removed in the meantime has no labels
+			vol, _ := g.cli.VolumeInspect(ctx, msg.Actor.ID)
+			labels, name = vol.Labels, msg.Actor.ID
+		}
+		if !guardExempt(labels) {
+			if name == "" {
+				name = msg.Actor.ID
+			}
+			return fmt.Sprintf("%s %s created without the label %s", msg.Type, name, LabelRunID)
+		}
+	}
+	return ""
+}
+
+// trustedImage reports whether the image ref, as reported by a pull event, is
+// in one of the registries or repository prefixes.
+func trustedImage(ref string, registries []string) bool {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return false
+	}
+	name := named.Name()
+	for _, registry := range registries {
+		registry = strings.TrimSuffix(registry, "/")
+		if name == registry || strings.HasPrefix(name, registry+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// guardExempt reports whether a resource with labels satisfies RequireLabel:
+// it carries LabelRunID, was created by dockertesting, or is a reaper of
+// testcontainers.
+func guardExempt(labels map[string]string) bool {
+	for _, label := range []string{LabelRunID, LabelManaged, reaperLabel, ryukLabel} {
+		if _, ok := labels[label]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// err returns the violations since the last call as a *SockGuardError in
+// SockGuardFail mode, otherwise nil. A nil guard has no violations.
+func (g *sockGuard) err() error {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	violations := g.violations[g.reported:]
+	g.reported = len(g.violations)
+	if len(violations) == 0 || g.cfg.Mode != SockGuardFail {
+		return nil
+	}
+	return &SockGuardError{Violations: violations}
+}
+
+// close stops watching the events and releases the client.
+func (g *sockGuard) close() {
+	g.cancel()
+	<-g.done
+	g.closeClient()
+}
+
+// cleanup removes the containers leaked by the tests: those labelled with the
+// run ID and, if networkName is not empty, those attached to it, except the
+// containers of dockertesting.
+func (g *sockGuard) cleanup(ctx context.Context, networkName string) error {
+	listFilters := []filters.Args{filters.NewArgs(filters.Arg("label", LabelRunID+"="+g.runID))}
+	if networkName != "" {
+		listFilters = append(listFilters, filters.NewArgs(filters.Arg("network", networkName)))
+	}
+	leaked := make(map[string]string)
+	for _, f := range listFilters {
+		containers, err := g.cli.ContainerList(ctx, container.ListOptions{All: true, Filters: f})
+		if err != nil {
+			return fmt.Errorf("failed to list leaked containers: %w", err)
+		}
+		for _, ctr := range containers {
+			if ctr.Labels[LabelManaged] != "true" {
+				leaked[ctr.ID] = strings.TrimPrefix(strings.Join(ctr.Names, ","), "/")
+			}
+		}
+	}
+	for id, name := range leaked {
+		if err := g.cli.ContainerRemove(ctx, id, container.RemoveOptions{Force: true, RemoveVolumes: true}); err != nil {
+			return fmt.Errorf("failed to remove leaked container %s: %w", name, err)
+		}
+		g.log.Info("leaked container removed", "container", id, "name", name)
+	}
+	return nil
+}
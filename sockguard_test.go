@@ -0,0 +1,110 @@
+package dockertesting
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/events"
+)
+
+func TestTrustedImage(t *testing.T) {
+	t.Parallel()
+	registries := []string{"docker.io/library", "registry.example.com/", "ghcr.io/myorg"}
+	tests := []struct {
+		ref     string
+		trusted bool
+	}{
+		{"postgres:16", true},
+		{"docker.io/library/redis@sha256:0000000000000000000000000000000000000000000000000000000000000000", true},
+		{"registry.example.com/team/app:1.0", true},
+		{"ghcr.io/myorg/tool", true},
+		{"ghcr.io/myorganization/tool", false},
+		{"bitnami/redis", false},
+		{"registry.example.com.evil.io/app", false},
+		{"Not A Reference", false},
+	}
+	for _, tt := range tests {
+		if trusted := trustedImage(tt.ref, registries); trusted != tt.trusted {
+			t.Errorf("%s: expected trusted %v, got %v", tt.ref, tt.trusted, trusted)
+		}
+	}
+}
+
+func TestGuardExempt(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		labels map[string]string
+		exempt bool
+	}{
+		{"run id", map[string]string{LabelRunID: "abc"}, true},
+		{"dockertesting", map[string]string{LabelManaged: "true"}, true},
+		{"reaper", map[string]string{reaperLabel: "true"}, true},
+		{"testcontainers", map[string]string{testcontainersLabelPrefix: "true"}, false},
+		{"unlabelled", nil, false},
+	}
+	for _, tt := range tests {
+		if exempt := guardExempt(tt.labels); exempt != tt.exempt {
+			t.Errorf("%s: expected exempt %v, got %v", tt.name, tt.exempt, exempt)
+		}
+	}
+}
+
+func TestSockGuard_Check(t *testing.T) {
+	t.Parallel()
+	g := &sockGuard{cfg: SockGuard{TrustedRegistries: []string{"docker.io/library"}, RequireLabel: true}}
+	tests := []struct {
+		name      string
+		msg       events.Message
+		violation string
+	}{
+		{"trusted pull", events.Message{Type: events.ImageEventType, Action: events.ActionPull, Actor: events.Actor{ID: "postgres:16"}}, ""},
+		{"untrusted pull", events.Message{Type: events.ImageEventType, Action: events.ActionPull, Actor: events.Actor{ID: "evil.io/miner:latest"}}, "image evil.io/miner:latest pulled from an untrusted registry"},
+		{"labelled container", events.Message{Type: events.ContainerEventType, Action: events.ActionCreate, Actor: events.Actor{
+			ID:         "c1",
+			Attributes: map[string]string{"name": "db", LabelRunID: "abc"},
+		}}, ""},
+		{"unlabelled container", events.Message{Type: events.ContainerEventType, Action: events.ActionCreate, Actor: events.Actor{
+			ID:         "c2",
+			Attributes: map[string]string{"name": "db", "image": "postgres:16"},
+		}}, "container db created without the label " + LabelRunID},
+	}
+	for _, tt := range tests {
+		violation := g.check(context.Background(), tt.msg)
+		if violation != tt.violation {
+			t.Errorf("%s: expected violation %q, got %q", tt.name, tt.violation, violation)
+		}
+	}
+}
+
+func TestSockGuard_Err(t *testing.T) {
+	t.Parallel()
+	var none *sockGuard
+	if err := none.err(); err != nil {
+		t.Errorf("expected no error without a guard, got %v", err)
+	}
+
+	warn := &sockGuard{violations: []string{"image a pulled from an untrusted registry"}}
+	if err := warn.err(); err != nil {
+		t.Errorf("expected no error in warn mode, got %v", err)
+	}
+
+	fail := &sockGuard{cfg: SockGuard{Mode: SockGuardFail}, violations: []string{"image a pulled from an untrusted registry"}}
+	var guardErr *SockGuardError
+	if err := fail.err(); !errors.As(err, &guardErr) || len(guardErr.Violations) != 1 {
+		t.Fatalf("expected a SockGuardError with 1 violation, got %v", err)
+	}
+	if err := fail.err(); err != nil {
+		t.Errorf("expected reported violations not to be returned again, got %v", err)
+	}
+}
+
+func TestSockGuardError(t *testing.T) {
+	t.Parallel()
+	err := &SockGuardError{Violations: []string{"image a pulled from an untrusted registry", "container b created without the label " + LabelRunID}}
+	if msg := err.Error(); !strings.Contains(msg, "2 violation(s)") || !strings.Contains(msg, "image a pulled") {
+		t.Errorf("unexpected message %q", msg)
+	}
+}
@@ -0,0 +1,85 @@
+package dockertesting
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// tarSpillThreshold is the size above which the build context archive is
+// written to a temporary file instead of being kept in memory.
+const tarSpillThreshold = 32 << 20
+
+// spillWriter keeps written data in memory up to threshold bytes and moves it
+// to a temporary file once it grows larger, so that large build contexts do not
+// have to fit in memory while still being seekable for the docker build.
+type spillWriter struct {
+	threshold int
+	buf       bytes.Buffer
+	file      *os.File
+}
+
+// Write implements io.Writer.
+func (w *spillWriter) Write(p []byte) (int, error) {
+	if w.file == nil && w.buf.Len()+len(p) > w.threshold {
+		file, err := os.CreateTemp("", "dockertesting-context-*.tar")
+		if err != nil {
+			return 0, fmt.Errorf("failed to create temporary build context file: %w", err)
+		}
+		w.file = file
+		if _, err := w.file.Write(w.buf.Bytes()); err != nil {
+			return 0, fmt.Errorf("failed to write temporary build context file: %w", err)
+		}
+		w.buf = bytes.Buffer{}
+	}
+	if w.file != nil {
+		return w.file.Write(p)
+	}
+	return w.buf.Write(p)
+}
+
+// reader returns the written data. Closing it removes the temporary file, if
+// one was created.
+func (w *spillWriter) reader() (io.ReadSeekCloser, error) {
+	if w.file == nil {
+		return nopReadSeekCloser{bytes.NewReader(w.buf.Bytes())}, nil
+	}
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		w.discard()
+		return nil, fmt.Errorf("failed to rewind temporary build context file: %w", err)
+	}
+	return &tempFile{w.file}, nil
+}
+
+// discard releases the written data, removing the temporary file if any.
+func (w *spillWriter) discard() {
+	if w.file != nil {
+		_ = (&tempFile{w.file}).Close()
+	}
+	w.buf = bytes.Buffer{}
+}
+
+// nopReadSeekCloser adds a no-op Close to an io.ReadSeeker.
+type nopReadSeekCloser struct {
+	io.ReadSeeker
+}
+
+// Close implements io.Closer.
+func (nopReadSeekCloser) Close() error {
+	return nil
+}
+
+// tempFile is a temporary file that is removed when it is closed.
+type tempFile struct {
+	*os.File
+}
+
+// Close closes and removes the file.
+func (f *tempFile) Close() error {
+	closeErr := f.File.Close()
+	if err := os.Remove(f.Name()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", f.Name(), err)
+	}
+	return closeErr
+}
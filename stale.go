@@ -0,0 +1,122 @@
+package dockertesting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+)
+
+// CleanupStale removes the containers and networks created by dockertesting
+// more than olderThan ago, or at any time if olderThan is 0, whose creating
+// process is no longer running, and returns the names of the removed
+// resources. Anonymous volumes of the removed containers are removed with
+// them. Such orphans are left behind by interrupted runs, e.g. cancelled CI
+// jobs, and by WithKeepResources.
+//
+// Only resources created on the current host are considered, as the
+// liveness of processes on other hosts sharing the daemon cannot be checked.
+// Failures to remove a resource are reported in the returned error, after the
+// remaining resources are removed.
+//
+// Example:
+//
+//	// Remove orphans of runs that ended more than an hour ago
+//	removed, err := dockertesting.CleanupStale(ctx, time.Hour)
+func CleanupStale(ctx context.Context, olderThan time.Duration) ([]string, error) {
+	cli, closeClient, err := dockerClient(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer closeClient()
+
+	managed := filters.NewArgs(filters.Arg("label", LabelManaged+"=true"))
+	cutoff := time.Now().Add(-olderThan)
+
+	containers, err := cli.ContainerList(ctx, container.ListOptions{All: true, Filters: managed})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	var removed []string
+	var errs []error
+	for _, ctr := range containers {
+		if !isStale(ctr.Labels, time.Unix(ctr.Created, 0), olderThan, cutoff) {
+			continue
+		}
+		name := ctr.ID
+		if len(ctr.Names) > 0 {
+			name = strings.TrimPrefix(ctr.Names[0], "/")
+		}
+		if err := cli.ContainerRemove(ctx, ctr.ID, container.RemoveOptions{Force: true, RemoveVolumes: true}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove container %s: %w", name, err))
+			continue
+		}
+		removed = append(removed, name)
+	}
+
+	// Networks go last, as they cannot be removed while containers use them
+	networks, err := cli.NetworkList(ctx, network.ListOptions{Filters: managed})
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to list networks: %w", err))
+		return removed, errors.Join(errs...)
+	}
+	for _, nw := range networks {
+		if !isStale(nw.Labels, nw.Created, olderThan, cutoff) {
+			continue
+		}
+		if err := cli.NetworkRemove(ctx, nw.ID); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove network %s: %w", nw.Name, err))
+			continue
+		}
+		removed = append(removed, nw.Name)
+	}
+
+	return removed, errors.Join(errs...)
+}
+
+// isStale reports whether a resource with the given labels, created at
+// created, was created before cutoff (unless olderThan is 0) by a process on
+// this host that is no longer running.
+func isStale(labels map[string]string, created time.Time, olderThan time.Duration, cutoff time.Time) bool {
+	if olderThan > 0 && created.After(cutoff) {
+		return false
+	}
+	own := processLabels()
+	if labels[LabelHost] == "" || labels[LabelHost] != own[LabelHost] {
+		return false
+	}
+	pid, err := strconv.Atoi(labels[LabelPID])
+	if err != nil {
+		return false
+	}
+	return !processRunning(pid)
+}
+
+// processRunning reports whether a process with the given ID is running.
+func processRunning(pid int) bool {
+	if pid == os.Getpid() {
+		return true
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		// FindProcess only fails for processes that do not exist (Windows)
+		return false
+	}
+	defer p.Release()
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	// Signal 0 only checks whether the process exists
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,97 @@
+package dockertesting
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestResourceLabels(t *testing.T) {
+	t.Parallel()
+
+	labels := resourceLabels()
+	if labels[LabelManaged] != "true" {
+		t.Errorf("expected %s=true, got %q", LabelManaged, labels[LabelManaged])
+	}
+	if labels[LabelPID] != strconv.Itoa(os.Getpid()) {
+		t.Errorf("expected %s=%d, got %q", LabelPID, os.Getpid(), labels[LabelPID])
+	}
+
+	// Callers may modify the returned labels
+	labels[LabelManaged] = "false"
+	if resourceLabels()[LabelManaged] != "true" {
+		t.Error("expected modifications not to affect later calls")
+	}
+}
+
+func TestIsStale(t *testing.T) {
+	t.Parallel()
+
+	// A process that has exited
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("failed to run helper process: %v", err)
+	}
+	deadPID := strconv.Itoa(cmd.Process.Pid)
+
+	host := processLabels()[LabelHost]
+	old := time.Now().Add(-2 * time.Hour)
+	cutoff := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name      string
+		labels    map[string]string
+		created   time.Time
+		olderThan time.Duration
+		expected  bool
+	}{
+		{
+			name:      "exited process",
+			labels:    map[string]string{LabelHost: host, LabelPID: deadPID},
+			created:   old,
+			olderThan: time.Hour,
+			expected:  true,
+		},
+		{
+			name:     "exited process without age limit",
+			labels:   map[string]string{LabelHost: host, LabelPID: deadPID},
+			created:  time.Now(),
+			expected: true,
+		},
+		{
+			name:      "too recent",
+			labels:    map[string]string{LabelHost: host, LabelPID: deadPID},
+			created:   time.Now(),
+			olderThan: time.Hour,
+		},
+		{
+			name:      "running process",
+			labels:    map[string]string{LabelHost: host, LabelPID: strconv.Itoa(os.Getpid())},
+			created:   old,
+			olderThan: time.Hour,
+		},
+		{
+			name:      "other host",
+			labels:    map[string]string{LabelHost: host + "-other", LabelPID: deadPID},
+			created:   old,
+			olderThan: time.Hour,
+		},
+		{
+			name:      "missing pid",
+			labels:    map[string]string{LabelHost: host},
+			created:   old,
+			olderThan: time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := isStale(tt.labels, tt.created, tt.olderThan, cutoff); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
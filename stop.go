@@ -0,0 +1,83 @@
+package dockertesting
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// stopPollInterval is how often the processes of the container are checked
+// while waiting for them to exit after SIGTERM.
+const stopPollInterval = 100 * time.Millisecond
+
+// stopExecMargin bounds how long the exec of stopProcessesScript may take
+// beyond the stop timeout, e.g. when the daemon is slow to start it.
+const stopExecMargin = 5 * time.Second
+
+// stopProcessesScript sends SIGTERM to the processes started with exec, such
+// as go test, and their descendants, then waits for up to $0 polls for them
+// to exit. Exec'd processes have no parent inside the container, unlike the
+// entrypoint that keeps the container alive: it is PID 1, or the child of the
+// init process of WithInit, whose exit would end the container before the
+// tests had their grace period. Zombies do not count as running.
+const stopProcessesScript = `
+ppid() {
+	s=$(cat "/proc/$1/stat" 2>/dev/null) || return
+	set -- ${s##*) }
+	echo "$2"
+}
+alive() {
+	s=$(cat "/proc/$1/stat" 2>/dev/null) || return 1
+	set -- ${s##*) }
+	[ "$1" != Z ]
+}
+tree=" "
+for p in /proc/[0-9]*; do
+	pid=${p#/proc/}
+	if [ "$pid" != 1 ] && [ "$pid" != $$ ] && [ "$(ppid "$pid")" = 0 ]; then
+		tree="$tree$pid "
+	fi
+done
+grown=1
+while [ -n "$grown" ]; do
+	grown=
+	for p in /proc/[0-9]*; do
+		pid=${p#/proc/}
+		case "$tree" in *" $pid "*) continue ;; esac
+		case "$tree" in *" $(ppid "$pid") "*) tree="$tree$pid "; grown=1 ;; esac
+	done
+done
+[ "$tree" = " " ] && exit 0
+kill -TERM $tree 2>/dev/null
+i=0
+while [ $i -lt "$0" ]; do
+	running=
+	for pid in $tree; do
+		if alive "$pid"; then
+			running=1
+			break
+		fi
+	done
+	[ -z "$running" ] && exit 0
+	sleep 0.1
+	i=$((i+1))
+done
+exit 1
+`
+
+// stopProcesses sends SIGTERM to the processes started in the container, such
+// as go test and the test binaries, and waits up to timeout for them to exit,
+// so that their own cleanup runs before the container is killed. It reports
+// whether all processes exited in time.
+func (c *TestContainer) stopProcesses(ctx context.Context, timeout time.Duration) bool {
+	if c.ctr == nil {
+		return false
+	}
+	polls := int(timeout / stopPollInterval)
+
+	stopCtx, cancel := context.WithTimeout(ctx, timeout+stopExecMargin)
+	defer cancel()
+
+	result, err := c.ExecCommand(stopCtx, []string{"sh", "-c", stopProcessesScript, strconv.Itoa(polls)}, ExecOptions{})
+	return err == nil && result.ExitCode == 0
+}
@@ -0,0 +1,24 @@
+package dockertesting
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStopProcesses_NilContainer(t *testing.T) {
+	t.Parallel()
+	container := &TestContainer{ctr: nil}
+
+	if container.stopProcesses(t.Context(), time.Second) {
+		t.Error("expected processes of a nil container not to be reported as stopped")
+	}
+}
+
+func TestTerminate_NilContainerWithStopTimeout(t *testing.T) {
+	t.Parallel()
+	container := &TestContainer{ctr: nil, stopTimeout: time.Second}
+
+	if err := container.Terminate(t.Context()); err != nil {
+		t.Errorf("expected nil error for nil container, got %v", err)
+	}
+}
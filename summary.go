@@ -0,0 +1,177 @@
+package dockertesting
+
+import (
+	"encoding/json"
+	"io"
+	"maps"
+	"slices"
+)
+
+// SummaryVersion is the version of the Summary format. It is incremented
+// when fields are renamed or removed; new fields may be added at any time.
+const SummaryVersion = 1
+
+// Summary is the machine-readable summary of a Result written by
+// Result.WriteJSON, for pipeline steps downstream of a run.
+type Summary struct {
+	// Version is the SummaryVersion of the document.
+	Version int `json:"version"`
+
+	// Name is the name of the run, see WithName.
+	Name string `json:"name,omitempty"`
+
+	// ExitCode is the exit code of go test.
+	ExitCode int `json:"exit_code"`
+
+	// Passed reports whether go test exited with code 0.
+	Passed bool `json:"passed"`
+
+	// Timings are the durations of the run.
+	Timings SummaryTimings `json:"timings"`
+
+	// CoveragePercent is the percentage of statements covered.
+	CoveragePercent float64 `json:"coverage_percent"`
+
+	// Tests counts the tests by outcome. It is nil unless the tests ran
+	// with -json, see WithArgs.
+	Tests *TestCounts `json:"tests,omitempty"`
+
+	// Outcomes are the final outcomes of the tests, sorted by package and
+	// test, which CompareSummaries compares. It is nil unless the tests ran
+	// with -json.
+	Outcomes []TestOutcome `json:"outcomes,omitempty"`
+
+	// FailFastTest is the test that triggered -failfast, see WithFailFast.
+	FailFastTest string `json:"fail_fast_test,omitempty"`
+
+	// Failures are the failed tests, see Result.Failures.
+	Failures []TestFailure `json:"failures,omitempty"`
+
+	// Skipped are the skipped tests, see Result.Skipped.
+	Skipped []SkippedTest `json:"skipped,omitempty"`
+
+	// Races are the data races, see Result.Races.
+	Races []RaceReport `json:"races,omitempty"`
+
+	// Crashes are the crashed test binaries, see Result.Crashes.
+	Crashes []Crash `json:"crashes,omitempty"`
+
+	// Artifacts are the sorted paths of the collected artifacts inside the
+	// container, which WithArtifactsDir mirrors on the host.
+	Artifacts []string `json:"artifacts,omitempty"`
+
+	// Resources identifies the Docker resources of the run.
+	Resources SummaryResources `json:"resources"`
+
+	// OOMKilled reports whether the OOM killer fired in the container.
+	OOMKilled bool `json:"oom_killed,omitempty"`
+}
+
+// SummaryTimings are the durations of a run in seconds.
+type SummaryTimings struct {
+	// Startup is the time until the tests started, see Result.StartupDuration.
+	Startup float64 `json:"startup_seconds"`
+
+	// Tests is the time go test ran, see Result.Duration.
+	Tests float64 `json:"tests_seconds"`
+}
+
+// TestCounts counts tests, including subtests, by outcome.
+type TestCounts struct {
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+}
+
+// SummaryResources identifies the Docker resources of a run.
+type SummaryResources struct {
+	// ContainerID is the ID of the test container.
+	ContainerID string `json:"container_id,omitempty"`
+
+	// NetworkName is the name of the network of the run.
+	NetworkName string `json:"network,omitempty"`
+
+	// Kept reports whether the resources were left running, see
+	// WithKeepOnFailure.
+	Kept bool `json:"kept,omitempty"`
+
+	// SidecarIDs are the IDs of the kept sidecar containers.
+	SidecarIDs []string `json:"sidecar_ids,omitempty"`
+}
+
+// Summary returns the machine-readable summary of the result.
+func (r *Result) Summary() Summary {
+	s := Summary{
+		Version:  SummaryVersion,
+		Name:     r.Name,
+		ExitCode: r.ExitCode,
+		Passed:   r.ExitCode == 0,
+		Timings: SummaryTimings{
+			Startup: r.StartupDuration.Seconds(),
+			Tests:   r.Duration.Seconds(),
+		},
+		CoveragePercent: r.CoveragePercent,
+		FailFastTest:    r.FailFastTest,
+		Failures:        r.Failures,
+		Skipped:         r.Skipped,
+		Races:           r.Races,
+		Crashes:         r.Crashes,
+		Resources: SummaryResources{
+			ContainerID: r.ContainerID,
+			NetworkName: r.NetworkName,
+		},
+		OOMKilled: r.OOMKilled,
+	}
+	if events := parseTestEvents(r.Stdout); len(events) > 0 {
+		counts := countTests(events)
+		s.Tests = &counts
+		s.Outcomes = testOutcomes(events)
+	}
+	if len(r.Artifacts) > 0 {
+		s.Artifacts = slices.Sorted(maps.Keys(r.Artifacts))
+	}
+	if r.Diagnostics != nil {
+		s.Resources.Kept = true
+		s.Resources.SidecarIDs = r.Diagnostics.SidecarIDs
+	}
+	return s
+}
+
+// WriteJSON writes the Summary of the result to w as indented JSON.
+//
+// Example:
+//
+//	f, _ := os.Create("summary.json")
+//	defer f.Close()
+//	err := result.WriteJSON(f)
+func (r *Result) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r.Summary())
+}
+
+// countTests counts the tests of events by their final outcome.
+func countTests(events []TestEvent) TestCounts {
+	type test struct{ pkg, name string }
+	outcomes := make(map[test]string)
+	for _, event := range events {
+		switch event.Action {
+		case "pass", "fail", "skip":
+			if event.Test != "" {
+				outcomes[test{event.Package, event.Test}] = event.Action
+			}
+		}
+	}
+	var counts TestCounts
+	for _, outcome := range outcomes {
+		switch outcome {
+		case "pass":
+			counts.Passed++
+		case "fail":
+			counts.Failed++
+		case "skip":
+			counts.Skipped++
+		}
+	}
+	return counts
+}
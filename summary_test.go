@@ -0,0 +1,77 @@
+package dockertesting
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestResult_WriteJSON(t *testing.T) {
+	t.Parallel()
+	result := &Result{
+		Name:            "payments",
+		Stdout:          []byte(sampleJSONOutput),
+		CoveragePercent: 75.5,
+		ContainerID:     "abc",
+		NetworkName:     "net",
+		ExitCode:        1,
+		Duration:        1500 * time.Millisecond,
+		StartupDuration: 30 * time.Second,
+		Failures:        []TestFailure{{Package: "example.com/mod", Test: "TestAdd", Message: "add_test.go:5: expected 3", File: "add_test.go", Line: 5}},
+		Skipped:         []SkippedTest{{Package: "example.com/mod", Test: "TestDocker", Reason: "no Docker socket"}},
+		Artifacts:       map[string][]byte{"/src/b.log": nil, "/src/a.log": nil},
+		Diagnostics:     &DiagnosticBundle{SidecarIDs: []string{"s1"}},
+	}
+
+	var buf bytes.Buffer
+	if err := result.WriteJSON(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var summary Summary
+	if err := json.Unmarshal(buf.Bytes(), &summary); err != nil {
+		t.Fatalf("failed to parse summary: %v\n%s", err, buf.Bytes())
+	}
+	if summary.Version != SummaryVersion || summary.Name != "payments" || summary.Passed || summary.ExitCode != 1 {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	if summary.Timings.Tests != 1.5 || summary.Timings.Startup != 30 {
+		t.Errorf("unexpected timings %+v", summary.Timings)
+	}
+	if summary.Tests == nil || *summary.Tests != (TestCounts{Passed: 1, Failed: 2}) {
+		t.Errorf("expected 1 passed and 2 failed tests, got %+v", summary.Tests)
+	}
+	if !slices.Equal(summary.Failures, result.Failures) {
+		t.Errorf("expected failures %+v, got %+v", result.Failures, summary.Failures)
+	}
+	if !slices.Equal(summary.Skipped, result.Skipped) {
+		t.Errorf("expected skipped tests %+v, got %+v", result.Skipped, summary.Skipped)
+	}
+	if !slices.Equal(summary.Artifacts, []string{"/src/a.log", "/src/b.log"}) {
+		t.Errorf("expected sorted artifacts, got %v", summary.Artifacts)
+	}
+	want := SummaryResources{ContainerID: "abc", NetworkName: "net", Kept: true, SidecarIDs: []string{"s1"}}
+	if got := summary.Resources; got.ContainerID != want.ContainerID || got.NetworkName != want.NetworkName || !got.Kept || !slices.Equal(got.SidecarIDs, want.SidecarIDs) {
+		t.Errorf("expected resources %+v, got %+v", want, got)
+	}
+
+	// The field names are part of the format
+	for _, key := range []string{`"exit_code"`, `"coverage_percent"`, `"tests_seconds"`, `"container_id"`} {
+		if !bytes.Contains(buf.Bytes(), []byte(key)) {
+			t.Errorf("expected %s in summary:\n%s", key, buf.Bytes())
+		}
+	}
+}
+
+func TestResult_SummaryWithoutJSONOutput(t *testing.T) {
+	t.Parallel()
+	summary := (&Result{Stdout: []byte("ok  \texample.com/pkg\t0.1s\n")}).Summary()
+	if summary.Tests != nil {
+		t.Errorf("expected no test counts without -json, got %+v", summary.Tests)
+	}
+	if !summary.Passed {
+		t.Error("expected the run to pass")
+	}
+}
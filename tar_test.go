@@ -311,3 +311,177 @@ func getFileNames(files map[string]string) []string {
 	}
 	return names
 }
+
+func TestDockerfileTemplates_LazyModDownload(t *testing.T) {
+	t.Parallel()
+
+	for name, template := range map[string]string{"classic": dockerfileTemplate, "buildkit": buildKitDockerfileTemplate} {
+		if !strings.Contains(template, "ARG LAZY_MOD_DOWNLOAD") {
+			t.Errorf("expected %s template to declare the LAZY_MOD_DOWNLOAD build arg", name)
+		}
+		if !strings.Contains(template, `if [ -z "$LAZY_MOD_DOWNLOAD" ]`) {
+			t.Errorf("expected %s template to skip go mod download when LAZY_MOD_DOWNLOAD is set", name)
+		}
+	}
+}
+
+func TestDockerfileTemplates_GitCredentials(t *testing.T) {
+	t.Parallel()
+
+	for name, template := range map[string]string{"classic": dockerfileTemplate, "buildkit": buildKitDockerfileTemplate} {
+		if !strings.Contains(template, "ARG "+goPrivateEnv+"\n") {
+			t.Errorf("expected %s template to declare the %s build arg", name, goPrivateEnv)
+		}
+	}
+	if !strings.Contains(buildKitDockerfileTemplate, "--mount=type=secret,id="+gitConfigSecretID) {
+		t.Errorf("expected buildkit template to mount the git configuration as secret")
+	}
+}
+
+func TestCreateTarContext_SpillsToTempFile(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module test\n\ngo 1.25.6\n"), 0644); err != nil {
+		t.Fatalf("failed to write go.mod: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "data.bin"), make([]byte, 64<<10), 0644); err != nil {
+		t.Fatalf("failed to write data.bin: %v", err)
+	}
+
+	reader, err := createTarContext(tmpDir, "", tarContextOptions{template: dockerfileTemplate, spillThreshold: 1024})
+	if err != nil {
+		t.Fatalf("createTarContext failed: %v", err)
+	}
+
+	spilled, ok := reader.(*tempFile)
+	if !ok {
+		t.Fatalf("expected the archive to be spilled to a temporary file, got %T", reader)
+	}
+
+	files := readTarContents(t, reader)
+	if len(files["data.bin"]) != 64<<10 {
+		t.Errorf("expected data.bin of %d bytes, got %d", 64<<10, len(files["data.bin"]))
+	}
+	if _, ok := files["Dockerfile"]; !ok {
+		t.Error("Dockerfile not found in tar")
+	}
+
+	if err := reader.Close(); err != nil {
+		t.Fatalf("failed to close archive: %v", err)
+	}
+	if _, err := os.Stat(spilled.Name()); !os.IsNotExist(err) {
+		t.Errorf("expected temporary file %s to be removed, got %v", spilled.Name(), err)
+	}
+}
+
+func TestCreateTarContext_StaysInMemoryBelowThreshold(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module test\n\ngo 1.25.6\n"), 0644); err != nil {
+		t.Fatalf("failed to write go.mod: %v", err)
+	}
+
+	reader, err := CreateTarContext(tmpDir, "")
+	if err != nil {
+		t.Fatalf("CreateTarContext failed: %v", err)
+	}
+	if _, ok := reader.(*tempFile); ok {
+		t.Error("expected a small archive to be kept in memory")
+	}
+	if _, ok := reader.(io.Closer); !ok {
+		t.Error("expected the archive to implement io.Closer")
+	}
+}
+
+func TestCreateTarContext_DefaultExcludes(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	for _, file := range []string{
+		"go.mod",
+		"main.go",
+		".git/HEAD",
+		".idea/workspace.xml",
+		".vscode/settings.json",
+		"web/node_modules/pkg/index.js",
+		"bazel-out/result",
+		"internal/git.go",
+	} {
+		full := filepath.Join(tmpDir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatalf("failed to create directory for %s: %v", file, err)
+		}
+		if err := os.WriteFile(full, []byte("content"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", file, err)
+		}
+	}
+
+	reader, err := CreateTarContext(tmpDir, "")
+	if err != nil {
+		t.Fatalf("CreateTarContext failed: %v", err)
+	}
+	files := readTarContents(t, reader)
+
+	for _, name := range []string{"go.mod", "main.go", "internal/git.go", "Dockerfile"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("expected %s in tar, got %v", name, getFileNames(files))
+		}
+	}
+	for name := range files {
+		if isContextExcluded(name, DefaultContextExcludes) {
+			t.Errorf("expected %s to be excluded from tar", name)
+		}
+	}
+}
+
+func TestCreateTarContext_CustomExcludes(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	for _, file := range []string{"go.mod", ".git/HEAD", "testdata/large/blob.bin", "docs/large/keep.md"} {
+		full := filepath.Join(tmpDir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatalf("failed to create directory for %s: %v", file, err)
+		}
+		if err := os.WriteFile(full, []byte("content"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", file, err)
+		}
+	}
+
+	reader, err := createTarContext(tmpDir, "", tarContextOptions{
+		template:       dockerfileTemplate,
+		excludes:       []string{"testdata/large"},
+		spillThreshold: tarSpillThreshold,
+	})
+	if err != nil {
+		t.Fatalf("createTarContext failed: %v", err)
+	}
+	files := readTarContents(t, reader)
+
+	if _, ok := files["testdata/large/blob.bin"]; ok {
+		t.Error("expected testdata/large to be excluded")
+	}
+	for _, name := range []string{"go.mod", ".git/HEAD", "docs/large/keep.md"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("expected %s in tar, got %v", name, getFileNames(files))
+		}
+	}
+}
+
+func TestCreateTarContext_InvalidExcludePattern(t *testing.T) {
+	t.Parallel()
+
+	_, err := createTarContext(t.TempDir(), "", tarContextOptions{
+		template:       dockerfileTemplate,
+		excludes:       []string{"[invalid"},
+		spillThreshold: tarSpillThreshold,
+	})
+	if err == nil {
+		t.Fatal("expected error for invalid exclude pattern")
+	}
+	if !strings.Contains(err.Error(), "[invalid") {
+		t.Errorf("expected error to mention the pattern, got %q", err.Error())
+	}
+}
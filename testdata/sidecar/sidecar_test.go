@@ -0,0 +1,37 @@
+package sidecar
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestSidecarReachable connects to an nginx sidecar via the injected
+// NGINX_HOST and NGINX_PORT env vars. This test will ONLY pass when running
+// via dockertesting with an nginx sidecar named "nginx".
+func TestSidecarReachable(t *testing.T) {
+	t.Parallel()
+	host, port := os.Getenv("NGINX_HOST"), os.Getenv("NGINX_PORT")
+	if host == "" || port == "" {
+		t.Fatalf("Expected NGINX_HOST and NGINX_PORT to be set, got %q and %q", host, port)
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	resp, err := client.Get("http://" + net.JoinHostPort(host, port) + "/")
+	if err != nil {
+		t.Fatalf("Failed to reach sidecar: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", resp.StatusCode, body)
+	}
+	t.Logf("Successfully reached sidecar via DNS alias")
+}
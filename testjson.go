@@ -0,0 +1,72 @@
+package dockertesting
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// eventBufferSize is the capacity of the channel of Runner.Events.
+const eventBufferSize = 256
+
+// TestEvent is a single event emitted by `go test -json` (see `go doc cmd/test2json`).
+type TestEvent struct {
+	// Time is when the event occurred.
+	Time time.Time
+
+	// Action is the event kind: start, run, pause, cont, pass, bench, fail, output or skip.
+	Action string
+
+	// Package is the import path of the package being tested.
+	Package string
+
+	// Test is the name of the test, empty for package-level events.
+	Test string
+
+	// Elapsed is the duration in seconds for pass and fail events.
+	Elapsed float64
+
+	// Output is the output text for output events.
+	Output string
+}
+
+// parseTestEvents parses `go test -json` output into events.
+// Lines that are not valid JSON events (e.g. build errors) are ignored.
+func parseTestEvents(output []byte) []TestEvent {
+	var events []TestEvent
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if event, ok := parseTestEvent(scanner.Bytes()); ok {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// parseTestEvent parses a line of `go test -json` output. It reports false for
+// lines that are not events.
+func parseTestEvent(line []byte) (TestEvent, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] != '{' {
+		return TestEvent{}, false
+	}
+	var event TestEvent
+	if err := json.Unmarshal(line, &event); err != nil || event.Action == "" {
+		return TestEvent{}, false
+	}
+	return event, true
+}
+
+// firstFailedTest returns the name of the first test that failed, or an empty
+// string if no test failed. For failing subtests the subtest name is returned,
+// as it fails before its parent.
+func firstFailedTest(events []TestEvent) string {
+	for _, event := range events {
+		if event.Action == "fail" && event.Test != "" {
+			return event.Test
+		}
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+package dockertesting
+
+import (
+	"testing"
+)
+
+const sampleJSONOutput = `{"Time":"2025-01-01T00:00:00Z","Action":"start","Package":"example.com/pkg"}
+{"Time":"2025-01-01T00:00:00Z","Action":"run","Package":"example.com/pkg","Test":"TestAdd"}
+{"Time":"2025-01-01T00:00:00Z","Action":"output","Package":"example.com/pkg","Test":"TestAdd","Output":"=== RUN   TestAdd\n"}
+{"Time":"2025-01-01T00:00:01Z","Action":"pass","Package":"example.com/pkg","Test":"TestAdd","Elapsed":0.01}
+{"Time":"2025-01-01T00:00:01Z","Action":"run","Package":"example.com/pkg","Test":"TestDiv"}
+{"Time":"2025-01-01T00:00:01Z","Action":"run","Package":"example.com/pkg","Test":"TestDiv/by_zero"}
+{"Time":"2025-01-01T00:00:02Z","Action":"fail","Package":"example.com/pkg","Test":"TestDiv/by_zero","Elapsed":0.02}
+{"Time":"2025-01-01T00:00:02Z","Action":"fail","Package":"example.com/pkg","Test":"TestDiv","Elapsed":0.03}
+{"Time":"2025-01-01T00:00:02Z","Action":"fail","Package":"example.com/pkg","Elapsed":0.1}
+`
+
+func TestParseTestEvents(t *testing.T) {
+	t.Parallel()
+	output := "go: downloading example.com/dep v1.0.0\n" + sampleJSONOutput + "not json\n"
+
+	events := parseTestEvents([]byte(output))
+	if len(events) != 9 {
+		t.Fatalf("expected 9 events, got %d", len(events))
+	}
+	if events[1].Action != "run" || events[1].Test != "TestAdd" {
+		t.Errorf("unexpected second event: %+v", events[1])
+	}
+	if events[2].Output != "=== RUN   TestAdd\n" {
+		t.Errorf("expected output event text, got %q", events[2].Output)
+	}
+	if events[3].Elapsed != 0.01 {
+		t.Errorf("expected elapsed 0.01, got %v", events[3].Elapsed)
+	}
+}
+
+func TestFirstFailedTest(t *testing.T) {
+	t.Parallel()
+	events := parseTestEvents([]byte(sampleJSONOutput))
+
+	if got := firstFailedTest(events); got != "TestDiv/by_zero" {
+		t.Errorf("expected 'TestDiv/by_zero', got %q", got)
+	}
+}
+
+func TestFirstFailedTest_NoFailures(t *testing.T) {
+	t.Parallel()
+	events := []TestEvent{{Action: "pass", Test: "TestAdd"}, {Action: "fail"}}
+
+	if got := firstFailedTest(events); got != "" {
+		t.Errorf("expected no failed test, got %q", got)
+	}
+}
@@ -0,0 +1,78 @@
+package dockertesting
+
+import (
+	"archive/tar"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// TLSBundleDir is the directory of the build context, relative to its root,
+// that holds the CA certificates of WithTLSBundle. The embedded templates add
+// them to the system trust store; custom Dockerfiles can copy them from here.
+const TLSBundleDir = ".dockertesting/certs"
+
+// tlsBundleCertificates returns the names of the CA certificates in dir: the
+// files with the extension .crt or .pem.
+func tlsBundleCertificates(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TLS bundle: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		switch filepath.Ext(entry.Name()) {
+		case ".crt", ".pem":
+			if entry.Type().IsRegular() {
+				names = append(names, entry.Name())
+			}
+		}
+	}
+	return names, nil
+}
+
+// addTLSBundle adds the CA certificates in dir to tw under TLSBundleDir.
+// Files with the extension .pem are renamed to .crt, the only extension
+// update-ca-certificates reads.
+func addTLSBundle(tw *tar.Writer, dir string) error {
+	names, err := tlsBundleCertificates(dir)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return fmt.Errorf("failed to read TLS bundle: %w", err)
+		}
+		header := &tar.Header{
+			Name: path.Join(TLSBundleDir, strings.TrimSuffix(name, ".pem")),
+			Mode: 0644,
+			Size: int64(len(content)),
+		}
+		if !strings.HasSuffix(header.Name, ".crt") {
+			header.Name += ".crt"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to write TLS bundle header: %w", err)
+		}
+		if _, err := tw.Write(content); err != nil {
+			return fmt.Errorf("failed to write TLS bundle: %w", err)
+		}
+	}
+	return nil
+}
+
+// tlsBundleProblem describes why dir cannot be used by WithTLSBundle, or
+// returns an empty string.
+func tlsBundleProblem(dir string) string {
+	names, err := tlsBundleCertificates(dir)
+	switch {
+	case err != nil:
+		return fmt.Sprintf("WithTLSBundle %q is not a readable directory", dir)
+	case len(names) == 0:
+		return fmt.Sprintf("WithTLSBundle %q contains no certificates; add PEM files with the extension .crt or .pem", dir)
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+package dockertesting
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCreateTarContext_TLSBundle(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module test\n\ngo 1.25.6\n"), 0644); err != nil {
+		t.Fatalf("failed to write go.mod: %v", err)
+	}
+	certsDir := t.TempDir()
+	for name, content := range map[string]string{"corp.crt": "corp", "proxy.pem": "proxy", "README": "not a certificate"} {
+		if err := os.WriteFile(filepath.Join(certsDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	reader, err := createTarContext(tmpDir, "", tarContextOptions{
+		template:       dockerfileTemplate,
+		spillThreshold: tarSpillThreshold,
+		tlsBundle:      certsDir,
+	})
+	if err != nil {
+		t.Fatalf("createTarContext failed: %v", err)
+	}
+
+	files := readTarContents(t, reader)
+	for name, content := range map[string]string{TLSBundleDir + "/corp.crt": "corp", TLSBundleDir + "/proxy.crt": "proxy"} {
+		if files[name] != content {
+			t.Errorf("expected %s with %q, got %q", name, content, files[name])
+		}
+	}
+	for name := range files {
+		if strings.HasPrefix(name, TLSBundleDir) && strings.Contains(name, "README") {
+			t.Errorf("expected only certificates in the bundle, got %s", name)
+		}
+	}
+}
+
+func TestTLSBundleProblem(t *testing.T) {
+	t.Parallel()
+
+	empty := t.TempDir()
+	if err := os.WriteFile(filepath.Join(empty, "notes.txt"), []byte("notes"), 0644); err != nil {
+		t.Fatalf("failed to write notes.txt: %v", err)
+	}
+	if problem := tlsBundleProblem(empty); !strings.Contains(problem, "contains no certificates") {
+		t.Errorf("expected a problem for a directory without certificates, got %q", problem)
+	}
+
+	certsDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(certsDir, "corp.pem"), []byte("corp"), 0644); err != nil {
+		t.Fatalf("failed to write corp.pem: %v", err)
+	}
+	if problem := tlsBundleProblem(certsDir); problem != "" {
+		t.Errorf("expected no problem, got %q", problem)
+	}
+}
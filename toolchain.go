@@ -0,0 +1,106 @@
+package dockertesting
+
+import (
+	"fmt"
+	"go/version"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// toolchainEnv is the environment variable selecting the Go toolchain, see
+// WithToolchain.
+const toolchainEnv = "GOTOOLCHAIN"
+
+// toolchainName returns the GOTOOLCHAIN value for toolchain, adding the "go"
+// prefix to bare versions such as "1.23.4". Other values, e.g. "local" or
+// "go1.23.4+auto", are returned unchanged.
+func toolchainName(toolchain string) string {
+	if toolchain != "" && toolchain[0] >= '0' && toolchain[0] <= '9' {
+		return "go" + toolchain
+	}
+	return toolchain
+}
+
+// goDirectives returns the versions of the go and toolchain directives of
+// the go.mod in dir, e.g. "go1.24" and "go1.24.2". Missing or invalid
+// directives are returned empty.
+func goDirectives(dir string) (goVersion, toolchain string) {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return "", ""
+	}
+	file, err := modfile.Parse("go.mod", data, nil)
+	if err != nil {
+		return "", ""
+	}
+	if file.Go != nil && version.IsValid("go"+file.Go.Version) {
+		goVersion = "go" + file.Go.Version
+	}
+	if file.Toolchain != nil {
+		// Custom toolchains carry a suffix, e.g. "go1.24.2+custom"
+		name, _, _ := strings.Cut(file.Toolchain.Name, "+")
+		if version.IsValid(name) {
+			toolchain = name
+		}
+	}
+	return goVersion, toolchain
+}
+
+// imageGoVersion returns the Go version of a golang image tag, e.g. "go1.24.2"
+// for "1.24.2-alpine", or an empty string for tags without a minor version,
+// e.g. "latest" or "1".
+func imageGoVersion(tag string) string {
+	v, _, _ := strings.Cut(tag, "-")
+	if !strings.Contains(v, ".") || !version.IsValid("go"+v) {
+		return ""
+	}
+	return "go" + v
+}
+
+// goVersionSatisfies reports whether an image with Go version image provides
+// at least the version required.
+func goVersionSatisfies(image, required string) bool {
+	if version.Lang(image) == image {
+		// Minor version tags follow the latest patch release
+		return version.Compare(image, version.Lang(required)) >= 0
+	}
+	return version.Compare(image, required) >= 0
+}
+
+// templateGoVersion returns the GO_VERSION build arg of the embedded template
+// for the module at dir, honoring its go.mod: without pinned version, the
+// base image is bumped to the toolchain directive, or the go directive, if
+// the default of template is older. An older pinned version fails here,
+// instead of go refusing the go directive in the container, as the golang
+// images do not download newer toolchains. An empty string keeps the
+// default of template.
+func templateGoVersion(dir string, template []byte, pinned string) (string, error) {
+	required, toolchain := goDirectives(dir)
+	if required == "" {
+		return pinned, nil
+	}
+	if pinned != "" {
+		if image := imageGoVersion(pinned); image != "" && !goVersionSatisfies(image, required) {
+			return "", fmt.Errorf("go.mod requires %s, but WithGoVersion(%q) selects an older golang base image; use WithGoVersion(%q) or newer",
+				required, pinned, strings.TrimPrefix(required, "go"))
+		}
+		return pinned, nil
+	}
+
+	preferred := required
+	if toolchain != "" && version.Compare(toolchain, required) > 0 {
+		preferred = toolchain
+	}
+	images := dockerfileBaseImages(template, nil)
+	if len(images) == 0 {
+		return "", nil
+	}
+	_, tag, _ := strings.Cut(images[0], ":")
+	if image := imageGoVersion(tag); image == "" || goVersionSatisfies(image, preferred) {
+		return "", nil
+	}
+	return strings.TrimPrefix(preferred, "go"), nil
+}
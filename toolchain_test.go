@@ -0,0 +1,120 @@
+package dockertesting
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToolchainName(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		toolchain string
+		expected  string
+	}{
+		{"go1.23.4", "go1.23.4"},
+		{"1.23.4", "go1.23.4"},
+		{"1.24rc1", "go1.24rc1"},
+		{"local", "local"},
+		{"go1.23.4+auto", "go1.23.4+auto"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := toolchainName(tt.toolchain); got != tt.expected {
+			t.Errorf("toolchainName(%q): expected %q, got %q", tt.toolchain, tt.expected, got)
+		}
+	}
+}
+
+func TestTestContainerEnv_Toolchain(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithToolchain("1.23.4"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if env := testContainerEnv(opts); env[toolchainEnv] != "go1.23.4" {
+		t.Errorf("expected %s=%q, got %q", toolchainEnv, "go1.23.4", env[toolchainEnv])
+	}
+
+	opts, err = NewOptions("/path/to/package")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := testContainerEnv(opts)[toolchainEnv]; ok {
+		t.Errorf("expected no %s by default", toolchainEnv)
+	}
+}
+
+func TestGoDirectives(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"go.mod": "module example.com/app\n\ngo 1.24\n\ntoolchain go1.24.2+custom\n"})
+
+	goVersion, toolchain := goDirectives(dir)
+	if goVersion != "go1.24" || toolchain != "go1.24.2" {
+		t.Errorf("expected go1.24 and go1.24.2, got %q and %q", goVersion, toolchain)
+	}
+}
+
+func TestImageGoVersion(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		"1.24.2":        "go1.24.2",
+		"1.24":          "go1.24",
+		"1.24.2-alpine": "go1.24.2",
+		"1.25rc1":       "go1.25rc1",
+		"latest":        "",
+		"1":             "",
+		"bookworm":      "",
+	}
+	for tag, expected := range tests {
+		if got := imageGoVersion(tag); got != expected {
+			t.Errorf("imageGoVersion(%q): expected %q, got %q", tag, expected, got)
+		}
+	}
+}
+
+func TestTemplateGoVersion(t *testing.T) {
+	t.Parallel()
+	template := []byte("ARG GO_VERSION=1.24.1\nFROM golang:${GO_VERSION}\n")
+	tests := []struct {
+		name     string
+		goMod    string
+		pinned   string
+		expected string
+		err      string
+	}{
+		{name: "no go.mod"},
+		{name: "satisfied", goMod: "go 1.23\n"},
+		{name: "newer go directive", goMod: "go 1.25.0\n", expected: "1.25.0"},
+		{name: "newer toolchain", goMod: "go 1.24\n\ntoolchain go1.24.3\n", expected: "1.24.3"},
+		{name: "older toolchain", goMod: "go 1.24\n\ntoolchain go1.23.0\n"},
+		{name: "pinned", goMod: "go 1.23\n", pinned: "1.23", expected: "1.23"},
+		{name: "pinned minor", goMod: "go 1.23.4\n", pinned: "1.23-alpine", expected: "1.23-alpine"},
+		{name: "pinned older toolchain", goMod: "go 1.23\n\ntoolchain go1.24.0\n", pinned: "1.23.1", expected: "1.23.1"},
+		{name: "pinned too old", goMod: "go 1.25.0\n", pinned: "1.24.2", err: `go.mod requires go1.25.0, but WithGoVersion("1.24.2")`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			if tt.goMod != "" {
+				writeFiles(t, dir, map[string]string{"go.mod": "module example.com/app\n\n" + tt.goMod})
+			}
+
+			got, err := templateGoVersion(dir, template, tt.pinned)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected GO_VERSION %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
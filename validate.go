@@ -0,0 +1,428 @@
+package dockertesting
+
+import (
+	"fmt"
+	"maps"
+	"net/netip"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ValidationError lists the problems of invalid Options, see Options.Validate.
+type ValidationError struct {
+	// Problems describes every problem found, each with how to fix it.
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return e.Problems[0]
+	}
+	return fmt.Sprintf("%d problems: %s", len(e.Problems), strings.Join(e.Problems, "; "))
+}
+
+// dnsLabelPattern matches a label of a DNS name: letters, digits and inner
+// hyphens, at most 63 characters.
+var dnsLabelPattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?$`)
+
+// Validate checks the options for invalid values and conflicting
+// combinations, so that they fail up front instead of in the middle of a run.
+// Run, RunT, NewRunner and Warmup call it. All problems are returned at once
+// as a *ValidationError.
+func (o *Options) Validate() error {
+	var problems []string
+	addf := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	for _, timeout := range []struct {
+		option string
+		value  time.Duration
+	}{
+		{"WithTimeout", o.Timeout},
+		{"WithCleanupTimeout", o.CleanupTimeout},
+		{"WithStopTimeout", o.StopTimeout},
+	} {
+		if timeout.value < 0 {
+			addf("%s is negative (%v); use 0 for no limit", timeout.option, timeout.value)
+		}
+	}
+	if problem := parallelModulesProblem(o.ParallelModules); problem != "" {
+		addf("%s", problem)
+	}
+	if o.MaxContextSize < 0 {
+		addf("WithMaxContextSize is negative (%d); use 0 for no limit", o.MaxContextSize)
+	}
+
+	if o.EnableVarSock {
+		if problem := sockPathProblem(o.SockPath); problem != "" {
+			addf("%s", problem)
+		}
+		if o.SocketProxy {
+			addf("WithSocketProxy replaces WithVarSock, which mounts the socket the proxy protects; use one of them")
+		}
+	}
+
+	for _, section := range o.SocketProxyWrite {
+		if problem := socketProxyProblem(section); problem != "" {
+			addf("%s", problem)
+		}
+	}
+
+	if o.SockGuard != nil {
+		if problem := sockGuardProblem(*o.SockGuard, o.EnableVarSock || o.SocketProxy); problem != "" {
+			addf("%s", problem)
+		}
+	}
+
+	if o.Template != "" {
+		if o.DockerfilePath != "" {
+			addf("WithTemplate conflicts with WithDockerfilePath, which takes precedence; use one of them")
+		}
+		if _, err := parseDockerfileTemplate(o.Template); err != nil {
+			addf("WithTemplate: %v", err)
+		}
+	}
+
+	if o.ImageFlavor != ImageFlavorDefault {
+		if o.DockerfilePath != "" {
+			addf("WithImageFlavor applies to the Dockerfile template, but WithDockerfilePath replaces it; select the base image in the Dockerfile")
+		}
+		if strings.Contains(o.GoVersion, "-") {
+			addf("WithGoVersion %q already selects a variant of the golang image; remove it from the version or drop WithImageFlavor", o.GoVersion)
+		}
+	}
+
+	if o.DockerfileGenerator != nil && o.DockerfilePath != "" {
+		addf("WithDockerfileGenerator conflicts with WithDockerfilePath, which takes precedence; use one of them")
+	}
+
+	for _, tool := range o.GoTools {
+		if problem := goToolProblem(tool); problem != "" {
+			addf("%s", problem)
+		}
+	}
+	if len(o.GoTools) > 0 && o.DockerfilePath != "" {
+		addf("WithGoTools applies to the Dockerfile template, but WithDockerfilePath replaces it; go install the tools in the Dockerfile")
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(o.SecretEnv)) {
+		if problem := secretEnvProblem(name, o.TemplateData); problem != "" {
+			addf("%s", problem)
+		}
+	}
+
+	if o.TLSBundle != "" {
+		if problem := tlsBundleProblem(o.TLSBundle); problem != "" {
+			addf("%s", problem)
+		}
+	}
+
+	for i, creds := range o.GitCredentials {
+		switch {
+		case creds.Host == "":
+			addf("WithGitCredentials entry %d has no Host; set e.g. %q", i, "github.com")
+		case creds.Token == "" && creds.Helper == "":
+			addf("WithGitCredentials for %s has neither Token nor Helper", creds.Host)
+		}
+	}
+
+	for _, alias := range o.Aliases {
+		if problem := aliasProblem(alias); problem != "" {
+			addf("alias %q %s", alias, problem)
+		}
+	}
+
+	if o.NetworkCIDR != "" {
+		if o.Network != nil {
+			addf("WithNetworkCIDR applies to the network created for the run, but WithNetwork replaces it; set the subnet when creating the network")
+		} else if problem := networkCIDRProblem(o.NetworkCIDR); problem != "" {
+			addf("WithNetworkCIDR %q %s", o.NetworkCIDR, problem)
+		} else if !dockerHostConfigFromEnv().IsRemote() {
+			// Only a local daemon shares the routes of this host
+			if problem := subnetOverlapProblem(o.NetworkCIDR, hostNetworks()); problem != "" {
+				addf("WithNetworkCIDR %q %s", o.NetworkCIDR, problem)
+			}
+		}
+	}
+	if o.NetworkDriver != "" || len(o.NetworkDriverOptions) > 0 {
+		if o.Network != nil {
+			addf("WithNetworkDriver applies to the network created for the run, but WithNetwork replaces it; set the driver when creating the network")
+		}
+		switch o.NetworkDriver {
+		case "host", "none":
+			addf("WithNetworkDriver %q cannot create networks; use \"bridge\"", o.NetworkDriver)
+		}
+		for _, key := range slices.Sorted(maps.Keys(o.NetworkDriverOptions)) {
+			if problem := networkOptionProblem(key); problem != "" {
+				addf("WithNetworkDriver option %q %s", key, problem)
+			}
+		}
+	}
+	for _, port := range o.HostPorts {
+		if port < 1 || port > 65535 {
+			addf("WithHostPorts port %d is not a TCP port; use 1 to 65535", port)
+		}
+	}
+	if o.StaticIP != "" {
+		if problem := staticIPProblem(o.StaticIP, o.NetworkCIDR, o.Network != nil); problem != "" {
+			addf("WithStaticIP %q %s", o.StaticIP, problem)
+		}
+		if o.ParallelModules > 1 {
+			addf("WithStaticIP conflicts with WithParallelModules, whose runs would share the address; run one module at a time")
+		}
+	}
+
+	if problem := packageSubdirProblem(o.PackagePath, o.PackageSubdir); problem != "" {
+		addf("WithPackageSubdir %q %s", o.PackageSubdir, problem)
+	}
+
+	switch {
+	case strings.TrimSpace(o.Pattern) == "":
+		addf("pattern is empty; use e.g. WithPattern(%q)", DefaultPattern)
+	case strings.HasPrefix(o.Pattern, "-") || strings.ContainsAny(o.Pattern, " \t"):
+		addf("pattern %q is not a package pattern; pass go test flags with WithArgs", o.Pattern)
+	}
+	problems = append(problems, argsProblems(o.Pattern, o.Args)...)
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// sockPathProblem describes why the Docker socket at path cannot be mounted,
+// or returns an empty string. Only explicitly configured paths are checked,
+// and whether they exist only where the daemon shares the host's file system:
+// on Linux without DOCKER_HOST.
+func sockPathProblem(path string) string {
+	if _, pipe := namedPipePath(path); pipe || path == "" || path == DefaultSockPath || path == WindowsSockPath {
+		return ""
+	}
+	if !filepath.IsAbs(path) {
+		return fmt.Sprintf("WithSockPath %q is not an absolute path; the daemon resolves it as mount source", path)
+	}
+	if runtime.GOOS == "linux" && os.Getenv("DOCKER_HOST") == "" && !fileExists(path) {
+		return fmt.Sprintf("WithSockPath %q does not exist, but WithVarSock mounts it; fix the path or remove WithSockPath to detect the socket", path)
+	}
+	return ""
+}
+
+// secretEnvProblem describes why the variable name of WithSecretEnv cannot be
+// passed at exec time only, or returns an empty string.
+func secretEnvProblem(name string, templateData map[string]any) string {
+	if name == "" || strings.ContainsAny(name, "= \t\n") {
+		return fmt.Sprintf("WithSecretEnv name %q is not a valid environment variable name", name)
+	}
+	if env, ok := templateData["Env"].(map[string]string); ok {
+		if _, ok := env[name]; ok {
+			return fmt.Sprintf("WithSecretEnv %s is also set by the Env of WithTemplateData, which writes it into the image", name)
+		}
+	}
+	return ""
+}
+
+// sockGuardProblem describes why the guard of WithSockGuard cannot watch the
+// tests, or returns an empty string. daemon reports whether the tests have
+// access to the Docker daemon.
+func sockGuardProblem(guard SockGuard, daemon bool) string {
+	if !daemon {
+		return "WithSockGuard guards the Docker daemon of the tests, but neither WithVarSock nor WithSocketProxy gives them access"
+	}
+	if guard.Mode != SockGuardWarn && guard.Mode != SockGuardFail {
+		return fmt.Sprintf("WithSockGuard has the unknown mode %d; use SockGuardWarn or SockGuardFail", guard.Mode)
+	}
+	for _, registry := range guard.TrustedRegistries {
+		if registry == "" || strings.Contains(registry, "://") || strings.ContainsAny(registry, " \t\n@") {
+			return fmt.Sprintf("WithSockGuard trusted registry %q must be a registry host or repository prefix, e.g. %q", registry, "docker.io/library")
+		}
+	}
+	return ""
+}
+
+// aliasProblem describes why alias is not a valid DNS name, or returns an
+// empty string.
+func aliasProblem(alias string) string {
+	if alias == "" {
+		return "is empty"
+	}
+	if len(alias) > 253 {
+		return "is longer than 253 characters"
+	}
+	for label := range strings.SplitSeq(alias, ".") {
+		if !dnsLabelPattern.MatchString(label) {
+			return fmt.Sprintf("has the invalid label %q; use letters, digits and inner hyphens, at most 63 per label", label)
+		}
+	}
+	return ""
+}
+
+// networkCIDRProblem describes why cidr is not a valid subnet for a network,
+// or returns an empty string.
+func networkCIDRProblem(cidr string) string {
+	prefix, err := netip.ParsePrefix(cidr)
+	switch {
+	case err != nil || !prefix.Addr().Is4():
+		return "is not an IPv4 subnet; use e.g. \"172.28.0.0/16\""
+	case prefix != prefix.Masked():
+		return fmt.Sprintf("has host bits set; use %q", prefix.Masked())
+	case prefix.Bits() > 30:
+		return "has no room for the gateway and a container; use at most 30 prefix bits"
+	}
+	return ""
+}
+
+// subnetOverlapProblem describes why the valid subnet cidr collides with one
+// of the networks of the host, or returns an empty string. Containers cannot
+// reach addresses of an overlapping host network, e.g. of a VPN.
+func subnetOverlapProblem(cidr string, networks []hostNetwork) string {
+	prefix := netip.MustParsePrefix(cidr)
+	for _, network := range networks {
+		if prefix.Overlaps(network.prefix) {
+			return fmt.Sprintf("overlaps the network %s of the host interface %s, e.g. of a VPN or another Docker network; choose another subnet", network.prefix, network.iface)
+		}
+	}
+	return ""
+}
+
+// networkOptionProblem describes why key is not the key of a network driver
+// option, or returns an empty string. Drivers ignore unknown keys, so short
+// names such as "mtu" would silently have no effect.
+func networkOptionProblem(key string) string {
+	switch strings.ToLower(key) {
+	case "":
+		return "is empty"
+	case "mtu":
+		return fmt.Sprintf("is not a driver option; use NetworkOptionMTU (%q)", NetworkOptionMTU)
+	case "icc", "enable_icc":
+		return fmt.Sprintf("is not a driver option; use NetworkOptionICC (%q)", NetworkOptionICC)
+	}
+	if !strings.Contains(key, ".") {
+		return "is not a driver option; use its full name, e.g. \"com.docker.network.bridge.enable_ip_masquerade\""
+	}
+	return ""
+}
+
+// staticIPProblem describes why ip cannot be assigned to the test container
+// on a network with the subnet cidr, or returns an empty string. The subnet
+// of a shared network is not known.
+func staticIPProblem(ip, cidr string, shared bool) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil || !addr.Is4() {
+		return "is not an IPv4 address"
+	}
+	if shared {
+		return ""
+	}
+	if cidr == "" {
+		return "needs a subnet; Docker only assigns addresses in subnets set with WithNetworkCIDR"
+	}
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil || networkCIDRProblem(cidr) != "" {
+		// Reported for WithNetworkCIDR
+		return ""
+	}
+	broadcast := prefix.Addr().As4()
+	for i := prefix.Bits(); i < 32; i++ {
+		broadcast[i/8] |= 1 << (7 - i%8)
+	}
+	switch addr {
+	case prefix.Addr():
+		return fmt.Sprintf("is the address of the subnet %s", cidr)
+	case prefix.Addr().Next():
+		return fmt.Sprintf("is the gateway of the subnet %s", cidr)
+	case netip.AddrFrom4(broadcast):
+		return fmt.Sprintf("is the broadcast address of the subnet %s", cidr)
+	}
+	if !prefix.Contains(addr) {
+		return fmt.Sprintf("is not in the subnet %s of WithNetworkCIDR", cidr)
+	}
+	return ""
+}
+
+// packageSubdirProblem describes why dir is not a directory of the package
+// at packagePath, or returns an empty string.
+func packageSubdirProblem(packagePath, dir string) string {
+	switch {
+	case dir == "":
+		return ""
+	case path.IsAbs(dir) || filepath.IsAbs(filepath.FromSlash(dir)):
+		return "is absolute; use a path relative to the package path"
+	case dir == ".." || strings.HasPrefix(dir, "../"):
+		return "leaves the package path, which is the build context"
+	}
+	if info, err := os.Stat(filepath.Join(packagePath, filepath.FromSlash(dir))); err != nil || !info.IsDir() {
+		return "is not a directory of the package path"
+	}
+	return ""
+}
+
+// goToolProblem describes why tool cannot be installed by WithGoTools, or returns
+// an empty string.
+func goToolProblem(tool string) string {
+	pkg, version, ok := strings.Cut(tool, "@")
+	switch {
+	case strings.ContainsAny(tool, " \t\n\\\"'$`;&|"):
+		return fmt.Sprintf("WithGoTools %q must be a package path with a version, without spaces or shell characters", tool)
+	case !ok || pkg == "" || version == "":
+		return fmt.Sprintf("WithGoTools %q has no version; go install needs one outside of the module, e.g. %q", tool, pkg+"@latest")
+	}
+	return ""
+}
+
+// parallelModulesProblem describes why n is not a valid number of parallel
+// modules, or returns an empty string.
+func parallelModulesProblem(n int) string {
+	if n < 0 {
+		return fmt.Sprintf("WithParallelModules is negative (%d); use 1 to run one module at a time", n)
+	}
+	return ""
+}
+
+// argsProblems describes the arguments of WithArgs that conflict with each
+// other, with the flags set by Run or with the package pattern.
+func argsProblems(pattern string, args []string) []string {
+	var problems []string
+	runs := 0
+	for i, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		switch strings.TrimPrefix(name, "test.") {
+		case "run":
+			runs++
+			if !hasValue && i == len(args)-1 {
+				problems = append(problems, "-run in WithArgs has no value; pass it as \"-run\", \"Regexp\" or \"-run=Regexp\"")
+			}
+		case "coverprofile":
+			problems = append(problems, "-coverprofile in WithArgs conflicts with the coverage collected by Run; use WithCoverageOutput")
+		}
+	}
+	if runs > 1 {
+		problems = append(problems, fmt.Sprintf("-run is passed %d times in WithArgs, only the last one applies; combine the expressions, e.g. \"-run=TestA|TestB\"", runs))
+	}
+	if runs > 0 && isTestNamePattern(pattern) {
+		problems = append(problems, fmt.Sprintf("pattern %q looks like a test name, but go test takes it as a package; select tests with -run in WithArgs and packages with WithPattern, e.g. %q", pattern, DefaultPattern))
+	}
+	return problems
+}
+
+// isTestNamePattern reports whether the package pattern looks like a test
+// name, e.g. "TestFoo", rather than a relative directory or a module path:
+// it neither starts with "." or "/" nor contains a "." or "/" like module
+// paths do, and is not one of the reserved patterns of go test. Patterns
+// holding flags are reported by Validate already.
+func isTestNamePattern(pattern string) bool {
+	switch pattern {
+	case "all", "std", "cmd", "tool", "work":
+		return false
+	}
+	return pattern != "" && !strings.HasPrefix(pattern, "-") && !strings.ContainsAny(pattern, "./ \t")
+}
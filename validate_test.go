@@ -0,0 +1,364 @@
+package dockertesting
+
+import (
+	"errors"
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate_Defaults(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithAliases("api.test", "db-1"), WithArgs("-run", "TestA", "-v"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := opts.Validate(); err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+}
+
+func TestValidate_ReportsAllProblems(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package",
+		WithTimeout(-time.Second),
+		WithStopTimeout(-time.Second),
+		WithAliases("my_app"),
+		WithPattern("-run TestA"),
+		WithArgs("-run=TestA", "-coverprofile=c.out", "-run"),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = opts.Validate()
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	for _, want := range []string{
+		"WithTimeout is negative",
+		"WithStopTimeout is negative",
+		`alias "my_app" has the invalid label "my_app"`,
+		`pattern "-run TestA" is not a package pattern`,
+		"-run in WithArgs has no value",
+		"-coverprofile in WithArgs",
+		"-run is passed 2 times",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got %q", want, err)
+		}
+	}
+	if len(validationErr.Problems) != 7 {
+		t.Errorf("expected 7 problems, got %d: %q", len(validationErr.Problems), validationErr.Problems)
+	}
+}
+
+func TestArgsProblems_TestNamePattern(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		pattern string
+		args    []string
+		problem bool
+	}{
+		{name: "test name with -run", pattern: "TestFoo", args: []string{"-run", "TestFoo"}, problem: true},
+		{name: "test name with -run=", pattern: "TestFoo", args: []string{"-test.run=TestFoo"}, problem: true},
+		{name: "test name without -run", pattern: "TestFoo", problem: false},
+		{name: "relative directory", pattern: "./pkg/...", args: []string{"-run", "TestFoo"}, problem: false},
+		{name: "absolute directory", pattern: "/src/pkg", args: []string{"-run", "TestFoo"}, problem: false},
+		{name: "module path", pattern: "example.com/mod/...", args: []string{"-run", "TestFoo"}, problem: false},
+		{name: "reserved pattern", pattern: "all", args: []string{"-run", "TestFoo"}, problem: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			problems := argsProblems(tt.pattern, tt.args)
+			got := slices.ContainsFunc(problems, func(problem string) bool {
+				return strings.Contains(problem, "looks like a test name")
+			})
+			if got != tt.problem {
+				t.Errorf("expected problem %v, got %q", tt.problem, problems)
+			}
+		})
+	}
+}
+
+func TestValidate_SockPath(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		path    string
+		problem bool
+	}{
+		{"", false},
+		{DefaultSockPath, false},
+		{"npipe:////./pipe/docker_engine", false},
+		{"relative/docker.sock", true},
+	}
+	for _, tt := range tests {
+		if got := sockPathProblem(tt.path) != ""; got != tt.problem {
+			t.Errorf("sockPathProblem(%q): expected problem %v, got %v", tt.path, tt.problem, got)
+		}
+	}
+
+	// The socket is only checked with WithVarSock
+	opts, err := NewOptions("/path/to/package", WithSockPath("relative/docker.sock"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := opts.Validate(); err != nil {
+		t.Errorf("unexpected validation error without WithVarSock: %v", err)
+	}
+	opts.EnableVarSock = true
+	if err := opts.Validate(); err == nil {
+		t.Error("expected validation error with WithVarSock")
+	}
+}
+
+func TestAliasProblem(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		alias   string
+		problem bool
+	}{
+		{"api", false},
+		{"api.test", false},
+		{"db-1.local", false},
+		{"", true},
+		{"-api", true},
+		{"api-", true},
+		{"api..test", true},
+		{"my_app", true},
+		{strings.Repeat("a", 64), true},
+	}
+	for _, tt := range tests {
+		if got := aliasProblem(tt.alias) != ""; got != tt.problem {
+			t.Errorf("aliasProblem(%q): expected problem %v, got %v", tt.alias, tt.problem, got)
+		}
+	}
+}
+
+func TestRun_InvalidOptions(t *testing.T) {
+	t.Parallel()
+	_, err := Run(t.Context(), "/path/to/package", WithTimeout(-time.Second))
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "invalid options: ") {
+		t.Errorf("expected invalid options error, got %q", err)
+	}
+}
+
+func TestValidate_GitCredentials(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package",
+		WithGitCredentials(GitCredentials{Token: "t"}, GitCredentials{Host: "gitlab.com"}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = opts.Validate()
+	for _, want := range []string{
+		"WithGitCredentials entry 0 has no Host",
+		"WithGitCredentials for gitlab.com has neither Token nor Helper",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got %v", want, err)
+		}
+	}
+}
+
+func TestValidate_PackageSubdir(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"internal/service/service.go": "package service", "main.go": "package main"})
+
+	tests := []struct {
+		subdir  string
+		problem string
+	}{
+		{"internal/service", ""},
+		{"internal/missing", "is not a directory"},
+		{"main.go", "is not a directory"},
+		{"../other", "leaves the package path"},
+		{"/abs/path", "is absolute"},
+	}
+	for _, tt := range tests {
+		opts, err := NewOptions(dir, WithPackageSubdir(tt.subdir))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		err = opts.Validate()
+		switch {
+		case tt.problem == "" && err != nil:
+			t.Errorf("WithPackageSubdir(%q): unexpected validation error: %v", tt.subdir, err)
+		case tt.problem != "" && (err == nil || !strings.Contains(err.Error(), tt.problem)):
+			t.Errorf("WithPackageSubdir(%q): expected problem %q, got %v", tt.subdir, tt.problem, err)
+		}
+	}
+}
+
+func TestValidate_Template(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithTemplate("FROM {{.Broken"), WithDockerfilePath("Dockerfile.test"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = opts.Validate()
+	for _, want := range []string{"WithTemplate conflicts with WithDockerfilePath", "failed to parse Dockerfile template"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got %v", want, err)
+		}
+	}
+}
+
+func TestValidate_DockerfileGenerator(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithDockerfileGenerator(TemplateGenerator{}), WithDockerfilePath("Dockerfile.test"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := opts.Validate(); err == nil || !strings.Contains(err.Error(), "WithDockerfileGenerator conflicts with WithDockerfilePath") {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+}
+
+func TestValidate_ImageFlavor(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package",
+		WithImageFlavor(ImageFlavorAlpine),
+		WithGoVersion("1.24-bookworm"),
+		WithDockerfilePath("Dockerfile.test"),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = opts.Validate()
+	for _, want := range []string{"WithImageFlavor applies to the Dockerfile template", `WithGoVersion "1.24-bookworm" already selects a variant`} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got %v", want, err)
+		}
+	}
+}
+
+func TestValidate_GoTools(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package", WithGoTools(
+		"golang.org/x/tools/cmd/stringer@latest",
+		"golang.org/x/tools/cmd/goimports",
+		"example.com/tool@v1; rm -rf /",
+	))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = opts.Validate()
+	for _, want := range []string{`"golang.org/x/tools/cmd/goimports" has no version`, "without spaces or shell characters"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "stringer") {
+		t.Errorf("expected no problem for a versioned tool, got %v", err)
+	}
+}
+
+func TestValidate_Network(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"no subnet", []Option{WithStaticIP("172.28.0.10")}, "needs a subnet"},
+		{"not IPv4", []Option{WithNetworkCIDR("172.28.0.0/16"), WithStaticIP("fd00::10")}, "is not an IPv4 address"},
+		{"outside", []Option{WithNetworkCIDR("172.28.0.0/16"), WithStaticIP("10.0.0.10")}, "is not in the subnet 172.28.0.0/16"},
+		{"gateway", []Option{WithNetworkCIDR("172.28.0.0/16"), WithStaticIP("172.28.0.1")}, "is the gateway"},
+		{"broadcast", []Option{WithNetworkCIDR("172.28.0.0/24"), WithStaticIP("172.28.0.255")}, "is the broadcast address"},
+		{"host bits", []Option{WithNetworkCIDR("172.28.0.1/16")}, `has host bits set; use "172.28.0.0/16"`},
+		{"invalid subnet", []Option{WithNetworkCIDR("172.28.0.0")}, "is not an IPv4 subnet"},
+		{"shared network", []Option{WithNetwork(&DockerNetwork{Name: "shared"}), WithNetworkCIDR("172.28.0.0/16")}, "WithNetwork replaces it"},
+		{"host port", []Option{WithHostPorts(0)}, "WithHostPorts port 0 is not a TCP port"},
+		{"socket proxy and var sock", []Option{WithSocketProxy(), WithVarSock()}, "WithSocketProxy replaces WithVarSock"},
+		{"socket proxy unknown section", []Option{WithSocketProxy("BUILD")}, `WithSocketProxy section "BUILD" is unknown`},
+		{"sock guard without daemon", []Option{WithSockGuard(SockGuard{Cleanup: true})}, "neither WithVarSock nor WithSocketProxy"},
+		{"sock guard mode", []Option{WithVarSock(), WithSockGuard(SockGuard{Mode: 7})}, "unknown mode 7"},
+		{"sock guard registry", []Option{WithSocketProxy(), WithSockGuard(SockGuard{TrustedRegistries: []string{"https://registry.example.com"}})}, `trusted registry "https://registry.example.com"`},
+		{"secret env name", []Option{WithSecretEnv("API TOKEN", "s3cr3t")}, `WithSecretEnv name "API TOKEN" is not a valid environment variable name`},
+		{"secret env in image", []Option{
+			WithSecretEnv("API_TOKEN", "s3cr3t"),
+			WithTemplateData(map[string]any{"Env": map[string]string{"API_TOKEN": "s3cr3t"}}),
+		}, "WithSecretEnv API_TOKEN is also set by the Env of WithTemplateData"},
+		{"tls bundle", []Option{WithTLSBundle("/nonexistent/certs")}, `WithTLSBundle "/nonexistent/certs" is not a readable directory`},
+		{"parallel modules", []Option{WithNetworkCIDR("172.28.0.0/16"), WithStaticIP("172.28.0.10"), WithParallelModules(2)}, "conflicts with WithParallelModules"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			opts, err := NewOptions("/path/to/package", tt.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := opts.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error to contain %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	// The subnet of a shared network is not known
+	opts, err := NewOptions("/path/to/package", WithNetwork(&DockerNetwork{Name: "shared"}), WithStaticIP("10.0.0.10"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := opts.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSubnetOverlapProblem(t *testing.T) {
+	t.Parallel()
+	networks := []hostNetwork{
+		{iface: "eth0", prefix: netip.MustParsePrefix("192.168.1.0/24")},
+		{iface: "tun0", prefix: netip.MustParsePrefix("10.0.0.0/8")},
+	}
+
+	if problem := subnetOverlapProblem("10.42.0.0/24", networks); !strings.Contains(problem, "overlaps the network 10.0.0.0/8 of the host interface tun0") {
+		t.Errorf("expected an overlap with tun0, got %q", problem)
+	}
+	if problem := subnetOverlapProblem("172.28.0.0/16", networks); problem != "" {
+		t.Errorf("expected no problem, got %q", problem)
+	}
+}
+
+func TestValidate_NetworkDriver(t *testing.T) {
+	t.Parallel()
+	opts, err := NewOptions("/path/to/package",
+		WithNetworkDriver("host", map[string]string{"mtu": "1400", "icc": "false", "com.docker.network.bridge.name": "br-test"}),
+		WithNetwork(&DockerNetwork{Name: "shared"}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = opts.Validate()
+	for _, want := range []string{
+		"WithNetwork replaces it",
+		`WithNetworkDriver "host" cannot create networks`,
+		`option "mtu" is not a driver option; use NetworkOptionMTU`,
+		`option "icc" is not a driver option; use NetworkOptionICC`,
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "br-test") || strings.Contains(err.Error(), "bridge.name") {
+		t.Errorf("expected no problem for a full option name, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package dockertesting
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// VendorMode controls whether go uses the vendor directory of the module.
+type VendorMode int
+
+const (
+	// VendorAuto uses the vendor directory if it is consistent with go.mod.
+	VendorAuto VendorMode = iota
+
+	// VendorEnabled always passes -mod=vendor to go.
+	VendorEnabled
+
+	// VendorDisabled passes -mod=mod to go, ignoring the vendor directory.
+	VendorDisabled
+)
+
+// goFlagsEnv is the environment variable with default flags of go commands.
+const goFlagsEnv = "GOFLAGS"
+
+// vendored reports whether the run uses the vendor directory of the module.
+func vendored(options *Options) bool {
+	switch options.Vendor {
+	case VendorEnabled:
+		return true
+	case VendorDisabled:
+		return false
+	}
+	return vendorConsistent(options.PackagePath)
+}
+
+// vendorGoFlags returns the GOFLAGS of the test container selecting the
+// vendor mode, or an empty string to leave it to go.
+func vendorGoFlags(options *Options) string {
+	switch {
+	case vendored(options):
+		return "-mod=vendor"
+	case options.Vendor == VendorDisabled:
+		return "-mod=mod"
+	}
+	return ""
+}
+
+// vendorConsistent reports whether dir has a vendor directory listing every
+// requirement of its go.mod at the required version, as go mod vendor
+// writes it. go refuses -mod=vendor for inconsistent vendor directories.
+func vendorConsistent(dir string) bool {
+	modules, err := os.ReadFile(filepath.Join(dir, "vendor", "modules.txt"))
+	if err != nil {
+		return false
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return false
+	}
+	file, err := modfile.Parse("go.mod", data, nil)
+	if err != nil {
+		return false
+	}
+
+	// Module lines have the form "# path version" with an optional
+	// "=> replacement"
+	vendoredVersions := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(modules))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 3 && fields[0] == "#" {
+			vendoredVersions[fields[1]] = fields[2]
+		}
+	}
+	for _, require := range file.Require {
+		if vendoredVersions[require.Mod.Path] != require.Mod.Version {
+			return false
+		}
+	}
+	return true
+}
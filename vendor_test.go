@@ -0,0 +1,90 @@
+package dockertesting
+
+import "testing"
+
+const vendorGoMod = `module example.com/app
+
+go 1.23
+
+require (
+	example.com/a v1.2.0
+	example.com/b v0.1.0 // indirect
+)
+`
+
+func TestVendorConsistent(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		modules string
+		want    bool
+	}{
+		{
+			name:    "consistent",
+			modules: "# example.com/a v1.2.0\n## explicit; go 1.21\nexample.com/a\n# example.com/b v0.1.0 => ../b\n## explicit\nexample.com/b\n",
+			want:    true,
+		},
+		{
+			name:    "outdated version",
+			modules: "# example.com/a v1.1.0\n## explicit\nexample.com/a\n# example.com/b v0.1.0\n## explicit\nexample.com/b\n",
+			want:    false,
+		},
+		{
+			name:    "missing module",
+			modules: "# example.com/a v1.2.0\n## explicit\nexample.com/a\n",
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{"go.mod": vendorGoMod, "vendor/modules.txt": tt.modules})
+			if got := vendorConsistent(dir); got != tt.want {
+				t.Errorf("expected %t, got %t", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestVendorConsistent_NoVendorDir(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"go.mod": vendorGoMod})
+	if vendorConsistent(dir) {
+		t.Error("expected a module without vendor directory not to be vendored")
+	}
+}
+
+func TestVendorGoFlags(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":             vendorGoMod,
+		"vendor/modules.txt": "# example.com/a v1.2.0\n# example.com/b v0.1.0\n",
+	})
+
+	tests := []struct {
+		name    string
+		path    string
+		options []Option
+		want    string
+	}{
+		{"auto vendored", dir, nil, "-mod=vendor"},
+		{"auto without vendor", "/path/to/package", nil, ""},
+		{"forced", "/path/to/package", []Option{WithVendor(true)}, "-mod=vendor"},
+		{"disabled", dir, []Option{WithVendor(false)}, "-mod=mod"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			opts, err := NewOptions(tt.path, tt.options...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := testContainerEnv(opts)[goFlagsEnv]; got != tt.want {
+				t.Errorf("expected GOFLAGS %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package dockertesting
+
+import (
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// DefaultStartupTimeout is how long the wait strategies created by this
+// package wait for a container to become ready.
+const DefaultStartupTimeout = 60 * time.Second
+
+// defaultWaitStrategy is used for the test container when no wait strategy is
+// configured. It waits until commands can be executed in the container.
+func defaultWaitStrategy() wait.Strategy {
+	return wait.ForExec([]string{"echo", "ready"})
+}
+
+// WaitForLog waits until the container output contains text.
+//
+// Example:
+//
+//	dockertesting.WaitForLog("database system is ready to accept connections")
+func WaitForLog(text string) wait.Strategy {
+	return wait.ForLog(text).WithStartupTimeout(DefaultStartupTimeout)
+}
+
+// WaitForPort waits until port (e.g. "5432/tcp") is listening in the container.
+// The port must be exposed, either by the image or via SidecarSpec.ExposedPorts.
+//
+// Example:
+//
+//	dockertesting.WaitForPort("6379/tcp")
+func WaitForPort(port string) wait.Strategy {
+	return wait.ForListeningPort(nat.Port(port)).WithStartupTimeout(DefaultStartupTimeout)
+}
+
+// WaitForHTTP waits until a GET request to path on port (e.g. "8080/tcp")
+// returns a 2xx status code. The port must be exposed, either by the image or
+// via SidecarSpec.ExposedPorts.
+//
+// Example:
+//
+//	dockertesting.WaitForHTTP("80/tcp", "/healthz")
+func WaitForHTTP(port, path string) wait.Strategy {
+	return wait.ForHTTP(path).
+		WithPort(nat.Port(port)).
+		WithStatusCodeMatcher(func(status int) bool {
+			return status >= 200 && status < 300
+		}).
+		WithStartupTimeout(DefaultStartupTimeout)
+}
+
+// WaitForHealthcheck waits until the HEALTHCHECK of the image reports the
+// container as healthy.
+//
+// Example:
+//
+//	dockertesting.WaitForHealthcheck()
+func WaitForHealthcheck() wait.Strategy {
+	return wait.ForHealthCheck().WithStartupTimeout(DefaultStartupTimeout)
+}
+
+// WaitForExec waits until cmd exits with code 0 inside the container.
+//
+// Example:
+//
+//	dockertesting.WaitForExec("pg_isready", "-U", "postgres")
+func WaitForExec(cmd ...string) wait.Strategy {
+	return wait.ForExec(cmd).WithStartupTimeout(DefaultStartupTimeout)
+}
+
+// WaitForAll waits until all of the given strategies are satisfied, in order.
+//
+// Example:
+//
+//	dockertesting.WaitForAll(
+//	    dockertesting.WaitForPort("5432/tcp"),
+//	    dockertesting.WaitForLog("ready to accept connections"),
+//	)
+func WaitForAll(strategies ...wait.Strategy) wait.Strategy {
+	return wait.ForAll(strategies...)
+}
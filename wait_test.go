@@ -0,0 +1,72 @@
+package dockertesting
+
+import (
+	"testing"
+
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+func TestWaitStrategies(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		strategy wait.Strategy
+		expected string
+	}{
+		{
+			name:     "log",
+			strategy: WaitForLog("ready to accept connections"),
+			expected: `log message "ready to accept connections"`,
+		},
+		{
+			name:     "port",
+			strategy: WaitForPort("5432/tcp"),
+			expected: "port 5432/tcp to be listening",
+		},
+		{
+			name:     "http",
+			strategy: WaitForHTTP("8080/tcp", "/healthz"),
+			expected: `HTTP GET request on port 8080 path "/healthz"`,
+		},
+		{
+			name:     "healthcheck",
+			strategy: WaitForHealthcheck(),
+			expected: "container to become healthy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s, ok := tt.strategy.(interface{ String() string })
+			if !ok {
+				t.Fatalf("expected strategy to implement String()")
+			}
+			if got := s.String(); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestWaitStrategies_StartupTimeout(t *testing.T) {
+	t.Parallel()
+
+	strategies := []wait.Strategy{
+		WaitForLog("ready"),
+		WaitForPort("80/tcp"),
+		WaitForHTTP("80/tcp", "/"),
+		WaitForHealthcheck(),
+		WaitForExec("true"),
+	}
+	for _, strategy := range strategies {
+		st, ok := strategy.(wait.StrategyTimeout)
+		if !ok {
+			t.Fatalf("expected %T to implement wait.StrategyTimeout", strategy)
+		}
+		if timeout := st.Timeout(); timeout == nil || *timeout != DefaultStartupTimeout {
+			t.Errorf("expected %T timeout %v, got %v", strategy, DefaultStartupTimeout, timeout)
+		}
+	}
+}
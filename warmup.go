@@ -0,0 +1,165 @@
+package dockertesting
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// Warmup builds the test image for the package and pulls the sidecar images
+// without running any tests, e.g. in a CI "prepare" stage or to pre-heat local
+// caches before a demo. The base image is pulled by the build.
+//
+// The test image is kept and tagged like WithImageCache does, so a later Run
+// with WithImageCache(true) starts from it directly; other runs still benefit
+// from the layer cache of the build. Use PruneImageCache to remove it.
+//
+// With WithBuildCacheRegistry or WithBuildCacheDir, the build cache is imported
+// before the build and exported after it; a failed export is returned.
+//
+// The options are those of Run; options that only affect the test execution
+// are ignored. A failure to build the test image is returned as a *BuildError.
+//
+// Example:
+//
+//	err := dockertesting.Warmup(ctx, "./mypackage",
+//	    dockertesting.WithSidecar(sidecar.Postgres("17", "app")),
+//	)
+func Warmup(ctx context.Context, packagePath string, opts ...Option) error {
+	options, err := NewOptions(packagePath, opts...)
+	if err == nil {
+		err = options.Validate()
+	}
+	if err != nil {
+		return fmt.Errorf("invalid options: %w", err)
+	}
+
+	// Apply timeout to context if configured
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	// Creating a provider reads the testcontainers configuration
+	if err := configureReaper(options.Reaper); err != nil {
+		return err
+	}
+
+	logger := containerLogger(options.Verbosity)
+	provider, err := runProvider(options, logger)
+	if err != nil {
+		return err
+	}
+	if provider == nil {
+		provider, err = newDockerProvider(logger)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = provider.Close()
+		}()
+	}
+
+	buildOutput, _, flush := runOutputs(options)
+	defer flush()
+
+	// Capture the build log for build errors
+	var buildLog bytes.Buffer
+	buildLogWriters := []io.Writer{&buildLog}
+	if buildOutput != nil {
+		buildLogWriters = append(buildLogWriters, buildOutput)
+	}
+	if options.ProgressReporter != nil {
+		buildLogWriters = append(buildLogWriters, newBuildProgressWriter(options.ProgressReporter))
+	}
+
+	log, metrics := runLogger(options), runMetrics(options)
+	imgBuild, err := prepareImageBuild(ctx, CreateContainerConfig{
+		PackagePath:         options.PackagePath,
+		DockerfilePath:      options.DockerfilePath,
+		Progress:            options.ProgressReporter,
+		BuildKit:            options.BuildKit,
+		LazyModDownload:     options.LazyModDownload,
+		Vendored:            vendored(options),
+		GoVersion:           options.GoVersion,
+		ImageFlavor:         options.ImageFlavor,
+		GoTools:             options.GoTools,
+		DockerfileGenerator: options.DockerfileGenerator,
+		BuildTarget:         options.BuildTarget,
+		Template:            options.Template,
+		TemplateData:        options.TemplateData,
+		GitCredentials:      options.GitCredentials,
+		ProxyEnv:            buildProxyEnv(options),
+		TLSBundle:           options.TLSBundle,
+		Root:                options.Root,
+		ImageCache:          true,
+		ContextExcludes:     options.ContextExcludes,
+		Labels:              runLabels(options),
+		MaxContextSize:      options.MaxContextSize,
+		BuildCacheRef:       options.BuildCacheRef,
+		BuildCacheDir:       options.BuildCacheDir,
+		Log:                 log,
+		Metrics:             metrics,
+	}, provider, io.MultiWriter(buildLogWriters...))
+	if err != nil {
+		return wrapTimeoutError(ctx, err, "prepare image build")
+	}
+	// Non-fatal: the archive and the session are only needed for the build
+	defer func() {
+		_ = imgBuild.archive.Close()
+		imgBuild.stopSession()
+	}()
+
+	// Build the image unless an identical one was built before
+	if imgBuild.image == "" {
+		req := testcontainers.ContainerRequest{FromDockerfile: imgBuild.fromDockerfile}
+		stop := cancelBuildOnDone(ctx, provider, imgBuild.buildID)
+		log.Info("image build started", "build_id", imgBuild.buildID)
+		invokeHook(ctx, runHooks(options).OnBuildStart, HookEvent{ImageTag: imgBuild.ref()})
+		start := time.Now()
+		tag, err := provider.BuildImage(ctx, &req)
+		stop()
+		if err != nil {
+			log.Info("image build failed", "duration", time.Since(start), "error", err)
+			if ctx.Err() != nil {
+				return wrapTimeoutError(ctx, err, "build image")
+			}
+			buildErr := &BuildError{Log: buildLog.Bytes(), Err: err}
+			if options.KeepFailedBuild {
+				// Non-fatal: the build error is more relevant than a tagging failure
+				buildErr.DebugImage, _ = tagDebugImage(ctx, provider, buildErr.Log)
+			}
+			return buildErr
+		}
+		log.Info("image build finished", "image", tag, "duration", time.Since(start))
+		observeMetric(metrics, MetricBuildDuration, time.Since(start).Seconds())
+
+		if imgBuild.cache.enabled() {
+			if err := imgBuild.cache.save(ctx, provider, tag); err != nil {
+				return wrapTimeoutError(ctx, err, "export build cache")
+			}
+		}
+	}
+
+	// Pull the images of the sidecars and the prober
+	images := make([]string, 0, len(options.Sidecars)+1)
+	for _, spec := range options.Sidecars {
+		images = append(images, spec.Image)
+	}
+	if len(options.Probes) > 0 {
+		images = append(images, DefaultProbeImage)
+	}
+	for _, image := range images {
+		if err := provider.PullImage(ctx, image); err != nil {
+			return wrapTimeoutError(ctx, err, fmt.Sprintf("pull image %s", image))
+		}
+		log.Info("image pulled", "image", image)
+	}
+
+	return nil
+}